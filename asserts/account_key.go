@@ -28,9 +28,10 @@ import (
 // belonging to the account.
 type AccountKey struct {
 	assertionBase
-	since  time.Time
-	until  time.Time
-	pubKey PublicKey
+	since   time.Time
+	until   time.Time
+	revoked bool
+	pubKey  PublicKey
 }
 
 // AccountID returns the account-id of this account-key.
@@ -48,6 +49,12 @@ func (ak *AccountKey) Until() time.Time {
 	return ak.until
 }
 
+// Revoked returns true if the account key has been revoked, a revoked
+// key cannot be used to verify any assertion anymore.
+func (ak *AccountKey) Revoked() bool {
+	return ak.revoked
+}
+
 // PublicKeyID returns the key id (as used to match signatures to signing keys) for the account key.
 func (ak *AccountKey) PublicKeyID() string {
 	return ak.pubKey.ID()
@@ -102,6 +109,14 @@ func assembleAccountKey(assert assertionBase) (Assertion, error) {
 	if !until.After(since) {
 		return nil, fmt.Errorf("invalid 'since' and 'until' times (no gap after 'since' till 'until')")
 	}
+	revoked := false
+	switch assert.headers["revoked"] {
+	case nil, "false":
+	case "true":
+		revoked = true
+	default:
+		return nil, fmt.Errorf(`"revoked" header must be 'true' or 'false'`)
+	}
 	pubk, err := checkPublicKey(&assert, "public-key-fingerprint", "public-key-id")
 	if err != nil {
 		return nil, err
@@ -111,6 +126,60 @@ func assembleAccountKey(assert assertionBase) (Assertion, error) {
 		assertionBase: assert,
 		since:         since,
 		until:         until,
+		revoked:       revoked,
+		pubKey:        pubk,
+	}, nil
+}
+
+// AccountKeyRequest holds an account-key-request assertion, which is
+// a self-signed request to prove that the requester holds the private
+// key and wishes to create an account-key assertion for it.
+type AccountKeyRequest struct {
+	assertionBase
+	since  time.Time
+	pubKey PublicKey
+}
+
+// AccountID returns the account-id of this account-key-request.
+func (akr *AccountKeyRequest) AccountID() string {
+	return akr.Header("account-id")
+}
+
+// Since returns the time when the requested account key starts being valid.
+func (akr *AccountKeyRequest) Since() time.Time {
+	return akr.since
+}
+
+// PublicKeyID returns the key id of the requested account key.
+func (akr *AccountKeyRequest) PublicKeyID() string {
+	return akr.pubKey.ID()
+}
+
+// PublicKeyFingerprint returns the fingerprint of the requested account key.
+func (akr *AccountKeyRequest) PublicKeyFingerprint() string {
+	return akr.pubKey.Fingerprint()
+}
+
+func assembleAccountKeyRequest(assert assertionBase) (Assertion, error) {
+	accountID, err := checkMandatory(assert.headers, "account-id")
+	if err != nil {
+		return nil, err
+	}
+	if accountID != assert.Header("authority-id") {
+		return nil, fmt.Errorf("authority-id and account-id must match, account-key-request assertions are expected to be signed by the requester: %q != %q", assert.Header("authority-id"), accountID)
+	}
+	since, err := checkRFC3339Date(assert.headers, "since")
+	if err != nil {
+		return nil, err
+	}
+	pubk, err := checkPublicKey(&assert, "public-key-fingerprint", "public-key-id")
+	if err != nil {
+		return nil, err
+	}
+	// ignore extra headers for future compatibility
+	return &AccountKeyRequest{
+		assertionBase: assert,
+		since:         since,
 		pubKey:        pubk,
 	}, nil
 }
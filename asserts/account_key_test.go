@@ -84,6 +84,27 @@ func (aks *accountKeySuite) TestDecodeOK(c *C) {
 	c.Check(accKey.PublicKeyID(), Equals, aks.keyid)
 	c.Check(accKey.Since(), Equals, aks.since)
 	c.Check(accKey.Until(), Equals, aks.until)
+	c.Check(accKey.Revoked(), Equals, false)
+}
+
+func (aks *accountKeySuite) TestDecodeRevoked(c *C) {
+	encoded := "type: account-key\n" +
+		"authority-id: canonical\n" +
+		"revision: 1\n" +
+		"account-id: acc-id1\n" +
+		"public-key-id: " + aks.keyid + "\n" +
+		"public-key-fingerprint: " + aks.fp + "\n" +
+		aks.sinceLine +
+		aks.untilLine +
+		"revoked: true\n" +
+		fmt.Sprintf("body-length: %v", len(aks.pubKeyBody)) + "\n\n" +
+		aks.pubKeyBody + "\n\n" +
+		"openpgp c2ln"
+	a, err := asserts.Decode([]byte(encoded))
+	c.Assert(err, IsNil)
+	accKey := a.(*asserts.AccountKey)
+	c.Check(accKey.Revision(), Equals, 1)
+	c.Check(accKey.Revoked(), Equals, true)
 }
 
 const (
@@ -108,6 +129,7 @@ func (aks *accountKeySuite) TestDecodeInvalidHeaders(c *C) {
 		{aks.untilLine, "", `"until" header is mandatory`},
 		{aks.sinceLine, "since: 12:30\n", `"since" header is not a RFC3339 date: .*`},
 		{aks.untilLine, "until: " + aks.since.Format(time.RFC3339) + "\n", `invalid 'since' and 'until' times \(no gap after 'since' till 'until'\)`},
+		{aks.untilLine, aks.untilLine + "revoked: maybe\n", `"revoked" header must be 'true' or 'false'`},
 		{"public-key-id: " + aks.keyid + "\n", "", `"public-key-id" header is mandatory`},
 		{"public-key-fingerprint: " + aks.fp + "\n", "", `"public-key-fingerprint" header is mandatory`},
 	}
@@ -198,7 +220,7 @@ func (aks *accountKeySuite) openDB(c *C) *asserts.Database {
 func (aks *accountKeySuite) TestAccountKeyCheck(c *C) {
 	trustedKey := testPrivKey0
 
-	headers := map[string]string{
+	headers := map[string]interface{}{
 		"authority-id":           "canonical",
 		"account-id":             "acc-id1",
 		"public-key-id":          aks.keyid,
@@ -218,7 +240,7 @@ func (aks *accountKeySuite) TestAccountKeyCheck(c *C) {
 func (aks *accountKeySuite) TestAccountKeyAddAndFind(c *C) {
 	trustedKey := testPrivKey0
 
-	headers := map[string]string{
+	headers := map[string]interface{}{
 		"authority-id":           "canonical",
 		"account-id":             "acc-id1",
 		"public-key-id":          aks.keyid,
@@ -267,3 +289,48 @@ func (aks *accountKeySuite) TestPublicKeyIsValidAt(c *C) {
 	c.Check(asserts.AccountKeyIsKeyValidAt(accKey, aks.until.AddDate(0, -1, 0)), Equals, true)
 	c.Check(asserts.AccountKeyIsKeyValidAt(accKey, aks.until.AddDate(0, 1, 0)), Equals, false)
 }
+
+func (aks *accountKeySuite) TestAccountKeyRequestDecodeOK(c *C) {
+	encoded := "type: account-key-request\n" +
+		"authority-id: acc-id1\n" +
+		"account-id: acc-id1\n" +
+		"public-key-id: " + aks.keyid + "\n" +
+		"public-key-fingerprint: " + aks.fp + "\n" +
+		aks.sinceLine +
+		fmt.Sprintf("body-length: %v", len(aks.pubKeyBody)) + "\n\n" +
+		aks.pubKeyBody + "\n\n" +
+		"openpgp c2ln"
+	a, err := asserts.Decode([]byte(encoded))
+	c.Assert(err, IsNil)
+	c.Check(a.Type(), Equals, asserts.AccountKeyRequestType)
+	accKeyReq := a.(*asserts.AccountKeyRequest)
+	c.Check(accKeyReq.AccountID(), Equals, "acc-id1")
+	c.Check(accKeyReq.PublicKeyFingerprint(), Equals, aks.fp)
+	c.Check(accKeyReq.PublicKeyID(), Equals, aks.keyid)
+	c.Check(accKeyReq.Since(), Equals, aks.since)
+}
+
+func (aks *accountKeySuite) TestAccountKeyRequestDecodeInvalid(c *C) {
+	encoded := "type: account-key-request\n" +
+		"authority-id: acc-id1\n" +
+		"account-id: acc-id1\n" +
+		"public-key-id: " + aks.keyid + "\n" +
+		"public-key-fingerprint: " + aks.fp + "\n" +
+		aks.sinceLine +
+		fmt.Sprintf("body-length: %v", len(aks.pubKeyBody)) + "\n\n" +
+		aks.pubKeyBody + "\n\n" +
+		"openpgp c2ln"
+
+	invalidHeaderTests := []struct{ original, invalid, expectedErr string }{
+		{"account-id: acc-id1\n", "", `"account-id" header is mandatory`},
+		{"account-id: acc-id1\n", "account-id: acc-id2\n", `authority-id and account-id must match, account-key-request assertions are expected to be signed by the requester: "acc-id1" != "acc-id2"`},
+		{aks.sinceLine, "", `"since" header is mandatory`},
+		{"public-key-id: " + aks.keyid + "\n", "public-key-id: 00ff\n", `public key does not match provided key id`},
+	}
+
+	for _, test := range invalidHeaderTests {
+		invalid := strings.Replace(encoded, test.original, test.invalid, 1)
+		_, err := asserts.Decode([]byte(invalid))
+		c.Check(err, ErrorMatches, "assertion account-key-request: "+test.expectedErr)
+	}
+}
@@ -24,11 +24,9 @@ import (
 	"bytes"
 	"fmt"
 	"io"
-	"regexp"
 	"sort"
 	"strconv"
 	"strings"
-	"unicode/utf8"
 )
 
 // AssertionType describes a known assertion type with its name and metadata.
@@ -44,25 +42,31 @@ type AssertionType struct {
 
 // Understood assertion types.
 var (
-	AccountKeyType      = &AssertionType{"account-key", []string{"account-id", "public-key-id"}, assembleAccountKey}
-	DeviceSerialType    = &AssertionType{"device-serial", []string{"brand-id", "model", "serial"}, assembleDeviceSerial}
-	IdentityType        = &AssertionType{"identity", []string{"account-id"}, assembleIdentity}
-	ModelType           = &AssertionType{"model", []string{"series", "brand-id", "model"}, assembleModel}
-	SnapDeclarationType = &AssertionType{"snap-declaration", []string{"series", "snap-id"}, assembleSnapDeclaration}
-	SnapBuildType       = &AssertionType{"snap-build", []string{"series", "snap-id", "snap-digest"}, assembleSnapBuild}
-	SnapRevisionType    = &AssertionType{"snap-revision", []string{"series", "snap-id", "snap-digest"}, assembleSnapRevision}
+	AccountKeyType        = &AssertionType{"account-key", []string{"account-id", "public-key-id"}, assembleAccountKey}
+	AccountKeyRequestType = &AssertionType{"account-key-request", []string{"public-key-id"}, assembleAccountKeyRequest}
+	DeviceSerialType      = &AssertionType{"device-serial", []string{"brand-id", "model", "serial"}, assembleDeviceSerial}
+	IdentityType          = &AssertionType{"identity", []string{"account-id"}, assembleIdentity}
+	ModelType             = &AssertionType{"model", []string{"series", "brand-id", "model"}, assembleModel}
+	SerialRequestType     = &AssertionType{"serial-request", []string{"brand-id", "model", "device-key-id"}, assembleSerialRequest}
+	SnapDeclarationType   = &AssertionType{"snap-declaration", []string{"series", "snap-id"}, assembleSnapDeclaration}
+	SnapBuildType         = &AssertionType{"snap-build", []string{"series", "snap-id", "snap-digest"}, assembleSnapBuild}
+	SnapRevisionType      = &AssertionType{"snap-revision", []string{"series", "snap-id", "snap-digest"}, assembleSnapRevision}
+	ValidationType        = &AssertionType{"validation", []string{"series", "snap-id", "approved-snap-id", "approved-revision"}, assembleValidation}
 
 // ...
 )
 
 var typeRegistry = map[string]*AssertionType{
-	AccountKeyType.Name:      AccountKeyType,
-	IdentityType.Name:        IdentityType,
-	ModelType.Name:           ModelType,
-	DeviceSerialType.Name:    DeviceSerialType,
-	SnapDeclarationType.Name: SnapDeclarationType,
-	SnapBuildType.Name:       SnapBuildType,
-	SnapRevisionType.Name:    SnapRevisionType,
+	AccountKeyType.Name:        AccountKeyType,
+	AccountKeyRequestType.Name: AccountKeyRequestType,
+	IdentityType.Name:          IdentityType,
+	ModelType.Name:             ModelType,
+	DeviceSerialType.Name:      DeviceSerialType,
+	SerialRequestType.Name:     SerialRequestType,
+	SnapDeclarationType.Name:   SnapDeclarationType,
+	SnapBuildType.Name:         SnapBuildType,
+	SnapRevisionType.Name:      SnapRevisionType,
+	ValidationType.Name:        ValidationType,
 }
 
 // Type returns the AssertionType with name or nil
@@ -70,6 +74,33 @@ func Type(name string) *AssertionType {
 	return typeRegistry[name]
 }
 
+// Ref expresses a reference to an assertion.
+type Ref struct {
+	Type       *AssertionType
+	PrimaryKey []string
+}
+
+func (ref *Ref) String() string {
+	return fmt.Sprintf("%s (%s)", ref.Type.Name, strings.Join(ref.PrimaryKey, "; "))
+}
+
+// Unique returns a unique string representing the reference that can be used as a key in maps.
+func (ref *Ref) Unique() string {
+	return fmt.Sprintf("%s/%s", ref.Type.Name, strings.Join(ref.PrimaryKey, "/"))
+}
+
+// Resolve resolves the reference using the given find function.
+func (ref *Ref) Resolve(find func(assertType *AssertionType, headers map[string]string) (Assertion, error)) (Assertion, error) {
+	if len(ref.PrimaryKey) != len(ref.Type.PrimaryKey) {
+		return nil, fmt.Errorf("%q assertion reference primary key has the wrong length (expected %v): %v", ref.Type.Name, ref.Type.PrimaryKey, ref.PrimaryKey)
+	}
+	headers := make(map[string]string, len(ref.PrimaryKey))
+	for i, name := range ref.Type.PrimaryKey {
+		headers[name] = ref.PrimaryKey[i]
+	}
+	return find(ref.Type, headers)
+}
+
 // Assertion represents an assertion through its general elements.
 type Assertion interface {
 	// Type returns the type of this assertion
@@ -79,17 +110,36 @@ type Assertion interface {
 	// AuthorityID returns the authority that signed this assertion
 	AuthorityID() string
 
-	// Header retrieves the header with name
+	// Header retrieves the string header with name, it returns ""
+	// if the header is absent or not a string
 	Header(name string) string
 
-	// Headers returns the complete headers
-	Headers() map[string]string
+	// HeaderList retrieves the list header with name, it returns
+	// nil if the header is absent or not a list
+	HeaderList(name string) []interface{}
+
+	// HeaderMap retrieves the map header with name, it returns nil
+	// if the header is absent or not a map
+	HeaderMap(name string) map[string]interface{}
+
+	// Headers returns the complete headers, whose values are
+	// strings, lists ([]interface{}) or maps
+	// (map[string]interface{}) of header values. Callers used to
+	// only string values need to type check them.
+	Headers() map[string]interface{}
 
 	// Body returns the body of this assertion
 	Body() []byte
 
 	// Signature returns the signed content and its unprocessed signature
 	Signature() (content, signature []byte)
+
+	// Ref returns a reference representing this assertion.
+	Ref() *Ref
+
+	// Prerequisites returns references to the prerequisite assertions for the validity of this one.
+	// The account-key signing the assertion is an implicit prerequisite and is not included.
+	Prerequisites() []*Ref
 }
 
 // MediaType is the media type for enconded assertions on the wire.
@@ -98,7 +148,7 @@ const MediaType = "application/x.ubuntu.assertion"
 // assertionBase is the concrete base to hold representation data for actual assertions.
 type assertionBase struct {
 	// TODO: worth having a type *AssertionType cache field now?
-	headers map[string]string
+	headers map[string]interface{}
 	body    []byte
 	// parsed revision
 	revision int
@@ -110,7 +160,7 @@ type assertionBase struct {
 
 // Type returns the assertion type.
 func (ab *assertionBase) Type() *AssertionType {
-	return Type(ab.headers["type"])
+	return Type(ab.Header("type"))
 }
 
 // Revision returns the assertion revision.
@@ -120,21 +170,30 @@ func (ab *assertionBase) Revision() int {
 
 // AuthorityID returns the authority-id a.k.a the signer id of the assertion.
 func (ab *assertionBase) AuthorityID() string {
-	return ab.headers["authority-id"]
+	return ab.Header("authority-id")
 }
 
-// Header returns the value of an header by name.
+// Header returns the value of a string header by name.
 func (ab *assertionBase) Header(name string) string {
-	return ab.headers[name]
+	s, _ := ab.headers[name].(string)
+	return s
+}
+
+// HeaderList returns the value of a list header by name.
+func (ab *assertionBase) HeaderList(name string) []interface{} {
+	l, _ := ab.headers[name].([]interface{})
+	return l
+}
+
+// HeaderMap returns the value of a map header by name.
+func (ab *assertionBase) HeaderMap(name string) map[string]interface{} {
+	m, _ := ab.headers[name].(map[string]interface{})
+	return m
 }
 
 // Headers returns the complete headers.
-func (ab *assertionBase) Headers() map[string]string {
-	res := make(map[string]string, len(ab.headers))
-	for name, v := range ab.headers {
-		res[name] = v
-	}
-	return res
+func (ab *assertionBase) Headers() map[string]interface{} {
+	return copyHeaders(ab.headers)
 }
 
 // Body returns the body of the assertion.
@@ -147,74 +206,27 @@ func (ab *assertionBase) Signature() (content, signature []byte) {
 	return ab.content, ab.signature
 }
 
-// sanity check
-var _ Assertion = (*assertionBase)(nil)
-
-var (
-	nl   = []byte("\n")
-	nlnl = []byte("\n\n")
-
-	// for basic sanity checking of header names
-	headerNameSanity = regexp.MustCompile("^[a-z][a-z0-9-]*[a-z0-9]$")
-)
-
-func parseHeaders(head []byte) (map[string]string, error) {
-	if !utf8.Valid(head) {
-		return nil, fmt.Errorf("header is not utf8")
-	}
-	headers := make(map[string]string)
-	lines := strings.Split(string(head), "\n")
-	for i := 0; i < len(lines); {
-		entry := lines[i]
-		i++
-		nameValueSplit := strings.Index(entry, ":")
-		if nameValueSplit == -1 {
-			return nil, fmt.Errorf("header entry missing ':' separator: %q", entry)
-		}
-		name := entry[:nameValueSplit]
-		if !headerNameSanity.MatchString(name) {
-			return nil, fmt.Errorf("invalid header name: %q", name)
-		}
-
-		afterSplit := nameValueSplit + 1
-		if afterSplit == len(entry) {
-			// multiline value
-			size := 0
-			j := i
-			for j < len(lines) {
-				iline := lines[j]
-				if len(iline) == 0 || iline[0] != ' ' {
-					break
-				}
-				size += len(iline)
-				j++
-			}
-			if j == i {
-				return nil, fmt.Errorf("empty multiline header value: %q", entry)
-			}
-
-			valueBuf := bytes.NewBuffer(make([]byte, 0, size-1))
-			valueBuf.WriteString(lines[i][1:])
-			i++
-			for i < j {
-				valueBuf.WriteByte('\n')
-				valueBuf.WriteString(lines[i][1:])
-				i++
-			}
-
-			headers[name] = valueBuf.String()
-			continue
-		}
-
-		if entry[afterSplit] != ' ' {
-			return nil, fmt.Errorf("header entry should have a space or newline (multiline) before value: %q", entry)
-		}
-
-		headers[name] = entry[afterSplit+1:]
+// Ref returns a reference representing this assertion.
+func (ab *assertionBase) Ref() *Ref {
+	assertType := ab.Type()
+	primKey := make([]string, len(assertType.PrimaryKey))
+	for i, name := range assertType.PrimaryKey {
+		primKey[i] = ab.Header(name)
+	}
+	return &Ref{
+		Type:       assertType,
+		PrimaryKey: primKey,
 	}
-	return headers, nil
 }
 
+// Prerequisites returns references to the prerequisite assertions for the validity of this one.
+func (ab *assertionBase) Prerequisites() []*Ref {
+	return nil
+}
+
+// sanity check
+var _ Assertion = (*assertionBase)(nil)
+
 // Decode parses a serialized assertion.
 //
 // The expected serialisation format looks like:
@@ -235,6 +247,9 @@ func parseHeaders(head []byte) (map[string]string, error) {
 //
 //   NAME ":\n"  1-space indented VALUE
 //
+// Header values can also be lists and maps, see headers.go for their
+// encoding.
+//
 // The following headers are mandatory:
 //
 //   type
@@ -451,7 +466,7 @@ func (d *Decoder) Decode() (Assertion, error) {
 	return Assemble(headers, finalBody, finalContent, finalSig)
 }
 
-func checkRevision(headers map[string]string) (int, error) {
+func checkRevision(headers map[string]interface{}) (int, error) {
 	revision, err := checkInteger(headers, "revision", 0)
 	if err != nil {
 		return -1, err
@@ -463,7 +478,7 @@ func checkRevision(headers map[string]string) (int, error) {
 }
 
 // Assemble assembles an assertion from its components.
-func Assemble(headers map[string]string, body, content, signature []byte) (Assertion, error) {
+func Assemble(headers map[string]interface{}, body, content, signature []byte) (Assertion, error) {
 	length, err := checkInteger(headers, "body-length", 0)
 	if err != nil {
 		return nil, fmt.Errorf("assertion: %v", err)
@@ -513,29 +528,21 @@ func Assemble(headers map[string]string, body, content, signature []byte) (Asser
 	return assert, nil
 }
 
-func writeHeader(buf *bytes.Buffer, headers map[string]string, name string) {
-	buf.WriteByte('\n')
-	buf.WriteString(name)
-	value := headers[name]
-	if strings.IndexRune(value, '\n') != -1 {
-		// multiline value => quote by 1-space indenting
-		buf.WriteString(":\n ")
-		value = strings.Replace(value, "\n", "\n ", -1)
-	} else {
-		buf.WriteString(": ")
-	}
-	buf.WriteString(value)
-}
-
-func assembleAndSign(assertType *AssertionType, headers map[string]string, body []byte, privKey PrivateKey) (Assertion, error) {
+func assembleAndSign(assertType *AssertionType, headers map[string]interface{}, body []byte, privKey PrivateKey) (Assertion, error) {
 	err := checkAssertType(assertType)
 	if err != nil {
 		return nil, err
 	}
 
-	finalHeaders := make(map[string]string, len(headers))
+	finalHeaders := make(map[string]interface{}, len(headers))
 	for name, value := range headers {
-		finalHeaders[name] = value
+		if !headerNameSanity.MatchString(name) {
+			return nil, fmt.Errorf("invalid header name: %q", name)
+		}
+		finalHeaders[name], err = normalizeValue(name, value, false)
+		if err != nil {
+			return nil, err
+		}
 	}
 	bodyLength := len(body)
 	finalBody := make([]byte, bodyLength)
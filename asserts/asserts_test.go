@@ -144,6 +144,101 @@ func (as *assertsSuite) TestDecodeHeaderParsingErrors(c *C) {
 	}
 }
 
+func (as *assertsSuite) TestDecodeStructuredHeaders(c *C) {
+	encoded := "type: test-only\n" +
+		"authority-id: auth-id1\n" +
+		"primary-key: abc\n" +
+		"list:\n" +
+		"  - a\n" +
+		"  - b\n" +
+		"map:\n" +
+		"  xx: 1\n" +
+		"  yy:\n" +
+		"    - c\n" +
+		"  zz:\n" +
+		"      first\n" +
+		"      second\n" +
+		"nested:\n" +
+		"  -\n" +
+		"    - d\n" +
+		"  -\n" +
+		"    kk: v\n" +
+		"multiline:\n" +
+		" line1\n" +
+		" line2" +
+		"\n\n" +
+		"openpgp c2ln"
+	a, err := asserts.Decode([]byte(encoded))
+	c.Assert(err, IsNil)
+
+	c.Check(a.HeaderList("list"), DeepEquals, []interface{}{"a", "b"})
+	c.Check(a.HeaderMap("map"), DeepEquals, map[string]interface{}{
+		"xx": "1",
+		"yy": []interface{}{"c"},
+		"zz": "first\nsecond",
+	})
+	c.Check(a.HeaderList("nested"), DeepEquals, []interface{}{
+		[]interface{}{"d"},
+		map[string]interface{}{"kk": "v"},
+	})
+	c.Check(a.Header("multiline"), Equals, "line1\nline2")
+
+	// accessors of the wrong kind return zero values
+	c.Check(a.Header("list"), Equals, "")
+	c.Check(a.HeaderList("map"), IsNil)
+	c.Check(a.HeaderMap("list"), IsNil)
+	c.Check(a.HeaderList("missing"), IsNil)
+}
+
+func (as *assertsSuite) TestDecodeLegacyMultilineHeaders(c *C) {
+	// multiline values encoded before lists and maps were supported
+	encoded := "type: test-only\n" +
+		"authority-id: auth-id1\n" +
+		"primary-key: abc\n" +
+		"description:\n" +
+		" Some text\n" +
+		"   - not a list\n" +
+		"   key: not a map\n" +
+		"  \n" +
+		" the end" +
+		"\n\n" +
+		"openpgp c2ln"
+	a, err := asserts.Decode([]byte(encoded))
+	c.Assert(err, IsNil)
+
+	c.Check(a.Header("description"), Equals, "Some text\n  - not a list\n  key: not a map\n \nthe end")
+	c.Check(a.Headers()["description"], Equals, "Some text\n  - not a list\n  key: not a map\n \nthe end")
+
+	// a value whose first line starts with a space is no longer a string
+	encoded = "type: test-only\n" +
+		"authority-id: auth-id1\n" +
+		"primary-key: abc\n" +
+		"description:\n" +
+		"  - indented text" +
+		"\n\n" +
+		"openpgp c2ln"
+	a, err = asserts.Decode([]byte(encoded))
+	c.Assert(err, IsNil)
+	c.Check(a.Header("description"), Equals, "")
+	c.Check(a.HeaderList("description"), DeepEquals, []interface{}{"indented text"})
+}
+
+func (as *assertsSuite) TestDecodeStructuredHeaderParsingErrors(c *C) {
+	headerParsingErrorsTests := []struct{ encoded, expectedErr string }{
+		{"foo: a\n  - b\n\n", `unexpected indentation: "  - b"`},
+		{"foo:\n  - a\n  bb: c\n\n", `expected list entry: "  bb: c"`},
+		{"foo:\n  aa: b\n  aa: c\n\n", `repeated map entry: "  aa: c"`},
+		{"foo:\n  Aa: b\n\n", `invalid header name: "Aa"`},
+		{"foo:\n  - a\n   - b\n\n", `unexpected indentation: "   - b"`},
+		{"foo:\n  -\n\n", `empty multiline header value: "  -"`},
+	}
+
+	for _, test := range headerParsingErrorsTests {
+		_, err := asserts.Decode([]byte(test.encoded))
+		c.Check(err, ErrorMatches, "parsing assertion headers: "+test.expectedErr, Commentf(test.encoded))
+	}
+}
+
 func (as *assertsSuite) TestDecodeInvalid(c *C) {
 	encoded := "type: test-only\n" +
 		"authority-id: auth-id\n" +
@@ -390,7 +485,7 @@ func (as *assertsSuite) TestEncoderSingleDecodeOK(c *C) {
 }
 
 func (as *assertsSuite) TestSignFormatSanityEmptyBody(c *C) {
-	headers := map[string]string{
+	headers := map[string]interface{}{
 		"authority-id": "auth-id1",
 		"primary-key":  "0",
 	}
@@ -402,7 +497,7 @@ func (as *assertsSuite) TestSignFormatSanityEmptyBody(c *C) {
 }
 
 func (as *assertsSuite) TestSignFormatSanityNonEmptyBody(c *C) {
-	headers := map[string]string{
+	headers := map[string]interface{}{
 		"authority-id": "auth-id1",
 		"primary-key":  "0",
 	}
@@ -417,7 +512,7 @@ func (as *assertsSuite) TestSignFormatSanityNonEmptyBody(c *C) {
 }
 
 func (as *assertsSuite) TestSignFormatSanitySupportMultilineHeaderValues(c *C) {
-	headers := map[string]string{
+	headers := map[string]interface{}{
 		"authority-id": "auth-id1",
 		"primary-key":  "0",
 	}
@@ -447,6 +542,59 @@ func (as *assertsSuite) TestSignFormatSanitySupportMultilineHeaderValues(c *C) {
 	}
 }
 
+func (as *assertsSuite) TestSignFormatSanityStructuredHeaderValues(c *C) {
+	headers := map[string]interface{}{
+		"authority-id": "auth-id1",
+		"primary-key":  "0",
+		"list":         []string{"a", "b"},
+		"map": map[string]interface{}{
+			"yy": []interface{}{"c", map[string]string{"kk": "v"}},
+			"xx": "first\nsecond",
+		},
+	}
+
+	a, err := asserts.AssembleAndSignInTest(asserts.TestOnlyType, headers, nil, asserts.OpenPGPPrivateKey(testPrivKey1))
+	c.Assert(err, IsNil)
+
+	encoded := string(asserts.Encode(a))
+	c.Check(encoded, Matches, `(?s).*list:\n  - a\n  - b\nmap:\n  xx:\n      first\n      second\n  yy:\n    - c\n    -\n      kk: v\n.*`)
+
+	decoded, err := asserts.Decode([]byte(encoded))
+	c.Assert(err, IsNil)
+	c.Check(decoded.Headers(), DeepEquals, a.Headers())
+	c.Check(decoded.HeaderList("list"), DeepEquals, []interface{}{"a", "b"})
+
+	// the encoding is canonical
+	content, signature := decoded.Signature()
+	reassembled, err := asserts.Assemble(decoded.Headers(), decoded.Body(), content, signature)
+	c.Assert(err, IsNil)
+	c.Check(string(asserts.Encode(reassembled)), Equals, encoded)
+}
+
+func (as *assertsSuite) TestSignInvalidStructuredHeaderValues(c *C) {
+	invalidTests := []struct {
+		value       interface{}
+		expectedErr string
+	}{
+		{[]interface{}{}, `header "hdr": lists cannot be empty`},
+		{map[string]interface{}{}, `header "hdr": maps cannot be empty`},
+		{[]interface{}{""}, `header "hdr": nested values cannot be empty strings`},
+		{map[string]interface{}{"Aa": "b"}, `header "hdr": invalid map entry name: "Aa"`},
+		{[]interface{}{1}, `header "hdr": unsupported value type int`},
+		{" a\nb", `header "hdr": multiline values cannot start with a space`},
+	}
+
+	for _, test := range invalidTests {
+		headers := map[string]interface{}{
+			"authority-id": "auth-id1",
+			"primary-key":  "0",
+			"hdr":          test.value,
+		}
+		_, err := asserts.AssembleAndSignInTest(asserts.TestOnlyType, headers, nil, asserts.OpenPGPPrivateKey(testPrivKey1))
+		c.Check(err, ErrorMatches, test.expectedErr)
+	}
+}
+
 func (as *assertsSuite) TestHeaders(c *C) {
 	encoded := []byte("type: test-only\n" +
 		"authority-id: auth-id2\n" +
@@ -462,7 +610,7 @@ func (as *assertsSuite) TestHeaders(c *C) {
 	c.Assert(err, IsNil)
 
 	hs := a.Headers()
-	c.Check(hs, DeepEquals, map[string]string{
+	c.Check(hs, DeepEquals, map[string]interface{}{
 		"type":         "test-only",
 		"authority-id": "auth-id2",
 		"primary-key":  "abc",
@@ -86,7 +86,16 @@ var openpgpConfig = &packet.Config{
 	DefaultHash: crypto.SHA256,
 }
 
+// A contentSigner is a PrivateKey that signs content itself instead of
+// exposing its private key material, e.g. because it lives elsewhere.
+type contentSigner interface {
+	signContent(content []byte) ([]byte, error)
+}
+
 func signContent(content []byte, privateKey PrivateKey) ([]byte, error) {
+	if signer, ok := privateKey.(contentSigner); ok {
+		return signer.signContent(content)
+	}
 	opgPrivKey, ok := privateKey.(openpgpPrivateKey)
 	if !ok {
 		panic(fmt.Errorf("not an internally supported PrivateKey: %T", privateKey))
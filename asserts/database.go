@@ -44,6 +44,20 @@ type Backstore interface {
 	Search(assertType *AssertionType, headers map[string]string, foundCb func(Assertion)) error
 }
 
+// removingBackstore is implemented by backstores that support
+// removing assertions.
+type removingBackstore interface {
+	// Remove removes the assertion with the given unique key for its primary key headers.
+	Remove(assertType *AssertionType, key []string) error
+}
+
+// indexingBackstore is implemented by backstores keeping secondary
+// indexes for searches.
+type indexingBackstore interface {
+	// Reindex checks the consistency of the stored assertions and rebuilds the indexes.
+	Reindex() error
+}
+
 type nullBackstore struct{}
 
 func (nbs nullBackstore) Put(t *AssertionType, a Assertion) error {
@@ -70,6 +84,31 @@ type KeypairManager interface {
 	Get(authorityID, keyID string) (PrivateKey, error)
 }
 
+// KeypairRef identifies a key pair held by a KeypairManager.
+type KeypairRef struct {
+	AuthorityID string
+	KeyID       string
+}
+
+// KeypairLister is implemented by keypair managers that can enumerate
+// the key pairs they hold.
+type KeypairLister interface {
+	// List returns references to all the stored key pairs,
+	// sorted by authority-id and then key id.
+	List() ([]KeypairRef, error)
+}
+
+type byAuthorityAndKeyID []KeypairRef
+
+func (refs byAuthorityAndKeyID) Len() int      { return len(refs) }
+func (refs byAuthorityAndKeyID) Swap(i, j int) { refs[i], refs[j] = refs[j], refs[i] }
+func (refs byAuthorityAndKeyID) Less(i, j int) bool {
+	if refs[i].AuthorityID != refs[j].AuthorityID {
+		return refs[i].AuthorityID < refs[j].AuthorityID
+	}
+	return refs[i].KeyID < refs[j].KeyID
+}
+
 // TODO: for more flexibility plugging the keypair manager make PrivatKey private encoding methods optional, and add an explicit sign method.
 
 // DatabaseConfig for an assertion database.
@@ -224,7 +263,7 @@ func (db *Database) PublicKey(authorityID string, keyID string) (PublicKey, erro
 
 // Sign assembles an assertion with the provided information and signs it
 // with the private key from `headers["authority-id"]` that has the provided key id.
-func (db *Database) Sign(assertType *AssertionType, headers map[string]string, body []byte, keyID string) (Assertion, error) {
+func (db *Database) Sign(assertType *AssertionType, headers map[string]interface{}, body []byte, keyID string) (Assertion, error) {
 	authorityID, err := checkMandatory(headers, "authority-id")
 	if err != nil {
 		return nil, err
@@ -380,6 +419,175 @@ func (db *Database) FindMany(assertionType *AssertionType, headers map[string]st
 	return res, nil
 }
 
+// trustedOnly exposes only the trusted assertions of a database.
+type trustedOnly struct {
+	db *Database
+}
+
+func (t trustedOnly) Find(assertionType *AssertionType, headers map[string]string) (Assertion, error) {
+	keyValues := make([]string, len(assertionType.PrimaryKey))
+	for i, k := range assertionType.PrimaryKey {
+		keyValues[i] = headers[k]
+	}
+	a, err := t.db.trusted.Get(assertionType, keyValues)
+	if err != nil {
+		return nil, err
+	}
+	if !searchMatch(a, headers) {
+		return nil, ErrNotFound
+	}
+	return a, nil
+}
+
+func (t trustedOnly) FindMany(assertionType *AssertionType, headers map[string]string) ([]Assertion, error) {
+	var res []Assertion
+	foundCb := func(assert Assertion) {
+		res = append(res, assert)
+	}
+	if err := t.db.trusted.Search(assertionType, headers, foundCb); err != nil {
+		return nil, err
+	}
+	if len(res) == 0 {
+		return nil, ErrNotFound
+	}
+	return res, nil
+}
+
+// Bundle returns the assertions indicated by refs together with,
+// recursively, their prerequisites and the account keys that signed
+// them, ordered so that each assertion comes after the ones needed to
+// verify it. Trusted assertions are left out, as they are expected to
+// be known already wherever the bundle is used.
+func (db *Database) Bundle(refs []*Ref) ([]Assertion, error) {
+	var bundle []Assertion
+	retrieve := func(ref *Ref) (Assertion, error) {
+		return ref.Resolve(db.Find)
+	}
+	save := func(a Assertion) error {
+		bundle = append(bundle, a)
+		return nil
+	}
+	f := NewFetcher(trustedOnly{db}, retrieve, save)
+	for _, ref := range refs {
+		if err := f.Fetch(ref); err != nil {
+			return nil, err
+		}
+	}
+	return bundle, nil
+}
+
+// Prune removes from the database the assertions of the given type
+// for which drop returns true. Trusted assertions are never removed.
+func (db *Database) Prune(assertionType *AssertionType, drop func(Assertion) bool) error {
+	err := checkAssertType(assertionType)
+	if err != nil {
+		return err
+	}
+	rbs, ok := db.bs.(removingBackstore)
+	if !ok {
+		return fmt.Errorf("cannot prune assertions: backstore does not support removing them")
+	}
+
+	var refs []*Ref
+	foundCb := func(assert Assertion) {
+		if drop(assert) {
+			refs = append(refs, assert.Ref())
+		}
+	}
+	err = db.bs.Search(assertionType, nil, foundCb)
+	if err != nil {
+		return err
+	}
+
+	for _, ref := range refs {
+		err := rbs.Remove(assertionType, ref.PrimaryKey)
+		if err != nil && err != ErrNotFound {
+			return err
+		}
+	}
+	return nil
+}
+
+// DropRevoked removes from the database the assertions signed with
+// revoked account keys, together with the ones that in turn depended
+// on a removed account key for their signature, returning all the
+// removed assertions. The revoked account keys themselves are kept
+// so that assertions signed with them keep being refused. After the
+// revocation of a key, assertions re-signed with a new key for the
+// same account can be added again, this is how keys are rotated.
+// Trusted assertions are never removed.
+func (db *Database) DropRevoked() ([]Assertion, error) {
+	rbs, ok := db.bs.(removingBackstore)
+	if !ok {
+		return nil, fmt.Errorf("cannot drop assertions: backstore does not support removing them")
+	}
+
+	var dropped []Assertion
+	for {
+		var toDrop []Assertion
+		for _, assertType := range typeRegistry {
+			var checkErr error
+			foundCb := func(assert Assertion) {
+				if checkErr != nil {
+					return
+				}
+				drop, err := db.signedWithRevokedKey(assert)
+				if err != nil {
+					checkErr = err
+					return
+				}
+				if drop {
+					toDrop = append(toDrop, assert)
+				}
+			}
+			if err := db.bs.Search(assertType, nil, foundCb); err != nil {
+				return nil, err
+			}
+			if checkErr != nil {
+				return nil, checkErr
+			}
+		}
+		if len(toDrop) == 0 {
+			return dropped, nil
+		}
+		for _, assert := range toDrop {
+			ref := assert.Ref()
+			err := rbs.Remove(ref.Type, ref.PrimaryKey)
+			if err != nil && err != ErrNotFound {
+				return nil, err
+			}
+		}
+		dropped = append(dropped, toDrop...)
+	}
+}
+
+// signedWithRevokedKey returns whether the assertion is signed with
+// a revoked account key or with one not in the database anymore.
+func (db *Database) signedWithRevokedKey(assert Assertion) (bool, error) {
+	_, signature := assert.Signature()
+	sig, err := decodeSignature(signature)
+	if err != nil {
+		return false, err
+	}
+	accKey, err := db.findAccountKey(assert.AuthorityID(), sig.KeyID())
+	if err == ErrNotFound {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return accKey.Revoked(), nil
+}
+
+// Reindex checks the consistency of the stored assertions and
+// rebuilds any secondary indexes the backstore keeps for searches.
+func (db *Database) Reindex() error {
+	if ibs, ok := db.bs.(indexingBackstore); ok {
+		return ibs.Reindex()
+	}
+	return nil
+}
+
 // assertion checkers
 
 // CheckSigningKeyIsNotExpired checks that the signing key is not expired.
@@ -390,6 +598,15 @@ func CheckSigningKeyIsNotExpired(assert Assertion, signature Signature, signingK
 	return nil
 }
 
+// CheckSigningKeyIsNotRevoked checks that the signing key has not
+// been revoked.
+func CheckSigningKeyIsNotRevoked(assert Assertion, signature Signature, signingKey *AccountKey, roDB RODatabase, checkTime time.Time) error {
+	if signingKey.Revoked() {
+		return fmt.Errorf("assertion is signed with revoked public key %q from %q", signature.KeyID(), assert.AuthorityID())
+	}
+	return nil
+}
+
 // CheckSignature checks that the signature is valid.
 func CheckSignature(assert Assertion, signature Signature, signingKey *AccountKey, roDB RODatabase, checkTime time.Time) error {
 	content, _ := assert.Signature()
@@ -400,6 +617,21 @@ func CheckSignature(assert Assertion, signature Signature, signingKey *AccountKe
 	return nil
 }
 
+// SignatureCheck checks that the assertion is signed with the given
+// public key. It is meant for requests signed with keys not (yet)
+// known to any database.
+func SignatureCheck(assert Assertion, pubKey PublicKey) error {
+	content, encodedSig := assert.Signature()
+	sig, err := decodeSignature(encodedSig)
+	if err != nil {
+		return err
+	}
+	if err := pubKey.verify(content, sig); err != nil {
+		return fmt.Errorf("failed signature verification: %v", err)
+	}
+	return nil
+}
+
 type timestamped interface {
 	Timestamp() time.Time
 }
@@ -440,6 +672,7 @@ func CheckCrossConsistency(assert Assertion, signature Signature, signingKey *Ac
 // DatabaseConfig.Checkers.
 var DefaultCheckers = []Checker{
 	CheckSigningKeyIsNotExpired,
+	CheckSigningKeyIsNotRevoked,
 	CheckSignature,
 	CheckTimestampVsSigningKeyValidity,
 	CheckCrossConsistency,
@@ -167,7 +167,7 @@ func (chks *checkSuite) SetUpTest(c *C) {
 	chks.bs, err = asserts.OpenFSBackstore(topDir)
 	c.Assert(err, IsNil)
 
-	headers := map[string]string{
+	headers := map[string]interface{}{
 		"authority-id": "canonical",
 		"primary-key":  "0",
 	}
@@ -275,7 +275,7 @@ func (safs *signAddFindSuite) SetUpTest(c *C) {
 }
 
 func (safs *signAddFindSuite) TestSign(c *C) {
-	headers := map[string]string{
+	headers := map[string]interface{}{
 		"authority-id": "canonical",
 		"primary-key":  "a",
 	}
@@ -287,7 +287,7 @@ func (safs *signAddFindSuite) TestSign(c *C) {
 }
 
 func (safs *signAddFindSuite) TestSignEmptyKeyID(c *C) {
-	headers := map[string]string{
+	headers := map[string]interface{}{
 		"authority-id": "canonical",
 		"primary-key":  "a",
 	}
@@ -297,7 +297,7 @@ func (safs *signAddFindSuite) TestSignEmptyKeyID(c *C) {
 }
 
 func (safs *signAddFindSuite) TestSignMissingAuthorityId(c *C) {
-	headers := map[string]string{
+	headers := map[string]interface{}{
 		"primary-key": "a",
 	}
 	a1, err := safs.signingDB.Sign(asserts.TestOnlyType, headers, nil, safs.signingKeyID)
@@ -306,7 +306,7 @@ func (safs *signAddFindSuite) TestSignMissingAuthorityId(c *C) {
 }
 
 func (safs *signAddFindSuite) TestSignMissingPrimaryKey(c *C) {
-	headers := map[string]string{
+	headers := map[string]interface{}{
 		"authority-id": "canonical",
 	}
 	a1, err := safs.signingDB.Sign(asserts.TestOnlyType, headers, nil, safs.signingKeyID)
@@ -315,7 +315,7 @@ func (safs *signAddFindSuite) TestSignMissingPrimaryKey(c *C) {
 }
 
 func (safs *signAddFindSuite) TestSignNoPrivateKey(c *C) {
-	headers := map[string]string{
+	headers := map[string]interface{}{
 		"authority-id": "canonical",
 		"primary-key":  "a",
 	}
@@ -325,7 +325,7 @@ func (safs *signAddFindSuite) TestSignNoPrivateKey(c *C) {
 }
 
 func (safs *signAddFindSuite) TestSignUnknownType(c *C) {
-	headers := map[string]string{
+	headers := map[string]interface{}{
 		"authority-id": "canonical",
 	}
 	a1, err := safs.signingDB.Sign(&asserts.AssertionType{Name: "xyz", PrimaryKey: nil}, headers, nil, safs.signingKeyID)
@@ -334,7 +334,7 @@ func (safs *signAddFindSuite) TestSignUnknownType(c *C) {
 }
 
 func (safs *signAddFindSuite) TestSignNonPredefinedType(c *C) {
-	headers := map[string]string{
+	headers := map[string]interface{}{
 		"authority-id": "canonical",
 	}
 	a1, err := safs.signingDB.Sign(&asserts.AssertionType{Name: "test-only", PrimaryKey: nil}, headers, nil, safs.signingKeyID)
@@ -343,7 +343,7 @@ func (safs *signAddFindSuite) TestSignNonPredefinedType(c *C) {
 }
 
 func (safs *signAddFindSuite) TestSignBadRevision(c *C) {
-	headers := map[string]string{
+	headers := map[string]interface{}{
 		"authority-id": "canonical",
 		"primary-key":  "a",
 		"revision":     "zzz",
@@ -354,7 +354,7 @@ func (safs *signAddFindSuite) TestSignBadRevision(c *C) {
 }
 
 func (safs *signAddFindSuite) TestSignAssemblerError(c *C) {
-	headers := map[string]string{
+	headers := map[string]interface{}{
 		"authority-id": "canonical",
 		"primary-key":  "a",
 		"count":        "zzz",
@@ -365,7 +365,7 @@ func (safs *signAddFindSuite) TestSignAssemblerError(c *C) {
 }
 
 func (safs *signAddFindSuite) TestAddSuperseding(c *C) {
-	headers := map[string]string{
+	headers := map[string]interface{}{
 		"authority-id": "canonical",
 		"primary-key":  "a",
 	}
@@ -401,7 +401,7 @@ func (safs *signAddFindSuite) TestAddSuperseding(c *C) {
 }
 
 func (safs *signAddFindSuite) TestFindNotFound(c *C) {
-	headers := map[string]string{
+	headers := map[string]interface{}{
 		"authority-id": "canonical",
 		"primary-key":  "a",
 	}
@@ -433,7 +433,7 @@ func (safs *signAddFindSuite) TestFindPrimaryLeftOut(c *C) {
 }
 
 func (safs *signAddFindSuite) TestFindMany(c *C) {
-	headers := map[string]string{
+	headers := map[string]interface{}{
 		"authority-id": "canonical",
 		"primary-key":  "a",
 		"other":        "other-x",
@@ -443,7 +443,7 @@ func (safs *signAddFindSuite) TestFindMany(c *C) {
 	err = safs.db.Add(aa)
 	c.Assert(err, IsNil)
 
-	headers = map[string]string{
+	headers = map[string]interface{}{
 		"authority-id": "canonical",
 		"primary-key":  "b",
 		"other":        "other-y",
@@ -453,7 +453,7 @@ func (safs *signAddFindSuite) TestFindMany(c *C) {
 	err = safs.db.Add(ab)
 	c.Assert(err, IsNil)
 
-	headers = map[string]string{
+	headers = map[string]interface{}{
 		"authority-id": "canonical",
 		"primary-key":  "c",
 		"other":        "other-x",
@@ -498,13 +498,226 @@ func (safs *signAddFindSuite) TestFindMany(c *C) {
 	c.Check(err, Equals, asserts.ErrNotFound)
 }
 
+func (safs *signAddFindSuite) TestBundle(c *C) {
+	pk1 := asserts.OpenPGPPrivateKey(testPrivKey1)
+	c.Assert(safs.signingDB.ImportKey("acc-id1", pk1), IsNil)
+	accKey := safs.addAccountKey(c, "acc-id1", pk1, nil)
+
+	a, err := safs.signingDB.Sign(asserts.TestOnlyType, map[string]interface{}{
+		"authority-id": "acc-id1",
+		"primary-key":  "a",
+	}, nil, pk1.PublicKey().ID())
+	c.Assert(err, IsNil)
+	c.Assert(safs.db.Add(a), IsNil)
+
+	b, err := safs.signingDB.Sign(asserts.TestOnlyType, map[string]interface{}{
+		"authority-id": "canonical",
+		"primary-key":  "b",
+	}, nil, safs.signingKeyID)
+	c.Assert(err, IsNil)
+	c.Assert(safs.db.Add(b), IsNil)
+
+	bundle, err := safs.db.Bundle([]*asserts.Ref{a.Ref(), b.Ref(), accKey.Ref()})
+	c.Assert(err, IsNil)
+	// the trusted key is left out, the signing key comes first
+	refs := make([]string, len(bundle))
+	for i, a := range bundle {
+		refs[i] = a.Ref().Unique()
+	}
+	c.Check(refs, DeepEquals, []string{accKey.Ref().Unique(), a.Ref().Unique(), b.Ref().Unique()})
+}
+
+func (safs *signAddFindSuite) TestBundleNotFound(c *C) {
+	ref := &asserts.Ref{Type: asserts.TestOnlyType, PrimaryKey: []string{"missing"}}
+	_, err := safs.db.Bundle([]*asserts.Ref{ref})
+	c.Check(err, ErrorMatches, `cannot retrieve test-only \(missing\): assertion not found`)
+}
+
+func (safs *signAddFindSuite) TestPrune(c *C) {
+	for _, primKey := range []string{"a", "b", "c"} {
+		headers := map[string]interface{}{
+			"authority-id": "canonical",
+			"primary-key":  primKey,
+		}
+		a, err := safs.signingDB.Sign(asserts.TestOnlyType, headers, nil, safs.signingKeyID)
+		c.Assert(err, IsNil)
+		c.Assert(safs.db.Add(a), IsNil)
+	}
+
+	err := safs.db.Prune(asserts.TestOnlyType, func(a asserts.Assertion) bool {
+		return a.Header("primary-key") != "b"
+	})
+	c.Assert(err, IsNil)
+
+	res, err := safs.db.FindMany(asserts.TestOnlyType, nil)
+	c.Assert(err, IsNil)
+	c.Assert(res, HasLen, 1)
+	c.Check(res[0].Header("primary-key"), Equals, "b")
+}
+
+func (safs *signAddFindSuite) TestPruneNeverTrusted(c *C) {
+	err := safs.db.Prune(asserts.AccountKeyType, func(a asserts.Assertion) bool {
+		return true
+	})
+	c.Assert(err, IsNil)
+
+	_, err = safs.db.Find(asserts.AccountKeyType, map[string]string{
+		"account-id":    "canonical",
+		"public-key-id": safs.signingKeyID,
+	})
+	c.Check(err, IsNil)
+}
+
+func (safs *signAddFindSuite) addAccountKey(c *C, accountID string, pk asserts.PrivateKey, extra map[string]interface{}) *asserts.AccountKey {
+	pubKeyEncoded, err := asserts.EncodePublicKey(pk.PublicKey())
+	c.Assert(err, IsNil)
+
+	now := time.Now().UTC()
+	headers := map[string]interface{}{
+		"authority-id":           "canonical",
+		"account-id":             accountID,
+		"public-key-id":          pk.PublicKey().ID(),
+		"public-key-fingerprint": pk.PublicKey().Fingerprint(),
+		"since":                  now.Format(time.RFC3339),
+		"until":                  now.AddDate(1, 0, 0).Format(time.RFC3339),
+	}
+	for k, v := range extra {
+		headers[k] = v
+	}
+	accKey, err := safs.signingDB.Sign(asserts.AccountKeyType, headers, []byte(pubKeyEncoded), safs.signingKeyID)
+	c.Assert(err, IsNil)
+	c.Assert(safs.db.Add(accKey), IsNil)
+	return accKey.(*asserts.AccountKey)
+}
+
+func (safs *signAddFindSuite) TestRevokedKeyDropRevoked(c *C) {
+	pk1 := asserts.OpenPGPPrivateKey(testPrivKey1)
+	c.Assert(safs.signingDB.ImportKey("acc-id1", pk1), IsNil)
+	safs.addAccountKey(c, "acc-id1", pk1, nil)
+
+	a, err := safs.signingDB.Sign(asserts.TestOnlyType, map[string]interface{}{
+		"authority-id": "acc-id1",
+		"primary-key":  "a",
+	}, nil, pk1.PublicKey().ID())
+	c.Assert(err, IsNil)
+	c.Assert(safs.db.Add(a), IsNil)
+
+	b, err := safs.signingDB.Sign(asserts.TestOnlyType, map[string]interface{}{
+		"authority-id": "canonical",
+		"primary-key":  "b",
+	}, nil, safs.signingKeyID)
+	c.Assert(err, IsNil)
+	c.Assert(safs.db.Add(b), IsNil)
+
+	// nothing to drop yet
+	dropped, err := safs.db.DropRevoked()
+	c.Assert(err, IsNil)
+	c.Check(dropped, HasLen, 0)
+
+	// revoke the key
+	revokedKey := safs.addAccountKey(c, "acc-id1", pk1, map[string]interface{}{
+		"revision": "1",
+		"revoked":  "true",
+	})
+	c.Check(revokedKey.Revoked(), Equals, true)
+
+	dropped, err = safs.db.DropRevoked()
+	c.Assert(err, IsNil)
+	c.Assert(dropped, HasLen, 1)
+	c.Check(dropped[0].Ref(), DeepEquals, a.Ref())
+
+	_, err = safs.db.Find(asserts.TestOnlyType, map[string]string{"primary-key": "a"})
+	c.Check(err, Equals, asserts.ErrNotFound)
+	_, err = safs.db.Find(asserts.TestOnlyType, map[string]string{"primary-key": "b"})
+	c.Check(err, IsNil)
+
+	// the revoked key is kept and refuses assertions signed with it
+	accKey, err := safs.db.Find(asserts.AccountKeyType, map[string]string{
+		"account-id":    "acc-id1",
+		"public-key-id": pk1.PublicKey().ID(),
+	})
+	c.Assert(err, IsNil)
+	c.Check(accKey.(*asserts.AccountKey).Revoked(), Equals, true)
+
+	err = safs.db.Add(a)
+	c.Check(err, ErrorMatches, `assertion is signed with revoked public key "[a-f0-9]+" from "acc-id1"`)
+
+	// rotate to a new key
+	pk2 := asserts.OpenPGPPrivateKey(testPrivKey2)
+	c.Assert(safs.signingDB.ImportKey("acc-id1", pk2), IsNil)
+	safs.addAccountKey(c, "acc-id1", pk2, nil)
+
+	a2, err := safs.signingDB.Sign(asserts.TestOnlyType, map[string]interface{}{
+		"authority-id": "acc-id1",
+		"primary-key":  "a",
+	}, nil, pk2.PublicKey().ID())
+	c.Assert(err, IsNil)
+	c.Check(safs.db.Add(a2), IsNil)
+}
+
+func (safs *signAddFindSuite) TestDropRevokedDependents(c *C) {
+	pk1 := asserts.OpenPGPPrivateKey(testPrivKey1)
+	c.Assert(safs.signingDB.ImportKey("acc-id1", pk1), IsNil)
+	safs.addAccountKey(c, "acc-id1", pk1, nil)
+
+	// a key certified by the account whose key gets revoked
+	pk2 := asserts.OpenPGPPrivateKey(testPrivKey2)
+	pubKey2Encoded, err := asserts.EncodePublicKey(pk2.PublicKey())
+	c.Assert(err, IsNil)
+	now := time.Now().UTC()
+	accKey2, err := safs.signingDB.Sign(asserts.AccountKeyType, map[string]interface{}{
+		"authority-id":           "acc-id1",
+		"account-id":             "acc-id2",
+		"public-key-id":          pk2.PublicKey().ID(),
+		"public-key-fingerprint": pk2.PublicKey().Fingerprint(),
+		"since":                  now.Format(time.RFC3339),
+		"until":                  now.AddDate(1, 0, 0).Format(time.RFC3339),
+	}, []byte(pubKey2Encoded), pk1.PublicKey().ID())
+	c.Assert(err, IsNil)
+	c.Assert(safs.db.Add(accKey2), IsNil)
+
+	c.Assert(safs.signingDB.ImportKey("acc-id2", pk2), IsNil)
+	a, err := safs.signingDB.Sign(asserts.TestOnlyType, map[string]interface{}{
+		"authority-id": "acc-id2",
+		"primary-key":  "a",
+	}, nil, pk2.PublicKey().ID())
+	c.Assert(err, IsNil)
+	c.Assert(safs.db.Add(a), IsNil)
+
+	safs.addAccountKey(c, "acc-id1", pk1, map[string]interface{}{
+		"revision": "1",
+		"revoked":  "true",
+	})
+
+	dropped, err := safs.db.DropRevoked()
+	c.Assert(err, IsNil)
+	c.Assert(dropped, HasLen, 2)
+	c.Check(dropped[0].Ref(), DeepEquals, accKey2.Ref())
+	c.Check(dropped[1].Ref(), DeepEquals, a.Ref())
+}
+
+func (safs *signAddFindSuite) TestReindex(c *C) {
+	headers := map[string]interface{}{
+		"authority-id": "canonical",
+		"primary-key":  "a",
+	}
+	a, err := safs.signingDB.Sign(asserts.TestOnlyType, headers, nil, safs.signingKeyID)
+	c.Assert(err, IsNil)
+	c.Assert(safs.db.Add(a), IsNil)
+
+	c.Assert(safs.db.Reindex(), IsNil)
+
+	_, err = safs.db.Find(asserts.TestOnlyType, map[string]string{"primary-key": "a"})
+	c.Check(err, IsNil)
+}
+
 func (safs *signAddFindSuite) TestFindFindsTrustedAccountKeys(c *C) {
 	pk1 := asserts.OpenPGPPrivateKey(testPrivKey1)
 	pubKey1Encoded, err := asserts.EncodePublicKey(pk1.PublicKey())
 	c.Assert(err, IsNil)
 
 	now := time.Now().UTC()
-	headers := map[string]string{
+	headers := map[string]interface{}{
 		"authority-id":           "canonical",
 		"account-id":             "acc-id1",
 		"public-key-id":          pk1.PublicKey().ID(),
@@ -541,7 +754,7 @@ func (safs *signAddFindSuite) TestDontLetAddConfusinglyAssertionClashingWithTrus
 	c.Assert(err, IsNil)
 
 	now := time.Now().UTC()
-	headers := map[string]string{
+	headers := map[string]interface{}{
 		"authority-id":           "canonical",
 		"account-id":             "canonical",
 		"public-key-id":          safs.signingKeyID,
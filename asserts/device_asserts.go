@@ -106,8 +106,8 @@ var _ consistencyChecker = (*Model)(nil)
 var modelMandatory = []string{"os", "architecture", "gadget", "kernel", "store", "class"}
 
 func assembleModel(assert assertionBase) (Assertion, error) {
-	if assert.headers["brand-id"] != assert.headers["authority-id"] {
-		return nil, fmt.Errorf("authority-id and brand-id must match, model assertions are expected to be signed by the brand: %q != %q", assert.headers["authority-id"], assert.headers["brand-id"])
+	if assert.Header("brand-id") != assert.Header("authority-id") {
+		return nil, fmt.Errorf("authority-id and brand-id must match, model assertions are expected to be signed by the brand: %q != %q", assert.Header("authority-id"), assert.Header("brand-id"))
 	}
 
 	for _, mandatory := range modelMandatory {
@@ -118,12 +118,12 @@ func assembleModel(assert assertionBase) (Assertion, error) {
 
 	// TODO: check 'class' value already here? fundamental policy derives from it
 
-	allowedModes, err := checkCommaSepList(assert.headers, "allowed-modes")
+	allowedModes, err := checkStringList(assert.headers, "allowed-modes")
 	if err != nil {
 		return nil, err
 	}
 
-	requiredSnaps, err := checkCommaSepList(assert.headers, "required-snaps")
+	requiredSnaps, err := checkStringList(assert.headers, "required-snaps")
 	if err != nil {
 		return nil, err
 	}
@@ -202,3 +202,50 @@ func assembleDeviceSerial(assert assertionBase) (Assertion, error) {
 		pubKey:        pubKey,
 	}, nil
 }
+
+// SerialRequest holds a serial-request assertion, which is a request
+// by a device for a device-serial assertion. It is signed with the
+// device key, on behalf of the brand of the device.
+type SerialRequest struct {
+	assertionBase
+	pubKey PublicKey
+}
+
+// BrandID returns the brand identifier of the device making the request.
+func (sreq *SerialRequest) BrandID() string {
+	return sreq.Header("brand-id")
+}
+
+// Model returns the model name identifier of the device making the request.
+func (sreq *SerialRequest) Model() string {
+	return sreq.Header("model")
+}
+
+// DeviceKey returns the public key of the device making the request.
+func (sreq *SerialRequest) DeviceKey() PublicKey {
+	return sreq.pubKey
+}
+
+func assembleSerialRequest(assert assertionBase) (Assertion, error) {
+	if assert.Header("brand-id") != assert.Header("authority-id") {
+		return nil, fmt.Errorf("authority-id and brand-id must match, serial-request assertions are expected to be made on behalf of the brand: %q != %q", assert.Header("authority-id"), assert.Header("brand-id"))
+	}
+
+	encodedKey, err := checkMandatory(assert.headers, "device-key")
+	if err != nil {
+		return nil, err
+	}
+	pubKey, err := decodePublicKey([]byte(encodedKey))
+	if err != nil {
+		return nil, err
+	}
+	if pubKey.ID() != assert.Header("device-key-id") {
+		return nil, fmt.Errorf("device key does not match provided key id")
+	}
+
+	// ignore extra headers and non-empty body for future compatibility
+	return &SerialRequest{
+		assertionBase: assert,
+		pubKey:        pubKey,
+	}, nil
+}
@@ -37,6 +37,7 @@ type modelSuite struct {
 var (
 	_ = Suite(&deviceSerialSuite{})
 	_ = Suite(&modelSuite{})
+	_ = Suite(&serialReqSuite{})
 )
 
 func (mods *modelSuite) SetUpSuite(c *C) {
@@ -83,6 +84,17 @@ func (mods *modelSuite) TestDecodeOK(c *C) {
 	c.Check(model.RequiredSnaps(), DeepEquals, []string{"foo", "bar"})
 }
 
+func (mods *modelSuite) TestDecodeListHeaders(c *C) {
+	encoded := strings.Replace(modelExample, "TSLINE", mods.tsLine, 1)
+	encoded = strings.Replace(encoded, "required-snaps: foo, bar\n", "required-snaps:\n  - foo\n  - bar\n", 1)
+	encoded = strings.Replace(encoded, "allowed-modes: \n", "allowed-modes:\n  - classic\n", 1)
+	a, err := asserts.Decode([]byte(encoded))
+	c.Assert(err, IsNil)
+	model := a.(*asserts.Model)
+	c.Check(model.RequiredSnaps(), DeepEquals, []string{"foo", "bar"})
+	c.Check(model.AllowedModes(), DeepEquals, []string{"classic"})
+}
+
 const (
 	modelErrPrefix = "assertion model: "
 )
@@ -105,6 +117,7 @@ func (mods *modelSuite) TestDecodeInvalid(c *C) {
 	invalidTests := []struct{ original, invalid, expectedErr string }{
 		{"brand-id: brand-id1\n", "brand-id: random\n", `authority-id and brand-id must match, model assertions are expected to be signed by the brand: "brand-id1" != "random"`},
 		{"required-snaps: foo, bar\n", "required-snaps: foo,\n", `empty entry in comma separated "required-snaps" header: "foo,"`},
+		{"required-snaps: foo, bar\n", "required-snaps:\n  foo: bar\n", `"required-snaps" header must be a list of strings`},
 		{"allowed-modes: \n", "allowed-modes: ,\n", `empty entry in comma separated "allowed-modes" header: ","`},
 		{mods.tsLine, "timestamp: 12:30\n", `"timestamp" header is not a RFC3339 date: .*`},
 	}
@@ -212,3 +225,83 @@ func (dss *deviceSerialSuite) TestDecodeInvalid(c *C) {
 		c.Check(err, ErrorMatches, deviceSerialErrPrefix+test.expectedErr)
 	}
 }
+
+type serialReqSuite struct {
+	deviceKey     asserts.PrivateKey
+	encodedDevKey string
+}
+
+func (srs *serialReqSuite) SetUpSuite(c *C) {
+	srs.deviceKey = asserts.OpenPGPPrivateKey(testPrivKey2)
+	encodedPubKey, err := asserts.EncodePublicKey(srs.deviceKey.PublicKey())
+	c.Assert(err, IsNil)
+	srs.encodedDevKey = string(encodedPubKey)
+}
+
+func (srs *serialReqSuite) signingDB(c *C) *asserts.Database {
+	db, err := asserts.OpenDatabase(&asserts.DatabaseConfig{
+		KeypairManager: asserts.NewMemoryKeypairManager(),
+	})
+	c.Assert(err, IsNil)
+	c.Assert(db.ImportKey("brand-id1", srs.deviceKey), IsNil)
+	return db
+}
+
+func (srs *serialReqSuite) TestSignAndDecode(c *C) {
+	db := srs.signingDB(c)
+	keyID := srs.deviceKey.PublicKey().ID()
+
+	a, err := db.Sign(asserts.SerialRequestType, map[string]interface{}{
+		"authority-id":  "brand-id1",
+		"brand-id":      "brand-id1",
+		"model":         "baz-3000",
+		"device-key":    srs.encodedDevKey,
+		"device-key-id": keyID,
+	}, nil, keyID)
+	c.Assert(err, IsNil)
+
+	decoded, err := asserts.Decode(asserts.Encode(a))
+	c.Assert(err, IsNil)
+	c.Check(decoded.Type(), Equals, asserts.SerialRequestType)
+	req := decoded.(*asserts.SerialRequest)
+	c.Check(req.BrandID(), Equals, "brand-id1")
+	c.Check(req.Model(), Equals, "baz-3000")
+	c.Check(req.DeviceKey().ID(), Equals, keyID)
+
+	c.Check(asserts.SignatureCheck(req, req.DeviceKey()), IsNil)
+	otherKey := asserts.OpenPGPPrivateKey(testPrivKey1).PublicKey()
+	c.Check(asserts.SignatureCheck(req, otherKey), ErrorMatches, "failed signature verification: .*")
+}
+
+const (
+	serialReqErrPrefix = "assertion serial-request: "
+)
+
+func (srs *serialReqSuite) TestDecodeInvalid(c *C) {
+	encoded := "type: serial-request\n" +
+		"authority-id: brand-id1\n" +
+		"brand-id: brand-id1\n" +
+		"model: baz-3000\n" +
+		"device-key:\n DEVICEKEY\n" +
+		"device-key-id: KEYID\n" +
+		"body-length: 0" +
+		"\n\n" +
+		"openpgp c2ln"
+
+	invalidTests := []struct{ original, invalid, expectedErr string }{
+		{"brand-id: brand-id1\n", "brand-id: brand-id2\n", `authority-id and brand-id must match, serial-request assertions are expected to be made on behalf of the brand: "brand-id1" != "brand-id2"`},
+		{"model: baz-3000\n", "", `"model" header is mandatory`},
+		{"device-key:\n DEVICEKEY\n", "", `"device-key" header is mandatory`},
+		{"device-key:\n DEVICEKEY\n", "device-key: openpgp ZZZ\n", `public key: could not decode base64 data:.*`},
+		{"device-key-id: KEYID\n", "device-key-id: 0000\n", "device key does not match provided key id"},
+	}
+
+	for _, test := range invalidTests {
+		invalid := strings.Replace(encoded, test.original, test.invalid, 1)
+		invalid = strings.Replace(invalid, "DEVICEKEY", strings.Replace(srs.encodedDevKey, "\n", "\n ", -1), 1)
+		invalid = strings.Replace(invalid, "KEYID", srs.deviceKey.PublicKey().ID(), 1)
+
+		_, err := asserts.Decode([]byte(invalid))
+		c.Check(err, ErrorMatches, serialReqErrPrefix+test.expectedErr)
+	}
+}
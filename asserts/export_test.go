@@ -37,6 +37,9 @@ var AssembleAndSignInTest = assembleAndSign
 // decodePrivateKey exposed for tests
 var DecodePrivateKeyInTest = decodePrivateKey
 
+// signContent exposed for tests
+var SignContentInTest = signContent
+
 // NewDecoderStressed makes a Decoder with a stressed setup with the given buffer and maximum sizes.
 func NewDecoderStressed(r io.Reader, bufSize, maxHeadersSize, maxBodySize, maxSigSize int) *Decoder {
 	return (&Decoder{
@@ -57,7 +60,7 @@ func makeAccountKeyForTest(authorityID string, pubKey *packet.PublicKey, validYe
 	openPGPPubKey := OpenPGPPublicKey(pubKey)
 	return &AccountKey{
 		assertionBase: assertionBase{
-			headers: map[string]string{
+			headers: map[string]interface{}{
 				"authority-id":  authorityID,
 				"account-id":    authorityID,
 				"public-key-id": openPGPPubKey.ID(),
@@ -112,3 +115,12 @@ func init() {
 func AccountKeyIsKeyValidAt(ak *AccountKey, when time.Time) bool {
 	return ak.isKeyValidAt(when)
 }
+
+// MockFSIndexedHeaders mocks the headers indexed by the filesystem backstore
+func MockFSIndexedHeaders(indexed map[string][]string) (restore func()) {
+	old := fsIndexedHeaders
+	fsIndexedHeaders = indexed
+	return func() {
+		fsIndexedHeaders = old
+	}
+}
@@ -0,0 +1,193 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package asserts
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// The external keypair manager delegates all operations involving
+// private keys to a helper program, so that those never need to be
+// loaded into this process. The helper is invoked once per operation as
+//
+//   <helper> <command> [<args>...]
+//
+// reading any input from its stdin and writing its results to its
+// stdout. A non-zero exit status signals failure, with the reason
+// written to stderr. The supported commands are:
+//
+//   list
+//       print a "<authority-id> <key-id>" line for each available key pair
+//   public-key <authority-id> <key-id>
+//       print the OpenPGP public key packet of the key pair, in binary form
+//   sign <authority-id> <key-id>
+//       sign the content read from stdin with the key pair, using SHA256,
+//       and print the OpenPGP signature packet, in binary form
+
+type externalKeypairManager struct {
+	helper string
+}
+
+// NewExternalKeypairManager returns a KeypairManager that delegates
+// to the given helper program for all operations involving private
+// keys. Its key pairs can be used to sign but private keys cannot
+// be imported into it.
+func NewExternalKeypairManager(helper string) (KeypairManager, error) {
+	path, err := exec.LookPath(helper)
+	if err != nil {
+		return nil, fmt.Errorf("cannot find external keypair manager helper: %v", err)
+	}
+	return &externalKeypairManager{helper: path}, nil
+}
+
+func (em *externalKeypairManager) run(input []byte, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(em.helper, args...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, fmt.Errorf("external keypair manager %s failed: %s", args[0], msg)
+	}
+	return stdout.Bytes(), nil
+}
+
+var errExternalPut = errors.New("cannot import private keys into an external keypair manager")
+
+func (em *externalKeypairManager) Put(authorityID string, privKey PrivateKey) error {
+	return errExternalPut
+}
+
+func (em *externalKeypairManager) List() ([]KeypairRef, error) {
+	out, err := em.run(nil, "list")
+	if err != nil {
+		return nil, err
+	}
+	var refs []KeypairRef
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("external keypair manager list returned malformed line: %q", line)
+		}
+		refs = append(refs, KeypairRef{AuthorityID: fields[0], KeyID: fields[1]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	sort.Sort(byAuthorityAndKeyID(refs))
+	return refs, nil
+}
+
+func (em *externalKeypairManager) Get(authorityID, keyID string) (PrivateKey, error) {
+	refs, err := em.List()
+	if err != nil {
+		return nil, err
+	}
+	found := false
+	for _, ref := range refs {
+		if ref.AuthorityID == authorityID && ref.KeyID == keyID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, errKeypairNotFound
+	}
+
+	out, err := em.run(nil, "public-key", authorityID, keyID)
+	if err != nil {
+		return nil, err
+	}
+	pkt, err := packet.Read(bytes.NewReader(out))
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode public key from external keypair manager: %v", err)
+	}
+	pubk, ok := pkt.(*packet.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("expected public key from external keypair manager, got instead: %T", pkt)
+	}
+	pubKey := OpenPGPPublicKey(pubk)
+	if pubKey.ID() != keyID {
+		return nil, fmt.Errorf("external keypair manager returned public key %q instead of %q", pubKey.ID(), keyID)
+	}
+	return &externalPrivateKey{
+		em:          em,
+		authorityID: authorityID,
+		pubKey:      pubKey,
+	}, nil
+}
+
+// externalPrivateKey is a handle on a private key held by the helper
+// of an external keypair manager.
+type externalPrivateKey struct {
+	em          *externalKeypairManager
+	authorityID string
+	pubKey      PublicKey
+}
+
+func (extKey *externalPrivateKey) PublicKey() PublicKey {
+	return extKey.pubKey
+}
+
+func (extKey *externalPrivateKey) keyFormat() string {
+	return "openpgp"
+}
+
+func (extKey *externalPrivateKey) keyEncode(w io.Writer) error {
+	return fmt.Errorf("cannot export private key held by an external keypair manager")
+}
+
+func (extKey *externalPrivateKey) signContent(content []byte) ([]byte, error) {
+	out, err := extKey.em.run(content, "sign", extKey.authorityID, extKey.pubKey.ID())
+	if err != nil {
+		return nil, err
+	}
+	pkt, err := packet.Read(bytes.NewReader(out))
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode signature from external keypair manager: %v", err)
+	}
+	sig, ok := pkt.(*packet.Signature)
+	if !ok || sig.IssuerKeyId == nil {
+		return nil, fmt.Errorf("expected signature with issuer key id from external keypair manager, got instead: %T", pkt)
+	}
+	// don't trust the helper blindly
+	if err := extKey.pubKey.verify(content, openpgpSignature{sig}); err != nil {
+		return nil, fmt.Errorf("external keypair manager produced a bad signature: %v", err)
+	}
+	return encodeFormatAndData("openpgp", out), nil
+}
@@ -0,0 +1,204 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package asserts_test
+
+import (
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/ubuntu-core/snappy/asserts"
+)
+
+// TestFakeKeypairHelper is not a real test, it implements the external
+// keypair manager helper protocol on top of a filesystem keypair
+// manager when the test binary is run through the fake helper script.
+func TestFakeKeypairHelper(t *testing.T) {
+	dir := os.Getenv("SNAPPY_FAKE_KEYPAIR_HELPER_DIR")
+	if dir == "" {
+		return
+	}
+	if err := runFakeKeypairHelper(dir, flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func runFakeKeypairHelper(dir string, args []string) error {
+	keypairMgr, err := asserts.OpenFSKeypairManager(dir)
+	if err != nil {
+		return err
+	}
+	switch {
+	case len(args) == 1 && args[0] == "list":
+		refs, err := keypairMgr.(asserts.KeypairLister).List()
+		if err != nil {
+			return err
+		}
+		for _, ref := range refs {
+			fmt.Printf("%s %s\n", ref.AuthorityID, ref.KeyID)
+		}
+	case len(args) == 3 && args[0] == "public-key":
+		privKey, err := keypairMgr.Get(args[1], args[2])
+		if err != nil {
+			return err
+		}
+		encoded, err := asserts.EncodePublicKey(privKey.PublicKey())
+		if err != nil {
+			return err
+		}
+		return writeDecodedPacket(encoded)
+	case len(args) == 3 && args[0] == "sign":
+		privKey, err := keypairMgr.Get(args[1], args[2])
+		if err != nil {
+			return err
+		}
+		content, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		if os.Getenv("SNAPPY_FAKE_KEYPAIR_HELPER_TAMPER") != "" {
+			content = append(content, "tampered"...)
+		}
+		signature, err := asserts.SignContentInTest(content, privKey)
+		if err != nil {
+			return err
+		}
+		return writeDecodedPacket(signature)
+	default:
+		return fmt.Errorf("unsupported command: %q", args)
+	}
+	return nil
+}
+
+// writeDecodedPacket writes the binary packet behind its "openpgp <base64>" encoding.
+func writeDecodedPacket(encoded []byte) error {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(string(encoded), "openpgp "))
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
+
+type extKeypairMgrSuite struct {
+	helper     string
+	keysDir    string
+	keypairMgr asserts.KeypairManager
+}
+
+var _ = Suite(&extKeypairMgrSuite{})
+
+func (ekms *extKeypairMgrSuite) SetUpTest(c *C) {
+	tmpDir := c.MkDir()
+	ekms.keysDir = filepath.Join(tmpDir, "keys")
+	fsKeypairMgr, err := asserts.OpenFSKeypairManager(ekms.keysDir)
+	c.Assert(err, IsNil)
+	c.Assert(fsKeypairMgr.Put("canonical", asserts.OpenPGPPrivateKey(testPrivKey0)), IsNil)
+	c.Assert(fsKeypairMgr.Put("developer1", asserts.OpenPGPPrivateKey(testPrivKey1)), IsNil)
+
+	ekms.helper = filepath.Join(tmpDir, "fake-keypair-helper")
+	script := fmt.Sprintf("#!/bin/sh\nSNAPPY_FAKE_KEYPAIR_HELPER_DIR=%s exec %s -test.run='^TestFakeKeypairHelper$' -- \"$@\"\n", ekms.keysDir, os.Args[0])
+	c.Assert(ioutil.WriteFile(ekms.helper, []byte(script), 0755), IsNil)
+
+	ekms.keypairMgr, err = asserts.NewExternalKeypairManager(ekms.helper)
+	c.Assert(err, IsNil)
+}
+
+func (ekms *extKeypairMgrSuite) TestNewMissingHelper(c *C) {
+	_, err := asserts.NewExternalKeypairManager(filepath.Join(c.MkDir(), "missing"))
+	c.Check(err, ErrorMatches, "cannot find external keypair manager helper: .*")
+}
+
+func (ekms *extKeypairMgrSuite) TestList(c *C) {
+	refs, err := ekms.keypairMgr.(asserts.KeypairLister).List()
+	c.Assert(err, IsNil)
+	c.Check(refs, DeepEquals, []asserts.KeypairRef{
+		{AuthorityID: "canonical", KeyID: asserts.OpenPGPPrivateKey(testPrivKey0).PublicKey().ID()},
+		{AuthorityID: "developer1", KeyID: asserts.OpenPGPPrivateKey(testPrivKey1).PublicKey().ID()},
+	})
+}
+
+func (ekms *extKeypairMgrSuite) TestGet(c *C) {
+	expected := asserts.OpenPGPPrivateKey(testPrivKey1).PublicKey()
+	privKey, err := ekms.keypairMgr.Get("developer1", expected.ID())
+	c.Assert(err, IsNil)
+	c.Check(privKey.PublicKey().Fingerprint(), Equals, expected.Fingerprint())
+
+	_, err = ekms.keypairMgr.Get("canonical", expected.ID())
+	c.Check(err, ErrorMatches, "no matching key pair found")
+}
+
+func (ekms *extKeypairMgrSuite) TestPutUnsupported(c *C) {
+	err := ekms.keypairMgr.Put("developer1", asserts.OpenPGPPrivateKey(testPrivKey2))
+	c.Check(err, ErrorMatches, "cannot import private keys into an external keypair manager")
+}
+
+func (ekms *extKeypairMgrSuite) TestSignAndCheck(c *C) {
+	signingDB, err := asserts.OpenDatabase(&asserts.DatabaseConfig{
+		KeypairManager: ekms.keypairMgr,
+	})
+	c.Assert(err, IsNil)
+	keyID := asserts.OpenPGPPrivateKey(testPrivKey0).PublicKey().ID()
+
+	a, err := signingDB.Sign(asserts.TestOnlyType, map[string]interface{}{
+		"authority-id": "canonical",
+		"primary-key":  "a",
+	}, nil, keyID)
+	c.Assert(err, IsNil)
+
+	db, err := asserts.OpenDatabase(&asserts.DatabaseConfig{
+		KeypairManager: asserts.NewMemoryKeypairManager(),
+		TrustedKeys:    []*asserts.AccountKey{asserts.BootstrapAccountKeyForTest("canonical", &testPrivKey0.PublicKey)},
+	})
+	c.Assert(err, IsNil)
+	c.Check(db.Check(a), IsNil)
+}
+
+func (ekms *extKeypairMgrSuite) TestSignBadSignature(c *C) {
+	os.Setenv("SNAPPY_FAKE_KEYPAIR_HELPER_TAMPER", "1")
+	defer os.Unsetenv("SNAPPY_FAKE_KEYPAIR_HELPER_TAMPER")
+
+	signingDB, err := asserts.OpenDatabase(&asserts.DatabaseConfig{
+		KeypairManager: ekms.keypairMgr,
+	})
+	c.Assert(err, IsNil)
+	keyID := asserts.OpenPGPPrivateKey(testPrivKey0).PublicKey().ID()
+
+	_, err = signingDB.Sign(asserts.TestOnlyType, map[string]interface{}{
+		"authority-id": "canonical",
+		"primary-key":  "a",
+	}, nil, keyID)
+	c.Check(err, ErrorMatches, "failed to sign assertion: external keypair manager produced a bad signature: .*")
+}
+
+func (ekms *extKeypairMgrSuite) TestHelperFailure(c *C) {
+	c.Assert(ioutil.WriteFile(ekms.helper, []byte("#!/bin/sh\necho 'device unavailable' >&2\nexit 1\n"), 0755), IsNil)
+	_, err := ekms.keypairMgr.(asserts.KeypairLister).List()
+	c.Check(err, ErrorMatches, "external keypair manager list failed: device unavailable")
+}
@@ -0,0 +1,138 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package asserts
+
+import (
+	"fmt"
+)
+
+type fetchProgress int
+
+const (
+	fetchNotSeen fetchProgress = iota
+	fetchRetrieved
+	fetchSaved
+)
+
+// A Fetcher helps fetching assertions and their prerequisites.
+type Fetcher interface {
+	// Fetch retrieves the assertion indicated by the given ref and its prerequisites.
+	Fetch(*Ref) error
+	// Save retrieves the prerequisites of the assertion recursively
+	// and then saves the assertion itself.
+	Save(Assertion) error
+}
+
+type fetcher struct {
+	db       RODatabase
+	retrieve func(*Ref) (Assertion, error)
+	save     func(Assertion) error
+
+	fetched map[string]fetchProgress
+}
+
+// NewFetcher creates a Fetcher which will use retrieve to get
+// assertions missing from the given database and save to store them,
+// prerequisites first. It is meant to be used for a single session.
+func NewFetcher(db RODatabase, retrieve func(*Ref) (Assertion, error), save func(Assertion) error) Fetcher {
+	return &fetcher{
+		db:       db,
+		retrieve: retrieve,
+		save:     save,
+		fetched:  make(map[string]fetchProgress),
+	}
+}
+
+// signKeyRef returns a reference to the account-key that signed the assertion.
+func signKeyRef(a Assertion) (*Ref, error) {
+	_, signature := a.Signature()
+	sig, err := decodeSignature(signature)
+	if err != nil {
+		return nil, err
+	}
+	return &Ref{Type: AccountKeyType, PrimaryKey: []string{a.AuthorityID(), sig.KeyID()}}, nil
+}
+
+func (f *fetcher) chase(ref *Ref, a Assertion) error {
+	u := ref.Unique()
+	switch f.fetched[u] {
+	case fetchSaved:
+		return nil // nothing to do
+	case fetchRetrieved:
+		return fmt.Errorf("circular assertions are not expected: %s", ref)
+	}
+	if a == nil {
+		_, err := ref.Resolve(f.db.Find)
+		if err == nil {
+			f.fetched[u] = fetchSaved
+			return nil
+		}
+		if err != ErrNotFound {
+			return err
+		}
+		a, err = f.retrieve(ref)
+		if err != nil {
+			return fmt.Errorf("cannot retrieve %s: %v", ref, err)
+		}
+	} else {
+		// the same revision might be known already, possibly as
+		// a trusted assertion that cannot be added
+		known, err := ref.Resolve(f.db.Find)
+		if err == nil && known.Revision() == a.Revision() {
+			f.fetched[u] = fetchSaved
+			return nil
+		}
+		if err != nil && err != ErrNotFound {
+			return err
+		}
+	}
+	f.fetched[u] = fetchRetrieved
+	keyRef, err := signKeyRef(a)
+	if err != nil {
+		return err
+	}
+	// a self-signed account-key can only be trusted, so it must already be known
+	if keyRef.Unique() != u {
+		if err := f.chase(keyRef, nil); err != nil {
+			return err
+		}
+	}
+	for _, preref := range a.Prerequisites() {
+		if err := f.chase(preref, nil); err != nil {
+			return err
+		}
+	}
+	if err := f.save(a); err != nil {
+		return err
+	}
+	f.fetched[u] = fetchSaved
+	return nil
+}
+
+// Fetch retrieves the assertion indicated by the given ref and its prerequisites.
+func (f *fetcher) Fetch(ref *Ref) error {
+	return f.chase(ref, nil)
+}
+
+// Save retrieves the prerequisites of the assertion recursively
+// and then saves the assertion itself.
+func (f *fetcher) Save(a Assertion) error {
+	return f.chase(a.Ref(), a)
+}
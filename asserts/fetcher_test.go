@@ -0,0 +1,214 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package asserts_test
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/ubuntu-core/snappy/asserts"
+)
+
+type fetcherSuite struct {
+	signingDB *asserts.Database
+	db        *asserts.Database
+
+	storeKey *asserts.AccountKey
+	identity asserts.Assertion
+	snapDecl asserts.Assertion
+	snapRev  asserts.Assertion
+}
+
+var _ = Suite(&fetcherSuite{})
+
+func (fs *fetcherSuite) SetUpTest(c *C) {
+	signingDB, err := asserts.OpenDatabase(&asserts.DatabaseConfig{
+		KeypairManager: asserts.NewMemoryKeypairManager(),
+	})
+	c.Assert(err, IsNil)
+	fs.signingDB = signingDB
+	canonicalKey := asserts.OpenPGPPrivateKey(testPrivKey0)
+	c.Assert(signingDB.ImportKey("canonical", canonicalKey), IsNil)
+	storeKey := asserts.OpenPGPPrivateKey(testPrivKey1)
+	c.Assert(signingDB.ImportKey("store-id1", storeKey), IsNil)
+
+	db, err := asserts.OpenDatabase(&asserts.DatabaseConfig{
+		Backstore:      asserts.NewMemoryBackstore(),
+		KeypairManager: asserts.NewMemoryKeypairManager(),
+		TrustedKeys:    []*asserts.AccountKey{asserts.BootstrapAccountKeyForTest("canonical", &testPrivKey0.PublicKey)},
+	})
+	c.Assert(err, IsNil)
+	fs.db = db
+
+	now := time.Now().UTC()
+	tstamp := now.Format(time.RFC3339)
+	encodedStorePubKey, err := asserts.EncodePublicKey(storeKey.PublicKey())
+	c.Assert(err, IsNil)
+	a, err := signingDB.Sign(asserts.AccountKeyType, map[string]interface{}{
+		"authority-id":           "canonical",
+		"account-id":             "store-id1",
+		"public-key-id":          storeKey.PublicKey().ID(),
+		"public-key-fingerprint": storeKey.PublicKey().Fingerprint(),
+		"since":                  now.AddDate(-1, 0, 0).Format(time.RFC3339),
+		"until":                  now.AddDate(1, 0, 0).Format(time.RFC3339),
+	}, encodedStorePubKey, canonicalKey.PublicKey().ID())
+	c.Assert(err, IsNil)
+	fs.storeKey = a.(*asserts.AccountKey)
+
+	fs.identity, err = signingDB.Sign(asserts.IdentityType, map[string]interface{}{
+		"authority-id": "canonical",
+		"account-id":   "dev-id1",
+		"display-name": "Developer",
+		"validation":   "certified",
+		"timestamp":    tstamp,
+	}, nil, canonicalKey.PublicKey().ID())
+	c.Assert(err, IsNil)
+
+	fs.snapDecl, err = signingDB.Sign(asserts.SnapDeclarationType, map[string]interface{}{
+		"authority-id": "canonical",
+		"series":       "16",
+		"snap-id":      "snap-id-1",
+		"snap-name":    "foo",
+		"publisher-id": "dev-id1",
+		"gates":        "",
+		"timestamp":    tstamp,
+	}, nil, canonicalKey.PublicKey().ID())
+	c.Assert(err, IsNil)
+
+	fs.snapRev, err = signingDB.Sign(asserts.SnapRevisionType, map[string]interface{}{
+		"authority-id":  "store-id1",
+		"series":        "16",
+		"snap-id":       "snap-id-1",
+		"snap-digest":   "sha256 ...",
+		"snap-size":     "123",
+		"snap-revision": "1",
+		"developer-id":  "dev-id1",
+		"timestamp":     tstamp,
+	}, nil, storeKey.PublicKey().ID())
+	c.Assert(err, IsNil)
+}
+
+func (fs *fetcherSuite) retrieveFrom(retrieved *[]string, available ...asserts.Assertion) func(*asserts.Ref) (asserts.Assertion, error) {
+	return func(ref *asserts.Ref) (asserts.Assertion, error) {
+		*retrieved = append(*retrieved, ref.Type.Name)
+		for _, a := range available {
+			if a.Ref().Unique() == ref.Unique() {
+				return a, nil
+			}
+		}
+		return nil, asserts.ErrNotFound
+	}
+}
+
+func (fs *fetcherSuite) saveTo(saved *[]string) func(asserts.Assertion) error {
+	return func(a asserts.Assertion) error {
+		*saved = append(*saved, a.Type().Name)
+		return fs.db.Add(a)
+	}
+}
+
+func (fs *fetcherSuite) TestSaveFetchesPrerequisitesInOrder(c *C) {
+	var retrieved, saved []string
+	f := asserts.NewFetcher(fs.db, fs.retrieveFrom(&retrieved, fs.storeKey, fs.identity, fs.snapDecl), fs.saveTo(&saved))
+
+	err := f.Save(fs.snapRev)
+	c.Assert(err, IsNil)
+	c.Check(retrieved, DeepEquals, []string{"account-key", "snap-declaration", "identity"})
+	c.Check(saved, DeepEquals, []string{"account-key", "identity", "snap-declaration", "snap-revision"})
+
+	_, err = fs.snapRev.Ref().Resolve(fs.db.Find)
+	c.Check(err, IsNil)
+}
+
+func (fs *fetcherSuite) TestFetch(c *C) {
+	var retrieved, saved []string
+	f := asserts.NewFetcher(fs.db, fs.retrieveFrom(&retrieved, fs.storeKey, fs.identity, fs.snapDecl, fs.snapRev), fs.saveTo(&saved))
+
+	err := f.Fetch(fs.snapRev.Ref())
+	c.Assert(err, IsNil)
+	c.Check(saved, DeepEquals, []string{"account-key", "identity", "snap-declaration", "snap-revision"})
+
+	// fetching again in the same session is a no-op
+	retrieved = nil
+	err = f.Fetch(fs.snapRev.Ref())
+	c.Assert(err, IsNil)
+	c.Check(retrieved, HasLen, 0)
+}
+
+func (fs *fetcherSuite) TestSaveSkipsKnownPrerequisites(c *C) {
+	c.Assert(fs.db.Add(fs.identity), IsNil)
+	c.Assert(fs.db.Add(fs.snapDecl), IsNil)
+
+	var retrieved, saved []string
+	f := asserts.NewFetcher(fs.db, fs.retrieveFrom(&retrieved, fs.storeKey), fs.saveTo(&saved))
+
+	err := f.Save(fs.snapRev)
+	c.Assert(err, IsNil)
+	c.Check(retrieved, DeepEquals, []string{"account-key"})
+	c.Check(saved, DeepEquals, []string{"account-key", "snap-revision"})
+}
+
+func (fs *fetcherSuite) TestSaveKnownRevision(c *C) {
+	c.Assert(fs.db.Add(fs.storeKey), IsNil)
+
+	var retrieved, saved []string
+	f := asserts.NewFetcher(fs.db, fs.retrieveFrom(&retrieved), fs.saveTo(&saved))
+
+	// the same revision is already saved
+	c.Assert(f.Save(fs.storeKey), IsNil)
+	c.Check(retrieved, HasLen, 0)
+	c.Check(saved, HasLen, 0)
+}
+
+func (fs *fetcherSuite) TestSaveTrusted(c *C) {
+	db, err := asserts.OpenDatabase(&asserts.DatabaseConfig{
+		Backstore:      asserts.NewMemoryBackstore(),
+		KeypairManager: asserts.NewMemoryKeypairManager(),
+		TrustedKeys:    []*asserts.AccountKey{fs.storeKey},
+	})
+	c.Assert(err, IsNil)
+
+	var retrieved, saved []string
+	f := asserts.NewFetcher(db, fs.retrieveFrom(&retrieved), fs.saveTo(&saved))
+
+	// trusted assertions count as saved
+	c.Assert(f.Save(fs.storeKey), IsNil)
+	c.Check(retrieved, HasLen, 0)
+	c.Check(saved, HasLen, 0)
+}
+
+func (fs *fetcherSuite) TestSaveMissingPrerequisite(c *C) {
+	var retrieved, saved []string
+	f := asserts.NewFetcher(fs.db, fs.retrieveFrom(&retrieved, fs.storeKey, fs.snapDecl), fs.saveTo(&saved))
+
+	err := f.Save(fs.snapRev)
+	c.Assert(err, ErrorMatches, `cannot retrieve identity \(dev-id1\): assertion not found`)
+	c.Check(saved, DeepEquals, []string{"account-key"})
+}
+
+func (fs *fetcherSuite) TestRefString(c *C) {
+	ref := fs.snapRev.Ref()
+	c.Check(ref.String(), Equals, "snap-revision (16; snap-id-1; sha256 ...)")
+	c.Check(ref.Unique(), Equals, "snap-revision/16/snap-id-1/sha256 ...")
+	c.Check(fs.snapRev.Prerequisites(), DeepEquals, []*asserts.Ref{
+		{Type: asserts.SnapDeclarationType, PrimaryKey: []string{"16", "snap-id-1"}},
+	})
+}
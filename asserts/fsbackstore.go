@@ -25,6 +25,8 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+
+	"github.com/ubuntu-core/snappy/osutil"
 )
 
 // the default filesystem based backstore for assertions
@@ -32,12 +34,24 @@ import (
 const (
 	assertionsLayoutVersion = "v0"
 	assertionsRoot          = "asserts-" + assertionsLayoutVersion
+	indexesRoot             = assertionsRoot + "-index"
 	activeFname             = "active"
 )
 
+// fsIndexedHeaders are the headers, per assertion type, for which
+// the filesystem backstore keeps secondary indexes. These are headers
+// commonly used in searches that are either not part of the primary
+// key or come after a component that is usually left unspecified.
+var fsIndexedHeaders = map[string][]string{
+	"snap-declaration": {"snap-name", "publisher-id"},
+	"snap-revision":    {"developer-id", "snap-revision"},
+	"validation":       {"approved-snap-id"},
+}
+
 type filesystemBackstore struct {
-	top string
-	mu  sync.RWMutex
+	top      string
+	indexTop string
+	mu       sync.RWMutex
 }
 
 // OpenFSBackstore opens a filesystem backed assertions backstore under path.
@@ -47,7 +61,15 @@ func OpenFSBackstore(path string) (Backstore, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &filesystemBackstore{top: top}, nil
+	indexTop := filepath.Join(path, indexesRoot)
+	fsbs := &filesystemBackstore{top: top, indexTop: indexTop}
+	if !osutil.IsDirectory(indexTop) {
+		// storage predating the indexes or with them lost, build them
+		if err := fsbs.Reindex(); err != nil {
+			return nil, err
+		}
+	}
+	return fsbs, nil
 }
 
 // guarantees that result assertion is of the expected type (both in the AssertionType and go type sense)
@@ -85,12 +107,7 @@ func (fsbs *filesystemBackstore) Put(assertType *AssertionType, assert Assertion
 	fsbs.mu.Lock()
 	defer fsbs.mu.Unlock()
 
-	primaryPath := make([]string, len(assertType.PrimaryKey))
-	for i, k := range assertType.PrimaryKey {
-		primaryPath[i] = assert.Header(k)
-	}
-
-	diskPrimaryPath := buildDiskPrimaryPath(primaryPath)
+	diskPrimaryPath := buildDiskPrimaryPath(assert.Ref().PrimaryKey)
 	curAssert, err := fsbs.readAssertion(assertType, diskPrimaryPath)
 	if err == nil {
 		curRev := curAssert.Revision()
@@ -101,10 +118,19 @@ func (fsbs *filesystemBackstore) Put(assertType *AssertionType, assert Assertion
 	} else if err != ErrNotFound {
 		return err
 	}
+	// index entries are written first, entries not backed by an
+	// assertion are harmless and are skipped by searches
+	err = addIndexEntries(fsbs.indexTop, assertType, assert, diskPrimaryPath)
+	if err != nil {
+		return err
+	}
 	err = atomicWriteEntry(Encode(assert), false, fsbs.top, assertType.Name, diskPrimaryPath)
 	if err != nil {
 		return fmt.Errorf("broken assertion storage, failed to write assertion: %v", err)
 	}
+	if curAssert != nil {
+		return fsbs.removeIndexEntries(assertType, curAssert, assert, diskPrimaryPath)
+	}
 	return nil
 }
 
@@ -156,5 +182,167 @@ func (fsbs *filesystemBackstore) Search(assertType *AssertionType, headers map[s
 			foundCb(a)
 		}
 	}
+
+	for _, indexed := range fsIndexedHeaders[assertType.Name] {
+		if headers[indexed] != "" {
+			return fsbs.searchIndex(assertType, indexed, headers[indexed], diskPattern, candCb)
+		}
+	}
 	return fsbs.search(assertType, diskPattern, candCb)
 }
+
+// Remove removes the assertion with the given unique key for its
+// primary key headers, together with its index entries.
+func (fsbs *filesystemBackstore) Remove(assertType *AssertionType, key []string) error {
+	fsbs.mu.Lock()
+	defer fsbs.mu.Unlock()
+
+	diskPrimaryPath := buildDiskPrimaryPath(key)
+	curAssert, err := fsbs.readAssertion(assertType, diskPrimaryPath)
+	if err != nil {
+		return err
+	}
+	err = removeEntry(fsbs.top, assertType.Name, diskPrimaryPath)
+	if err != nil {
+		return fmt.Errorf("broken assertion storage, failed to remove assertion: %v", err)
+	}
+	return fsbs.removeIndexEntries(assertType, curAssert, nil, diskPrimaryPath)
+}
+
+func indexEntryPath(assertType *AssertionType, header, value, diskPrimaryPath string) []string {
+	return []string{assertType.Name, header, url.QueryEscape(value), url.QueryEscape(diskPrimaryPath)}
+}
+
+// addIndexEntries adds the index entries of assert to the indexes under indexTop.
+func addIndexEntries(indexTop string, assertType *AssertionType, assert Assertion, diskPrimaryPath string) error {
+	for _, indexed := range fsIndexedHeaders[assertType.Name] {
+		value := assert.Header(indexed)
+		if value == "" {
+			continue
+		}
+		err := atomicWriteEntry(nil, false, indexTop, indexEntryPath(assertType, indexed, value, diskPrimaryPath)...)
+		if err != nil {
+			return fmt.Errorf("broken assertion storage, failed to write index entry: %v", err)
+		}
+	}
+	return nil
+}
+
+// removeIndexEntries removes the index entries of old, except for
+// those shared with its replacement if any.
+func (fsbs *filesystemBackstore) removeIndexEntries(assertType *AssertionType, old, replacement Assertion, diskPrimaryPath string) error {
+	for _, indexed := range fsIndexedHeaders[assertType.Name] {
+		value := old.Header(indexed)
+		if value == "" || (replacement != nil && replacement.Header(indexed) == value) {
+			continue
+		}
+		err := removeEntry(fsbs.indexTop, indexEntryPath(assertType, indexed, value, diskPrimaryPath)...)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("broken assertion storage, failed to remove index entry: %v", err)
+		}
+	}
+	return nil
+}
+
+func (fsbs *filesystemBackstore) searchIndex(assertType *AssertionType, header, value string, diskPattern []string, foundCb func(Assertion)) error {
+	entriesDir := filepath.Join(fsbs.indexTop, filepath.Join(indexEntryPath(assertType, header, value, "")[:3]...))
+	names, err := readDirNames(entriesDir)
+	if err != nil {
+		return fmt.Errorf("broken assertion storage, searching index of %s: %v", assertType.Name, err)
+	}
+	pattern := filepath.Join(diskPattern...)
+	for _, name := range names {
+		diskPrimaryPath, err := url.QueryUnescape(name)
+		if err != nil {
+			return fmt.Errorf("broken assertion storage, invalid index entry for %s: %q", assertType.Name, name)
+		}
+		if ok, _ := filepath.Match(pattern, diskPrimaryPath); !ok {
+			continue
+		}
+		a, err := fsbs.readAssertion(assertType, diskPrimaryPath)
+		if err == ErrNotFound {
+			// stale entry
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		foundCb(a)
+	}
+	return nil
+}
+
+// Reindex checks the consistency of the stored assertions and
+// rebuilds from scratch the secondary indexes for them.
+// The indexes are built aside and only then swapped in, so that an
+// interrupted rebuild leaves either the old indexes or none, in which
+// case they get rebuilt when the backstore is next opened.
+func (fsbs *filesystemBackstore) Reindex() error {
+	fsbs.mu.Lock()
+	defer fsbs.mu.Unlock()
+
+	newIndexTop := fsbs.indexTop + ".new"
+	// drop any leftovers of an interrupted rebuild
+	if err := os.RemoveAll(newIndexTop); err != nil {
+		return fmt.Errorf("broken assertion storage, failed to remove indexes: %v", err)
+	}
+	if err := ensureTop(newIndexTop); err != nil {
+		return err
+	}
+	if err := fsbs.buildIndexes(newIndexTop); err != nil {
+		os.RemoveAll(newIndexTop)
+		return err
+	}
+
+	if err := os.RemoveAll(fsbs.indexTop); err != nil {
+		return fmt.Errorf("broken assertion storage, failed to remove indexes: %v", err)
+	}
+	if err := os.Rename(newIndexTop, fsbs.indexTop); err != nil {
+		return fmt.Errorf("broken assertion storage, failed to put indexes in place: %v", err)
+	}
+	return nil
+}
+
+// buildIndexes checks the stored assertions and builds their indexes
+// under indexTop.
+func (fsbs *filesystemBackstore) buildIndexes(indexTop string) error {
+
+	typeNames, err := readDirNames(fsbs.top)
+	if err != nil {
+		return fmt.Errorf("broken assertion storage, failed to list assertion types: %v", err)
+	}
+	for _, typeName := range typeNames {
+		assertType := Type(typeName)
+		if assertType == nil {
+			return fmt.Errorf("broken assertion storage, unknown assertion type: %s", typeName)
+		}
+		n := len(assertType.PrimaryKey)
+		diskPattern := make([]string, n+1)
+		for i := range assertType.PrimaryKey {
+			diskPattern[i] = "*"
+		}
+		diskPattern[n] = activeFname
+
+		assertTypeTop := filepath.Join(fsbs.top, assertType.Name)
+		var checkErr error
+		checkCb := func(diskPrimaryPath string) error {
+			a, err := fsbs.readAssertion(assertType, diskPrimaryPath)
+			if err != nil {
+				checkErr = err
+			} else if buildDiskPrimaryPath(a.Ref().PrimaryKey) != diskPrimaryPath {
+				checkErr = fmt.Errorf("broken assertion storage, assertion stored under the wrong primary key: %s/%s", assertType.Name, diskPrimaryPath)
+			} else {
+				checkErr = addIndexEntries(indexTop, assertType, a, diskPrimaryPath)
+			}
+			return checkErr
+		}
+		err := findWildcard(assertTypeTop, diskPattern, checkCb)
+		if checkErr != nil {
+			return checkErr
+		}
+		if err != nil {
+			return fmt.Errorf("broken assertion storage, checking %s: %v", assertType.Name, err)
+		}
+	}
+	return nil
+}
@@ -20,13 +20,17 @@
 package asserts_test
 
 import (
+	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"syscall"
 
 	. "gopkg.in/check.v1"
 
 	"github.com/ubuntu-core/snappy/asserts"
+	"github.com/ubuntu-core/snappy/osutil"
 )
 
 type fsBackstoreSuite struct{}
@@ -102,3 +106,173 @@ func (fsbss *fsBackstoreSuite) TestPutOldRevision(c *C) {
 	c.Check(err, ErrorMatches, `revision 0 is older than current revision 1`)
 	c.Check(err, DeepEquals, &asserts.RevisionError{Current: 1, Used: 0})
 }
+
+type fsReindexer interface {
+	Remove(*asserts.AssertionType, []string) error
+	Reindex() error
+}
+
+func testOnly(c *C, primaryKey, other string, revision int) asserts.Assertion {
+	a, err := asserts.Decode([]byte(fmt.Sprintf("type: test-only\n"+
+		"authority-id: auth-id1\n"+
+		"primary-key: %s\n"+
+		"other: %s\n"+
+		"revision: %d\n"+
+		"\n"+
+		"openpgp c2ln", primaryKey, other, revision)))
+	c.Assert(err, IsNil)
+	return a
+}
+
+func searchPrimaryKeys(c *C, bs asserts.Backstore, headers map[string]string) []string {
+	var found []string
+	err := bs.Search(asserts.TestOnlyType, headers, func(a asserts.Assertion) {
+		found = append(found, a.Header("primary-key"))
+	})
+	c.Assert(err, IsNil)
+	sort.Strings(found)
+	return found
+}
+
+func (fsbss *fsBackstoreSuite) TestSearchIndexed(c *C) {
+	restore := asserts.MockFSIndexedHeaders(map[string][]string{"test-only": {"other"}})
+	defer restore()
+
+	topDir := filepath.Join(c.MkDir(), "asserts-db")
+	bs, err := asserts.OpenFSBackstore(topDir)
+	c.Assert(err, IsNil)
+
+	c.Assert(bs.Put(asserts.TestOnlyType, testOnly(c, "foo", "x", 0)), IsNil)
+	c.Assert(bs.Put(asserts.TestOnlyType, testOnly(c, "bar", "x", 0)), IsNil)
+	c.Assert(bs.Put(asserts.TestOnlyType, testOnly(c, "baz", "y", 0)), IsNil)
+
+	c.Check(osutil.FileExists(filepath.Join(topDir, "asserts-v0-index", "test-only", "other", "x", "foo%2Factive")), Equals, true)
+
+	c.Check(searchPrimaryKeys(c, bs, map[string]string{"other": "x"}), DeepEquals, []string{"bar", "foo"})
+	c.Check(searchPrimaryKeys(c, bs, map[string]string{"other": "x", "primary-key": "foo"}), DeepEquals, []string{"foo"})
+	c.Check(searchPrimaryKeys(c, bs, map[string]string{"other": "z"}), HasLen, 0)
+
+	// a new revision moves the index entry
+	c.Assert(bs.Put(asserts.TestOnlyType, testOnly(c, "foo", "y", 1)), IsNil)
+	c.Check(osutil.FileExists(filepath.Join(topDir, "asserts-v0-index", "test-only", "other", "x", "foo%2Factive")), Equals, false)
+	c.Check(searchPrimaryKeys(c, bs, map[string]string{"other": "x"}), DeepEquals, []string{"bar"})
+	c.Check(searchPrimaryKeys(c, bs, map[string]string{"other": "y"}), DeepEquals, []string{"baz", "foo"})
+}
+
+func (fsbss *fsBackstoreSuite) TestSearchIndexedSkipsStaleEntries(c *C) {
+	restore := asserts.MockFSIndexedHeaders(map[string][]string{"test-only": {"other"}})
+	defer restore()
+
+	topDir := filepath.Join(c.MkDir(), "asserts-db")
+	bs, err := asserts.OpenFSBackstore(topDir)
+	c.Assert(err, IsNil)
+	c.Assert(bs.Put(asserts.TestOnlyType, testOnly(c, "foo", "x", 0)), IsNil)
+
+	stale := filepath.Join(topDir, "asserts-v0-index", "test-only", "other", "x", "gone%2Factive")
+	c.Assert(ioutil.WriteFile(stale, nil, 0644), IsNil)
+
+	c.Check(searchPrimaryKeys(c, bs, map[string]string{"other": "x"}), DeepEquals, []string{"foo"})
+}
+
+func (fsbss *fsBackstoreSuite) TestRemove(c *C) {
+	restore := asserts.MockFSIndexedHeaders(map[string][]string{"test-only": {"other"}})
+	defer restore()
+
+	topDir := filepath.Join(c.MkDir(), "asserts-db")
+	bs, err := asserts.OpenFSBackstore(topDir)
+	c.Assert(err, IsNil)
+	c.Assert(bs.Put(asserts.TestOnlyType, testOnly(c, "foo", "x", 0)), IsNil)
+
+	err = bs.(fsReindexer).Remove(asserts.TestOnlyType, []string{"foo"})
+	c.Assert(err, IsNil)
+
+	_, err = bs.Get(asserts.TestOnlyType, []string{"foo"})
+	c.Check(err, Equals, asserts.ErrNotFound)
+	c.Check(searchPrimaryKeys(c, bs, map[string]string{"other": "x"}), HasLen, 0)
+	// emptied directories are cleaned up
+	c.Check(osutil.FileExists(filepath.Join(topDir, "asserts-v0", "test-only", "foo")), Equals, false)
+	c.Check(osutil.FileExists(filepath.Join(topDir, "asserts-v0-index", "test-only", "other", "x")), Equals, false)
+
+	err = bs.(fsReindexer).Remove(asserts.TestOnlyType, []string{"foo"})
+	c.Check(err, Equals, asserts.ErrNotFound)
+}
+
+func (fsbss *fsBackstoreSuite) TestOpenBuildsMissingIndexes(c *C) {
+	topDir := filepath.Join(c.MkDir(), "asserts-db")
+	bs, err := asserts.OpenFSBackstore(topDir)
+	c.Assert(err, IsNil)
+	c.Assert(bs.Put(asserts.TestOnlyType, testOnly(c, "foo", "x", 0)), IsNil)
+
+	// storage from before the header was indexed
+	restore := asserts.MockFSIndexedHeaders(map[string][]string{"test-only": {"other"}})
+	defer restore()
+	c.Assert(os.RemoveAll(filepath.Join(topDir, "asserts-v0-index")), IsNil)
+
+	bs, err = asserts.OpenFSBackstore(topDir)
+	c.Assert(err, IsNil)
+	c.Check(searchPrimaryKeys(c, bs, map[string]string{"other": "x"}), DeepEquals, []string{"foo"})
+}
+
+func (fsbss *fsBackstoreSuite) TestReindex(c *C) {
+	restore := asserts.MockFSIndexedHeaders(map[string][]string{"test-only": {"other"}})
+	defer restore()
+
+	topDir := filepath.Join(c.MkDir(), "asserts-db")
+	bs, err := asserts.OpenFSBackstore(topDir)
+	c.Assert(err, IsNil)
+	c.Assert(bs.Put(asserts.TestOnlyType, testOnly(c, "foo", "x", 0)), IsNil)
+
+	stale := filepath.Join(topDir, "asserts-v0-index", "test-only", "other", "x", "gone%2Factive")
+	c.Assert(ioutil.WriteFile(stale, nil, 0644), IsNil)
+	c.Assert(os.Remove(filepath.Join(topDir, "asserts-v0-index", "test-only", "other", "x", "foo%2Factive")), IsNil)
+
+	err = bs.(fsReindexer).Reindex()
+	c.Assert(err, IsNil)
+	c.Check(osutil.FileExists(stale), Equals, false)
+	c.Check(searchPrimaryKeys(c, bs, map[string]string{"other": "x"}), DeepEquals, []string{"foo"})
+}
+
+func (fsbss *fsBackstoreSuite) TestReindexInterrupted(c *C) {
+	restore := asserts.MockFSIndexedHeaders(map[string][]string{"test-only": {"other"}})
+	defer restore()
+
+	topDir := filepath.Join(c.MkDir(), "asserts-db")
+	bs, err := asserts.OpenFSBackstore(topDir)
+	c.Assert(err, IsNil)
+	c.Assert(bs.Put(asserts.TestOnlyType, testOnly(c, "foo", "x", 0)), IsNil)
+
+	// a rebuild interrupted after removing the old indexes
+	partial := filepath.Join(topDir, "asserts-v0-index.new", "test-only", "other", "y", "partial%2Factive")
+	c.Assert(os.MkdirAll(filepath.Dir(partial), 0775), IsNil)
+	c.Assert(ioutil.WriteFile(partial, nil, 0644), IsNil)
+	c.Assert(os.RemoveAll(filepath.Join(topDir, "asserts-v0-index")), IsNil)
+
+	bs, err = asserts.OpenFSBackstore(topDir)
+	c.Assert(err, IsNil)
+	c.Check(osutil.FileExists(filepath.Join(topDir, "asserts-v0-index.new")), Equals, false)
+	c.Check(searchPrimaryKeys(c, bs, map[string]string{"other": "x"}), DeepEquals, []string{"foo"})
+	c.Check(searchPrimaryKeys(c, bs, map[string]string{"other": "y"}), HasLen, 0)
+}
+
+func (fsbss *fsBackstoreSuite) TestReindexBroken(c *C) {
+	topDir := filepath.Join(c.MkDir(), "asserts-db")
+	bs, err := asserts.OpenFSBackstore(topDir)
+	c.Assert(err, IsNil)
+	c.Assert(bs.Put(asserts.TestOnlyType, testOnly(c, "foo", "x", 0)), IsNil)
+
+	// an assertion under the wrong primary key
+	misplaced := filepath.Join(topDir, "asserts-v0", "test-only", "bar", "active")
+	c.Assert(os.MkdirAll(filepath.Dir(misplaced), 0775), IsNil)
+	c.Assert(ioutil.WriteFile(misplaced, asserts.Encode(testOnly(c, "foo", "x", 0)), 0644), IsNil)
+
+	err = bs.(fsReindexer).Reindex()
+	c.Check(err, ErrorMatches, "broken assertion storage, assertion stored under the wrong primary key: test-only/bar/active")
+	// the previous indexes were left in place
+	c.Check(osutil.IsDirectory(filepath.Join(topDir, "asserts-v0-index")), Equals, true)
+	c.Check(osutil.FileExists(filepath.Join(topDir, "asserts-v0-index.new")), Equals, false)
+
+	// garbage
+	c.Assert(ioutil.WriteFile(misplaced, []byte("garbage"), 0644), IsNil)
+	err = bs.(fsReindexer).Reindex()
+	c.Check(err, ErrorMatches, "broken assertion storage, failed to decode assertion: .*")
+}
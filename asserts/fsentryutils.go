@@ -24,6 +24,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/ubuntu-core/snappy/osutil"
 )
@@ -68,3 +69,30 @@ func readEntry(top string, subpath ...string) ([]byte, error) {
 	fpath := filepath.Join(top, filepath.Join(subpath...))
 	return ioutil.ReadFile(fpath)
 }
+
+func removeEntry(top string, subpath ...string) error {
+	fpath := filepath.Join(top, filepath.Join(subpath...))
+	err := os.Remove(fpath)
+	if err != nil {
+		return err
+	}
+	// clean up directories left empty, up to top
+	for dir := filepath.Dir(fpath); dir != top && strings.HasPrefix(dir, top); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+	return nil
+}
+
+func readDirNames(dir string) ([]string, error) {
+	d, err := os.Open(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer d.Close()
+	return d.Readdirnames(-1)
+}
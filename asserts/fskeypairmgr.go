@@ -25,6 +25,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 )
 
@@ -92,3 +93,24 @@ func (fskm *filesystemKeypairManager) Get(authorityID, keyID string) (PrivateKey
 	}
 	return privKey, nil
 }
+
+// List returns the authority-ids and key ids of all the stored key pairs.
+func (fskm *filesystemKeypairManager) List() ([]KeypairRef, error) {
+	fskm.mu.RLock()
+	defer fskm.mu.RUnlock()
+
+	var refs []KeypairRef
+	matches, err := filepath.Glob(filepath.Join(fskm.top, "*", "*"))
+	if err != nil {
+		return nil, err
+	}
+	for _, match := range matches {
+		authorityID, err := url.QueryUnescape(filepath.Base(filepath.Dir(match)))
+		if err != nil {
+			return nil, fmt.Errorf("cannot list key pairs: %v", err)
+		}
+		refs = append(refs, KeypairRef{AuthorityID: authorityID, KeyID: filepath.Base(match)})
+	}
+	sort.Sort(byAuthorityAndKeyID(refs))
+	return refs, nil
+}
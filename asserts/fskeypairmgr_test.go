@@ -63,3 +63,26 @@ func (fsbss *fsKeypairMgrSuite) TestOpenWorldWritableFail(c *C) {
 	c.Assert(err, ErrorMatches, "assert storage root unexpectedly world-writable: .*")
 	c.Check(bs, IsNil)
 }
+
+func (fsbss *fsKeypairMgrSuite) TestList(c *C) {
+	topDir := filepath.Join(c.MkDir(), "asserts-db")
+	keypairMgr, err := asserts.OpenFSKeypairManager(topDir)
+	c.Assert(err, IsNil)
+	lister := keypairMgr.(asserts.KeypairLister)
+
+	refs, err := lister.List()
+	c.Assert(err, IsNil)
+	c.Check(refs, HasLen, 0)
+
+	pk1 := asserts.OpenPGPPrivateKey(testPrivKey1)
+	pk2 := asserts.OpenPGPPrivateKey(testPrivKey2)
+	c.Assert(keypairMgr.Put("auth/id2", pk2), IsNil)
+	c.Assert(keypairMgr.Put("auth-id1", pk1), IsNil)
+
+	refs, err = lister.List()
+	c.Assert(err, IsNil)
+	c.Check(refs, DeepEquals, []asserts.KeypairRef{
+		{AuthorityID: "auth-id1", KeyID: pk1.PublicKey().ID()},
+		{AuthorityID: "auth/id2", KeyID: pk2.PublicKey().ID()},
+	})
+}
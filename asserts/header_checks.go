@@ -28,11 +28,15 @@ import (
 
 // common checks used when decoding/assembling assertions
 
-func checkMandatory(headers map[string]string, name string) (string, error) {
-	value, ok := headers[name]
+func checkMandatory(headers map[string]interface{}, name string) (string, error) {
+	v, ok := headers[name]
 	if !ok {
 		return "", fmt.Errorf("%q header is mandatory", name)
 	}
+	value, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("%q header must be a string", name)
+	}
 	if len(value) == 0 {
 		return "", fmt.Errorf("%q header should not be empty", name)
 	}
@@ -57,11 +61,15 @@ func checkAssertType(assertType *AssertionType) error {
 }
 
 // use 'defl' default if missing
-func checkInteger(headers map[string]string, name string, defl int) (int, error) {
-	valueStr, ok := headers[name]
+func checkInteger(headers map[string]interface{}, name string, defl int) (int, error) {
+	v, ok := headers[name]
 	if !ok {
 		return defl, nil
 	}
+	valueStr, ok := v.(string)
+	if !ok {
+		return -1, fmt.Errorf("%q header must be a string", name)
+	}
 	value, err := strconv.Atoi(valueStr)
 	if err != nil {
 		return -1, fmt.Errorf("%q header is not an integer: %v", name, valueStr)
@@ -69,7 +77,7 @@ func checkInteger(headers map[string]string, name string, defl int) (int, error)
 	return value, nil
 }
 
-func checkRFC3339Date(headers map[string]string, name string) (time.Time, error) {
+func checkRFC3339Date(headers map[string]interface{}, name string) (time.Time, error) {
 	dateStr, err := checkMandatory(headers, name)
 	if err != nil {
 		return time.Time{}, err
@@ -81,7 +89,7 @@ func checkRFC3339Date(headers map[string]string, name string) (time.Time, error)
 	return date, nil
 }
 
-func checkUint(headers map[string]string, name string, bitSize int) (uint64, error) {
+func checkUint(headers map[string]interface{}, name string, bitSize int) (uint64, error) {
 	valueStr, err := checkMandatory(headers, name)
 	if err != nil {
 		return 0, err
@@ -94,12 +102,32 @@ func checkUint(headers map[string]string, name string, bitSize int) (uint64, err
 	return value, nil
 }
 
-func checkCommaSepList(headers map[string]string, name string) ([]string, error) {
-	listStr, ok := headers[name]
+// checkStringList checks that the header is a list of strings, for
+// compatibility a comma separated string is also accepted.
+func checkStringList(headers map[string]interface{}, name string) ([]string, error) {
+	v, ok := headers[name]
 	if !ok {
 		return nil, fmt.Errorf("%q header is mandatory", name)
 	}
+	switch x := v.(type) {
+	case string:
+		return commaSepList(name, x)
+	case []interface{}:
+		entries := make([]string, len(x))
+		for i, item := range x {
+			entry, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%q header must be a list of strings", name)
+			}
+			entries[i] = entry
+		}
+		return entries, nil
+	default:
+		return nil, fmt.Errorf("%q header must be a list of strings", name)
+	}
+}
 
+func commaSepList(name, listStr string) ([]string, error) {
 	// XXX: we likely don't need this much white-space flexibility,
 	// just supporting newline after , could be enough
 
@@ -0,0 +1,371 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package asserts
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Header values are either strings, lists ([]interface{}) or maps
+// (map[string]interface{}) whose entries are again header values.
+//
+// A top level header entry with a single line string value looks like:
+//
+//   NAME ": " VALUE
+//
+// With a multiline string value it looks like:
+//
+//   NAME ":\n"  1-space indented VALUE lines
+//
+// A list or map value follows on the next lines indented by 2
+// spaces, a list as one "- " prefixed entry per item and a map as
+// one "KEY: " prefixed entry per key, in lexicographic order of the
+// keys. Entries of nested lists or maps are indented 2 more spaces
+// than the entry holding them, an entry holding them is just "-" or
+// "KEY:". Nested multiline strings follow such an entry indented 4
+// more spaces than it:
+//
+//   required-snaps:
+//     - foo
+//     - bar
+//   rules:
+//     allow:
+//       - a
+//     comment:
+//         first line
+//         second line
+//
+// Empty lists and maps cannot be represented.
+//
+// Before lists and maps were supported all header values were
+// strings. Multiline string values encoded as before, with their first
+// line indented by exactly 1 space, still decode to the same strings,
+// whatever the indentation of their further lines. But a top level
+// value whose first line is indented by 2 or more spaces, i.e. a
+// multiline string whose first line started with a space, is now
+// parsed as a list, a map or a 4-space indented string instead, or
+// refused.
+
+var (
+	nl   = []byte("\n")
+	nlnl = []byte("\n\n")
+
+	// for basic sanity checking of header names
+	headerNameSanity = regexp.MustCompile("^[a-z][a-z0-9-]*[a-z0-9]$")
+)
+
+type headerParser struct {
+	lines []string
+	i     int
+}
+
+func (p *headerParser) more() bool {
+	return p.i < len(p.lines)
+}
+
+func (p *headerParser) peek() string {
+	return p.lines[p.i]
+}
+
+// hasIndent returns whether line starts with exactly n spaces
+// followed by a non space.
+func hasIndent(line string, n int) bool {
+	return len(line) > n && strings.Count(line[:n], " ") == n && line[n] != ' '
+}
+
+func hasPrefixSpaces(line string, n int) bool {
+	return len(line) >= n && strings.Count(line[:n], " ") == n
+}
+
+// parseMultiline collects the lines prefixed by n spaces into a
+// multiline string value.
+func (p *headerParser) parseMultiline(n int, entry string) (string, error) {
+	var valueLines []string
+	for p.more() && hasPrefixSpaces(p.peek(), n) && len(p.peek()) > 0 {
+		valueLines = append(valueLines, p.peek()[n:])
+		p.i++
+	}
+	if len(valueLines) == 0 {
+		return "", fmt.Errorf("empty multiline header value: %q", entry)
+	}
+	return strings.Join(valueLines, "\n"), nil
+}
+
+// parseNested parses the value following an entry, indented by
+// indent, that is not followed by an inline value.
+func (p *headerParser) parseNested(indent int, entry string) (interface{}, error) {
+	if !p.more() {
+		return nil, fmt.Errorf("empty multiline header value: %q", entry)
+	}
+	line := p.peek()
+	if hasIndent(line, indent+2) {
+		return p.parseStructure(indent + 2)
+	}
+	return p.parseMultiline(indent+4, entry)
+}
+
+// parseStructure parses a list or map whose entries are indented by
+// indent.
+func (p *headerParser) parseStructure(indent int) (interface{}, error) {
+	first := p.peek()[indent:]
+	if first == "-" || strings.HasPrefix(first, "- ") {
+		return p.parseList(indent)
+	}
+	return p.parseMap(indent)
+}
+
+func (p *headerParser) parseList(indent int) (interface{}, error) {
+	var l []interface{}
+	for p.more() && hasIndent(p.peek(), indent) {
+		entry := p.peek()
+		item := entry[indent:]
+		p.i++
+		switch {
+		case item == "-":
+			v, err := p.parseNested(indent, entry)
+			if err != nil {
+				return nil, err
+			}
+			l = append(l, v)
+		case strings.HasPrefix(item, "- "):
+			l = append(l, item[2:])
+		default:
+			return nil, fmt.Errorf("expected list entry: %q", entry)
+		}
+	}
+	if p.more() && hasPrefixSpaces(p.peek(), indent) {
+		return nil, fmt.Errorf("unexpected indentation: %q", p.peek())
+	}
+	return l, nil
+}
+
+func (p *headerParser) parseMap(indent int) (interface{}, error) {
+	m := make(map[string]interface{})
+	for p.more() && hasIndent(p.peek(), indent) {
+		entry := p.peek()
+		p.i++
+		name, v, err := p.parseEntry(indent, entry)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := m[name]; ok {
+			return nil, fmt.Errorf("repeated map entry: %q", entry)
+		}
+		m[name] = v
+	}
+	if p.more() && hasPrefixSpaces(p.peek(), indent) {
+		return nil, fmt.Errorf("unexpected indentation: %q", p.peek())
+	}
+	return m, nil
+}
+
+// parseEntry parses a NAME: VALUE entry, indented by indent, of
+// either the top level headers or of a map.
+func (p *headerParser) parseEntry(indent int, entry string) (string, interface{}, error) {
+	line := entry[indent:]
+	nameValueSplit := strings.Index(line, ":")
+	if nameValueSplit == -1 {
+		return "", nil, fmt.Errorf("header entry missing ':' separator: %q", entry)
+	}
+	name := line[:nameValueSplit]
+	if !headerNameSanity.MatchString(name) {
+		return "", nil, fmt.Errorf("invalid header name: %q", name)
+	}
+
+	afterSplit := nameValueSplit + 1
+	if afterSplit == len(line) {
+		if indent == 0 && p.more() && !hasPrefixSpaces(p.peek(), 2) {
+			// top level multiline string value, 1-space indented
+			v, err := p.parseMultiline(1, entry)
+			return name, v, err
+		}
+		v, err := p.parseNested(indent, entry)
+		return name, v, err
+	}
+
+	if line[afterSplit] != ' ' {
+		return "", nil, fmt.Errorf("header entry should have a space or newline (multiline) before value: %q", entry)
+	}
+
+	return name, line[afterSplit+1:], nil
+}
+
+func parseHeaders(head []byte) (map[string]interface{}, error) {
+	if !utf8.Valid(head) {
+		return nil, fmt.Errorf("header is not utf8")
+	}
+	headers := make(map[string]interface{})
+	p := &headerParser{lines: strings.Split(string(head), "\n")}
+	for p.more() {
+		entry := p.peek()
+		p.i++
+		if len(entry) > 0 && entry[0] == ' ' {
+			return nil, fmt.Errorf("unexpected indentation: %q", entry)
+		}
+		name, v, err := p.parseEntry(0, entry)
+		if err != nil {
+			return nil, err
+		}
+		headers[name] = v
+	}
+	return headers, nil
+}
+
+// writeValue writes v in canonical form after an entry indented by
+// indent, the entry itself up to ":" or "-" is already written.
+func writeValue(buf *bytes.Buffer, indent int, v interface{}) {
+	switch x := v.(type) {
+	case string:
+		if strings.IndexRune(x, '\n') == -1 {
+			buf.WriteByte(' ')
+			buf.WriteString(x)
+			return
+		}
+		// multiline value => quote by indenting
+		pad := "\n" + strings.Repeat(" ", indent+4)
+		if indent == 0 {
+			pad = "\n "
+		}
+		buf.WriteString(pad)
+		buf.WriteString(strings.Replace(x, "\n", pad, -1))
+	case []interface{}:
+		pad := "\n" + strings.Repeat(" ", indent+2)
+		for _, item := range x {
+			buf.WriteString(pad)
+			buf.WriteByte('-')
+			writeValue(buf, indent+2, item)
+		}
+	case map[string]interface{}:
+		pad := "\n" + strings.Repeat(" ", indent+2)
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			buf.WriteString(pad)
+			buf.WriteString(k)
+			buf.WriteByte(':')
+			writeValue(buf, indent+2, x[k])
+		}
+	default:
+		panic(fmt.Sprintf("internal error: unexpected header value type %T", v))
+	}
+}
+
+func writeHeader(buf *bytes.Buffer, headers map[string]interface{}, name string) {
+	buf.WriteByte('\n')
+	buf.WriteString(name)
+	buf.WriteByte(':')
+	writeValue(buf, 0, headers[name])
+}
+
+// normalizeValue checks that v can be used as a header value and
+// returns a deep copy of it using only string, []interface{} and
+// map[string]interface{} values. For convenience []string and
+// map[string]string are accepted too.
+func normalizeValue(name string, v interface{}, nested bool) (interface{}, error) {
+	switch x := v.(type) {
+	case string:
+		if nested && x == "" {
+			return nil, fmt.Errorf("header %q: nested values cannot be empty strings", name)
+		}
+		if !nested && strings.HasPrefix(x, " ") && strings.IndexRune(x, '\n') != -1 {
+			return nil, fmt.Errorf("header %q: multiline values cannot start with a space", name)
+		}
+		return x, nil
+	case []string:
+		l := make([]interface{}, len(x))
+		for i, s := range x {
+			l[i] = s
+		}
+		return normalizeValue(name, l, nested)
+	case map[string]string:
+		m := make(map[string]interface{}, len(x))
+		for k, s := range x {
+			m[k] = s
+		}
+		return normalizeValue(name, m, nested)
+	case []interface{}:
+		if len(x) == 0 {
+			return nil, fmt.Errorf("header %q: lists cannot be empty", name)
+		}
+		l := make([]interface{}, len(x))
+		for i, item := range x {
+			nv, err := normalizeValue(name, item, true)
+			if err != nil {
+				return nil, err
+			}
+			l[i] = nv
+		}
+		return l, nil
+	case map[string]interface{}:
+		if len(x) == 0 {
+			return nil, fmt.Errorf("header %q: maps cannot be empty", name)
+		}
+		m := make(map[string]interface{}, len(x))
+		for k, item := range x {
+			if !headerNameSanity.MatchString(k) {
+				return nil, fmt.Errorf("header %q: invalid map entry name: %q", name, k)
+			}
+			nv, err := normalizeValue(name, item, true)
+			if err != nil {
+				return nil, err
+			}
+			m[k] = nv
+		}
+		return m, nil
+	default:
+		return nil, fmt.Errorf("header %q: unsupported value type %T", name, v)
+	}
+}
+
+// copyHeaders returns a deep copy of headers.
+func copyHeaders(headers map[string]interface{}) map[string]interface{} {
+	res := make(map[string]interface{}, len(headers))
+	for name, v := range headers {
+		res[name] = copyValue(v)
+	}
+	return res
+}
+
+func copyValue(v interface{}) interface{} {
+	switch x := v.(type) {
+	case []interface{}:
+		l := make([]interface{}, len(x))
+		for i, item := range x {
+			l[i] = copyValue(item)
+		}
+		return l
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(x))
+		for k, item := range x {
+			m[k] = copyValue(item)
+		}
+		return m
+	default:
+		return v
+	}
+}
@@ -63,7 +63,7 @@ func assembleIdentity(assert assertionBase) (Assertion, error) {
 	if err != nil {
 		return nil, err
 	}
-	certified := assert.headers["validation"] == identityValidationCertified
+	certified := assert.Header("validation") == identityValidationCertified
 
 	timestamp, err := checkRFC3339Date(assert.headers, "timestamp")
 	if err != nil {
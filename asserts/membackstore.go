@@ -32,6 +32,7 @@ type memBSNode interface {
 	put(key []string, assert Assertion) error
 	get(key []string) (Assertion, error)
 	search(hint []string, found func(Assertion))
+	remove(key []string) error
 }
 
 type memBSBranch map[string]memBSNode
@@ -84,6 +85,24 @@ func (leaf memBSLeaf) get(key []string) (Assertion, error) {
 	return cur, nil
 }
 
+func (br memBSBranch) remove(key []string) error {
+	key0 := key[0]
+	down := br[key0]
+	if down == nil {
+		return ErrNotFound
+	}
+	return down.remove(key[1:])
+}
+
+func (leaf memBSLeaf) remove(key []string) error {
+	key0 := key[0]
+	if leaf[key0] == nil {
+		return ErrNotFound
+	}
+	delete(leaf, key0)
+	return nil
+}
+
 func (br memBSBranch) search(hint []string, found func(Assertion)) {
 	hint0 := hint[0]
 	if hint0 == "" {
@@ -165,3 +184,15 @@ func (mbs *memoryBackstore) Search(assertType *AssertionType, headers map[string
 	mbs.top.search(hint, candCb)
 	return nil
 }
+
+// Remove removes the assertion with the given unique key for its
+// primary key headers.
+func (mbs *memoryBackstore) Remove(assertType *AssertionType, key []string) error {
+	mbs.mu.Lock()
+	defer mbs.mu.Unlock()
+
+	internalKey := make([]string, 1+len(key))
+	internalKey[0] = assertType.Name
+	copy(internalKey[1:], key)
+	return mbs.top.remove(internalKey)
+}
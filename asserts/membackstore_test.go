@@ -196,3 +196,20 @@ func (mbss *memBackstoreSuite) TestPutOldRevision(c *C) {
 	c.Check(err, ErrorMatches, `revision 0 is older than current revision 1`)
 	c.Check(err, DeepEquals, &asserts.RevisionError{Current: 1, Used: 0})
 }
+
+func (mbss *memBackstoreSuite) TestRemove(c *C) {
+	err := mbss.bs.Put(asserts.TestOnlyType, mbss.a)
+	c.Assert(err, IsNil)
+
+	rbs := mbss.bs.(interface {
+		Remove(*asserts.AssertionType, []string) error
+	})
+	err = rbs.Remove(asserts.TestOnlyType, []string{"foo"})
+	c.Assert(err, IsNil)
+
+	_, err = mbss.bs.Get(asserts.TestOnlyType, []string{"foo"})
+	c.Check(err, Equals, asserts.ErrNotFound)
+
+	err = rbs.Remove(asserts.TestOnlyType, []string{"foo"})
+	c.Check(err, Equals, asserts.ErrNotFound)
+}
@@ -20,6 +20,7 @@
 package asserts
 
 import (
+	"sort"
 	"sync"
 )
 
@@ -61,3 +62,18 @@ func (mkm *memoryKeypairManager) Get(authorityID, keyID string) (PrivateKey, err
 	}
 	return privKey, nil
 }
+
+// List returns the authority-ids and key ids of all the stored key pairs.
+func (mkm *memoryKeypairManager) List() ([]KeypairRef, error) {
+	mkm.mu.RLock()
+	defer mkm.mu.RUnlock()
+
+	var refs []KeypairRef
+	for authorityID, perAuthID := range mkm.pairs {
+		for keyID := range perAuthID {
+			refs = append(refs, KeypairRef{AuthorityID: authorityID, KeyID: keyID})
+		}
+	}
+	sort.Sort(byAuthorityAndKeyID(refs))
+	return refs, nil
+}
@@ -71,3 +71,17 @@ func (mkms *memKeypairMgtSuite) TestGetNotFound(c *C) {
 	c.Check(got, IsNil)
 	c.Check(err, ErrorMatches, "no matching key pair found")
 }
+
+func (mkms *memKeypairMgtSuite) TestList(c *C) {
+	pk1 := asserts.OpenPGPPrivateKey(testPrivKey1)
+	pk2 := asserts.OpenPGPPrivateKey(testPrivKey2)
+	c.Assert(mkms.keypairMgr.Put("auth-id2", pk2), IsNil)
+	c.Assert(mkms.keypairMgr.Put("auth-id1", pk1), IsNil)
+
+	refs, err := mkms.keypairMgr.(asserts.KeypairLister).List()
+	c.Assert(err, IsNil)
+	c.Check(refs, DeepEquals, []asserts.KeypairRef{
+		{AuthorityID: "auth-id1", KeyID: pk1.PublicKey().ID()},
+		{AuthorityID: "auth-id2", KeyID: pk2.PublicKey().ID()},
+	})
+}
@@ -20,7 +20,13 @@
 package asserts
 
 import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
 	"time"
+
+	"golang.org/x/crypto/sha3"
 )
 
 // SnapDeclaration holds a snap-declaration assertion, declaring a
@@ -62,6 +68,13 @@ func (snapdcl *SnapDeclaration) Timestamp() time.Time {
 	return snapdcl.timestamp
 }
 
+// Prerequisites returns references to this snap-declaration's prerequisite assertions.
+func (snapdcl *SnapDeclaration) Prerequisites() []*Ref {
+	return []*Ref{
+		{Type: IdentityType, PrimaryKey: []string{snapdcl.PublisherID()}},
+	}
+}
+
 // XXX: consistency check is signed by canonical
 
 func assembleSnapDeclaration(assert assertionBase) (Assertion, error) {
@@ -75,7 +88,7 @@ func assembleSnapDeclaration(assert assertionBase) (Assertion, error) {
 		return nil, err
 	}
 
-	gates, err := checkCommaSepList(assert.headers, "gates")
+	gates, err := checkStringList(assert.headers, "gates")
 	if err != nil {
 		return nil, err
 	}
@@ -204,6 +217,13 @@ func (snaprev *SnapRevision) Timestamp() time.Time {
 	return snaprev.timestamp
 }
 
+// Prerequisites returns references to this snap-revision's prerequisite assertions.
+func (snaprev *SnapRevision) Prerequisites() []*Ref {
+	return []*Ref{
+		{Type: SnapDeclarationType, PrimaryKey: []string{snaprev.Series(), snaprev.SnapID()}},
+	}
+}
+
 // Implement further consistency checks.
 func (snaprev *SnapRevision) checkConsistency(db RODatabase, acck *AccountKey) error {
 	return nil
@@ -242,3 +262,126 @@ func assembleSnapRevision(assert assertionBase) (Assertion, error) {
 		timestamp:     timestamp,
 	}, nil
 }
+
+// Validation holds a validation assertion, a statement by the publisher
+// of a gating snap about whether a specific revision of a gated snap is
+// approved or has been revoked.
+type Validation struct {
+	assertionBase
+	approvedRevision uint64
+	revoked          bool
+	timestamp        time.Time
+}
+
+// Series returns the series for which the validation holds.
+func (validation *Validation) Series() string {
+	return validation.Header("series")
+}
+
+// SnapID returns the snap id of the gating snap.
+func (validation *Validation) SnapID() string {
+	return validation.Header("snap-id")
+}
+
+// ApprovedSnapID returns the snap id of the gated snap.
+func (validation *Validation) ApprovedSnapID() string {
+	return validation.Header("approved-snap-id")
+}
+
+// ApprovedRevision returns the approved revision of the gated snap.
+func (validation *Validation) ApprovedRevision() uint64 {
+	return validation.approvedRevision
+}
+
+// Revoked returns true if the validation has been revoked.
+func (validation *Validation) Revoked() bool {
+	return validation.revoked
+}
+
+// Timestamp returns the time when the validation was issued.
+func (validation *Validation) Timestamp() time.Time {
+	return validation.timestamp
+}
+
+// Prerequisites returns references to this validation's prerequisite assertions.
+func (validation *Validation) Prerequisites() []*Ref {
+	return []*Ref{
+		{Type: SnapDeclarationType, PrimaryKey: []string{validation.Series(), validation.SnapID()}},
+		{Type: SnapDeclarationType, PrimaryKey: []string{validation.Series(), validation.ApprovedSnapID()}},
+	}
+}
+
+// checkConsistency checks that the validation is signed by the
+// publisher of the gating snap and that the gating snap does gate
+// the approved one.
+func (validation *Validation) checkConsistency(db RODatabase, acck *AccountKey) error {
+	a, err := db.Find(SnapDeclarationType, map[string]string{
+		"series":  validation.Series(),
+		"snap-id": validation.SnapID(),
+	})
+	if err == ErrNotFound {
+		return fmt.Errorf("validation assertion by snap-id %q not accompanied by snap declaration", validation.SnapID())
+	}
+	if err != nil {
+		return err
+	}
+	gatingDecl := a.(*SnapDeclaration)
+	if gatingDecl.PublisherID() != validation.AuthorityID() {
+		return fmt.Errorf("validation assertion by snap %q (id %q) not signed by its publisher", gatingDecl.SnapName(), validation.SnapID())
+	}
+	for _, gated := range gatingDecl.Gates() {
+		if gated == validation.ApprovedSnapID() {
+			return nil
+		}
+	}
+	return fmt.Errorf("snap %q (id %q) does not gate snap-id %q", gatingDecl.SnapName(), validation.SnapID(), validation.ApprovedSnapID())
+}
+
+// sanity
+var _ consistencyChecker = (*Validation)(nil)
+
+func assembleValidation(assert assertionBase) (Assertion, error) {
+	approvedRevision, err := checkUint(assert.headers, "approved-revision", 64)
+	if err != nil {
+		return nil, err
+	}
+
+	revoked := false
+	switch assert.headers["revoked"] {
+	case nil, "false":
+	case "true":
+		revoked = true
+	default:
+		return nil, fmt.Errorf(`"revoked" header must be 'true' or 'false'`)
+	}
+
+	timestamp, err := checkRFC3339Date(assert.headers, "timestamp")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Validation{
+		assertionBase:    assert,
+		approvedRevision: approvedRevision,
+		revoked:          revoked,
+		timestamp:        timestamp,
+	}, nil
+}
+
+// SnapFileSHA3_384 computes the SHA3-384 digest of the snap file at
+// snapPath, in the form used by the snap-digest header of snap-revision
+// assertions, and returns it together with the size of the file.
+func SnapFileSHA3_384(snapPath string) (digest string, size uint64, err error) {
+	f, err := os.Open(snapPath)
+	if err != nil {
+		return "", 0, fmt.Errorf("cannot compute snap %q digest: %v", snapPath, err)
+	}
+	defer f.Close()
+
+	h := sha3.New384()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, fmt.Errorf("cannot compute snap %q digest: %v", snapPath, err)
+	}
+	return "sha3-384 " + base64.RawURLEncoding.EncodeToString(h.Sum(nil)), uint64(n), nil
+}
@@ -20,6 +20,7 @@
 package asserts_test
 
 import (
+	"io/ioutil"
 	"path/filepath"
 	"strings"
 	"time"
@@ -33,6 +34,7 @@ var (
 	_ = Suite(&snapDeclSuite{})
 	_ = Suite(&snapBuildSuite{})
 	_ = Suite(&snapRevSuite{})
+	_ = Suite(&validationSuite{})
 )
 
 type snapDeclSuite struct {
@@ -195,7 +197,7 @@ func makeSignAndCheckDbWithAccountKey(c *C, accountID string) (signingKeyID stri
 	c.Assert(err, IsNil)
 	accPubKeyBody := string(pubKeyEncoded)
 
-	headers := map[string]string{
+	headers := map[string]interface{}{
 		"authority-id":           "canonical",
 		"account-id":             accountID,
 		"public-key-id":          accKeyID,
@@ -226,7 +228,7 @@ func makeSignAndCheckDbWithAccountKey(c *C, accountID string) (signingKeyID stri
 func (sbs *snapBuildSuite) TestSnapBuildCheck(c *C) {
 	signingKeyID, accSignDB, db := makeSignAndCheckDbWithAccountKey(c, "dev-id1")
 
-	headers := map[string]string{
+	headers := map[string]interface{}{
 		"authority-id": "dev-id1",
 		"series":       "16",
 		"snap-id":      "snap-id-1",
@@ -245,7 +247,7 @@ func (sbs *snapBuildSuite) TestSnapBuildCheck(c *C) {
 func (sbs *snapBuildSuite) TestSnapBuildCheckInconsistentTimestamp(c *C) {
 	signingKeyID, accSignDB, db := makeSignAndCheckDbWithAccountKey(c, "dev-id1")
 
-	headers := map[string]string{
+	headers := map[string]interface{}{
 		"authority-id": "dev-id1",
 		"series":       "16",
 		"snap-id":      "snap-id-1",
@@ -288,8 +290,8 @@ func (srs *snapRevSuite) makeValidEncoded() string {
 		"openpgp c2ln"
 }
 
-func (srs *snapRevSuite) makeHeaders(overrides map[string]string) map[string]string {
-	headers := map[string]string{
+func (srs *snapRevSuite) makeHeaders(overrides map[string]interface{}) map[string]interface{} {
+	headers := map[string]interface{}{
 		"authority-id":  "store-id1",
 		"series":        "16",
 		"snap-id":       "snap-id-1",
@@ -364,7 +366,7 @@ func (srs *snapRevSuite) TestSnapRevisionCheck(c *C) {
 func (srs *snapRevSuite) TestSnapRevisionCheckInconsistentTimestamp(c *C) {
 	signingKeyID, accSignDB, db := makeSignAndCheckDbWithAccountKey(c, "store-id1")
 
-	headers := srs.makeHeaders(map[string]string{
+	headers := srs.makeHeaders(map[string]interface{}{
 		"timestamp": "2013-01-01T14:00:00Z",
 	})
 	snapRev, err := accSignDB.Sign(asserts.SnapRevisionType, headers, nil, signingKeyID)
@@ -385,8 +387,184 @@ func (srs *snapRevSuite) TestPrimaryKey(c *C) {
 
 	_, err = db.Find(asserts.SnapRevisionType, map[string]string{
 		"series":      "16",
-		"snap-id":     headers["snap-id"],
-		"snap-digest": headers["snap-digest"],
+		"snap-id":     headers["snap-id"].(string),
+		"snap-digest": headers["snap-digest"].(string),
 	})
 	c.Assert(err, IsNil)
 }
+
+func (srs *snapRevSuite) TestSnapFileSHA3_384(c *C) {
+	snapPath := filepath.Join(c.MkDir(), "foo.snap")
+	c.Assert(ioutil.WriteFile(snapPath, []byte("hello"), 0644), IsNil)
+
+	digest, size, err := asserts.SnapFileSHA3_384(snapPath)
+	c.Assert(err, IsNil)
+	c.Check(digest, Equals, "sha3-384 cgrqEQGe8GRA-_Bdh6okaAohU985B7I2MecXfOYg-hMw_wfA_d7lRpmkw-4O6diH")
+	c.Check(size, Equals, uint64(5))
+
+	_, _, err = asserts.SnapFileSHA3_384(filepath.Join(c.MkDir(), "missing.snap"))
+	c.Check(err, ErrorMatches, `cannot compute snap ".*/missing.snap" digest: .*`)
+}
+
+type validationSuite struct {
+	ts     time.Time
+	tsLine string
+}
+
+func (vs *validationSuite) SetUpSuite(c *C) {
+	vs.ts = time.Now().Truncate(time.Second).UTC()
+	vs.tsLine = "timestamp: " + vs.ts.Format(time.RFC3339) + "\n"
+}
+
+func (vs *validationSuite) makeValidEncoded() string {
+	return "type: validation\n" +
+		"authority-id: dev-id1\n" +
+		"series: 16\n" +
+		"snap-id: snap-id-1\n" +
+		"approved-snap-id: snap-id-2\n" +
+		"approved-revision: 42\n" +
+		"revision: 1\n" +
+		vs.tsLine +
+		"body-length: 0" +
+		"\n\n" +
+		"openpgp c2ln"
+}
+
+func (vs *validationSuite) makeHeaders(overrides map[string]interface{}) map[string]interface{} {
+	headers := map[string]interface{}{
+		"authority-id":      "dev-id1",
+		"series":            "16",
+		"snap-id":           "snap-id-1",
+		"approved-snap-id":  "snap-id-2",
+		"approved-revision": "42",
+		"revision":          "1",
+		"timestamp":         "2015-11-25T20:00:00Z",
+	}
+	for k, v := range overrides {
+		headers[k] = v
+	}
+	return headers
+}
+
+func (vs *validationSuite) TestDecodeOK(c *C) {
+	encoded := vs.makeValidEncoded()
+	a, err := asserts.Decode([]byte(encoded))
+	c.Assert(err, IsNil)
+	c.Check(a.Type(), Equals, asserts.ValidationType)
+	validation := a.(*asserts.Validation)
+	c.Check(validation.AuthorityID(), Equals, "dev-id1")
+	c.Check(validation.Timestamp(), Equals, vs.ts)
+	c.Check(validation.Series(), Equals, "16")
+	c.Check(validation.SnapID(), Equals, "snap-id-1")
+	c.Check(validation.ApprovedSnapID(), Equals, "snap-id-2")
+	c.Check(validation.ApprovedRevision(), Equals, uint64(42))
+	c.Check(validation.Revoked(), Equals, false)
+	c.Check(validation.Revision(), Equals, 1)
+	c.Check(validation.Prerequisites(), DeepEquals, []*asserts.Ref{
+		{Type: asserts.SnapDeclarationType, PrimaryKey: []string{"16", "snap-id-1"}},
+		{Type: asserts.SnapDeclarationType, PrimaryKey: []string{"16", "snap-id-2"}},
+	})
+}
+
+func (vs *validationSuite) TestDecodeRevoked(c *C) {
+	encoded := strings.Replace(vs.makeValidEncoded(), vs.tsLine, vs.tsLine+"revoked: true\n", 1)
+	a, err := asserts.Decode([]byte(encoded))
+	c.Assert(err, IsNil)
+	c.Check(a.(*asserts.Validation).Revoked(), Equals, true)
+}
+
+const (
+	validationErrPrefix = "assertion validation: "
+)
+
+func (vs *validationSuite) TestDecodeInvalid(c *C) {
+	encoded := vs.makeValidEncoded()
+	invalidTests := []struct{ original, invalid, expectedErr string }{
+		{"series: 16\n", "", `"series" header is mandatory`},
+		{"snap-id: snap-id-1\n", "", `"snap-id" header is mandatory`},
+		{"approved-snap-id: snap-id-2\n", "", `"approved-snap-id" header is mandatory`},
+		{"approved-revision: 42\n", "", `"approved-revision" header is mandatory`},
+		{"approved-revision: 42\n", "approved-revision: z\n", `"approved-revision" header is not an unsigned integer: z`},
+		{vs.tsLine, vs.tsLine + "revoked: maybe\n", `"revoked" header must be 'true' or 'false'`},
+		{vs.tsLine, "timestamp: 12:30\n", `"timestamp" header is not a RFC3339 date: .*`},
+	}
+
+	for _, test := range invalidTests {
+		invalid := strings.Replace(encoded, test.original, test.invalid, 1)
+		_, err := asserts.Decode([]byte(invalid))
+		c.Check(err, ErrorMatches, validationErrPrefix+test.expectedErr)
+	}
+}
+
+func (vs *validationSuite) addSnapDecl(c *C, db *asserts.Database, snapID, publisherID, gates string) {
+	headers := map[string]interface{}{
+		"authority-id": "canonical",
+		"series":       "16",
+		"snap-id":      snapID,
+		"snap-name":    "snap-" + snapID,
+		"publisher-id": publisherID,
+		"gates":        gates,
+		"timestamp":    "2015-11-25T20:00:00Z",
+	}
+	snapDecl, err := asserts.AssembleAndSignInTest(asserts.SnapDeclarationType, headers, nil, asserts.OpenPGPPrivateKey(testPrivKey0))
+	c.Assert(err, IsNil)
+	err = db.Add(snapDecl)
+	c.Assert(err, IsNil)
+}
+
+func (vs *validationSuite) TestValidationCheck(c *C) {
+	signingKeyID, accSignDB, db := makeSignAndCheckDbWithAccountKey(c, "dev-id1")
+	vs.addSnapDecl(c, db, "snap-id-1", "dev-id1", "snap-id-2")
+
+	validation, err := accSignDB.Sign(asserts.ValidationType, vs.makeHeaders(nil), nil, signingKeyID)
+	c.Assert(err, IsNil)
+
+	err = db.Check(validation)
+	c.Assert(err, IsNil)
+}
+
+func (vs *validationSuite) TestValidationCheckWithoutSnapDecl(c *C) {
+	signingKeyID, accSignDB, db := makeSignAndCheckDbWithAccountKey(c, "dev-id1")
+
+	validation, err := accSignDB.Sign(asserts.ValidationType, vs.makeHeaders(nil), nil, signingKeyID)
+	c.Assert(err, IsNil)
+
+	err = db.Check(validation)
+	c.Assert(err, ErrorMatches, `validation assertion violates other knowledge: validation assertion by snap-id "snap-id-1" not accompanied by snap declaration`)
+}
+
+func (vs *validationSuite) TestValidationCheckWrongAuthority(c *C) {
+	signingKeyID, accSignDB, db := makeSignAndCheckDbWithAccountKey(c, "dev-id1")
+	vs.addSnapDecl(c, db, "snap-id-1", "dev-id2", "snap-id-2")
+
+	validation, err := accSignDB.Sign(asserts.ValidationType, vs.makeHeaders(nil), nil, signingKeyID)
+	c.Assert(err, IsNil)
+
+	err = db.Check(validation)
+	c.Assert(err, ErrorMatches, `validation assertion violates other knowledge: validation assertion by snap "snap-snap-id-1" \(id "snap-id-1"\) not signed by its publisher`)
+}
+
+func (vs *validationSuite) TestValidationCheckNotGated(c *C) {
+	signingKeyID, accSignDB, db := makeSignAndCheckDbWithAccountKey(c, "dev-id1")
+	vs.addSnapDecl(c, db, "snap-id-1", "dev-id1", "snap-id-3")
+
+	validation, err := accSignDB.Sign(asserts.ValidationType, vs.makeHeaders(nil), nil, signingKeyID)
+	c.Assert(err, IsNil)
+
+	err = db.Check(validation)
+	c.Assert(err, ErrorMatches, `validation assertion violates other knowledge: snap "snap-snap-id-1" \(id "snap-id-1"\) does not gate snap-id "snap-id-2"`)
+}
+
+func (vs *validationSuite) TestValidationCheckInconsistentTimestamp(c *C) {
+	signingKeyID, accSignDB, db := makeSignAndCheckDbWithAccountKey(c, "dev-id1")
+	vs.addSnapDecl(c, db, "snap-id-1", "dev-id1", "snap-id-2")
+
+	headers := vs.makeHeaders(map[string]interface{}{
+		"timestamp": "2013-01-01T14:00:00Z",
+	})
+	validation, err := accSignDB.Sign(asserts.ValidationType, headers, nil, signingKeyID)
+	c.Assert(err, IsNil)
+
+	err = db.Check(validation)
+	c.Assert(err, ErrorMatches, "validation assertion timestamp outside of signing key validity")
+}
@@ -45,7 +45,7 @@ func (sdbs *sysDBSuite) SetUpTest(c *C) {
 	trustedPubKeyEncoded, err := asserts.EncodePublicKey(trustedPubKey)
 	c.Assert(err, IsNil)
 	// self-signed
-	headers := map[string]string{
+	headers := map[string]interface{}{
 		"authority-id":           "canonical",
 		"account-id":             "canonical",
 		"public-key-id":          trustedPubKey.ID(),
@@ -66,7 +66,7 @@ func (sdbs *sysDBSuite) SetUpTest(c *C) {
 	err = ioutil.WriteFile(dirs.SnapTrustedAccountKey, asserts.Encode(trustedAccKey), os.ModePerm)
 	c.Assert(err, IsNil)
 
-	headers = map[string]string{
+	headers = map[string]interface{}{
 		"authority-id": "canonical",
 		"primary-key":  "0",
 	}
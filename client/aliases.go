@@ -0,0 +1,65 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// AliasStatus is the status of an alias of an app of a snap.
+type AliasStatus struct {
+	App    string `json:"app"`
+	Status string `json:"status"`
+}
+
+// Aliases returns the aliases of the apps of the active snaps, mapping
+// the names of the snaps to their aliases.
+func (client *Client) Aliases() (map[string]map[string]AliasStatus, error) {
+	var aliases map[string]map[string]AliasStatus
+	if _, err := client.doSync("GET", "/v2/aliases", nil, nil, nil, &aliases); err != nil {
+		return nil, fmt.Errorf("cannot list aliases: %s", err)
+	}
+	return aliases, nil
+}
+
+type aliasAction struct {
+	Action  string   `json:"action"`
+	Snap    string   `json:"snap"`
+	Aliases []string `json:"aliases"`
+}
+
+// Alias enables the given aliases of the apps of the snap.
+func (client *Client) Alias(snapName string, aliases []string) (changeID string, err error) {
+	return client.doAliasAction("alias", snapName, aliases)
+}
+
+// Unalias disables the given aliases of the apps of the snap.
+func (client *Client) Unalias(snapName string, aliases []string) (changeID string, err error) {
+	return client.doAliasAction("unalias", snapName, aliases)
+}
+
+func (client *Client) doAliasAction(action, snapName string, aliases []string) (changeID string, err error) {
+	data, err := json.Marshal(&aliasAction{Action: action, Snap: snapName, Aliases: aliases})
+	if err != nil {
+		return "", fmt.Errorf("cannot marshal alias action: %s", err)
+	}
+	return client.doAsync("POST", "/v2/aliases", nil, nil, bytes.NewBuffer(data))
+}
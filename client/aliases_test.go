@@ -0,0 +1,87 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package client_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+
+	"gopkg.in/check.v1"
+
+	"github.com/ubuntu-core/snappy/client"
+)
+
+func (cs *clientSuite) TestClientAliases(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"result": {
+			"tool": {
+				"convert": {"app": "convert", "status": "enabled"},
+				"resize": {"app": "resize", "status": "disabled"}
+			}
+		}
+	}`
+	aliases, err := cs.cli.Aliases()
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/aliases")
+	c.Check(aliases, check.DeepEquals, map[string]map[string]client.AliasStatus{
+		"tool": {
+			"convert": {App: "convert", Status: "enabled"},
+			"resize":  {App: "resize", Status: "disabled"},
+		},
+	})
+}
+
+func (cs *clientSuite) TestClientAliasesError(c *check.C) {
+	cs.rsp = `{"type": "error", "status-code": 500, "result": {"message": "boom"}}`
+	_, err := cs.cli.Aliases()
+	c.Assert(err, check.ErrorMatches, `cannot list aliases: boom`)
+}
+
+func (cs *clientSuite) TestClientAliasOps(c *check.C) {
+	cs.rsp = `{
+		"change": "d728",
+		"status-code": 202,
+		"type": "async"
+	}`
+	for _, t := range []struct {
+		op     func() (string, error)
+		action string
+	}{
+		{func() (string, error) { return cs.cli.Alias("tool", []string{"convert"}) }, "alias"},
+		{func() (string, error) { return cs.cli.Unalias("tool", []string{"convert"}) }, "unalias"},
+	} {
+		id, err := t.op()
+		c.Assert(err, check.IsNil, check.Commentf(t.action))
+		c.Check(id, check.Equals, "d728")
+		c.Check(cs.req.Method, check.Equals, "POST")
+		c.Check(cs.req.URL.Path, check.Equals, "/v2/aliases")
+
+		body, err := ioutil.ReadAll(cs.req.Body)
+		c.Assert(err, check.IsNil)
+		var jsonBody map[string]interface{}
+		c.Assert(json.Unmarshal(body, &jsonBody), check.IsNil)
+		c.Check(jsonBody, check.DeepEquals, map[string]interface{}{
+			"action":  t.action,
+			"snap":    "tool",
+			"aliases": []interface{}{"convert"},
+		})
+	}
+}
@@ -0,0 +1,217 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AppInfo describes an app of a snap, with the status of its service
+// if the app is one.
+type AppInfo struct {
+	Snap    string `json:"snap"`
+	Name    string `json:"name"`
+	Daemon  string `json:"daemon,omitempty"`
+	Enabled bool   `json:"enabled,omitempty"`
+	Active  bool   `json:"active,omitempty"`
+}
+
+// IsService returns whether the app is a service.
+func (a *AppInfo) IsService() bool {
+	return a.Daemon != ""
+}
+
+// AppOptions represent the options of the Apps call.
+type AppOptions struct {
+	// Service restricts the result to the apps that are services.
+	Service bool
+}
+
+// Apps returns the apps of the given snaps, or of the given apps in
+// "snap.app" form, or of all the active snaps if no names are given.
+func (client *Client) Apps(names []string, opts AppOptions) ([]*AppInfo, error) {
+	q := url.Values{}
+	if len(names) > 0 {
+		q.Set("names", strings.Join(names, ","))
+	}
+	if opts.Service {
+		q.Set("select", "service")
+	}
+
+	var appInfos []*AppInfo
+	if _, err := client.doSync("GET", "/v2/apps", q, nil, nil, &appInfos); err != nil {
+		return nil, fmt.Errorf("cannot list apps: %s", err)
+	}
+	return appInfos, nil
+}
+
+// StartOptions represent the options of the Start call.
+type StartOptions struct {
+	// Enable the services to start on boot as well.
+	Enable bool
+}
+
+// Start starts the services of the given snaps, or the given services
+// in "snap.app" form.
+func (client *Client) Start(names []string, opts StartOptions) (changeID string, err error) {
+	action := "start"
+	if opts.Enable {
+		action = "enable"
+	}
+	return client.doAppAction(action, names)
+}
+
+// StopOptions represent the options of the Stop call.
+type StopOptions struct {
+	// Disable the services so they don't start on boot either.
+	Disable bool
+}
+
+// Stop stops the services of the given snaps, or the given services
+// in "snap.app" form.
+func (client *Client) Stop(names []string, opts StopOptions) (changeID string, err error) {
+	action := "stop"
+	if opts.Disable {
+		action = "disable"
+	}
+	return client.doAppAction(action, names)
+}
+
+// Restart restarts the services of the given snaps, or the given
+// services in "snap.app" form.
+func (client *Client) Restart(names []string) (changeID string, err error) {
+	return client.doAppAction("restart", names)
+}
+
+type appAction struct {
+	Action string   `json:"action"`
+	Names  []string `json:"names"`
+}
+
+func (client *Client) doAppAction(action string, names []string) (changeID string, err error) {
+	data, err := json.Marshal(&appAction{Action: action, Names: names})
+	if err != nil {
+		return "", fmt.Errorf("cannot marshal service action: %s", err)
+	}
+	return client.doAsync("POST", "/v2/apps", nil, nil, bytes.NewBuffer(data))
+}
+
+// Log is a single entry of the log of a service.
+type Log struct {
+	Timestamp string `json:"timestamp"`
+	Message   string `json:"message"`
+	SID       string `json:"sid"`
+	PID       string `json:"pid,omitempty"`
+}
+
+func (l Log) String() string {
+	return fmt.Sprintf("%s %s %s", l.Timestamp, l.SID, l.Message)
+}
+
+// LogOptions represent the options of the Logs and FollowLogs calls.
+type LogOptions struct {
+	// N is the number of most recent entries to get, with 0 meaning
+	// the default number of the daemon and a negative number all of
+	// them.
+	N int
+	// Since only includes the entries logged after it, if not zero.
+	Since time.Time
+}
+
+func logsQuery(names []string, opts LogOptions) url.Values {
+	q := url.Values{}
+	if len(names) > 0 {
+		q.Set("names", strings.Join(names, ","))
+	}
+	switch {
+	case opts.N < 0:
+		q.Set("n", "all")
+	case opts.N > 0:
+		q.Set("n", strconv.Itoa(opts.N))
+	}
+	if !opts.Since.IsZero() {
+		q.Set("since", opts.Since.Format(time.RFC3339))
+	}
+
+	return q
+}
+
+// Logs returns the log entries of the services of the given snaps, or
+// of the given services in "snap.app" form, or of the services of all
+// the active snaps if no names are given.
+func (client *Client) Logs(names []string, opts LogOptions) ([]Log, error) {
+	var logs []Log
+	if _, err := client.doSync("GET", "/v2/logs", logsQuery(names, opts), nil, nil, &logs); err != nil {
+		return nil, fmt.Errorf("cannot get logs: %s", err)
+	}
+	return logs, nil
+}
+
+// FollowLogs is like Logs, but it returns a stream that keeps
+// delivering the new log entries as they come. The stream must be
+// closed when done with.
+func (client *Client) FollowLogs(names []string, opts LogOptions) (*LogStream, error) {
+	q := logsQuery(names, opts)
+	q.Set("follow", "true")
+
+	rsp, err := client.raw("GET", "/v2/logs", q, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("cannot follow logs: cannot communicate with server: %s", err)
+	}
+	if rsp.StatusCode != http.StatusOK {
+		defer rsp.Body.Close()
+		return nil, parseError(rsp)
+	}
+
+	return &LogStream{body: rsp.Body, dec: json.NewDecoder(rsp.Body)}, nil
+}
+
+// A LogStream decodes the log entries streamed by the daemon, one at a
+// time as they come.
+type LogStream struct {
+	body io.ReadCloser
+	dec  *json.Decoder
+}
+
+// Next returns the next entry of the stream, blocking until there's
+// one. It returns io.EOF when the stream is over.
+func (s *LogStream) Next() (Log, error) {
+	var log Log
+	if err := s.dec.Decode(&log); err != nil {
+		if err == io.EOF {
+			return Log{}, err
+		}
+		return Log{}, fmt.Errorf("cannot decode log entry: %v", err)
+	}
+	return log, nil
+}
+
+// Close stops the stream.
+func (s *LogStream) Close() error {
+	return s.body.Close()
+}
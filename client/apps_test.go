@@ -0,0 +1,155 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client_test
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"gopkg.in/check.v1"
+
+	"github.com/ubuntu-core/snappy/client"
+)
+
+func (cs *clientSuite) TestClientApps(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"result": [
+			{"snap": "foo", "name": "app"},
+			{"snap": "foo", "name": "svc", "daemon": "simple", "enabled": true, "active": true}
+		]
+	}`
+	apps, err := cs.cli.Apps([]string{"foo", "bar.baz"}, client.AppOptions{Service: true})
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/apps")
+	c.Check(cs.req.URL.Query().Get("names"), check.Equals, "foo,bar.baz")
+	c.Check(cs.req.URL.Query().Get("select"), check.Equals, "service")
+	c.Check(apps, check.DeepEquals, []*client.AppInfo{
+		{Snap: "foo", Name: "app"},
+		{Snap: "foo", Name: "svc", Daemon: "simple", Enabled: true, Active: true},
+	})
+	c.Check(apps[0].IsService(), check.Equals, false)
+	c.Check(apps[1].IsService(), check.Equals, true)
+}
+
+func (cs *clientSuite) TestClientAppsError(c *check.C) {
+	cs.rsp = `{"type": "error", "status-code": 404, "result": {"message": "cannot find snap \"foo\""}}`
+	_, err := cs.cli.Apps([]string{"foo"}, client.AppOptions{})
+	c.Assert(err, check.ErrorMatches, `cannot list apps: cannot find snap "foo"`)
+	c.Check(cs.req.URL.Query().Get("select"), check.Equals, "")
+}
+
+func (cs *clientSuite) TestClientServiceOps(c *check.C) {
+	cs.rsp = `{
+		"change": "d728",
+		"status-code": 202,
+		"type": "async"
+	}`
+	for _, t := range []struct {
+		op     func() (string, error)
+		action string
+	}{
+		{func() (string, error) { return cs.cli.Start([]string{"foo"}, client.StartOptions{}) }, "start"},
+		{func() (string, error) { return cs.cli.Start([]string{"foo"}, client.StartOptions{Enable: true}) }, "enable"},
+		{func() (string, error) { return cs.cli.Stop([]string{"foo"}, client.StopOptions{}) }, "stop"},
+		{func() (string, error) { return cs.cli.Stop([]string{"foo"}, client.StopOptions{Disable: true}) }, "disable"},
+		{func() (string, error) { return cs.cli.Restart([]string{"foo"}) }, "restart"},
+	} {
+		id, err := t.op()
+		c.Assert(err, check.IsNil, check.Commentf(t.action))
+		c.Check(id, check.Equals, "d728")
+		c.Check(cs.req.Method, check.Equals, "POST")
+		c.Check(cs.req.URL.Path, check.Equals, "/v2/apps")
+
+		body, err := ioutil.ReadAll(cs.req.Body)
+		c.Assert(err, check.IsNil)
+		var jsonBody map[string]interface{}
+		c.Assert(json.Unmarshal(body, &jsonBody), check.IsNil)
+		c.Check(jsonBody, check.DeepEquals, map[string]interface{}{
+			"action": t.action,
+			"names":  []interface{}{"foo"},
+		})
+	}
+}
+
+func (cs *clientSuite) TestClientLogs(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"result": [
+			{"timestamp": "2016-06-01T12:00:00.123456Z", "message": "hello", "sid": "foo.svc", "pid": "42"}
+		]
+	}`
+	logs, err := cs.cli.Logs([]string{"foo"}, client.LogOptions{N: 5})
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/logs")
+	c.Check(cs.req.URL.Query().Get("names"), check.Equals, "foo")
+	c.Check(cs.req.URL.Query().Get("n"), check.Equals, "5")
+	c.Check(logs, check.DeepEquals, []client.Log{
+		{Timestamp: "2016-06-01T12:00:00.123456Z", Message: "hello", SID: "foo.svc", PID: "42"},
+	})
+	c.Check(logs[0].String(), check.Equals, "2016-06-01T12:00:00.123456Z foo.svc hello")
+
+	_, err = cs.cli.Logs(nil, client.LogOptions{N: -1})
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.URL.Query().Get("n"), check.Equals, "all")
+	c.Check(cs.req.URL.Query().Get("names"), check.Equals, "")
+	c.Check(cs.req.URL.Query().Get("since"), check.Equals, "")
+	c.Check(cs.req.URL.Query().Get("follow"), check.Equals, "")
+}
+
+func (cs *clientSuite) TestClientFollowLogs(c *check.C) {
+	cs.header = http.Header{"Content-Type": {"application/x-ndjson"}}
+	cs.rsp = `{"timestamp": "2016-06-01T12:00:00.123456Z", "message": "hello", "sid": "foo.svc", "pid": "42"}
+{"timestamp": "2016-06-01T12:00:01.123456Z", "message": "bye", "sid": "foo.svc"}
+`
+	since := time.Date(2016, 6, 1, 11, 0, 0, 0, time.UTC)
+	stream, err := cs.cli.FollowLogs([]string{"foo.svc"}, client.LogOptions{Since: since})
+	c.Assert(err, check.IsNil)
+	defer stream.Close()
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/logs")
+	q := cs.req.URL.Query()
+	c.Check(q.Get("names"), check.Equals, "foo.svc")
+	c.Check(q.Get("follow"), check.Equals, "true")
+	c.Check(q.Get("since"), check.Equals, "2016-06-01T11:00:00Z")
+	c.Check(q.Get("n"), check.Equals, "")
+
+	log, err := stream.Next()
+	c.Assert(err, check.IsNil)
+	c.Check(log, check.DeepEquals, client.Log{Timestamp: "2016-06-01T12:00:00.123456Z", Message: "hello", SID: "foo.svc", PID: "42"})
+	log, err = stream.Next()
+	c.Assert(err, check.IsNil)
+	c.Check(log.Message, check.Equals, "bye")
+	_, err = stream.Next()
+	c.Check(err, check.Equals, io.EOF)
+}
+
+func (cs *clientSuite) TestClientFollowLogsError(c *check.C) {
+	cs.status = http.StatusBadRequest
+	cs.header = http.Header{"Content-Type": {"application/json"}}
+	cs.rsp = `{"type": "error", "status-code": 400, "result": {"message": "cannot follow logs: no services found"}}`
+	_, err := cs.cli.FollowLogs(nil, client.LogOptions{})
+	c.Check(err, check.ErrorMatches, "cannot follow logs: no services found")
+}
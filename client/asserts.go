@@ -26,6 +26,7 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 
 	"github.com/ubuntu-core/snappy/asserts" // for parsing
 )
@@ -43,6 +44,17 @@ func (client *Client) Ack(b []byte) error {
 	return nil
 }
 
+// CheckAssertions asks for a consistency check of the system
+// assertion database, which also rebuilds the indexes it keeps.
+func (client *Client) CheckAssertions() error {
+	var rsp interface{}
+	if _, err := client.doSync("POST", "/v2/debug/check-assertions", nil, nil, nil, &rsp); err != nil {
+		return fmt.Errorf("cannot check assertions: %v", err)
+	}
+
+	return nil
+}
+
 // Known queries assertions with type assertTypeName and matching assertion headers.
 func (client *Client) Known(assertTypeName string, headers map[string]string) ([]asserts.Assertion, error) {
 	path := fmt.Sprintf("/v2/assertions/%s", assertTypeName)
@@ -54,6 +66,26 @@ func (client *Client) Known(assertTypeName string, headers map[string]string) ([
 		}
 	}
 
+	return client.getAssertions(path, q)
+}
+
+// KnownBundle queries a dependency closed bundle of the assertions
+// needed to verify the given installed snaps and, if withModel is set,
+// the model and serial of the device. The assertions are ordered so
+// that each comes after the ones needed to verify it.
+func (client *Client) KnownBundle(snapNames []string, withModel bool) ([]asserts.Assertion, error) {
+	q := url.Values{}
+	if len(snapNames) > 0 {
+		q.Set("snaps", strings.Join(snapNames, ","))
+	}
+	if withModel {
+		q.Set("model", "true")
+	}
+
+	return client.getAssertions("/v2/assertions", q)
+}
+
+func (client *Client) getAssertions(path string, q url.Values) ([]asserts.Assertion, error) {
 	response, err := client.raw("GET", path, q, nil, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query assertions: %v", err)
@@ -45,6 +45,17 @@ func (cs *clientSuite) TestClientAssert(c *C) {
 	c.Check(cs.req.URL.Path, Equals, "/v2/assertions")
 }
 
+func (cs *clientSuite) TestClientCheckAssertions(c *C) {
+	cs.rsp = `{
+		"type": "sync",
+		"result": true
+	}`
+	err := cs.cli.CheckAssertions()
+	c.Assert(err, IsNil)
+	c.Check(cs.req.Method, Equals, "POST")
+	c.Check(cs.req.URL.Path, Equals, "/v2/debug/check-assertions")
+}
+
 func (cs *clientSuite) TestClientAssertsCallsEndpoint(c *C) {
 	_, _ = cs.cli.Known("snap-revision", nil)
 	c.Check(cs.req.Method, Equals, "GET")
@@ -143,3 +154,33 @@ func (cs *clientSuite) TestClientAssertsMissingAssertions(c *C) {
 	_, err := cs.cli.Known("snap-build", nil)
 	c.Assert(err, ErrorMatches, "response did not have the expected number of assertions")
 }
+
+func (cs *clientSuite) TestClientKnownBundle(c *C) {
+	cs.header = http.Header{}
+	cs.header.Add("X-Ubuntu-Assertions-Count", "1")
+	cs.rsp = `type: snap-revision
+authority-id: store-id1
+series: 16
+snap-id: snap-id-1
+snap-digest: sha256 ...
+snap-size: 123
+snap-revision: 1
+developer-id: dev-id1
+revision: 1
+timestamp: 2015-11-25T20:00:00Z
+body-length: 0
+
+openpgp ...
+`
+
+	a, err := cs.cli.KnownBundle([]string{"foo", "bar"}, true)
+	c.Assert(err, IsNil)
+	c.Check(a, HasLen, 1)
+
+	c.Check(cs.req.Method, Equals, "GET")
+	c.Check(cs.req.URL.Path, Equals, "/v2/assertions")
+	c.Check(cs.req.URL.Query(), DeepEquals, url.Values{
+		"snaps": []string{"foo,bar"},
+		"model": []string{"true"},
+	})
+}
@@ -234,6 +234,9 @@ type SysInfo struct {
 	DefaultChannel   string `json:"default-channel"`
 	APICompatibility string `json:"api-compat"`
 	Store            string `json:"store,omitempty"`
+	Brand            string `json:"brand,omitempty"`
+	Model            string `json:"model,omitempty"`
+	Serial           string `json:"serial,omitempty"`
 }
 
 func (rsp *response) err() error {
@@ -152,7 +152,10 @@ func (cs *clientSuite) TestClientSysInfo(c *check.C) {
                       "release": "r",
                       "default-channel": "dc",
                       "api-compat": "42",
-                      "store": "store"}}`
+                      "store": "store",
+                      "brand": "brand",
+                      "model": "model",
+                      "serial": "serial"}}`
 	sysInfo, err := cs.cli.SysInfo()
 	c.Check(err, check.IsNil)
 	c.Check(sysInfo, check.DeepEquals, &client.SysInfo{
@@ -161,6 +164,9 @@ func (cs *clientSuite) TestClientSysInfo(c *check.C) {
 		DefaultChannel:   "dc",
 		APICompatibility: "42",
 		Store:            "store",
+		Brand:            "brand",
+		Model:            "model",
+		Serial:           "serial",
 	})
 }
 
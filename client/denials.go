@@ -0,0 +1,54 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client
+
+import (
+	"fmt"
+	"time"
+)
+
+// Denial describes an operation that the confinement of a snap denied, or
+// would have denied if the snap wasn't installed in developer mode.
+type Denial struct {
+	Snap      string `json:"snap"`
+	App       string `json:"app"`
+	Security  string `json:"security"`
+	Operation string `json:"operation"`
+	Target    string `json:"target,omitempty"`
+	Mask      string `json:"mask,omitempty"`
+	Complain  bool   `json:"complain,omitempty"`
+
+	Count     int       `json:"count"`
+	FirstSeen time.Time `json:"first-seen"`
+	LastSeen  time.Time `json:"last-seen"`
+
+	// Interfaces lists the builtin interfaces that would grant the operation.
+	Interfaces []string `json:"interfaces,omitempty"`
+}
+
+// Denials returns the operations denied to the snap with the provided name.
+func (client *Client) Denials(name string) ([]*Denial, error) {
+	var denials []*Denial
+	path := fmt.Sprintf("/v2/snaps/%s/denials", name)
+	if _, err := client.doSync("GET", path, nil, nil, nil, &denials); err != nil {
+		return nil, fmt.Errorf("cannot list denials of snap %q: %s", name, err)
+	}
+	return denials, nil
+}
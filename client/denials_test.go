@@ -0,0 +1,76 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client_test
+
+import (
+	"time"
+
+	"gopkg.in/check.v1"
+
+	"github.com/ubuntu-core/snappy/client"
+)
+
+func (cs *clientSuite) TestClientDenialsCallsEndpoint(c *check.C) {
+	_, _ = cs.cli.Denials("foo")
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/snaps/foo/denials")
+}
+
+func (cs *clientSuite) TestClientDenials(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"result": [
+			{
+				"snap": "foo",
+				"app": "bar",
+				"security": "apparmor",
+				"operation": "open",
+				"target": "/var/log/syslog",
+				"mask": "r",
+				"complain": true,
+				"count": 3,
+				"first-seen": "2016-05-05T10:00:00Z",
+				"last-seen": "2016-05-05T11:00:00Z",
+				"interfaces": ["log-observe"]
+			}
+		]
+	}`
+	denials, err := cs.cli.Denials("foo")
+	c.Assert(err, check.IsNil)
+	c.Check(denials, check.DeepEquals, []*client.Denial{{
+		Snap:       "foo",
+		App:        "bar",
+		Security:   "apparmor",
+		Operation:  "open",
+		Target:     "/var/log/syslog",
+		Mask:       "r",
+		Complain:   true,
+		Count:      3,
+		FirstSeen:  time.Date(2016, 5, 5, 10, 0, 0, 0, time.UTC),
+		LastSeen:   time.Date(2016, 5, 5, 11, 0, 0, 0, time.UTC),
+		Interfaces: []string{"log-observe"},
+	}})
+}
+
+func (cs *clientSuite) TestClientDenialsError(c *check.C) {
+	cs.rsp = `{"type": "error", "status-code": 404, "result": {"message": "cannot find snap \"foo\""}}`
+	_, err := cs.cli.Denials("foo")
+	c.Assert(err, check.ErrorMatches, `cannot list denials of snap "foo": cannot find snap "foo"`)
+}
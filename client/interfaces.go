@@ -58,10 +58,26 @@ type SlotRef struct {
 	Name string `json:"slot"`
 }
 
+// AttrSpec describes a single attribute of a plug or slot.
+type AttrSpec struct {
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`
+	Required bool     `json:"required,omitempty"`
+	Pattern  string   `json:"pattern,omitempty"`
+	Values   []string `json:"values,omitempty"`
+}
+
+// AttrSchema describes the attributes of the plugs and slots of an interface.
+type AttrSchema struct {
+	Plug []AttrSpec `json:"plug,omitempty"`
+	Slot []AttrSpec `json:"slot,omitempty"`
+}
+
 // Interfaces contains information about all plugs, slots and their connections
 type Interfaces struct {
-	Plugs []Plug `json:"plugs"`
-	Slots []Slot `json:"slots"`
+	Plugs   []Plug                `json:"plugs"`
+	Slots   []Slot                `json:"slots"`
+	Schemas map[string]AttrSchema `json:"schemas,omitempty"`
 }
 
 // InterfaceAction represents an action performed on the interface system.
@@ -95,6 +95,30 @@ func (cs *clientSuite) TestClientInterfaces(c *check.C) {
 	})
 }
 
+func (cs *clientSuite) TestClientInterfacesSchemas(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"result": {
+			"plugs": [],
+			"slots": [],
+			"schemas": {
+				"bool-file": {
+					"slot": [
+						{"name": "path", "type": "string", "required": true}
+					]
+				}
+			}
+		}
+	}`
+	interfaces, err := cs.cli.Interfaces()
+	c.Assert(err, check.IsNil)
+	c.Check(interfaces.Schemas, check.DeepEquals, map[string]client.AttrSchema{
+		"bool-file": {
+			Slot: []client.AttrSpec{{Name: "path", Type: "string", Required: true}},
+		},
+	})
+}
+
 func (cs *clientSuite) TestClientConnectCallsEndpoint(c *check.C) {
 	cs.cli.Connect("producer", "plug", "consumer", "slot")
 	c.Check(cs.req.Method, check.Equals, "POST")
@@ -41,8 +41,22 @@ type Snap struct {
 	Type          string    `json:"type"`
 	Version       string    `json:"version"`
 	Revision      int       `json:"revision"`
+	Revoked       bool      `json:"revoked,omitempty"`
+	Channel       string    `json:"channel,omitempty"`
+	License       string    `json:"license,omitempty"`
 
-	Prices map[string]float64 `json:"prices"`
+	Prices   map[string]float64          `json:"prices"`
+	Channels map[string]*ChannelSnapInfo `json:"channels,omitempty"`
+	Apps     []AppInfo                   `json:"apps,omitempty"`
+}
+
+// ChannelSnapInfo describes the revision of a snap published in a
+// channel of the store.
+type ChannelSnapInfo struct {
+	Channel  string `json:"channel"`
+	Revision int    `json:"revision"`
+	Version  string `json:"version"`
+	Size     int64  `json:"size,omitempty"`
 }
 
 // SnapFilter is used to filter snaps by source, name and/or type
@@ -96,6 +110,14 @@ func (client *Client) FilterSnaps(filter SnapFilter) ([]*Snap, *ResultInfo, erro
 	return client.snapsFromPath("/v2/snaps", q)
 }
 
+// Find returns the snaps in the store matching the given query.
+func (client *Client) Find(query string) ([]*Snap, *ResultInfo, error) {
+	q := url.Values{}
+	q.Set("q", query)
+
+	return client.snapsFromPath("/v2/find", q)
+}
+
 func (client *Client) snapsFromPath(path string, query url.Values) ([]*Snap, *ResultInfo, error) {
 	var snaps []*Snap
 	ri, err := client.doSync("GET", path, query, nil, nil, &snaps)
@@ -105,6 +105,17 @@ func (cs *clientSuite) TestClientFilterSnaps(c *check.C) {
 	}
 }
 
+func (cs *clientSuite) TestClientFind(c *check.C) {
+	cs.rsp = `{"type": "sync", "result": [{"name": "hello-world", "version": "1.0.18"}]}`
+	snaps, _, err := cs.cli.Find("hello")
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/find")
+	c.Check(cs.req.URL.RawQuery, check.Equals, "q=hello")
+	c.Assert(snaps, check.HasLen, 1)
+	c.Check(snaps[0].Name, check.Equals, "hello-world")
+}
+
 const (
 	pkgName = "chatroom.ogra"
 )
@@ -125,7 +136,11 @@ func (cs *clientSuite) TestClientSnap(c *check.C) {
 			"resource": "/v2/snaps/chatroom.ogra",
 			"status": "active",
 			"type": "app",
-			"version": "0.1-8"
+			"version": "0.1-8",
+			"channel": "stable",
+			"license": "GPL-3.0",
+			"channels": {"stable": {"channel": "stable", "revision": 8, "version": "0.1-8"}},
+			"apps": [{"snap": "chatroom", "name": "chatroom"}]
 		}
 	}`
 	pkg, _, err := cs.cli.Snap(pkgName)
@@ -145,5 +160,11 @@ func (cs *clientSuite) TestClientSnap(c *check.C) {
 		Status:        client.StatusActive,
 		Type:          client.TypeApp,
 		Version:       "0.1-8",
+		Channel:       "stable",
+		License:       "GPL-3.0",
+		Channels: map[string]*client.ChannelSnapInfo{
+			"stable": {Channel: "stable", Revision: 8, Version: "0.1-8"},
+		},
+		Apps: []client.AppInfo{{Snap: "chatroom", Name: "chatroom"}},
 	})
 }
@@ -0,0 +1,54 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client
+
+import (
+	"fmt"
+)
+
+// SecurityProfile holds a security profile generated for an application
+// along with the snippets that went into it.
+type SecurityProfile struct {
+	Profile  string          `json:"profile"`
+	Snippets []SnippetSource `json:"snippets,omitempty"`
+}
+
+// SnippetSource holds a security snippet and the plug or slot it came from.
+//
+// Permanent snippets refer either to a plug or to a slot while snippets
+// specific to a connection refer to both.
+type SnippetSource struct {
+	Interface string   `json:"interface"`
+	Plug      *PlugRef `json:"plug,omitempty"`
+	Slot      *SlotRef `json:"slot,omitempty"`
+	Snippet   string   `json:"snippet"`
+}
+
+// Security returns the security profiles generated for the applications of
+// the snap with the provided name, keyed by application name and security
+// system.
+func (client *Client) Security(name string) (map[string]map[string]*SecurityProfile, error) {
+	var security map[string]map[string]*SecurityProfile
+	path := fmt.Sprintf("/v2/snaps/%s/security", name)
+	if _, err := client.doSync("GET", path, nil, nil, nil, &security); err != nil {
+		return nil, fmt.Errorf("cannot obtain security profiles of snap %q: %s", name, err)
+	}
+	return security, nil
+}
@@ -0,0 +1,80 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client_test
+
+import (
+	"gopkg.in/check.v1"
+
+	"github.com/ubuntu-core/snappy/client"
+)
+
+func (cs *clientSuite) TestClientSecurityCallsEndpoint(c *check.C) {
+	_, _ = cs.cli.Security("foo")
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/snaps/foo/security")
+}
+
+func (cs *clientSuite) TestClientSecurity(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"result": {
+			"bar": {
+				"apparmor": {
+					"profile": "profile \"snap.foo.bar\" {}",
+					"snippets": [
+						{
+							"interface": "network",
+							"plug": {"snap": "foo", "plug": "network"},
+							"slot": {"snap": "ubuntu-core", "slot": "network"},
+							"snippet": "network inet,"
+						}
+					]
+				},
+				"seccomp": {
+					"profile": "open\n"
+				}
+			}
+		}
+	}`
+	security, err := cs.cli.Security("foo")
+	c.Assert(err, check.IsNil)
+	c.Check(security, check.DeepEquals, map[string]map[string]*client.SecurityProfile{
+		"bar": {
+			"apparmor": {
+				Profile: `profile "snap.foo.bar" {}`,
+				Snippets: []client.SnippetSource{{
+					Interface: "network",
+					Plug:      &client.PlugRef{Snap: "foo", Name: "network"},
+					Slot:      &client.SlotRef{Snap: "ubuntu-core", Name: "network"},
+					Snippet:   "network inet,",
+				}},
+			},
+			"seccomp": {
+				Profile: "open\n",
+			},
+		},
+	})
+}
+
+func (cs *clientSuite) TestClientSecurityError(c *check.C) {
+	cs.rsp = `{"type": "error", "status-code": 404, "result": {"message": "cannot find snap \"foo\""}}`
+	_, err := cs.cli.Security("foo")
+	c.Assert(err, check.ErrorMatches, `cannot obtain security profiles of snap "foo": cannot find snap "foo"`)
+}
@@ -27,7 +27,7 @@ import (
 
 type cmdAbort struct {
 	Positional struct {
-		Id string `positional-arg-name:"change-id"`
+		Id changeID `positional-arg-name:"change-id"`
 	} `positional-args:"yes" required:"yes"`
 }
 
@@ -48,6 +48,12 @@ func init() {
 
 func (x *cmdAbort) Execute(args []string) error {
 	cli := Client()
-	_, err := cli.Abort(x.Positional.Id)
-	return err
+	chg, err := cli.Abort(string(x.Positional.Id))
+	if err != nil {
+		return err
+	}
+	if structuredOutput() {
+		return printStructured(chg)
+	}
+	return nil
 }
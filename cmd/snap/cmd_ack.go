@@ -43,6 +43,10 @@ will replace.
 To succeed the assertion must be valid, its signature verified with a known
 public key and the assertion consistent with and its prerequisite in the
 database.
+
+The file may contain several assertions separated by empty lines, in any
+order. Missing prerequisites, like the account-key that signed an assertion,
+are taken from the file or fetched from the store.
 `)
 
 func init() {
@@ -52,6 +56,10 @@ func init() {
 }
 
 func (x *cmdAck) Execute(args []string) error {
+	if err := rejectStructuredOutput("ack"); err != nil {
+		return err
+	}
+
 	assertFile := x.AckOptions.AssertionFile
 
 	assertData, err := ioutil.ReadFile(assertFile)
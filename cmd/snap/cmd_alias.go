@@ -0,0 +1,135 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ubuntu-core/snappy/client"
+	"github.com/ubuntu-core/snappy/i18n"
+	"github.com/ubuntu-core/snappy/snap"
+
+	"github.com/jessevdk/go-flags"
+)
+
+var (
+	shortAliasHelp   = i18n.G("Enable aliases of the apps of a snap")
+	shortUnaliasHelp = i18n.G("Disable aliases of the apps of a snap")
+	shortAliasesHelp = i18n.G("List the aliases of the apps of snaps")
+)
+
+var longAliasHelp = i18n.G(`
+The alias command enables the given aliases, as declared by the apps of the
+snap, so that the apps can be run by their aliases, without the snap name
+prefix. An alias can only be enabled for one snap at a time.`)
+
+var longUnaliasHelp = i18n.G(`
+The unalias command disables the given aliases of the apps of the snap.`)
+
+var longAliasesHelp = i18n.G(`
+The aliases command lists the aliases declared by the apps of the given snap,
+or of all the active snaps, with whether they are enabled.`)
+
+type cmdAlias struct {
+	Positional struct {
+		Snap    installedSnapName `positional-arg-name:"<snap>"`
+		Aliases []string          `positional-arg-name:"<alias>" required:"1"`
+	} `positional-args:"yes" required:"yes"`
+}
+
+type cmdUnalias struct {
+	Positional struct {
+		Snap    installedSnapName `positional-arg-name:"<snap>"`
+		Aliases []string          `positional-arg-name:"<alias>" required:"1"`
+	} `positional-args:"yes" required:"yes"`
+}
+
+type cmdAliases struct {
+	Positional struct {
+		Snap installedSnapName `positional-arg-name:"<snap>"`
+	} `positional-args:"yes"`
+}
+
+func init() {
+	addCommand("alias", shortAliasHelp, longAliasHelp, func() flags.Commander { return &cmdAlias{} })
+	addCommand("unalias", shortUnaliasHelp, longUnaliasHelp, func() flags.Commander { return &cmdUnalias{} })
+	addCommand("aliases", shortAliasesHelp, longAliasesHelp, func() flags.Commander { return &cmdAliases{} })
+}
+
+func (x *cmdAlias) Execute([]string) error {
+	cli := Client()
+	changeID, err := cli.Alias(string(x.Positional.Snap), x.Positional.Aliases)
+	return waitChange(cli, changeID, err)
+}
+
+func (x *cmdUnalias) Execute([]string) error {
+	cli := Client()
+	changeID, err := cli.Unalias(string(x.Positional.Snap), x.Positional.Aliases)
+	return waitChange(cli, changeID, err)
+}
+
+func (x *cmdAliases) Execute([]string) error {
+	allAliases, err := Client().Aliases()
+	if err != nil {
+		return err
+	}
+
+	only := string(x.Positional.Snap)
+	var snapNames []string
+	for snapName := range allAliases {
+		if only == "" || only == snapName {
+			snapNames = append(snapNames, snapName)
+		}
+	}
+
+	if structuredOutput() {
+		shown := make(map[string]map[string]client.AliasStatus, len(snapNames))
+		for _, snapName := range snapNames {
+			shown[snapName] = allAliases[snapName]
+		}
+		return printStructured(shown)
+	}
+	if len(snapNames) == 0 {
+		if only != "" {
+			return fmt.Errorf(i18n.G("no aliases found for snap %q"), only)
+		}
+		return fmt.Errorf(i18n.G("no aliases found"))
+	}
+	sort.Strings(snapNames)
+
+	w := tabWriter()
+	defer w.Flush()
+
+	fmt.Fprintln(w, i18n.G("Command\tAlias\tStatus"))
+	for _, snapName := range snapNames {
+		aliases := allAliases[snapName]
+		names := make([]string, 0, len(aliases))
+		for alias := range aliases {
+			names = append(names, alias)
+		}
+		sort.Strings(names)
+		for _, alias := range names {
+			status := aliases[alias]
+			fmt.Fprintf(w, "%s\t%s\t%s\n", snap.JoinSnapApp(snapName, status.App), alias, status.Status)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,130 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package main_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	. "gopkg.in/check.v1"
+
+	snap "github.com/ubuntu-core/snappy/cmd/snap"
+)
+
+func (s *SnapSuite) testAliasOp(c *C, action string) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch n {
+		case 0:
+			c.Check(r.Method, Equals, "POST")
+			c.Check(r.URL.Path, Equals, "/v2/aliases")
+			var body map[string]interface{}
+			c.Assert(json.NewDecoder(r.Body).Decode(&body), IsNil)
+			c.Check(body, DeepEquals, map[string]interface{}{
+				"action":  action,
+				"snap":    "tool",
+				"aliases": []interface{}{"convert", "resize"},
+			})
+			fmt.Fprintln(w, `{"type": "async", "change": "42", "status-code": 202}`)
+		case 1:
+			c.Check(r.Method, Equals, "GET")
+			c.Check(r.URL.Path, Equals, "/v2/changes/42")
+			fmt.Fprintln(w, `{"type": "sync", "result": {"ready": true, "status": "Done"}}`)
+		default:
+			c.Fatalf("expected to get 2 requests, now on %d", n+1)
+		}
+		n++
+	})
+	rest, err := snap.Parser().ParseArgs([]string{action, "tool", "convert", "resize"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+	c.Check(s.Stdout(), Matches, `(?sm).*^Done$`)
+	c.Check(n, Equals, 2)
+}
+
+func (s *SnapSuite) TestAlias(c *C) {
+	s.testAliasOp(c, "alias")
+}
+
+func (s *SnapSuite) TestUnalias(c *C) {
+	s.testAliasOp(c, "unalias")
+}
+
+func (s *SnapSuite) TestAliasNeedsAliases(c *C) {
+	_, err := snap.Parser().ParseArgs([]string{"alias", "tool"})
+	c.Assert(err, ErrorMatches, "the required argument .* was not provided")
+}
+
+const aliasesResult = `{"type": "sync", "result": {
+	"tool": {
+		"convert": {"app": "convert", "status": "enabled"},
+		"tool-convert": {"app": "convert", "status": "disabled"}
+	},
+	"editor": {
+		"edit": {"app": "editor", "status": "disabled"}
+	}
+}}`
+
+func (s *SnapSuite) TestAliases(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, Equals, "GET")
+		c.Check(r.URL.Path, Equals, "/v2/aliases")
+		fmt.Fprintln(w, aliasesResult)
+	})
+	rest, err := snap.Parser().ParseArgs([]string{"aliases"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+	c.Check(s.Stdout(), Equals, ""+
+		"Command       Alias         Status\n"+
+		"editor        edit          disabled\n"+
+		"tool.convert  convert       enabled\n"+
+		"tool.convert  tool-convert  disabled\n")
+}
+
+func (s *SnapSuite) TestAliasesOfSnap(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, aliasesResult)
+	})
+	_, err := snap.Parser().ParseArgs([]string{"aliases", "editor"})
+	c.Assert(err, IsNil)
+	c.Check(s.Stdout(), Equals, ""+
+		"Command  Alias  Status\n"+
+		"editor   edit   disabled\n")
+
+	_, err = snap.Parser().ParseArgs([]string{"aliases", "other"})
+	c.Assert(err, ErrorMatches, `no aliases found for snap "other"`)
+}
+
+func (s *SnapSuite) TestAliasesJSON(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, aliasesResult)
+	})
+	_, err := snap.Parser().ParseArgs([]string{"--format=json", "aliases", "editor"})
+	c.Assert(err, IsNil)
+	c.Check(s.Stdout(), Equals, `{
+  "editor": {
+    "edit": {
+      "app": "editor",
+      "status": "disabled"
+    }
+  }
+}
+`)
+}
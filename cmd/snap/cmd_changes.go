@@ -36,7 +36,7 @@ The changes command displays a summary of the recent system changes performed.`)
 
 type cmdChanges struct {
 	Positional struct {
-		Id string `positional-arg-name:"<id>"`
+		Id changeID `positional-arg-name:"<id>"`
 	} `positional-args:"yes"`
 }
 
@@ -53,7 +53,7 @@ func (s changesByTime) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
 func (c *cmdChanges) Execute([]string) error {
 
 	if c.Positional.Id != "" {
-		return c.showChange(c.Positional.Id)
+		return c.showChange(string(c.Positional.Id))
 	}
 
 	cli := Client()
@@ -62,12 +62,16 @@ func (c *cmdChanges) Execute([]string) error {
 		return err
 	}
 
+	sort.Sort(changesByTime(changes))
+
+	if structuredOutput() {
+		return printStructured(changes)
+	}
+
 	if len(changes) == 0 {
 		return fmt.Errorf(i18n.G("no changes found"))
 	}
 
-	sort.Sort(changesByTime(changes))
-
 	w := tabWriter()
 
 	fmt.Fprintf(w, i18n.G("ID\tStatus\tSpawn\tReady\tSummary\n"))
@@ -93,6 +97,10 @@ func (c *cmdChanges) showChange(id string) error {
 		return err
 	}
 
+	if structuredOutput() {
+		return printStructured(chg)
+	}
+
 	w := tabWriter()
 
 	fmt.Fprintf(w, i18n.G("Status\tSpawn\tReady\tSummary\n"))
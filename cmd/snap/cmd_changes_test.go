@@ -0,0 +1,129 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/ubuntu-core/snappy/client"
+	snap "github.com/ubuntu-core/snappy/cmd/snap"
+)
+
+const changesResult = `{"type": "sync", "result": [
+	{"id": "2", "kind": "remove-snap", "summary": "Remove foo", "status": "Doing", "spawn-time": "2016-04-21T01:02:04Z"},
+	{"id": "1", "kind": "install-snap", "summary": "Install foo", "status": "Done", "ready": true, "spawn-time": "2016-04-21T01:02:03Z", "ready-time": "2016-04-21T01:02:05Z"}
+]}`
+
+func (s *SnapSuite) TestChanges(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, Equals, "GET")
+		c.Check(r.URL.Path, Equals, "/v2/changes")
+		fmt.Fprintln(w, changesResult)
+	})
+	_, err := snap.Parser().ParseArgs([]string{"changes"})
+	c.Assert(err, IsNil)
+	c.Check(s.Stdout(), Equals, ""+
+		"ID   Status  Spawn                 Ready                 Summary\n"+
+		"1    Done    2016-04-21T01:02:03Z  2016-04-21T01:02:05Z  Install foo\n"+
+		"2    Doing   2016-04-21T01:02:04Z  -                     Remove foo\n"+
+		"\n")
+}
+
+func (s *SnapSuite) TestChangesJSON(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, changesResult)
+	})
+	_, err := snap.Parser().ParseArgs([]string{"--format=json", "changes"})
+	c.Assert(err, IsNil)
+
+	var changes []*client.Change
+	c.Assert(json.Unmarshal([]byte(s.Stdout()), &changes), IsNil)
+	c.Assert(changes, HasLen, 2)
+	c.Check(changes[0].ID, Equals, "1")
+	c.Check(changes[0].Kind, Equals, "install-snap")
+	c.Check(changes[0].Ready, Equals, true)
+	c.Check(changes[0].ReadyTime.Equal(time.Date(2016, 4, 21, 1, 2, 5, 0, time.UTC)), Equals, true)
+	c.Check(changes[1].ID, Equals, "2")
+	c.Check(changes[1].Status, Equals, "Doing")
+}
+
+func (s *SnapSuite) TestChangeYAML(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.URL.Path, Equals, "/v2/changes/1")
+		fmt.Fprintln(w, `{"type": "sync", "result": {
+			"id": "1", "kind": "install-snap", "summary": "Install foo", "status": "Done", "ready": true,
+			"spawn-time": "2016-04-21T01:02:03Z", "ready-time": "2016-04-21T01:02:05Z",
+			"tasks": [{"id": "11", "kind": "download-snap", "summary": "Download foo", "status": "Done",
+				"log": ["downloaded"], "progress": {"done": 1, "total": 1},
+				"spawn-time": "2016-04-21T01:02:03Z", "ready-time": "2016-04-21T01:02:04Z"}]
+		}}`)
+	})
+	_, err := snap.Parser().ParseArgs([]string{"--format=yaml", "changes", "1"})
+	c.Assert(err, IsNil)
+	c.Check(s.Stdout(), Equals, `id: "1"
+kind: install-snap
+ready: true
+ready-time: "2016-04-21T01:02:05Z"
+spawn-time: "2016-04-21T01:02:03Z"
+status: Done
+summary: Install foo
+tasks:
+- id: "11"
+  kind: download-snap
+  log:
+  - downloaded
+  progress:
+    done: 1
+    total: 1
+  ready-time: "2016-04-21T01:02:04Z"
+  spawn-time: "2016-04-21T01:02:03Z"
+  status: Done
+  summary: Download foo
+`)
+}
+
+func (s *SnapSuite) TestChangesJSONNoChanges(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"type": "sync", "result": null}`)
+	})
+	_, err := snap.Parser().ParseArgs([]string{"--format=json", "changes"})
+	c.Assert(err, IsNil)
+	c.Check(s.Stdout(), Equals, "[]\n")
+}
+
+func (s *SnapSuite) TestAbortJSON(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, Equals, "POST")
+		c.Check(r.URL.Path, Equals, "/v2/changes/1")
+		fmt.Fprintln(w, `{"type": "sync", "result": {"id": "1", "kind": "install-snap", "summary": "Install foo", "status": "Hold", "ready": true}}`)
+	})
+	_, err := snap.Parser().ParseArgs([]string{"--format=json", "abort", "1"})
+	c.Assert(err, IsNil)
+
+	var chg client.Change
+	c.Assert(json.Unmarshal([]byte(s.Stdout()), &chg), IsNil)
+	c.Check(chg.ID, Equals, "1")
+	c.Check(chg.Status, Equals, "Hold")
+}
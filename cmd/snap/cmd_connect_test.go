@@ -53,8 +53,11 @@ the gadget snap, the kernel snap, and then the os snap, in that order. The
 first of these snaps that has a matching plug name is used and the command
 proceeds as above.
 
+Application Options:
+      --format=[table|json|yaml] Output format (default: table)
+
 Help Options:
-  -h, --help               Show this help message
+  -h, --help                     Show this help message
 `
 	rest, err := Parser().ParseArgs([]string{"connect", "--help"})
 	c.Assert(err.Error(), Equals, msg)
@@ -94,6 +97,31 @@ func (s *SnapSuite) TestConnectExplicitEverything(c *C) {
 	c.Assert(rest, DeepEquals, []string{})
 }
 
+func (s *SnapSuite) TestConnectYAML(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/interfaces":
+			c.Check(r.Method, Equals, "POST")
+			fmt.Fprintln(w, `{"type":"async", "status-code": 202, "change": "zzz"}`)
+		case "/v2/changes/zzz":
+			c.Check(r.Method, Equals, "GET")
+			fmt.Fprintln(w, `{"type":"sync", "result":{"id": "zzz", "kind": "connect-snap", "summary": "Connect producer:plug to consumer:slot", "ready": true, "status": "Done", "spawn-time": "2016-04-21T01:02:03Z", "ready-time": "2016-04-21T01:02:05Z"}}`)
+		default:
+			c.Fatalf("unexpected path %q", r.URL.Path)
+		}
+	})
+	_, err := Parser().ParseArgs([]string{"--format=yaml", "connect", "producer:plug", "consumer:slot"})
+	c.Assert(err, IsNil)
+	c.Check(s.Stdout(), Equals, `id: zzz
+kind: connect-snap
+ready: true
+ready-time: "2016-04-21T01:02:05Z"
+spawn-time: "2016-04-21T01:02:03Z"
+status: Done
+summary: Connect producer:plug to consumer:slot
+`)
+}
+
 func (s *SnapSuite) TestConnectExplicitPlugImplicitSlot(c *C) {
 	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
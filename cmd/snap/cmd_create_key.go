@@ -0,0 +1,72 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/ubuntu-core/snappy/i18n"
+)
+
+type cmdCreateKey struct {
+	Positional struct {
+		KeyName string `positional-arg-name:"<key-name>" description:"name of the key to create; defaults to 'default'"`
+	} `positional-args:"true"`
+}
+
+var shortCreateKeyHelp = i18n.G("Creates a cryptographic key pair")
+var longCreateKeyHelp = i18n.G(`
+The create-key command creates a cryptographic key pair that can be used for
+signing assertions. The key pair is stored under ~/.snap/keys and can be
+referred to by the given name.
+
+Keys cannot be created when SNAPPY_KEYPAIR_HELPER names an external helper
+managing the key pairs instead.
+`)
+
+func init() {
+	addCommand("create-key", shortCreateKeyHelp, longCreateKeyHelp, func() flags.Commander {
+		return &cmdCreateKey{}
+	})
+}
+
+func (x *cmdCreateKey) Execute(args []string) error {
+	keyName := x.Positional.KeyName
+	if keyName == "" {
+		keyName = defaultKeyName
+	}
+
+	kr, err := openKeyring()
+	if err != nil {
+		return err
+	}
+	keyID, err := kr.Create(keyName)
+	if err != nil {
+		return err
+	}
+
+	if structuredOutput() {
+		return printStructured(keyJSON{Name: keyName, ID: keyID})
+	}
+	fmt.Fprintf(Stdout, i18n.G("Created key %q with ID %s\n"), keyName, keyID)
+	return nil
+}
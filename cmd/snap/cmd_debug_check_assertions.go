@@ -0,0 +1,55 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/ubuntu-core/snappy/i18n"
+
+	"github.com/jessevdk/go-flags"
+)
+
+var shortDebugCheckAssertionsHelp = i18n.G("Checks the system assertion database")
+var longDebugCheckAssertionsHelp = i18n.G(`
+The check-assertions command checks the consistency of the system assertion
+database, rebuilding from scratch the indexes kept to speed up searches.
+`)
+
+type cmdDebugCheckAssertions struct{}
+
+func init() {
+	addDebugCommand("check-assertions", shortDebugCheckAssertionsHelp, longDebugCheckAssertionsHelp, func() flags.Commander {
+		return &cmdDebugCheckAssertions{}
+	})
+}
+
+func (x *cmdDebugCheckAssertions) Execute([]string) error {
+	if err := rejectStructuredOutput("debug check-assertions"); err != nil {
+		return err
+	}
+
+	if err := Client().CheckAssertions(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(Stdout, i18n.G("System assertion database is consistent."))
+	return nil
+}
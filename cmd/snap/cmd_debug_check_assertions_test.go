@@ -0,0 +1,49 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"fmt"
+	"net/http"
+
+	. "gopkg.in/check.v1"
+
+	snap "github.com/ubuntu-core/snappy/cmd/snap"
+)
+
+func (s *SnapSuite) TestDebugCheckAssertions(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, Equals, "POST")
+		c.Check(r.URL.Path, Equals, "/v2/debug/check-assertions")
+		fmt.Fprintln(w, `{"type": "sync", "result": true}`)
+	})
+	_, err := snap.Parser().ParseArgs([]string{"debug", "check-assertions"})
+	c.Assert(err, IsNil)
+	c.Check(s.Stdout(), Equals, "System assertion database is consistent.\n")
+}
+
+func (s *SnapSuite) TestDebugCheckAssertionsError(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(500)
+		fmt.Fprintln(w, `{"type": "error", "result": {"message": "cannot check assertions: broken assertion storage"}}`)
+	})
+	_, err := snap.Parser().ParseArgs([]string{"debug", "check-assertions"})
+	c.Assert(err, ErrorMatches, "cannot check assertions: .*broken assertion storage")
+}
@@ -0,0 +1,128 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ubuntu-core/snappy/client"
+	"github.com/ubuntu-core/snappy/i18n"
+
+	"github.com/jessevdk/go-flags"
+)
+
+var shortDebugSecurityHelp = i18n.G("Shows the security profiles generated for a snap")
+var longDebugSecurityHelp = i18n.G(`
+The security command shows, for each app of the given snap and each security
+system, the profile generated from the interfaces affecting the snap.
+
+Each profile is preceded by the snippets it was built from, along with the
+interface and the plug or slot that contributed them.
+
+$ snap debug security <snap>
+
+Shows all the profiles of the given snap.
+
+$ snap debug security --app=<app> --security=<security> <snap>
+
+Shows only the profiles of the given app and security system.
+`)
+
+type cmdDebugSecurity struct {
+	App        string `long:"app" description:"show only the profiles of the given app"`
+	Security   string `long:"security" description:"show only the profiles of the given security system"`
+	Positional struct {
+		Snap string `positional-arg-name:"<snap>"`
+	} `positional-args:"yes" required:"yes"`
+}
+
+func init() {
+	addDebugCommand("security", shortDebugSecurityHelp, longDebugSecurityHelp, func() flags.Commander {
+		return &cmdDebugSecurity{}
+	})
+}
+
+func (x *cmdDebugSecurity) Execute([]string) error {
+	snapName := x.Positional.Snap
+	security, err := Client().Security(snapName)
+	if err != nil {
+		return err
+	}
+
+	apps := make([]string, 0, len(security))
+	for app := range security {
+		if x.App == "" || x.App == app {
+			apps = append(apps, app)
+		}
+	}
+	sort.Strings(apps)
+
+	shown := make(map[string]map[string]*client.SecurityProfile)
+	for _, app := range apps {
+		systems := make([]string, 0, len(security[app]))
+		for system := range security[app] {
+			if x.Security == "" || x.Security == system {
+				systems = append(systems, system)
+			}
+		}
+		sort.Strings(systems)
+		for _, system := range systems {
+			profile := security[app][system]
+			if shown[app] == nil {
+				shown[app] = make(map[string]*client.SecurityProfile)
+			}
+			shown[app][system] = profile
+			if structuredOutput() {
+				continue
+			}
+			fmt.Fprintf(Stdout, i18n.G("==> %s profile of %s.%s\n"), system, snapName, app)
+			for _, source := range profile.Snippets {
+				fmt.Fprintf(Stdout, i18n.G("--> snippet of interface %q from %s\n"), source.Interface, snippetOrigin(&source))
+				fmt.Fprintln(Stdout, strings.TrimSpace(source.Snippet))
+			}
+			fmt.Fprintln(Stdout, i18n.G("--> profile"))
+			fmt.Fprintln(Stdout, strings.TrimSpace(profile.Profile))
+		}
+	}
+	if len(shown) == 0 {
+		return fmt.Errorf(i18n.G("no matching security profiles found for snap %q"), snapName)
+	}
+	if structuredOutput() {
+		return printStructured(shown)
+	}
+
+	return nil
+}
+
+// snippetOrigin describes the plug and slot that contributed a snippet.
+func snippetOrigin(source *client.SnippetSource) string {
+	switch {
+	case source.Plug != nil && source.Slot != nil:
+		return fmt.Sprintf(i18n.G("connection of plug %s:%s to slot %s:%s"),
+			source.Plug.Snap, source.Plug.Name, source.Slot.Snap, source.Slot.Name)
+	case source.Plug != nil:
+		return fmt.Sprintf(i18n.G("plug %s:%s"), source.Plug.Snap, source.Plug.Name)
+	case source.Slot != nil:
+		return fmt.Sprintf(i18n.G("slot %s:%s"), source.Slot.Snap, source.Slot.Name)
+	}
+	return "-"
+}
@@ -0,0 +1,136 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/ubuntu-core/snappy/client"
+	snap "github.com/ubuntu-core/snappy/cmd/snap"
+)
+
+const securityResponse = `{"type": "sync", "result": {
+	"bar": {
+		"apparmor": {
+			"profile": "profile \"snap.foo.bar\" {\n  network inet,\n}\n",
+			"snippets": [
+				{"interface": "network", "plug": {"snap": "foo", "plug": "network"}, "slot": {"snap": "ubuntu-core", "slot": "network"}, "snippet": "\nnetwork inet,\n"}
+			]
+		},
+		"seccomp": {"profile": "open\n"}
+	},
+	"baz": {
+		"seccomp": {
+			"profile": "open\nbind\n",
+			"snippets": [
+				{"interface": "network-bind", "plug": {"snap": "foo", "plug": "network-bind"}, "snippet": "bind"}
+			]
+		}
+	}
+}}`
+
+func (s *SnapSuite) TestDebugSecurity(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, Equals, "GET")
+		c.Check(r.URL.Path, Equals, "/v2/snaps/foo/security")
+		fmt.Fprintln(w, securityResponse)
+	})
+	rest, err := snap.Parser().ParseArgs([]string{"debug", "security", "foo"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+	c.Check(s.Stdout(), Equals, ""+
+		"==> apparmor profile of foo.bar\n"+
+		"--> snippet of interface \"network\" from connection of plug foo:network to slot ubuntu-core:network\n"+
+		"network inet,\n"+
+		"--> profile\n"+
+		"profile \"snap.foo.bar\" {\n"+
+		"  network inet,\n"+
+		"}\n"+
+		"==> seccomp profile of foo.bar\n"+
+		"--> profile\n"+
+		"open\n"+
+		"==> seccomp profile of foo.baz\n"+
+		"--> snippet of interface \"network-bind\" from plug foo:network-bind\n"+
+		"bind\n"+
+		"--> profile\n"+
+		"open\n"+
+		"bind\n")
+	c.Check(s.Stderr(), Equals, "")
+}
+
+func (s *SnapSuite) TestDebugSecurityFiltered(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, securityResponse)
+	})
+	_, err := snap.Parser().ParseArgs([]string{"debug", "security", "--app=bar", "--security=seccomp", "foo"})
+	c.Assert(err, IsNil)
+	c.Check(s.Stdout(), Equals, ""+
+		"==> seccomp profile of foo.bar\n"+
+		"--> profile\n"+
+		"open\n")
+}
+
+func (s *SnapSuite) TestDebugSecurityJSON(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, securityResponse)
+	})
+	_, err := snap.Parser().ParseArgs([]string{"--format=json", "debug", "security", "--app=baz", "foo"})
+	c.Assert(err, IsNil)
+
+	var security map[string]map[string]*client.SecurityProfile
+	c.Assert(json.Unmarshal([]byte(s.Stdout()), &security), IsNil)
+	c.Check(security, DeepEquals, map[string]map[string]*client.SecurityProfile{
+		"baz": {
+			"seccomp": {
+				Profile: "open\nbind\n",
+				Snippets: []client.SnippetSource{{
+					Interface: "network-bind",
+					Plug:      &client.PlugRef{Snap: "foo", Name: "network-bind"},
+					Snippet:   "bind",
+				}},
+			},
+		},
+	})
+}
+
+func (s *SnapSuite) TestDebugSecurityYAML(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, securityResponse)
+	})
+	_, err := snap.Parser().ParseArgs([]string{"--format=yaml", "debug", "security", "--app=bar", "--security=seccomp", "foo"})
+	c.Assert(err, IsNil)
+	c.Check(s.Stdout(), Equals, ""+
+		"bar:\n"+
+		"  seccomp:\n"+
+		"    profile: |\n"+
+		"      open\n")
+}
+
+func (s *SnapSuite) TestDebugSecurityNoMatches(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, securityResponse)
+	})
+	_, err := snap.Parser().ParseArgs([]string{"debug", "security", "--app=qux", "foo"})
+	c.Assert(err, ErrorMatches, `no matching security profiles found for snap "foo"`)
+}
@@ -0,0 +1,81 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ubuntu-core/snappy/i18n"
+
+	"github.com/jessevdk/go-flags"
+)
+
+var shortDenialsHelp = i18n.G("List operations denied to a snap")
+var longDenialsHelp = i18n.G(`
+The denials command lists the operations that the confinement denied to the
+given snap or, for snaps installed in developer mode, would have denied.
+
+Each denial is shown along with the interfaces that would grant the operation
+once connected, if any.`)
+
+type cmdDenials struct {
+	Positional struct {
+		Snap string `positional-arg-name:"<snap>"`
+	} `positional-args:"yes" required:"yes"`
+}
+
+func init() {
+	addCommand("denials", shortDenialsHelp, longDenialsHelp, func() flags.Commander { return &cmdDenials{} })
+}
+
+func (x *cmdDenials) Execute([]string) error {
+	cli := Client()
+	denials, err := cli.Denials(x.Positional.Snap)
+	if err != nil {
+		return err
+	}
+
+	if structuredOutput() {
+		return printStructured(denials)
+	}
+
+	if len(denials) == 0 {
+		return fmt.Errorf(i18n.G("no denials recorded for snap %q"), x.Positional.Snap)
+	}
+
+	w := tabWriter()
+	defer w.Flush()
+
+	fmt.Fprintln(w, i18n.G("App\tSecurity\tOperation\tTarget\tMask\tCount\tInterfaces"))
+	for _, d := range denials {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%s\n", d.App, d.Security, d.Operation,
+			orDash(d.Target), orDash(d.Mask), d.Count, orDash(strings.Join(d.Interfaces, ",")))
+	}
+
+	return nil
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
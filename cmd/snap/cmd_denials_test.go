@@ -0,0 +1,76 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/ubuntu-core/snappy/client"
+	snap "github.com/ubuntu-core/snappy/cmd/snap"
+)
+
+func (s *SnapSuite) TestDenials(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, Equals, "GET")
+		c.Check(r.URL.Path, Equals, "/v2/snaps/foo/denials")
+		fmt.Fprintln(w, `{"type": "sync", "result": [
+			{"snap": "foo", "app": "bar", "security": "apparmor", "operation": "open", "target": "/var/log/syslog", "mask": "r", "count": 3, "interfaces": ["log-observe"]},
+			{"snap": "foo", "app": "bar", "security": "seccomp", "operation": "syscall", "target": "ptrace", "count": 1}
+		]}`)
+	})
+	rest, err := snap.Parser().ParseArgs([]string{"denials", "foo"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+	c.Check(s.Stdout(), Equals, ""+
+		"App  Security  Operation  Target           Mask  Count  Interfaces\n"+
+		"bar  apparmor  open       /var/log/syslog  r     3      log-observe\n"+
+		"bar  seccomp   syscall    ptrace           -     1      -\n")
+	c.Check(s.Stderr(), Equals, "")
+}
+
+func (s *SnapSuite) TestDenialsNone(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"type": "sync", "result": []}`)
+	})
+	_, err := snap.Parser().ParseArgs([]string{"denials", "foo"})
+	c.Assert(err, ErrorMatches, `no denials recorded for snap "foo"`)
+}
+
+func (s *SnapSuite) TestDenialsJSON(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"type": "sync", "result": [
+			{"snap": "foo", "app": "bar", "security": "apparmor", "operation": "open", "target": "/var/log/syslog", "mask": "r", "count": 3, "interfaces": ["log-observe"]}
+		]}`)
+	})
+	_, err := snap.Parser().ParseArgs([]string{"--format=json", "denials", "foo"})
+	c.Assert(err, IsNil)
+
+	var denials []*client.Denial
+	c.Assert(json.Unmarshal([]byte(s.Stdout()), &denials), IsNil)
+	c.Assert(denials, HasLen, 1)
+	c.Check(denials[0].App, Equals, "bar")
+	c.Check(denials[0].Target, Equals, "/var/log/syslog")
+	c.Check(denials[0].Count, Equals, 3)
+	c.Check(denials[0].Interfaces, DeepEquals, []string{"log-observe"})
+}
@@ -70,6 +70,12 @@ func (x *cmdDisconnect) Execute(args []string) error {
 		return err
 	}
 
-	_, err = wait(cli, id)
-	return err
+	chg, err := wait(cli, id)
+	if err != nil {
+		return err
+	}
+	if structuredOutput() {
+		return printStructured(chg)
+	}
+	return nil
 }
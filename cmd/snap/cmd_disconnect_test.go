@@ -48,8 +48,11 @@ $ snap disconnect <snap>
 
 Disconnects all plugs from the provided snap.
 
+Application Options:
+      --format=[table|json|yaml] Output format (default: table)
+
 Help Options:
-  -h, --help               Show this help message
+  -h, --help                     Show this help message
 `
 	rest, err := Parser().ParseArgs([]string{"disconnect", "--help"})
 	c.Assert(err.Error(), Equals, msg)
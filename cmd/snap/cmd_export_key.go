@@ -0,0 +1,103 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/ubuntu-core/snappy/asserts"
+	"github.com/ubuntu-core/snappy/i18n"
+)
+
+type cmdExportKey struct {
+	Account    string `long:"account" description:"format public key material as a request for an account-key for this account-id"`
+	Positional struct {
+		KeyName string `positional-arg-name:"<key-name>" description:"name of the key to export; defaults to 'default'"`
+	} `positional-args:"true"`
+}
+
+var shortExportKeyHelp = i18n.G("Exports a cryptographic public key")
+var longExportKeyHelp = i18n.G(`
+The export-key command exports a public key assertion body that may be
+imported by other systems.
+
+With --account it produces instead an account-key-request assertion, signed
+by the key itself, that can be submitted to the store to obtain an
+account-key assertion for the given account.
+`)
+
+func init() {
+	addCommand("export-key", shortExportKeyHelp, longExportKeyHelp, func() flags.Commander {
+		return &cmdExportKey{}
+	})
+}
+
+func (x *cmdExportKey) Execute(args []string) error {
+	keyName := x.Positional.KeyName
+	if keyName == "" {
+		keyName = defaultKeyName
+	}
+
+	kr, err := openKeyring()
+	if err != nil {
+		return err
+	}
+	privKey, err := kr.Key(keyName)
+	if err != nil {
+		return err
+	}
+	pubKey := privKey.PublicKey()
+	encodedPubKey, err := asserts.EncodePublicKey(pubKey)
+	if err != nil {
+		return err
+	}
+
+	if x.Account == "" {
+		fmt.Fprintf(Stdout, "%s\n", encodedPubKey)
+		return nil
+	}
+
+	// the request is self-signed, with the key filed under the account
+	db, err := asserts.OpenDatabase(&asserts.DatabaseConfig{
+		KeypairManager: asserts.NewMemoryKeypairManager(),
+	})
+	if err != nil {
+		return err
+	}
+	if err := db.ImportKey(x.Account, privKey); err != nil {
+		return err
+	}
+	headers := map[string]interface{}{
+		"authority-id":           x.Account,
+		"account-id":             x.Account,
+		"public-key-id":          pubKey.ID(),
+		"public-key-fingerprint": pubKey.Fingerprint(),
+		"since":                  time.Now().UTC().Format(time.RFC3339),
+	}
+	a, err := db.Sign(asserts.AccountKeyRequestType, headers, encodedPubKey, pubKey.ID())
+	if err != nil {
+		return fmt.Errorf(i18n.G("cannot create account-key-request: %v"), err)
+	}
+
+	return asserts.NewEncoder(Stdout).Encode(a)
+}
@@ -114,6 +114,12 @@ func (x *cmdFind) Execute([]string) error {
 		return err
 	}
 
+	sort.Sort(snapsByName(snaps))
+
+	if structuredOutput() {
+		return printStructured(snaps)
+	}
+
 	if len(snaps) == 0 {
 		if filter.Query == "" {
 			return fmt.Errorf("no snaps found")
@@ -122,8 +128,6 @@ func (x *cmdFind) Execute([]string) error {
 		return fmt.Errorf("no snaps found for %q", filter.Query)
 	}
 
-	sort.Sort(snapsByName(snaps))
-
 	w := tabWriter()
 	defer w.Flush()
 
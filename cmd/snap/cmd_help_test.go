@@ -47,6 +47,9 @@ func (s *SnapSuite) TestHelpPrintsHelp(c *check.C) {
 		c.Check(stdout.String(), check.Matches, `(?smU)Usage:
  +snap \[OPTIONS\] <command>
 
+Application Options:
+ +--format=\[table\|json\|yaml\] +Output format \(default: table\)
+
 Help Options:
  +-h, --help +Show this help message
 
@@ -0,0 +1,273 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/ubuntu-core/snappy/client"
+	"github.com/ubuntu-core/snappy/i18n"
+	"github.com/ubuntu-core/snappy/osutil"
+	"github.com/ubuntu-core/snappy/snap"
+)
+
+var shortInfoHelp = i18n.G("Show detailed information about a snap")
+var longInfoHelp = i18n.G(`
+The info command shows detailed information about the given snaps, be
+they installed, in the store, or snap files on disk.
+
+For snaps in the store the version and revision published in each of
+their channels is shown.
+`)
+
+type cmdSnapInfo struct {
+	Positional struct {
+		Snaps []remoteSnapName `positional-arg-name:"<snap>" required:"1"`
+	} `positional-args:"yes" required:"yes"`
+}
+
+func init() {
+	addCommand("info", shortInfoHelp, longInfoHelp, func() flags.Commander { return &cmdSnapInfo{} })
+}
+
+// snapDetails is what info shows about a snap.
+type snapDetails struct {
+	*client.Snap
+	Plugs []string `json:"plugs,omitempty"`
+	Slots []string `json:"slots,omitempty"`
+}
+
+func (x *cmdSnapInfo) Execute([]string) error {
+	all := make([]*snapDetails, len(x.Positional.Snaps))
+	for i, name := range x.Positional.Snaps {
+		var err error
+		if isSnapPath(string(name)) {
+			all[i], err = snapFileDetails(string(name))
+		} else {
+			all[i], err = storeOrInstalledDetails(string(name))
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if structuredOutput() {
+		return printStructured(all)
+	}
+
+	for i, details := range all {
+		if i > 0 {
+			fmt.Fprintln(Stdout, "---")
+		}
+		printSnapDetails(details)
+	}
+
+	return nil
+}
+
+// isSnapPath tells whether the argument refers to a snap file rather
+// than to a snap by name.
+func isSnapPath(name string) bool {
+	return (strings.Contains(name, "/") || strings.HasSuffix(name, ".snap")) && osutil.FileExists(name)
+}
+
+func snapFileDetails(path string) (*snapDetails, error) {
+	snapf, err := snap.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := snap.ReadInfoFromSnapFile(snapf, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	details := &snapDetails{Snap: &client.Snap{
+		Name:        info.Name(),
+		Summary:     info.Summary(),
+		Description: info.Description(),
+		Type:        string(info.Type),
+		Version:     info.Version,
+	}}
+	for _, app := range info.Apps {
+		details.Apps = append(details.Apps, client.AppInfo{Snap: info.Name(), Name: app.Name, Daemon: app.Daemon})
+	}
+	for _, plug := range info.Plugs {
+		details.Plugs = append(details.Plugs, plugOrSlotString(plug.Name, plug.Interface))
+	}
+	for _, slot := range info.Slots {
+		details.Slots = append(details.Slots, plugOrSlotString(slot.Name, slot.Interface))
+	}
+	sort.Strings(details.Plugs)
+	sort.Strings(details.Slots)
+
+	return details, nil
+}
+
+func storeOrInstalledDetails(name string) (*snapDetails, error) {
+	cli := Client()
+	found, _, err := cli.Snap(name)
+	if err != nil {
+		return nil, err
+	}
+
+	details := &snapDetails{Snap: found}
+	if found.Status != client.StatusInstalled && found.Status != client.StatusActive {
+		return details, nil
+	}
+
+	ifaces, err := cli.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	for _, plug := range ifaces.Plugs {
+		if plug.Snap == name {
+			details.Plugs = append(details.Plugs, plugOrSlotString(plug.Name, plug.Interface))
+		}
+	}
+	for _, slot := range ifaces.Slots {
+		if slot.Snap == name {
+			details.Slots = append(details.Slots, plugOrSlotString(slot.Name, slot.Interface))
+		}
+	}
+
+	return details, nil
+}
+
+func plugOrSlotString(name, iface string) string {
+	if name == iface {
+		return name
+	}
+	return fmt.Sprintf("%s (%s)", name, iface)
+}
+
+// channelRisks are the channels of a track shown first, in this order.
+var channelRisks = []string{"stable", "candidate", "beta", "edge"}
+
+// splitChannel splits the name of a channel, "<track>/<risk>" or just
+// "<risk>" for the default track, into its track and risk.
+func splitChannel(name string) (track, risk string) {
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return "", name
+}
+
+func riskRank(risk string) int {
+	for i, known := range channelRisks {
+		if risk == known {
+			return i
+		}
+	}
+	return len(channelRisks)
+}
+
+type byTrackAndRisk []string
+
+func (cs byTrackAndRisk) Len() int      { return len(cs) }
+func (cs byTrackAndRisk) Swap(i, j int) { cs[i], cs[j] = cs[j], cs[i] }
+func (cs byTrackAndRisk) Less(i, j int) bool {
+	ti, ri := splitChannel(cs[i])
+	tj, rj := splitChannel(cs[j])
+	if ti != tj {
+		return ti < tj
+	}
+	if rankI, rankJ := riskRank(ri), riskRank(rj); rankI != rankJ {
+		return rankI < rankJ
+	}
+	return ri < rj
+}
+
+// sortedChannels returns the names of the channels, those of the
+// default track first, and within each track the well-known ones from
+// the most to the least stable and then the others.
+func sortedChannels(channels map[string]*client.ChannelSnapInfo) []string {
+	names := make([]string, 0, len(channels))
+	for name := range channels {
+		names = append(names, name)
+	}
+	sort.Sort(byTrackAndRisk(names))
+
+	return names
+}
+
+func printSnapDetails(details *snapDetails) {
+	w := tabWriter()
+	defer w.Flush()
+
+	fmt.Fprintf(w, "name:\t%s\n", details.Name)
+	fmt.Fprintf(w, "summary:\t%s\n", details.Summary)
+	if details.Developer != "" {
+		fmt.Fprintf(w, "publisher:\t%s\n", details.Developer)
+	}
+	if details.License != "" {
+		fmt.Fprintf(w, "license:\t%s\n", details.License)
+	}
+	if details.Description != "" {
+		fmt.Fprintln(w, "description: |")
+		for _, line := range strings.Split(strings.TrimSpace(details.Description), "\n") {
+			fmt.Fprintf(w, "  %s\n", line)
+		}
+	}
+
+	var commands, services []string
+	for _, app := range details.Apps {
+		snapApp := snap.JoinSnapApp(details.Name, app.Name)
+		if app.IsService() {
+			services = append(services, fmt.Sprintf("%s (%s)", snapApp, app.Daemon))
+		} else {
+			commands = append(commands, snapApp)
+		}
+	}
+	sort.Strings(commands)
+	sort.Strings(services)
+	printList(w, "commands", commands)
+	printList(w, "services", services)
+	printList(w, "plugs", details.Plugs)
+	printList(w, "slots", details.Slots)
+
+	if details.Channel != "" {
+		fmt.Fprintf(w, "tracking:\t%s\n", details.Channel)
+	}
+	if details.Status == client.StatusInstalled || details.Status == client.StatusActive {
+		fmt.Fprintf(w, "installed:\t%s (%d)\n", details.Version, details.Revision)
+	}
+	if len(details.Channels) > 0 {
+		fmt.Fprintln(w, "channels:")
+		for _, name := range sortedChannels(details.Channels) {
+			ch := details.Channels[name]
+			fmt.Fprintf(w, "  %s:\t%s (%d)\n", name, ch.Version, ch.Revision)
+		}
+	}
+}
+
+func printList(w *tabwriter.Writer, title string, items []string) {
+	if len(items) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "%s:\n", title)
+	for _, item := range items {
+		fmt.Fprintf(w, "  - %s\n", item)
+	}
+}
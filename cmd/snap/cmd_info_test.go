@@ -0,0 +1,173 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/ubuntu-core/snappy/client"
+	snap "github.com/ubuntu-core/snappy/cmd/snap"
+)
+
+const remoteInfoJSON = `{"type": "sync", "result": {
+	"name": "hello",
+	"summary": "Hello world",
+	"description": "Says hello.\nAnd nothing else.",
+	"developer": "canonical",
+	"license": "GPL-3.0",
+	"status": "available",
+	"version": "2.10",
+	"revision": 38,
+	"channels": {
+		"edge": {"channel": "edge", "revision": 40, "version": "2.11"},
+		"1.0/stable": {"channel": "1.0/stable", "revision": 8, "version": "1.0.4"},
+		"stable": {"channel": "stable", "revision": 38, "version": "2.10"},
+		"1.0/beta": {"channel": "1.0/beta", "revision": 9, "version": "1.0.5"}
+	}
+}}`
+
+func (s *SnapSuite) TestInfoRemote(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, Equals, "GET")
+		c.Check(r.URL.Path, Equals, "/v2/snaps/hello")
+		fmt.Fprintln(w, remoteInfoJSON)
+	})
+	rest, err := snap.Parser().ParseArgs([]string{"info", "hello"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+	c.Check(s.Stdout(), Equals, `name:       hello
+summary:    Hello world
+publisher:  canonical
+license:    GPL-3.0
+description: |
+  Says hello.
+  And nothing else.
+channels:
+  stable:      2.10 (38)
+  edge:        2.11 (40)
+  1.0/stable:  1.0.4 (8)
+  1.0/beta:    1.0.5 (9)
+`)
+}
+
+func (s *SnapSuite) TestInfoInstalled(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, Equals, "GET")
+		switch r.URL.Path {
+		case "/v2/snaps/tool":
+			fmt.Fprintln(w, `{"type": "sync", "result": {
+				"name": "tool",
+				"summary": "A tool",
+				"developer": "bar",
+				"status": "active",
+				"version": "1.0",
+				"revision": 7,
+				"channel": "beta",
+				"apps": [
+					{"snap": "tool", "name": "tool"},
+					{"snap": "tool", "name": "convert"},
+					{"snap": "tool", "name": "svc", "daemon": "simple"}
+				]
+			}}`)
+		case "/v2/interfaces":
+			fmt.Fprintln(w, `{"type": "sync", "result": {
+				"plugs": [
+					{"snap": "tool", "plug": "network", "interface": "network"},
+					{"snap": "other", "plug": "home", "interface": "home"}
+				],
+				"slots": [
+					{"snap": "tool", "slot": "data", "interface": "content"}
+				]
+			}}`)
+		default:
+			c.Fatalf("unexpected path %q", r.URL.Path)
+		}
+	})
+	_, err := snap.Parser().ParseArgs([]string{"info", "tool"})
+	c.Assert(err, IsNil)
+	c.Check(s.Stdout(), Equals, `name:       tool
+summary:    A tool
+publisher:  bar
+commands:
+  - tool
+  - tool.convert
+services:
+  - tool.svc (simple)
+plugs:
+  - network
+slots:
+  - data (content)
+tracking:   beta
+installed:  1.0 (7)
+`)
+}
+
+func (s *SnapSuite) TestInfoMany(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/snaps/hello":
+			fmt.Fprintln(w, remoteInfoJSON)
+		case "/v2/snaps/other":
+			fmt.Fprintln(w, `{"type": "sync", "result": {"name": "other", "summary": "Other", "status": "available"}}`)
+		default:
+			c.Fatalf("unexpected path %q", r.URL.Path)
+		}
+	})
+	_, err := snap.Parser().ParseArgs([]string{"info", "other", "hello"})
+	c.Assert(err, IsNil)
+	c.Check(s.Stdout(), Matches, `(?s)name: +other
+summary: +Other
+---
+name: +hello
+.*`)
+}
+
+func (s *SnapSuite) TestInfoNotFound(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintln(w, `{"type": "error", "status-code": 404, "result": {"message": "cannot find snap \"nope\""}}`)
+	})
+	_, err := snap.Parser().ParseArgs([]string{"info", "nope"})
+	c.Assert(err, ErrorMatches, `cannot retrieve snap "nope": cannot find snap "nope"`)
+}
+
+func (s *SnapSuite) TestInfoJSON(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, remoteInfoJSON)
+	})
+	_, err := snap.Parser().ParseArgs([]string{"--format=json", "info", "hello"})
+	c.Assert(err, IsNil)
+
+	var infos []*client.Snap
+	c.Assert(json.Unmarshal([]byte(s.Stdout()), &infos), IsNil)
+	c.Assert(infos, HasLen, 1)
+	c.Check(infos[0].Name, Equals, "hello")
+	c.Check(infos[0].License, Equals, "GPL-3.0")
+	c.Check(infos[0].Channels, DeepEquals, map[string]*client.ChannelSnapInfo{
+		"edge":       {Channel: "edge", Revision: 40, Version: "2.11"},
+		"stable":     {Channel: "stable", Revision: 38, Version: "2.10"},
+		"1.0/stable": {Channel: "1.0/stable", Revision: 8, Version: "1.0.4"},
+		"1.0/beta":   {Channel: "1.0/beta", Revision: 9, Version: "1.0.5"},
+	})
+}
@@ -22,6 +22,7 @@ package main
 import (
 	"fmt"
 
+	"github.com/ubuntu-core/snappy/client"
 	"github.com/ubuntu-core/snappy/i18n"
 
 	"github.com/jessevdk/go-flags"
@@ -59,8 +60,33 @@ func init() {
 	})
 }
 
+// matches tells whether the plug or slot is one of those asked for.
+func (x *cmdInterfaces) matches(snapName, name, iface string) bool {
+	if x.Positionals.Query.Snap != "" && x.Positionals.Query.Snap != snapName {
+		return false
+	}
+	if x.Positionals.Query.Name != "" && x.Positionals.Query.Name != name {
+		return false
+	}
+	return x.Interface == "" || x.Interface == iface
+}
+
 func (x *cmdInterfaces) Execute(args []string) error {
 	ifaces, err := Client().Interfaces()
+	if err == nil && structuredOutput() {
+		shown := client.Interfaces{Plugs: []client.Plug{}, Slots: []client.Slot{}}
+		for _, plug := range ifaces.Plugs {
+			if x.matches(plug.Snap, plug.Name, plug.Interface) {
+				shown.Plugs = append(shown.Plugs, plug)
+			}
+		}
+		for _, slot := range ifaces.Slots {
+			if x.matches(slot.Snap, slot.Name, slot.Interface) {
+				shown.Slots = append(shown.Slots, slot)
+			}
+		}
+		return printStructured(shown)
+	}
 	if err == nil {
 		if len(ifaces.Plugs) == 0 && len(ifaces.Slots) == 0 {
 			return fmt.Errorf(i18n.G("no interfaces found"))
@@ -69,13 +95,7 @@ func (x *cmdInterfaces) Execute(args []string) error {
 		fmt.Fprintln(w, i18n.G("Slot\tPlug"))
 		defer w.Flush()
 		for _, slot := range ifaces.Slots {
-			if x.Positionals.Query.Snap != "" && x.Positionals.Query.Snap != slot.Snap {
-				continue
-			}
-			if x.Positionals.Query.Name != "" && x.Positionals.Query.Name != slot.Name {
-				continue
-			}
-			if x.Interface != "" && slot.Interface != x.Interface {
+			if !x.matches(slot.Snap, slot.Name, slot.Interface) {
 				continue
 			}
 			// The OS snap (always ubuntu-core) is special and enable abbreviated
@@ -104,13 +124,7 @@ func (x *cmdInterfaces) Execute(args []string) error {
 		// Plugs are treated differently. Since the loop above already printed each connected
 		// plug, the loop below focuses on printing just the disconnected plugs.
 		for _, plug := range ifaces.Plugs {
-			if x.Positionals.Query.Snap != "" && x.Positionals.Query.Snap != plug.Snap {
-				continue
-			}
-			if x.Positionals.Query.Name != "" && x.Positionals.Query.Name != plug.Name {
-				continue
-			}
-			if x.Interface != "" && plug.Interface != x.Interface {
+			if !x.matches(plug.Snap, plug.Name, plug.Interface) {
 				continue
 			}
 			// Display visual indicator for disconnected plugs.
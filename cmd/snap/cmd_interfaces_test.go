@@ -21,6 +21,7 @@
 package main_test
 
 import (
+	"encoding/json"
 	"io/ioutil"
 	"net/http"
 
@@ -51,6 +52,9 @@ $ snap interfaces -i=<interface> [<snap>]
 Filters the complete output so only plugs and/or slots matching the provided
 details are listed.
 
+Application Options:
+      --format=[table|json|yaml]   Output format (default: table)
+
 Help Options:
   -h, --help                       Show this help message
 
@@ -507,3 +511,30 @@ func (s *SnapSuite) TestInterfacesNothingAtAll(c *C) {
 	c.Assert(s.Stdout(), Equals, "")
 	c.Assert(s.Stderr(), Equals, "")
 }
+
+func (s *SnapSuite) TestInterfacesJSONFiltering(c *C) {
+	ifaces := client.Interfaces{
+		Plugs: []client.Plug{
+			{Snap: "keyboard-lights", Name: "capslock-led", Interface: "bool-file"},
+		},
+		Slots: []client.Slot{
+			{Snap: "canonical-pi2", Name: "debug-console", Interface: "serial-port"},
+			{Snap: "canonical-pi2", Name: "pin-13", Interface: "bool-file"},
+		},
+	}
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		EncodeResponseBody(c, w, map[string]interface{}{
+			"type":   "sync",
+			"result": ifaces,
+		})
+	})
+	_, err := Parser().ParseArgs([]string{"--format=json", "interfaces", "-i=bool-file"})
+	c.Assert(err, IsNil)
+
+	var shown client.Interfaces
+	c.Assert(json.Unmarshal([]byte(s.Stdout()), &shown), IsNil)
+	c.Check(shown, DeepEquals, client.Interfaces{
+		Plugs: ifaces.Plugs,
+		Slots: ifaces.Slots[1:],
+	})
+}
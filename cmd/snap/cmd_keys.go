@@ -0,0 +1,71 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/ubuntu-core/snappy/i18n"
+)
+
+type cmdKeys struct{}
+
+// keyJSON aids in printing keys with --format.
+type keyJSON struct {
+	Name string `json:"name"`
+	ID   string `json:"id"`
+}
+
+var shortKeysHelp = i18n.G("Lists cryptographic keys")
+var longKeysHelp = i18n.G(`
+The keys command lists the cryptographic keys that can be used for signing
+assertions.
+`)
+
+func init() {
+	addCommand("keys", shortKeysHelp, longKeysHelp, func() flags.Commander {
+		return &cmdKeys{}
+	})
+}
+
+func (x *cmdKeys) Execute(args []string) error {
+	kr, err := openKeyring()
+	if err != nil {
+		return err
+	}
+	keys := kr.Keys()
+	if structuredOutput() {
+		return printStructured(keys)
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf(i18n.G("no keys found, see 'snap create-key'"))
+	}
+
+	w := tabWriter()
+	defer w.Flush()
+
+	fmt.Fprintln(w, i18n.G("Name\tID"))
+	for _, key := range keys {
+		fmt.Fprintf(w, "%s\t%s\n", key.Name, key.ID)
+	}
+	return nil
+}
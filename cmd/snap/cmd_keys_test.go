@@ -0,0 +1,399 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/openpgp/packet"
+	. "gopkg.in/check.v1"
+
+	"github.com/ubuntu-core/snappy/asserts"
+	snap "github.com/ubuntu-core/snappy/cmd/snap"
+)
+
+type SnapKeysSuite struct {
+	SnapSuite
+	oldHome string
+}
+
+var _ = Suite(&SnapKeysSuite{})
+
+func (s *SnapKeysSuite) SetUpTest(c *C) {
+	s.SnapSuite.SetUpTest(c)
+	s.oldHome = os.Getenv("HOME")
+	os.Setenv("HOME", c.MkDir())
+}
+
+func (s *SnapKeysSuite) TearDownTest(c *C) {
+	os.Setenv("HOME", s.oldHome)
+	s.SnapSuite.TearDownTest(c)
+}
+
+var createdKeyRx = regexp.MustCompile(`^Created key "(.*)" with ID ([0-9a-f]+)\n$`)
+
+func (s *SnapKeysSuite) createKey(c *C, name string) (keyID string) {
+	s.stdout.Reset()
+	_, err := snap.Parser().ParseArgs([]string{"create-key", name})
+	c.Assert(err, IsNil)
+	m := createdKeyRx.FindStringSubmatch(s.Stdout())
+	c.Assert(m, HasLen, 3)
+	c.Check(m[1], Equals, name)
+	s.stdout.Reset()
+	return m[2]
+}
+
+func (s *SnapKeysSuite) TestCreateKeyAndList(c *C) {
+	_, err := snap.Parser().ParseArgs([]string{"keys"})
+	c.Assert(err, ErrorMatches, `no keys found, see 'snap create-key'`)
+
+	defaultID := s.createKey(c, "default")
+	otherID := s.createKey(c, "another")
+
+	_, err = snap.Parser().ParseArgs([]string{"create-key", "another"})
+	c.Assert(err, ErrorMatches, `key named "another" already exists`)
+
+	_, err = snap.Parser().ParseArgs([]string{"keys"})
+	c.Assert(err, IsNil)
+	c.Check(s.Stdout(), Equals, ""+
+		"Name     ID\n"+
+		"another  "+otherID+"\n"+
+		"default  "+defaultID+"\n")
+
+	s.stdout.Reset()
+	_, err = snap.Parser().ParseArgs([]string{"--format=yaml", "keys"})
+	c.Assert(err, IsNil)
+	c.Check(s.Stdout(), Equals, ""+
+		"- id: "+otherID+"\n"+
+		"  name: another\n"+
+		"- id: "+defaultID+"\n"+
+		"  name: default\n")
+
+	info, err := os.Stat(os.Getenv("HOME") + "/.snap/keys")
+	c.Assert(err, IsNil)
+	c.Check(info.Mode().Perm(), Equals, os.FileMode(0700))
+
+	// the names are kept in an index of their own
+	data, err := ioutil.ReadFile(os.Getenv("HOME") + "/.snap/keys/names.json")
+	c.Assert(err, IsNil)
+	var names map[string]string
+	c.Assert(json.Unmarshal(data, &names), IsNil)
+	c.Check(names, DeepEquals, map[string]string{"another": otherID, "default": defaultID})
+}
+
+func (s *SnapKeysSuite) TestCreateKeyJSON(c *C) {
+	_, err := snap.Parser().ParseArgs([]string{"--format=json", "create-key", "another"})
+	c.Assert(err, IsNil)
+
+	var key map[string]string
+	c.Assert(json.Unmarshal([]byte(s.Stdout()), &key), IsNil)
+	c.Check(key["name"], Equals, "another")
+	c.Check(key["id"], Not(Equals), "")
+
+	s.stdout.Reset()
+	_, err = snap.Parser().ParseArgs([]string{"keys"})
+	c.Assert(err, IsNil)
+	c.Check(s.Stdout(), Equals, ""+
+		"Name     ID\n"+
+		"another  "+key["id"]+"\n")
+}
+
+func (s *SnapKeysSuite) TestExportKey(c *C) {
+	keyID := s.createKey(c, "default")
+
+	_, err := snap.Parser().ParseArgs([]string{"export-key"})
+	c.Assert(err, IsNil)
+	c.Check(s.Stdout(), Matches, "(?s)openpgp [A-Za-z0-9+/=\n]+")
+
+	s.stdout.Reset()
+	_, err = snap.Parser().ParseArgs([]string{"export-key", "--account", "developer1"})
+	c.Assert(err, IsNil)
+	a, err := asserts.NewDecoder(s.stdout).Decode()
+	c.Assert(err, IsNil)
+	c.Check(a.Type(), Equals, asserts.AccountKeyRequestType)
+	req := a.(*asserts.AccountKeyRequest)
+	c.Check(req.AccountID(), Equals, "developer1")
+	c.Check(req.AuthorityID(), Equals, "developer1")
+	c.Check(req.PublicKeyID(), Equals, keyID)
+
+	_, err = snap.Parser().ParseArgs([]string{"export-key", "missing"})
+	c.Assert(err, ErrorMatches, `cannot find key named "missing"`)
+}
+
+func (s *SnapKeysSuite) TestSignYAML(c *C) {
+	s.createKey(c, "brand")
+
+	s.stdin.WriteString(`type: model
+authority-id: my-brand
+brand-id: my-brand
+series: 16
+model: my-model
+class: general
+os: core
+architecture: amd64
+gadget: my-gadget
+kernel: my-kernel
+store: my-store
+allowed-modes:
+required-snaps: foo, bar
+timestamp: 2016-06-01T10:00:00Z
+`)
+	_, err := snap.Parser().ParseArgs([]string{"sign", "-k", "brand"})
+	c.Assert(err, IsNil)
+	a, err := asserts.NewDecoder(s.stdout).Decode()
+	c.Assert(err, IsNil)
+	c.Check(a.Type(), Equals, asserts.ModelType)
+	c.Check(a.AuthorityID(), Equals, "my-brand")
+	c.Check(a.Header("series"), Equals, "16")
+	c.Check(a.Header("model"), Equals, "my-model")
+	c.Check(a.Header("allowed-modes"), Equals, "")
+}
+
+func (s *SnapKeysSuite) TestSignYAMLWithListHeader(c *C) {
+	s.createKey(c, "brand")
+
+	s.stdin.WriteString(`type: model
+authority-id: my-brand
+series: 16
+brand-id: my-brand
+model: my-model
+os: core
+architecture: amd64
+gadget: my-gadget
+kernel: my-kernel
+store: my-store
+allowed-modes:
+required-snaps: [foo, bar]
+class: fixed
+timestamp: 2016-06-01T10:00:00Z
+`)
+	_, err := snap.Parser().ParseArgs([]string{"sign", "-k", "brand"})
+	c.Assert(err, IsNil)
+	a, err := asserts.NewDecoder(s.stdout).Decode()
+	c.Assert(err, IsNil)
+	c.Check(a.HeaderList("required-snaps"), DeepEquals, []interface{}{"foo", "bar"})
+	c.Check(a.(*asserts.Model).RequiredSnaps(), DeepEquals, []string{"foo", "bar"})
+}
+
+func (s *SnapKeysSuite) TestSignJSONWithBody(c *C) {
+	s.createKey(c, "default")
+
+	s.stdin.WriteString(`{"type": "snap-build", "authority-id": "developer1", "series": "16",
+"snap-id": "snap-id-1", "snap-digest": "sha256 ...", "snap-size": 123, "grade": "stable",
+"timestamp": "2016-06-01T10:00:00Z", "body": "some body"}`)
+	_, err := snap.Parser().ParseArgs([]string{"sign"})
+	c.Assert(err, IsNil)
+	a, err := asserts.NewDecoder(s.stdout).Decode()
+	c.Assert(err, IsNil)
+	c.Check(a.Type(), Equals, asserts.SnapBuildType)
+	c.Check(a.Header("snap-size"), Equals, "123")
+	c.Check(string(a.Body()), Equals, "some body")
+}
+
+func (s *SnapKeysSuite) TestSignErrors(c *C) {
+	s.createKey(c, "default")
+
+	for _, t := range []struct {
+		input, err string
+	}{
+		{"[1, 2]", `(?s)cannot parse the assertion input as YAML or JSON: .*`},
+		{"authority-id: foo\n", `missing assertion type in the "type" header`},
+		{"type: mystery\n", `invalid assertion type: "mystery"`},
+		{"type: model\ngates: {1: a}\n", `header "gates": map keys must be strings`},
+		{"type: model\nbody: [a, b]\n", `the "body" pseudo-header must be a string`},
+		{"type: identity\naccount-id: foo\n", `cannot sign assertion: "authority-id" header is mandatory`},
+	} {
+		s.stdin.Reset()
+		s.stdin.WriteString(t.input)
+		_, err := snap.Parser().ParseArgs([]string{"sign"})
+		c.Check(err, ErrorMatches, t.err, Commentf("input: %q", t.input))
+	}
+}
+
+// fakeKeyCreationTime is the creation time of the key pairs of the fake
+// keypair helper, which is part of their ids.
+var fakeKeyCreationTime = time.Date(2016, 6, 1, 0, 0, 0, 0, time.UTC)
+
+// TestFakeKeypairHelper is not a real test, it implements the external
+// keypair manager helper protocol when the test binary is run through
+// the fake helper script. The RSA keys are stored in the given
+// directory, each in a file named after the key.
+func TestFakeKeypairHelper(t *testing.T) {
+	dir := os.Getenv("SNAPPY_FAKE_KEYPAIR_HELPER_DIR")
+	if dir == "" {
+		return
+	}
+	if err := runFakeKeypairHelper(dir, flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func fakeHelperKey(dir, name string) (*packet.PrivateKey, error) {
+	der, err := ioutil.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, err := x509.ParsePKCS1PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+	return packet.NewRSAPrivateKey(fakeKeyCreationTime, rsaKey), nil
+}
+
+func runFakeKeypairHelper(dir string, args []string) error {
+	switch {
+	case len(args) == 1 && args[0] == "list":
+		names, err := ioutil.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		for _, fi := range names {
+			privk, err := fakeHelperKey(dir, fi.Name())
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s %s\n", fi.Name(), asserts.OpenPGPPrivateKey(privk).PublicKey().ID())
+		}
+	case len(args) == 3 && args[0] == "public-key":
+		privk, err := fakeHelperKey(dir, args[1])
+		if err != nil {
+			return err
+		}
+		return privk.PublicKey.Serialize(os.Stdout)
+	case len(args) == 3 && args[0] == "sign":
+		privk, err := fakeHelperKey(dir, args[1])
+		if err != nil {
+			return err
+		}
+		content, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		sig := &packet.Signature{
+			SigType:      packet.SigTypeBinary,
+			PubKeyAlgo:   privk.PubKeyAlgo,
+			Hash:         crypto.SHA256,
+			CreationTime: time.Now(),
+			IssuerKeyId:  &privk.KeyId,
+		}
+		h := crypto.SHA256.New()
+		h.Write(content)
+		if err := sig.Sign(h, privk, nil); err != nil {
+			return err
+		}
+		return sig.Serialize(os.Stdout)
+	default:
+		return fmt.Errorf("unsupported command: %q", args)
+	}
+	return nil
+}
+
+type SnapKeysExternalSuite struct {
+	SnapSuite
+	oldHome string
+	keyID   string
+}
+
+var _ = Suite(&SnapKeysExternalSuite{})
+
+func (s *SnapKeysExternalSuite) SetUpTest(c *C) {
+	s.SnapSuite.SetUpTest(c)
+	s.oldHome = os.Getenv("HOME")
+	os.Setenv("HOME", c.MkDir())
+
+	tmpDir := c.MkDir()
+	keysDir := filepath.Join(tmpDir, "keys")
+	c.Assert(os.Mkdir(keysDir, 0700), IsNil)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	c.Assert(err, IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(keysDir, "brand"), x509.MarshalPKCS1PrivateKey(rsaKey), 0600), IsNil)
+	s.keyID = asserts.OpenPGPPrivateKey(packet.NewRSAPrivateKey(fakeKeyCreationTime, rsaKey)).PublicKey().ID()
+
+	helper := filepath.Join(tmpDir, "fake-keypair-helper")
+	script := fmt.Sprintf("#!/bin/sh\nSNAPPY_FAKE_KEYPAIR_HELPER_DIR=%s exec %s -test.run='^TestFakeKeypairHelper$' -- \"$@\"\n", keysDir, os.Args[0])
+	c.Assert(ioutil.WriteFile(helper, []byte(script), 0755), IsNil)
+	os.Setenv("SNAPPY_KEYPAIR_HELPER", helper)
+}
+
+func (s *SnapKeysExternalSuite) TearDownTest(c *C) {
+	os.Unsetenv("SNAPPY_KEYPAIR_HELPER")
+	os.Setenv("HOME", s.oldHome)
+	s.SnapSuite.TearDownTest(c)
+}
+
+func (s *SnapKeysExternalSuite) TestListAndCreate(c *C) {
+	_, err := snap.Parser().ParseArgs([]string{"keys"})
+	c.Assert(err, IsNil)
+	c.Check(s.Stdout(), Equals, ""+
+		"Name   ID\n"+
+		"brand  "+s.keyID+"\n")
+
+	_, err = snap.Parser().ParseArgs([]string{"create-key", "another"})
+	c.Assert(err, ErrorMatches, `cannot create keys through an external keypair manager`)
+
+	// nothing is stored under ~/.snap/keys
+	_, err = os.Stat(filepath.Join(os.Getenv("HOME"), ".snap", "keys"))
+	c.Check(os.IsNotExist(err), Equals, true)
+}
+
+func (s *SnapKeysExternalSuite) TestExportKey(c *C) {
+	_, err := snap.Parser().ParseArgs([]string{"export-key", "brand", "--account", "my-brand"})
+	c.Assert(err, IsNil)
+	a, err := asserts.NewDecoder(s.stdout).Decode()
+	c.Assert(err, IsNil)
+	req := a.(*asserts.AccountKeyRequest)
+	c.Check(req.AccountID(), Equals, "my-brand")
+	c.Check(req.PublicKeyID(), Equals, s.keyID)
+
+	_, err = snap.Parser().ParseArgs([]string{"export-key"})
+	c.Assert(err, ErrorMatches, `cannot find key named "default"`)
+}
+
+func (s *SnapKeysExternalSuite) TestSign(c *C) {
+	s.stdin.WriteString(`type: snap-build
+authority-id: developer1
+series: 16
+snap-id: snap-id-1
+snap-digest: sha256 ...
+snap-size: 123
+grade: stable
+timestamp: 2016-06-01T10:00:00Z
+`)
+	_, err := snap.Parser().ParseArgs([]string{"sign", "-k", "brand"})
+	c.Assert(err, IsNil)
+	a, err := asserts.NewDecoder(s.stdout).Decode()
+	c.Assert(err, IsNil)
+	c.Check(a.Type(), Equals, asserts.SnapBuildType)
+	c.Check(a.AuthorityID(), Equals, "developer1")
+}
@@ -30,10 +30,12 @@ import (
 )
 
 type cmdKnown struct {
+	Bundle       bool `long:"bundle" description:"show a bundle of the assertions needed to verify the named snaps"`
+	Model        bool `long:"model" description:"with --bundle, include the assertions needed to verify the device model and serial"`
 	KnownOptions struct {
-		AssertTypeName string   `positional-arg-name:"<assertion type>" description:"assertion type name" required:"true"`
+		AssertTypeName string   `positional-arg-name:"<assertion type>" description:"assertion type name"`
 		HeaderFilters  []string `positional-arg-name:"<header filters>" description:"header=value" required:"0"`
-	} `positional-args:"true" required:"true"`
+	} `positional-args:"true"`
 }
 
 var shortKnownHelp = i18n.G("Shows known assertions of the provided type")
@@ -41,6 +43,11 @@ var longKnownHelp = i18n.G(`
 The known command shows known assertions of the provided type.
 If header=value pairs are provided after the assertion type, the assertions
 shown must also have the specified headers matching the provided values.
+
+With --bundle the arguments are instead names of installed snaps, and the
+command shows, ordered so that they can be added in sequence, all the
+assertions needed to verify them, and with --model the device model and
+serial as well.
 `)
 
 func init() {
@@ -52,6 +59,16 @@ func init() {
 var nl = []byte{'\n'}
 
 func (x *cmdKnown) Execute(args []string) error {
+	if x.Bundle {
+		return x.showBundle()
+	}
+	if x.Model {
+		return fmt.Errorf(i18n.G("--model can only be used together with --bundle"))
+	}
+	if x.KnownOptions.AssertTypeName == "" {
+		return fmt.Errorf(i18n.G("the required argument `<assertion type>` was not provided"))
+	}
+
 	// TODO: share this kind of parsing once it's clearer how often is used in snap
 	headers := map[string]string{}
 	for _, headerFilter := range x.KnownOptions.HeaderFilters {
@@ -67,10 +84,48 @@ func (x *cmdKnown) Execute(args []string) error {
 		return err
 	}
 
+	return printAssertions(assertions)
+}
+
+// assertionJSON aids in printing assertions with --format.
+type assertionJSON struct {
+	Headers map[string]interface{} `json:"headers"`
+	Body    string                 `json:"body,omitempty"`
+}
+
+func printAssertions(assertions []asserts.Assertion) error {
+	if structuredOutput() {
+		shown := make([]assertionJSON, len(assertions))
+		for i, a := range assertions {
+			shown[i] = assertionJSON{Headers: a.Headers(), Body: string(a.Body())}
+		}
+		return printStructured(shown)
+	}
+
 	enc := asserts.NewEncoder(Stdout)
 	for _, a := range assertions {
-		enc.Encode(a)
+		if err := enc.Encode(a); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
+
+func (x *cmdKnown) showBundle() error {
+	var snapNames []string
+	if x.KnownOptions.AssertTypeName != "" {
+		snapNames = append(snapNames, x.KnownOptions.AssertTypeName)
+	}
+	snapNames = append(snapNames, x.KnownOptions.HeaderFilters...)
+	if len(snapNames) == 0 && !x.Model {
+		return fmt.Errorf(i18n.G("--bundle needs the names of some snaps or --model"))
+	}
+
+	assertions, err := Client().KnownBundle(snapNames, x.Model)
+	if err != nil {
+		return err
+	}
+
+	return printAssertions(assertions)
+}
@@ -0,0 +1,88 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	. "gopkg.in/check.v1"
+
+	snap "github.com/ubuntu-core/snappy/cmd/snap"
+)
+
+const mockBundleAssertion = `type: snap-revision
+authority-id: store-id1
+series: 16
+snap-id: snap-id-1
+snap-digest: sha256 ...
+snap-size: 123
+snap-revision: 1
+developer-id: dev-id1
+revision: 1
+timestamp: 2015-11-25T20:00:00Z
+body-length: 0
+
+openpgp ...
+`
+
+func (s *SnapSuite) TestKnownBundle(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, Equals, "GET")
+		c.Check(r.URL.Path, Equals, "/v2/assertions")
+		c.Check(r.URL.Query().Get("snaps"), Equals, "foo,bar")
+		c.Check(r.URL.Query().Get("model"), Equals, "true")
+		w.Header().Set("X-Ubuntu-Assertions-Count", "1")
+		fmt.Fprint(w, mockBundleAssertion)
+	})
+	_, err := snap.Parser().ParseArgs([]string{"known", "--bundle", "--model", "foo", "bar"})
+	c.Assert(err, IsNil)
+	c.Check(s.Stdout(), Matches, "(?s)type: snap-revision\n.*")
+}
+
+func (s *SnapSuite) TestKnownBundleErrors(c *C) {
+	for _, t := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"known", "--bundle"}, `--bundle needs the names of some snaps or --model`},
+		{[]string{"known", "--model", "model"}, `--model can only be used together with --bundle`},
+		{[]string{"known"}, "the required argument `<assertion type>` was not provided"},
+	} {
+		_, err := snap.Parser().ParseArgs(t.args)
+		c.Check(err, ErrorMatches, t.err)
+	}
+}
+
+func (s *SnapSuite) TestKnownBundleJSON(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Ubuntu-Assertions-Count", "1")
+		fmt.Fprint(w, mockBundleAssertion)
+	})
+	_, err := snap.Parser().ParseArgs([]string{"--format=json", "known", "--bundle", "foo"})
+	c.Assert(err, IsNil)
+
+	var assertions []map[string]map[string]interface{}
+	c.Assert(json.Unmarshal([]byte(s.Stdout()), &assertions), IsNil)
+	c.Assert(assertions, HasLen, 1)
+	c.Check(assertions[0]["headers"]["type"], Equals, "snap-revision")
+	c.Check(assertions[0]["headers"]["snap-id"], Equals, "snap-id-1")
+}
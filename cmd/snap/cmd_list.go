@@ -38,7 +38,7 @@ The list command displays a summary of snaps installed in the current system.`)
 
 type cmdList struct {
 	Positional struct {
-		Snaps []string `positional-arg-name:"<snap>"`
+		Snaps []installedSnapName `positional-arg-name:"<snap>"`
 	} `positional-args:"yes"`
 }
 
@@ -53,7 +53,11 @@ func (s snapsByName) Less(i, j int) bool { return s[i].Name < s[j].Name }
 func (s snapsByName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
 
 func (x *cmdList) Execute([]string) error {
-	return listSnaps(x.Positional.Snaps)
+	names := make([]string, len(x.Positional.Snaps))
+	for i, name := range x.Positional.Snaps {
+		names[i] = string(name)
+	}
+	return listSnaps(names)
 }
 
 func listSnaps(args []string) error {
@@ -67,12 +71,16 @@ func listSnaps(args []string) error {
 		return err
 	}
 
+	sort.Sort(snapsByName(snaps))
+
+	if structuredOutput() {
+		return printStructured(snaps)
+	}
+
 	if len(snaps) == 0 {
 		return fmt.Errorf(i18n.G("no snaps found"))
 	}
 
-	sort.Sort(snapsByName(snaps))
-
 	w := tabWriter()
 	defer w.Flush()
 
@@ -21,6 +21,7 @@
 package main_test
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -84,3 +85,57 @@ foo +4.2 +17 +bar
 	// ensure that the fake server api was actually hit
 	c.Check(n, check.Equals, 1)
 }
+
+func (s *SnapSuite) TestListJSON(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"type": "sync", "result": [{"name": "foo", "status": "active", "version": "4.2", "developer": "bar", "revision":17}]}`)
+	})
+	_, err := snap.Parser().ParseArgs([]string{"--format=json", "list"})
+	c.Assert(err, check.IsNil)
+
+	var snaps []map[string]interface{}
+	c.Assert(json.Unmarshal([]byte(s.Stdout()), &snaps), check.IsNil)
+	c.Assert(snaps, check.HasLen, 1)
+	c.Check(snaps[0]["name"], check.Equals, "foo")
+	c.Check(snaps[0]["version"], check.Equals, "4.2")
+	c.Check(snaps[0]["developer"], check.Equals, "bar")
+	c.Check(snaps[0]["revision"], check.Equals, 17.)
+	c.Check(snaps[0]["status"], check.Equals, "active")
+}
+
+func (s *SnapSuite) TestListYAML(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"type": "sync", "result": [{"name": "foo", "status": "active", "version": "4.2", "developer": "bar", "revision":17, "installed-size": 18976651}]}`)
+	})
+	_, err := snap.Parser().ParseArgs([]string{"--format", "yaml", "list"})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Matches, `(?ms)- .*
+  installed-size: 18976651
+.*  name: foo
+.*  revision: 17
+.*  version: "4.2"
+.*`)
+}
+
+func (s *SnapSuite) TestListJSONNoSnaps(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"type": "sync", "result": []}`)
+	})
+	_, err := snap.Parser().ParseArgs([]string{"--format=json", "list"})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Equals, "[]\n")
+}
+
+func (s *SnapSuite) TestFormatInvalid(c *check.C) {
+	_, err := snap.Parser().ParseArgs([]string{"--format=xml", "list"})
+	c.Assert(err, check.ErrorMatches, ".*xml.*")
+}
+
+func (s *SnapSuite) TestListJSONNullSnaps(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"type": "sync", "result": null}`)
+	})
+	_, err := snap.Parser().ParseArgs([]string{"--format=json", "list"})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Equals, "[]\n")
+}
@@ -82,6 +82,10 @@ func requestLoginWith2faRetry(username, password string) error {
 }
 
 func (x *cmdLogin) Execute(args []string) error {
+	if err := rejectStructuredOutput("login"); err != nil {
+		return err
+	}
+
 	username := x.Positional.UserName
 	fmt.Fprint(Stdout, i18n.G("Password: "))
 	password, err := terminal.ReadPassword(0)
@@ -41,5 +41,9 @@ func init() {
 }
 
 func (cmd *cmdLogout) Execute(args []string) error {
+	if err := rejectStructuredOutput("logout"); err != nil {
+		return err
+	}
+
 	return Client().Logout()
 }
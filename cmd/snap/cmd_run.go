@@ -0,0 +1,226 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/ubuntu-core/snappy/arch"
+	"github.com/ubuntu-core/snappy/dirs"
+	"github.com/ubuntu-core/snappy/i18n"
+	"github.com/ubuntu-core/snappy/snap"
+	"github.com/ubuntu-core/snappy/snap/snapenv"
+)
+
+var shortRunHelp = i18n.G("Run the given snap command")
+var longRunHelp = i18n.G(`
+The run command executes the given snap command with the right confinement
+and environment, as computed from the current revision of its snap.
+
+Arguments meant for the command itself go after "--", as in
+	snap run foo.bar -- --verbose
+The wrappers of the apps in /snap/bin are symbolic links to snap, which runs
+the app they're named after with all their arguments.
+
+With --command=complete the completer of the app is run instead, as the
+bash completion of snap apps does.`)
+
+type cmdRun struct {
+	Shell      bool   `long:"shell" description:"Run a shell with the confinement and environment of the command instead of the command itself"`
+	Strace     bool   `long:"strace" description:"Run the command under strace"`
+	Command    string `long:"command" description:"Run the given alternative command of the app, \"complete\" for its completer, instead of the app itself"`
+	Positional struct {
+		SnapApp string `positional-arg-name:"<snap>.<app>" required:"yes"`
+	} `positional-args:"yes" required:"yes"`
+}
+
+func init() {
+	addCommand("run", shortRunHelp, longRunHelp, func() flags.Commander { return &cmdRun{} })
+}
+
+func (x *cmdRun) Execute(args []string) error {
+	return snapRunApp(x.Positional.SnapApp, args, runOptions{shell: x.Shell, strace: x.Strace, command: x.Command})
+}
+
+type runOptions struct {
+	shell   bool
+	strace  bool
+	command string
+}
+
+// syscallExec replaces snap with the app; mocked in tests.
+var syscallExec = syscall.Exec
+
+// currentInfo reads the information of the current revision of the
+// installed snap.
+func currentInfo(snapName string) (*snap.Info, error) {
+	target, err := os.Readlink(filepath.Join(dirs.SnapSnapsDir, snapName, "current"))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf(i18n.G("cannot find installed snap %q"), snapName)
+	}
+	if err != nil {
+		return nil, err
+	}
+	revision, err := strconv.Atoi(filepath.Base(target))
+	if err != nil {
+		return nil, fmt.Errorf(i18n.G("cannot find current revision of snap %q: %v"), snapName, err)
+	}
+
+	return snap.ReadInfo(snapName, &snap.SideInfo{Revision: revision})
+}
+
+// appEnv returns the environment to run the app with: the current one
+// with the snap environment variables of the app on top.
+func appEnv(app *snap.AppInfo, home string) []string {
+	desc := struct {
+		SnapName string
+		SnapArch string
+		SnapPath string
+		Version  string
+		Revision int
+		Home     string
+	}{
+		SnapName: app.Snap.Name(),
+		SnapArch: arch.UbuntuArchitecture(),
+		SnapPath: app.Snap.MountDir(),
+		Version:  app.Snap.Version,
+		Revision: app.Snap.Revision,
+		Home:     home,
+	}
+
+	vars := snapenv.GetBasicSnapEnvVars(desc)
+	vars = append(vars, snapenv.GetUserSnapEnvVars(desc)...)
+	for name, value := range app.Environment {
+		vars = append(vars, name+"="+value)
+	}
+
+	env := make(map[string]string)
+	var names []string
+	for _, envVar := range append(os.Environ(), vars...) {
+		kv := strings.SplitN(envVar, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		if _, ok := env[kv[0]]; !ok {
+			names = append(names, kv[0])
+		}
+		env[kv[0]] = kv[1]
+	}
+	// the home of the app is its user data
+	if _, ok := env["HOME"]; !ok {
+		names = append(names, "HOME")
+	}
+	env["HOME"] = env["SNAP_USER_DATA"]
+
+	envList := make([]string, len(names))
+	for i, name := range names {
+		envList[i] = name + "=" + env[name]
+	}
+	return envList
+}
+
+// wrapperApp returns the name of the wrapper or alias that argv0,
+// as run, names, if it is one of the symlinks in dirs.SnapBinariesDir.
+func wrapperApp(argv0 string) (string, bool) {
+	name := filepath.Base(argv0)
+	if name == "snap" {
+		return "", false
+	}
+
+	path := argv0
+	if !strings.Contains(argv0, "/") {
+		var err error
+		path, err = exec.LookPath(argv0)
+		if err != nil {
+			return "", false
+		}
+	}
+	path, err := filepath.Abs(path)
+	if err != nil || filepath.Dir(path) != filepath.Clean(dirs.SnapBinariesDir) {
+		return "", false
+	}
+	fi, err := os.Lstat(path)
+	if err != nil || fi.Mode()&os.ModeSymlink == 0 {
+		return "", false
+	}
+	return name, true
+}
+
+// resolveApp returns the app the given wrapper or alias runs; the
+// aliases are symlinks to the wrappers of their apps.
+func resolveApp(snapApp string) string {
+	target, err := os.Readlink(filepath.Join(dirs.SnapBinariesDir, snapApp))
+	if err != nil || filepath.IsAbs(target) {
+		return snapApp
+	}
+	return filepath.Base(target)
+}
+
+func snapRunApp(snapApp string, args []string, opts runOptions) error {
+	snapName, appName := snap.SplitSnapApp(snapApp)
+	info, err := currentInfo(snapName)
+	if err != nil {
+		return err
+	}
+	app := info.Apps[appName]
+	if app == nil {
+		return fmt.Errorf(i18n.G("cannot find app %q in snap %q"), appName, snapName)
+	}
+
+	argv := app.LauncherCommandArgv()
+	switch opts.command {
+	case "":
+	case "complete":
+		if app.Completer == "" {
+			return fmt.Errorf(i18n.G("app %q of snap %q has no completer"), appName, snapName)
+		}
+		argv = app.LauncherCompleterArgv()
+	default:
+		return fmt.Errorf(i18n.G("cannot run unknown command %q of app %q of snap %q"), opts.command, appName, snapName)
+	}
+
+	env := appEnv(app, os.Getenv("HOME"))
+	userData := snapenv.MakeMapFromEnvList(env)["SNAP_USER_DATA"]
+	if err := os.MkdirAll(userData, 0755); err != nil {
+		return fmt.Errorf(i18n.G("cannot create user data directory: %v"), err)
+	}
+
+	if opts.shell {
+		argv = app.LauncherArgv("/bin/bash")
+	} else {
+		argv = append(argv, args...)
+	}
+	if opts.strace {
+		strace, err := exec.LookPath("strace")
+		if err != nil {
+			return fmt.Errorf(i18n.G("cannot find strace: %v"), err)
+		}
+		argv = append([]string{strace, "-f"}, argv...)
+	}
+
+	return syscallExec(argv[0], argv, env)
+}
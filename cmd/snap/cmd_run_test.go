@@ -0,0 +1,212 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package main_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+
+	snaprun "github.com/ubuntu-core/snappy/cmd/snap"
+	"github.com/ubuntu-core/snappy/dirs"
+	"github.com/ubuntu-core/snappy/snap"
+	"github.com/ubuntu-core/snappy/snap/snaptest"
+)
+
+const mockRunYaml = `name: snapname
+version: 1.0
+apps:
+ app:
+  command: bin/app --verbose
+  environment:
+   APP_MODE: test
+ other:
+  command: bin/other
+  completer: bin/other-complete
+`
+
+type execCall struct {
+	argv0 string
+	argv  []string
+	env   map[string]string
+}
+
+func (s *SnapSuite) mockRun(c *C) (*execCall, string) {
+	root := c.MkDir()
+	dirs.SetRootDir(root)
+	s.AddCleanup(func() { dirs.SetRootDir("") })
+
+	home := filepath.Join(root, "home", "user")
+	oldHome := os.Getenv("HOME")
+	os.Setenv("HOME", home)
+	s.AddCleanup(func() { os.Setenv("HOME", oldHome) })
+
+	snaptest.MockSnap(c, mockRunYaml, &snap.SideInfo{Revision: 42})
+	err := os.Symlink("42", filepath.Join(dirs.SnapSnapsDir, "snapname", "current"))
+	c.Assert(err, IsNil)
+
+	call := &execCall{}
+	restore := snaprun.MockSyscallExec(func(argv0 string, argv []string, env []string) error {
+		call.argv0 = argv0
+		call.argv = argv
+		call.env = make(map[string]string)
+		for _, kv := range env {
+			for i := range kv {
+				if kv[i] == '=' {
+					call.env[kv[:i]] = kv[i+1:]
+					break
+				}
+			}
+		}
+		return nil
+	})
+	s.AddCleanup(restore)
+
+	return call, home
+}
+
+func (s *SnapSuite) TestRun(c *C) {
+	call, home := s.mockRun(c)
+
+	rest, err := snaprun.Parser().ParseArgs([]string{"run", "snapname.app", "--", "--arg", "x"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{"--arg", "x"})
+
+	c.Check(call.argv0, Equals, "/usr/bin/ubuntu-core-launcher")
+	c.Check(call.argv, DeepEquals, []string{
+		"/usr/bin/ubuntu-core-launcher",
+		"snap.snapname.app", "snap.snapname.app",
+		filepath.Join(dirs.SnapSnapsDir, "snapname", "42", "bin", "app"),
+		"--verbose", "--arg", "x",
+	})
+
+	userData := filepath.Join(home, dirs.SnapSnapsDir, "snapname", "42")
+	c.Check(call.env["SNAP"], Equals, filepath.Join(dirs.SnapSnapsDir, "snapname", "42"))
+	c.Check(call.env["SNAP_NAME"], Equals, "snapname")
+	c.Check(call.env["SNAP_REVISION"], Equals, "42")
+	c.Check(call.env["SNAP_USER_DATA"], Equals, userData)
+	c.Check(call.env["HOME"], Equals, userData)
+	c.Check(call.env["APP_MODE"], Equals, "test")
+
+	st, err := os.Stat(userData)
+	c.Assert(err, IsNil)
+	c.Check(st.IsDir(), Equals, true)
+}
+
+func (s *SnapSuite) TestRunShell(c *C) {
+	call, _ := s.mockRun(c)
+
+	_, err := snaprun.Parser().ParseArgs([]string{"run", "--shell", "snapname.other"})
+	c.Assert(err, IsNil)
+	c.Check(call.argv, DeepEquals, []string{
+		"/usr/bin/ubuntu-core-launcher",
+		"snap.snapname.other", "snap.snapname.other",
+		"/bin/bash",
+	})
+}
+
+func (s *SnapSuite) TestRunCompleter(c *C) {
+	call, _ := s.mockRun(c)
+
+	_, err := snaprun.Parser().ParseArgs([]string{"run", "--command=complete", "snapname.other", "--", "sub", "--fo"})
+	c.Assert(err, IsNil)
+	c.Check(call.argv, DeepEquals, []string{
+		"/usr/bin/ubuntu-core-launcher",
+		"snap.snapname.other", "snap.snapname.other",
+		filepath.Join(dirs.SnapSnapsDir, "snapname", "42", "bin", "other-complete"),
+		"sub", "--fo",
+	})
+}
+
+func (s *SnapSuite) TestRunCommandErrors(c *C) {
+	call, _ := s.mockRun(c)
+
+	_, err := snaprun.Parser().ParseArgs([]string{"run", "--command=complete", "snapname.app"})
+	c.Check(err, ErrorMatches, `app "app" of snap "snapname" has no completer`)
+	_, err = snaprun.Parser().ParseArgs([]string{"run", "--command=unknown", "snapname.other"})
+	c.Check(err, ErrorMatches, `cannot run unknown command "unknown" of app "other" of snap "snapname"`)
+	c.Check(call.argv, IsNil)
+}
+
+func (s *SnapSuite) TestRunUnknownSnap(c *C) {
+	s.mockRun(c)
+
+	_, err := snaprun.Parser().ParseArgs([]string{"run", "foo.app"})
+	c.Assert(err, ErrorMatches, `cannot find installed snap "foo"`)
+}
+
+func (s *SnapSuite) TestRunUnknownApp(c *C) {
+	s.mockRun(c)
+
+	_, err := snaprun.Parser().ParseArgs([]string{"run", "snapname"})
+	c.Assert(err, ErrorMatches, `cannot find app "snapname" in snap "snapname"`)
+}
+
+func (s *SnapSuite) TestResolveApp(c *C) {
+	s.mockRun(c)
+
+	c.Assert(os.MkdirAll(dirs.SnapBinariesDir, 0755), IsNil)
+	c.Assert(os.Symlink("/usr/bin/snap", filepath.Join(dirs.SnapBinariesDir, "snapname.app")), IsNil)
+	c.Assert(os.Symlink("snapname.app", filepath.Join(dirs.SnapBinariesDir, "app")), IsNil)
+
+	c.Check(snaprun.ResolveApp("snapname.app"), Equals, "snapname.app")
+	c.Check(snaprun.ResolveApp("app"), Equals, "snapname.app")
+	c.Check(snaprun.ResolveApp("unknown"), Equals, "unknown")
+}
+
+func (s *SnapSuite) TestWrapperApp(c *C) {
+	dirs.SetRootDir(c.MkDir())
+	defer dirs.SetRootDir("")
+
+	c.Assert(os.MkdirAll(dirs.SnapBinariesDir, 0755), IsNil)
+	snapBinary := filepath.Join(c.MkDir(), "snap")
+	c.Assert(ioutil.WriteFile(snapBinary, nil, 0755), IsNil)
+	c.Assert(os.Symlink(snapBinary, filepath.Join(dirs.SnapBinariesDir, "snapname.app")), IsNil)
+	c.Assert(os.Symlink("snapname.app", filepath.Join(dirs.SnapBinariesDir, "app")), IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(dirs.SnapBinariesDir, "plain"), nil, 0755), IsNil)
+
+	app, ok := snaprun.WrapperApp(filepath.Join(dirs.SnapBinariesDir, "snapname.app"))
+	c.Check(ok, Equals, true)
+	c.Check(app, Equals, "snapname.app")
+	app, ok = snaprun.WrapperApp(filepath.Join(dirs.SnapBinariesDir, "app"))
+	c.Check(ok, Equals, true)
+	c.Check(app, Equals, "app")
+
+	// found in PATH
+	oldPath := os.Getenv("PATH")
+	defer os.Setenv("PATH", oldPath)
+	os.Setenv("PATH", dirs.SnapBinariesDir+":"+oldPath)
+	app, ok = snaprun.WrapperApp("app")
+	c.Check(ok, Equals, true)
+	c.Check(app, Equals, "app")
+
+	for _, argv0 := range []string{
+		"snap",
+		"/usr/bin/snap",
+		"unknown",
+		filepath.Join(dirs.SnapBinariesDir, "plain"),
+		filepath.Join(dirs.SnapBinariesDir, "missing"),
+		filepath.Join(c.MkDir(), "snapname.app"),
+	} {
+		_, ok := snaprun.WrapperApp(argv0)
+		c.Check(ok, Equals, false, Commentf(argv0))
+	}
+}
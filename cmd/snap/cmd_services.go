@@ -0,0 +1,244 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/ubuntu-core/snappy/client"
+	"github.com/ubuntu-core/snappy/i18n"
+
+	"github.com/jessevdk/go-flags"
+)
+
+var (
+	shortServicesHelp = i18n.G("List the services of snaps")
+	shortStartHelp    = i18n.G("Start services")
+	shortStopHelp     = i18n.G("Stop services")
+	shortRestartHelp  = i18n.G("Restart services")
+	shortLogsHelp     = i18n.G("Show the logs of services")
+)
+
+var longServicesHelp = i18n.G(`
+The services command lists the services of the given snaps, or of all the
+active snaps, with whether they start on boot and whether they are running.
+
+Single services can be given as <snap>.<app>.`)
+
+var longStartHelp = i18n.G(`
+The start command starts the given services, or all the services of the given
+snaps. Single services are given as <snap>.<app>.
+
+With --enable the services are also set to start on boot.`)
+
+var longStopHelp = i18n.G(`
+The stop command stops the given services, or all the services of the given
+snaps. Single services are given as <snap>.<app>.
+
+With --disable the services are also set not to start on boot.`)
+
+var longRestartHelp = i18n.G(`
+The restart command restarts the given services, or all the services of the
+given snaps. Single services are given as <snap>.<app>.`)
+
+var longLogsHelp = i18n.G(`
+The logs command shows the most recent log entries of the given services, of all
+the services of the given snaps, or of all the services of the active snaps.
+Single services are given as <snap>.<app>.
+
+With --follow the command keeps showing the new entries as they are logged,
+until interrupted.`)
+
+type cmdServices struct {
+	Positional struct {
+		Names []string `positional-arg-name:"<service>"`
+	} `positional-args:"yes"`
+}
+
+type cmdStart struct {
+	Enable     bool `long:"enable" description:"As well as starting the services, set them to start on boot"`
+	Positional struct {
+		Names []string `positional-arg-name:"<service>" required:"1"`
+	} `positional-args:"yes"`
+}
+
+type cmdStop struct {
+	Disable    bool `long:"disable" description:"As well as stopping the services, set them not to start on boot"`
+	Positional struct {
+		Names []string `positional-arg-name:"<service>" required:"1"`
+	} `positional-args:"yes"`
+}
+
+type cmdRestart struct {
+	Positional struct {
+		Names []string `positional-arg-name:"<service>" required:"1"`
+	} `positional-args:"yes"`
+}
+
+type cmdLogs struct {
+	N          string `short:"n" default:"10" description:"Show only the given number of most recent entries, or all of them"`
+	Follow     bool   `short:"f" long:"follow" description:"Keep showing new entries as they are logged"`
+	Since      string `long:"since" description:"Show only the entries logged since the given RFC3339 time, or that long ago (like 1h30m)"`
+	Positional struct {
+		Names []string `positional-arg-name:"<service>"`
+	} `positional-args:"yes"`
+}
+
+func init() {
+	addCommand("services", shortServicesHelp, longServicesHelp, func() flags.Commander { return &cmdServices{} })
+	addCommand("start", shortStartHelp, longStartHelp, func() flags.Commander { return &cmdStart{} })
+	addCommand("stop", shortStopHelp, longStopHelp, func() flags.Commander { return &cmdStop{} })
+	addCommand("restart", shortRestartHelp, longRestartHelp, func() flags.Commander { return &cmdRestart{} })
+	addCommand("logs", shortLogsHelp, longLogsHelp, func() flags.Commander { return &cmdLogs{} })
+}
+
+func (x *cmdServices) Execute([]string) error {
+	apps, err := Client().Apps(x.Positional.Names, client.AppOptions{Service: true})
+	if err != nil {
+		return err
+	}
+
+	if structuredOutput() {
+		return printStructured(apps)
+	}
+
+	if len(apps) == 0 {
+		return fmt.Errorf(i18n.G("no services found"))
+	}
+
+	w := tabWriter()
+	defer w.Flush()
+
+	fmt.Fprintln(w, i18n.G("Service\tStartup\tCurrent"))
+	for _, app := range apps {
+		startup := i18n.G("disabled")
+		if app.Enabled {
+			startup = i18n.G("enabled")
+		}
+		current := i18n.G("inactive")
+		if app.Active {
+			current = i18n.G("active")
+		}
+		fmt.Fprintf(w, "%s.%s\t%s\t%s\n", app.Snap, app.Name, startup, current)
+	}
+
+	return nil
+}
+
+// waitChange waits for the change to be done and says so, printing the
+// change itself for structured output.
+func waitChange(cli *client.Client, changeID string, err error) error {
+	if err != nil {
+		return err
+	}
+	chg, err := wait(cli, changeID)
+	if err != nil {
+		return err
+	}
+	if structuredOutput() {
+		return printStructured(chg)
+	}
+	fmt.Fprintln(Stdout, "Done")
+	return nil
+}
+
+func (x *cmdStart) Execute([]string) error {
+	cli := Client()
+	changeID, err := cli.Start(x.Positional.Names, client.StartOptions{Enable: x.Enable})
+	return waitChange(cli, changeID, err)
+}
+
+func (x *cmdStop) Execute([]string) error {
+	cli := Client()
+	changeID, err := cli.Stop(x.Positional.Names, client.StopOptions{Disable: x.Disable})
+	return waitChange(cli, changeID, err)
+}
+
+func (x *cmdRestart) Execute([]string) error {
+	cli := Client()
+	changeID, err := cli.Restart(x.Positional.Names)
+	return waitChange(cli, changeID, err)
+}
+
+func parseSince(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return time.Time{}, fmt.Errorf(i18n.G("invalid value for --since: %q, must be an RFC3339 time or a duration"), s)
+	}
+	return time.Now().Add(-d), nil
+}
+
+func (x *cmdLogs) Execute([]string) error {
+	opts := client.LogOptions{N: -1}
+	if x.N != "all" {
+		n, err := strconv.Atoi(x.N)
+		if err != nil || n <= 0 {
+			return fmt.Errorf(i18n.G(`invalid value for -n: %q, must be a positive number or "all"`), x.N)
+		}
+		opts.N = n
+	}
+	if x.Since != "" {
+		since, err := parseSince(x.Since)
+		if err != nil {
+			return err
+		}
+		opts.Since = since
+	}
+
+	if x.Follow {
+		return followLogs(x.Positional.Names, opts)
+	}
+
+	logs, err := Client().Logs(x.Positional.Names, opts)
+	if err != nil {
+		return err
+	}
+
+	for _, log := range logs {
+		fmt.Fprintln(Stdout, log)
+	}
+
+	return nil
+}
+
+func followLogs(names []string, opts client.LogOptions) error {
+	stream, err := Client().FollowLogs(names, opts)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	for {
+		log, err := stream.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(Stdout, log)
+	}
+}
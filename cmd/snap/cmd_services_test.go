@@ -0,0 +1,205 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	snap "github.com/ubuntu-core/snappy/cmd/snap"
+)
+
+func (s *SnapSuite) TestServices(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, Equals, "GET")
+		c.Check(r.URL.Path, Equals, "/v2/apps")
+		c.Check(r.URL.Query().Get("names"), Equals, "foo,bar.baz")
+		c.Check(r.URL.Query().Get("select"), Equals, "service")
+		fmt.Fprintln(w, `{"type": "sync", "result": [
+			{"snap": "bar", "name": "baz", "daemon": "simple"},
+			{"snap": "foo", "name": "svc", "daemon": "forking", "enabled": true, "active": true}
+		]}`)
+	})
+	rest, err := snap.Parser().ParseArgs([]string{"services", "foo", "bar.baz"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+	c.Check(s.Stdout(), Equals, ""+
+		"Service  Startup   Current\n"+
+		"bar.baz  disabled  inactive\n"+
+		"foo.svc  enabled   active\n")
+	c.Check(s.Stderr(), Equals, "")
+}
+
+func (s *SnapSuite) TestServicesNone(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.URL.Query().Get("names"), Equals, "")
+		fmt.Fprintln(w, `{"type": "sync", "result": []}`)
+	})
+	_, err := snap.Parser().ParseArgs([]string{"services"})
+	c.Assert(err, ErrorMatches, `no services found`)
+}
+
+func (s *SnapSuite) testServiceOp(c *C, args []string, action string) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch n {
+		case 0:
+			c.Check(r.Method, Equals, "POST")
+			c.Check(r.URL.Path, Equals, "/v2/apps")
+			var body map[string]interface{}
+			c.Assert(json.NewDecoder(r.Body).Decode(&body), IsNil)
+			c.Check(body, DeepEquals, map[string]interface{}{
+				"action": action,
+				"names":  []interface{}{"foo", "bar.baz"},
+			})
+			fmt.Fprintln(w, `{"type": "async", "change": "42", "status-code": 202}`)
+		case 1:
+			c.Check(r.Method, Equals, "GET")
+			c.Check(r.URL.Path, Equals, "/v2/changes/42")
+			fmt.Fprintln(w, `{"type": "sync", "result": {"ready": true, "status": "Done"}}`)
+		default:
+			c.Fatalf("expected to get 2 requests, now on %d", n+1)
+		}
+		n++
+	})
+	rest, err := snap.Parser().ParseArgs(append(args, "foo", "bar.baz"))
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+	c.Check(s.Stdout(), Matches, `(?sm).*^Done$`)
+	c.Check(n, Equals, 2)
+}
+
+func (s *SnapSuite) TestStart(c *C) {
+	s.testServiceOp(c, []string{"start"}, "start")
+}
+
+func (s *SnapSuite) TestStartEnable(c *C) {
+	s.testServiceOp(c, []string{"start", "--enable"}, "enable")
+}
+
+func (s *SnapSuite) TestStop(c *C) {
+	s.testServiceOp(c, []string{"stop"}, "stop")
+}
+
+func (s *SnapSuite) TestStopDisable(c *C) {
+	s.testServiceOp(c, []string{"stop", "--disable"}, "disable")
+}
+
+func (s *SnapSuite) TestRestart(c *C) {
+	s.testServiceOp(c, []string{"restart"}, "restart")
+}
+
+func (s *SnapSuite) TestStartNeedsNames(c *C) {
+	_, err := snap.Parser().ParseArgs([]string{"start"})
+	c.Assert(err, ErrorMatches, "the required argument .* was not provided")
+}
+
+func (s *SnapSuite) TestLogs(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, Equals, "GET")
+		c.Check(r.URL.Path, Equals, "/v2/logs")
+		c.Check(r.URL.Query().Get("names"), Equals, "foo")
+		c.Check(r.URL.Query().Get("n"), Equals, "10")
+		fmt.Fprintln(w, `{"type": "sync", "result": [
+			{"timestamp": "2016-06-01T12:00:00.123456Z", "message": "hello", "sid": "foo.svc", "pid": "42"},
+			{"timestamp": "2016-06-01T12:00:01.000000Z", "message": "bye", "sid": "foo.svc", "pid": "42"}
+		]}`)
+	})
+	rest, err := snap.Parser().ParseArgs([]string{"logs", "foo"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+	c.Check(s.Stdout(), Equals, ""+
+		"2016-06-01T12:00:00.123456Z foo.svc hello\n"+
+		"2016-06-01T12:00:01.000000Z foo.svc bye\n")
+}
+
+func (s *SnapSuite) TestLogsAll(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.URL.Query().Get("names"), Equals, "")
+		c.Check(r.URL.Query().Get("n"), Equals, "all")
+		fmt.Fprintln(w, `{"type": "sync", "result": []}`)
+	})
+	_, err := snap.Parser().ParseArgs([]string{"logs", "-n", "all"})
+	c.Assert(err, IsNil)
+}
+
+func (s *SnapSuite) TestLogsFollow(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.URL.Path, Equals, "/v2/logs")
+		c.Check(r.URL.Query().Get("names"), Equals, "foo.svc")
+		c.Check(r.URL.Query().Get("follow"), Equals, "true")
+		c.Check(r.URL.Query().Get("since"), Equals, "2016-06-01T11:00:00Z")
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		fmt.Fprintln(w, `{"timestamp": "2016-06-01T12:00:00.123456Z", "message": "hello", "sid": "foo.svc", "pid": "42"}`)
+		fmt.Fprintln(w, `{"timestamp": "2016-06-01T12:00:01.000000Z", "message": "bye", "sid": "foo.svc", "pid": "42"}`)
+	})
+	rest, err := snap.Parser().ParseArgs([]string{"logs", "-f", "--since", "2016-06-01T11:00:00Z", "foo.svc"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+	c.Check(s.Stdout(), Equals, ""+
+		"2016-06-01T12:00:00.123456Z foo.svc hello\n"+
+		"2016-06-01T12:00:01.000000Z foo.svc bye\n")
+}
+
+func (s *SnapSuite) TestLogsSinceDuration(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		since, err := time.Parse(time.RFC3339, r.URL.Query().Get("since"))
+		c.Assert(err, IsNil)
+		ago := time.Now().Sub(since)
+		c.Check(ago > 59*time.Minute && ago < 61*time.Minute, Equals, true)
+		fmt.Fprintln(w, `{"type": "sync", "result": []}`)
+	})
+	_, err := snap.Parser().ParseArgs([]string{"logs", "--since", "1h"})
+	c.Assert(err, IsNil)
+}
+
+func (s *SnapSuite) TestLogsBadSince(c *C) {
+	_, err := snap.Parser().ParseArgs([]string{"logs", "--since", "yesterday"})
+	c.Assert(err, ErrorMatches, `invalid value for --since: "yesterday", must be an RFC3339 time or a duration`)
+}
+
+func (s *SnapSuite) TestLogsBadN(c *C) {
+	_, err := snap.Parser().ParseArgs([]string{"logs", "-n", "0", "foo"})
+	c.Assert(err, ErrorMatches, `invalid value for -n: "0", must be a positive number or "all"`)
+}
+
+func (s *SnapSuite) TestServicesYAML(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"type": "sync", "result": [
+			{"snap": "bar", "name": "baz", "daemon": "simple"},
+			{"snap": "foo", "name": "svc", "daemon": "forking", "enabled": true, "active": true}
+		]}`)
+	})
+	_, err := snap.Parser().ParseArgs([]string{"--format=yaml", "services"})
+	c.Assert(err, IsNil)
+	c.Check(s.Stdout(), Equals, ""+
+		"- daemon: simple\n"+
+		"  name: baz\n"+
+		"  snap: bar\n"+
+		"- active: true\n"+
+		"  daemon: forking\n"+
+		"  enabled: true\n"+
+		"  name: svc\n"+
+		"  snap: foo\n")
+}
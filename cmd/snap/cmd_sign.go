@@ -0,0 +1,170 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/jessevdk/go-flags"
+	"gopkg.in/yaml.v2"
+
+	"github.com/ubuntu-core/snappy/asserts"
+	"github.com/ubuntu-core/snappy/i18n"
+)
+
+type cmdSign struct {
+	KeyName string `short:"k" long:"key-name" default:"default" description:"name of the key to use, otherwise use the default key"`
+}
+
+var shortSignHelp = i18n.G("Signs an assertion")
+var longSignHelp = i18n.G(`
+The sign command signs an assertion using the specified key, using the input
+for headers from a YAML or JSON mapping provided through stdin. The body of
+the assertion can be specified through a "body" pseudo-header.
+`)
+
+func init() {
+	addCommand("sign", shortSignHelp, longSignHelp, func() flags.Commander {
+		return &cmdSign{}
+	})
+}
+
+// parseSignHeaders parses the YAML or JSON mapping of headers (JSON
+// being a subset of YAML), returning the assertion type, the headers
+// and the body. Header values can be scalars or lists and maps of them.
+func parseSignHeaders(input []byte) (*asserts.AssertionType, map[string]interface{}, []byte, error) {
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(input, &raw); err != nil {
+		return nil, nil, nil, fmt.Errorf(i18n.G("cannot parse the assertion input as YAML or JSON: %v"), err)
+	}
+
+	headers := make(map[string]interface{}, len(raw))
+	for name, value := range raw {
+		v, err := signHeaderValue(value)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf(i18n.G("header %q: %v"), name, err)
+		}
+		headers[name] = v
+	}
+
+	typeName, _ := headers["type"].(string)
+	if typeName == "" {
+		return nil, nil, nil, fmt.Errorf(i18n.G("missing assertion type in the \"type\" header"))
+	}
+	assertType := asserts.Type(typeName)
+	if assertType == nil {
+		return nil, nil, nil, fmt.Errorf(i18n.G("invalid assertion type: %q"), typeName)
+	}
+	delete(headers, "type")
+
+	var body []byte
+	if b, ok := headers["body"]; ok {
+		s, ok := b.(string)
+		if !ok {
+			return nil, nil, nil, fmt.Errorf(i18n.G("the \"body\" pseudo-header must be a string"))
+		}
+		body = []byte(s)
+		delete(headers, "body")
+	}
+	return assertType, headers, body, nil
+}
+
+// signHeaderValue converts a parsed YAML value into the string, list
+// or map form expected for assertion headers.
+func signHeaderValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case nil:
+		return "", nil
+	case int, int64, uint64, float64, bool:
+		return fmt.Sprint(v), nil
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, elem := range v {
+			conv, err := signHeaderValue(elem)
+			if err != nil {
+				return nil, err
+			}
+			l[i] = conv
+		}
+		return l, nil
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, elem := range v {
+			k, ok := key.(string)
+			if !ok {
+				return nil, fmt.Errorf(i18n.G("map keys must be strings"))
+			}
+			conv, err := signHeaderValue(elem)
+			if err != nil {
+				return nil, err
+			}
+			m[k] = conv
+		}
+		return m, nil
+	default:
+		return nil, fmt.Errorf(i18n.G("unsupported value type %T"), value)
+	}
+}
+
+func (x *cmdSign) Execute(args []string) error {
+	// the output is the signed assertion, in its own format
+	if err := rejectStructuredOutput("sign"); err != nil {
+		return err
+	}
+
+	input, err := ioutil.ReadAll(Stdin)
+	if err != nil {
+		return fmt.Errorf(i18n.G("cannot read assertion input: %v"), err)
+	}
+	assertType, headers, body, err := parseSignHeaders(input)
+	if err != nil {
+		return err
+	}
+
+	kr, err := openKeyring()
+	if err != nil {
+		return err
+	}
+	privKey, err := kr.Key(x.KeyName)
+	if err != nil {
+		return err
+	}
+
+	// file the key under the signing authority for the sake of signing
+	authorityID, _ := headers["authority-id"].(string)
+	db, err := asserts.OpenDatabase(&asserts.DatabaseConfig{
+		KeypairManager: asserts.NewMemoryKeypairManager(),
+	})
+	if err != nil {
+		return err
+	}
+	if err := db.ImportKey(authorityID, privKey); err != nil {
+		return err
+	}
+	a, err := db.Sign(assertType, headers, body, privKey.PublicKey().ID())
+	if err != nil {
+		return fmt.Errorf(i18n.G("cannot sign assertion: %v"), err)
+	}
+
+	return asserts.NewEncoder(Stdout).Encode(a)
+}
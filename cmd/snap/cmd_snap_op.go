@@ -40,11 +40,13 @@ func lastLogStr(logs []string) string {
 }
 
 func wait(client *client.Client, id string) (*client.Change, error) {
-	pb := progress.NewTextProgress()
-	defer func() {
-		pb.Finished()
-		fmt.Fprint(Stdout, "\n")
-	}()
+	// the progress would get in the way of structured output
+	var pb progress.Meter = &progress.NullProgress{}
+	if !structuredOutput() {
+		pb = progress.NewTextProgress()
+		defer fmt.Fprint(Stdout, "\n")
+	}
+	defer pb.Finished()
 
 	var lastID string
 	lastLog := map[string]string{}
@@ -116,30 +118,22 @@ The refresh command refreshes (updates) the named snap.
 
 type cmdRemove struct {
 	Positional struct {
-		Snap string `positional-arg-name:"<snap>"`
+		Snap installedSnapName `positional-arg-name:"<snap>"`
 	} `positional-args:"yes" required:"yes"`
 }
 
 func (x *cmdRemove) Execute([]string) error {
 	cli := Client()
-	name := x.Positional.Snap
+	name := string(x.Positional.Snap)
 	changeID, err := cli.Remove(name, nil)
-	if err != nil {
-		return err
-	}
-
-	if _, err := wait(cli, changeID); err != nil {
-		return err
-	}
-	fmt.Fprintln(Stdout, "Done")
-	return nil
+	return waitChange(cli, changeID, err)
 }
 
 type cmdInstall struct {
 	Channel    string `long:"channel" description:"Install from this channel instead of the device's default"`
 	DevMode    bool   `long:"devmode" description:"Install the snap with non-enforcing security"`
 	Positional struct {
-		Snap string `positional-arg-name:"<snap>"`
+		Snap remoteSnapName `positional-arg-name:"<snap>"`
 	} `positional-args:"yes" required:"yes"`
 }
 
@@ -149,7 +143,7 @@ func (x *cmdInstall) Execute([]string) error {
 	var installFromFile bool
 
 	cli := Client()
-	name := x.Positional.Snap
+	name := string(x.Positional.Snap)
 	opts := &client.SnapOptions{Channel: x.Channel, DevMode: x.DevMode}
 	if strings.Contains(name, "/") || strings.HasSuffix(name, ".snap") || strings.Contains(name, ".snap.") {
 		installFromFile = true
@@ -182,13 +176,13 @@ func (x *cmdInstall) Execute([]string) error {
 type cmdRefresh struct {
 	Channel    string `long:"channel" description:"Refresh to the latest on this channel, and track this channel henceforth"`
 	Positional struct {
-		Snap string `positional-arg-name:"<snap>"`
+		Snap installedSnapName `positional-arg-name:"<snap>"`
 	} `positional-args:"yes" required:"yes"`
 }
 
 func (x *cmdRefresh) Execute([]string) error {
 	cli := Client()
-	name := x.Positional.Snap
+	name := string(x.Positional.Snap)
 	opts := &client.SnapOptions{Channel: x.Channel}
 	changeID, err := cli.Refresh(name, opts)
 	if err != nil {
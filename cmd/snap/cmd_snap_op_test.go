@@ -21,6 +21,7 @@
 package main_test
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -102,6 +103,27 @@ func (s *SnapOpSuite) TestInstall(c *check.C) {
 	c.Check(s.srv.n, check.Equals, s.srv.total)
 }
 
+func (s *SnapOpSuite) TestRemoveJSON(c *check.C) {
+	s.srv.total = 3
+	s.srv.checker = func(r *http.Request) {
+		c.Check(r.URL.Path, check.Equals, "/v2/snaps/foo")
+		c.Check(DecodedRequestBody(c, r), check.DeepEquals, map[string]interface{}{
+			"action": "remove",
+			"name":   "foo",
+		})
+	}
+
+	s.RedirectClientToTestServer(s.srv.handle)
+	_, err := snap.Parser().ParseArgs([]string{"--format=json", "remove", "foo"})
+	c.Assert(err, check.IsNil)
+	// the change, with no progress in the way
+	var chg map[string]interface{}
+	c.Assert(json.Unmarshal([]byte(s.Stdout()), &chg), check.IsNil)
+	c.Check(chg["status"], check.Equals, "Done")
+	c.Check(chg["ready"], check.Equals, true)
+	c.Check(s.srv.n, check.Equals, s.srv.total)
+}
+
 func (s *SnapOpSuite) TestInstallDevMode(c *check.C) {
 	s.srv.checker = func(r *http.Request) {
 		c.Check(r.URL.Path, check.Equals, "/v2/snaps/foo.bar")
@@ -0,0 +1,114 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package main
+
+import (
+	"strings"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/ubuntu-core/snappy/client"
+)
+
+// The types below are the arguments of the commands that can be
+// completed; see data/completion/snap for how the shell asks for the
+// completions.
+
+// installedSnapName is the name of an installed snap.
+type installedSnapName string
+
+func (s installedSnapName) Complete(match string) []flags.Completion {
+	snaps, _, err := Client().FilterSnaps(client.SnapFilter{Sources: []string{"local"}})
+	if err != nil {
+		return nil
+	}
+
+	var ret []flags.Completion
+	for _, snap := range snaps {
+		if strings.HasPrefix(snap.Name, match) {
+			ret = append(ret, flags.Completion{Item: snap.Name})
+		}
+	}
+	return ret
+}
+
+// remoteSnapName is the name of a snap in the store.
+type remoteSnapName string
+
+func (s remoteSnapName) Complete(match string) []flags.Completion {
+	// the store needs something to look for; snap files are completed
+	// by the shell itself
+	if match == "" || strings.ContainsAny(match, "/.") {
+		return nil
+	}
+
+	snaps, _, err := Client().Find(match)
+	if err != nil {
+		return nil
+	}
+
+	var ret []flags.Completion
+	for _, snap := range snaps {
+		if strings.HasPrefix(snap.Name, match) {
+			ret = append(ret, flags.Completion{Item: snap.Name})
+		}
+	}
+	return ret
+}
+
+// changeID is the id of a change.
+type changeID string
+
+func (s changeID) Complete(match string) []flags.Completion {
+	changes, err := Client().Changes(client.ChangesAll)
+	if err != nil {
+		return nil
+	}
+
+	var ret []flags.Completion
+	for _, chg := range changes {
+		if strings.HasPrefix(chg.ID, match) {
+			ret = append(ret, flags.Completion{Item: chg.ID, Description: chg.Summary})
+		}
+	}
+	return ret
+}
+
+// Complete completes the plugs and slots of the snaps as <snap>:<name>.
+func (sn *SnapAndName) Complete(match string) []flags.Completion {
+	ifaces, err := Client().Interfaces()
+	if err != nil {
+		return nil
+	}
+
+	var ret []flags.Completion
+	add := func(snapName, name, iface string) {
+		ref := snapName + ":" + name
+		if strings.HasPrefix(ref, match) {
+			ret = append(ret, flags.Completion{Item: ref, Description: iface})
+		}
+	}
+	for _, plug := range ifaces.Plugs {
+		add(plug.Snap, plug.Name, plug.Interface)
+	}
+	for _, slot := range ifaces.Slots {
+		add(slot.Snap, slot.Name, slot.Interface)
+	}
+	return ret
+}
@@ -0,0 +1,93 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/jessevdk/go-flags"
+	. "gopkg.in/check.v1"
+
+	snap "github.com/ubuntu-core/snappy/cmd/snap"
+)
+
+func (s *SnapSuite) TestCompleteInstalledSnapName(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, Equals, "GET")
+		c.Check(r.URL.Path, Equals, "/v2/snaps")
+		c.Check(r.URL.Query().Get("sources"), Equals, "local")
+		fmt.Fprintln(w, `{"type": "sync", "result": [{"name": "foo"}, {"name": "foobar"}, {"name": "bar"}]}`)
+	})
+	c.Check(snap.CompleteInstalledSnapName("foo"), DeepEquals, []flags.Completion{
+		{Item: "foo"},
+		{Item: "foobar"},
+	})
+}
+
+func (s *SnapSuite) TestCompleteRemoteSnapName(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, Equals, "GET")
+		c.Check(r.URL.Path, Equals, "/v2/find")
+		c.Check(r.URL.Query().Get("q"), Equals, "he")
+		fmt.Fprintln(w, `{"type": "sync", "result": [{"name": "hello"}, {"name": "the-hello"}]}`)
+	})
+	c.Check(snap.CompleteRemoteSnapName("he"), DeepEquals, []flags.Completion{
+		{Item: "hello"},
+	})
+}
+
+func (s *SnapSuite) TestCompleteRemoteSnapNameSkipsFiles(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Fatalf("the store should not be asked about %q", r.URL.Query().Get("q"))
+	})
+	c.Check(snap.CompleteRemoteSnapName(""), IsNil)
+	c.Check(snap.CompleteRemoteSnapName("./foo"), IsNil)
+	c.Check(snap.CompleteRemoteSnapName("foo_1.0_all.snap"), IsNil)
+}
+
+func (s *SnapSuite) TestCompleteChangeID(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, Equals, "GET")
+		c.Check(r.URL.Path, Equals, "/v2/changes")
+		c.Check(r.URL.Query().Get("select"), Equals, "all")
+		fmt.Fprintln(w, `{"type": "sync", "result": [{"id": "1", "summary": "Install foo"}, {"id": "12", "summary": "Remove foo"}, {"id": "2", "summary": "Install bar"}]}`)
+	})
+	c.Check(snap.CompleteChangeID("1"), DeepEquals, []flags.Completion{
+		{Item: "1", Description: "Install foo"},
+		{Item: "12", Description: "Remove foo"},
+	})
+}
+
+func (s *SnapSuite) TestCompleteSnapAndName(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, Equals, "GET")
+		c.Check(r.URL.Path, Equals, "/v2/interfaces")
+		fmt.Fprintln(w, `{"type": "sync", "result": {
+			"plugs": [{"snap": "consumer", "plug": "network", "interface": "network"}],
+			"slots": [{"snap": "ubuntu-core", "slot": "network", "interface": "network"}, {"snap": "producer", "slot": "data", "interface": "content"}]
+		}}`)
+	})
+	var sn snap.SnapAndName
+	c.Check(sn.Complete("c"), DeepEquals, []flags.Completion{
+		{Item: "consumer:network", Description: "network"},
+	})
+	c.Check(sn.Complete(""), HasLen, 3)
+}
@@ -20,4 +20,32 @@
 
 package main
 
+import (
+	"github.com/jessevdk/go-flags"
+)
+
 var RunMain = run
+
+func MockSyscallExec(f func(string, []string, []string) error) (restore func()) {
+	oldSyscallExec := syscallExec
+	syscallExec = f
+	return func() {
+		syscallExec = oldSyscallExec
+	}
+}
+
+var ResolveApp = resolveApp
+
+var WrapperApp = wrapperApp
+
+func CompleteInstalledSnapName(match string) []flags.Completion {
+	return installedSnapName("").Complete(match)
+}
+
+func CompleteRemoteSnapName(match string) []flags.Completion {
+	return remoteSnapName("").Complete(match)
+}
+
+func CompleteChangeID(match string) []flags.Completion {
+	return changeID("").Complete(match)
+}
@@ -0,0 +1,109 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/ubuntu-core/snappy/i18n"
+)
+
+// The formats the commands can print their results in, chosen with
+// the global --format option.
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatYAML  = "yaml"
+)
+
+// structuredOutput tells whether the results are to be printed with
+// printStructured instead of as text.
+func structuredOutput() bool {
+	return optionsData.Format == formatJSON || optionsData.Format == formatYAML
+}
+
+// rejectStructuredOutput fails if structured output was asked for the
+// named command, which has no results to print that way.
+func rejectStructuredOutput(command string) error {
+	if structuredOutput() {
+		return fmt.Errorf(i18n.G("cannot use --format=%s with %s"), optionsData.Format, command)
+	}
+	return nil
+}
+
+// printStructured prints v, usually made of the types of the client
+// package, in the format asked for. YAML uses the same field names as
+// JSON, which are those of the REST API.
+func printStructured(v interface{}) error {
+	// empty results are empty lists, not nothing
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Slice && rv.IsNil() {
+		v = reflect.MakeSlice(rv.Type(), 0, 0).Interface()
+	}
+
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if optionsData.Format == formatJSON {
+		_, err = Stdout.Write(append(data, '\n'))
+		return err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var generic interface{}
+	if err := dec.Decode(&generic); err != nil {
+		return err
+	}
+	data, err = yaml.Marshal(withNumbers(generic))
+	if err != nil {
+		return err
+	}
+	_, err = Stdout.Write(data)
+	return err
+}
+
+// withNumbers turns the json.Numbers in the decoded JSON value into
+// integers or floats, so that YAML doesn't print them as strings.
+func withNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, x := range v {
+			v[k] = withNumbers(x)
+		}
+	case []interface{}:
+		for i, x := range v {
+			v[i] = withNumbers(x)
+		}
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	}
+	return v
+}
@@ -0,0 +1,50 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"net/http"
+
+	. "gopkg.in/check.v1"
+
+	snap "github.com/ubuntu-core/snappy/cmd/snap"
+)
+
+func (s *SnapSuite) TestRejectStructuredOutput(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Errorf("unexpected request to %s", r.URL.Path)
+	})
+	for _, t := range []struct {
+		args []string
+		cmd  string
+	}{
+		{[]string{"ack", "some-file"}, "ack"},
+		{[]string{"login", "someone@example.com"}, "login"},
+		{[]string{"logout"}, "logout"},
+		{[]string{"sign"}, "sign"},
+		{[]string{"debug", "check-assertions"}, "debug check-assertions"},
+	} {
+		for _, format := range []string{"json", "yaml"} {
+			_, err := snap.Parser().ParseArgs(append([]string{"--format=" + format}, t.args...))
+			c.Check(err, ErrorMatches, "cannot use --format="+format+" with "+t.cmd, Commentf("%v", t.args))
+		}
+	}
+	c.Check(s.Stdout(), Equals, "")
+}
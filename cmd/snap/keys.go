@@ -0,0 +1,170 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/ubuntu-core/snappy/asserts"
+	"github.com/ubuntu-core/snappy/i18n"
+	"github.com/ubuntu-core/snappy/osutil"
+)
+
+// defaultKeyName is the name of the key used when none is given.
+const defaultKeyName = "default"
+
+// keyringAuthority is the authority-id under which the key pairs of the
+// user are filed. It is not an account: when signing, the key is filed
+// under the actual signing authority.
+const keyringAuthority = "keyring"
+
+// keyring holds the key pairs of the current user, stored under
+// ~/.snap/keys, together with an index of their names.
+type keyring struct {
+	keypairMgr asserts.KeypairManager
+	indexPath  string
+	// keyIDs maps key names to key ids
+	keyIDs map[string]string
+	// external is set if the key pairs are held by an external helper
+	external bool
+}
+
+// openKeyring opens the keyring of the current user. If
+// SNAPPY_KEYPAIR_HELPER is set, the key pairs are instead managed by the
+// named external helper.
+func openKeyring() (*keyring, error) {
+	if helper := os.Getenv("SNAPPY_KEYPAIR_HELPER"); helper != "" {
+		return openExternalKeyring(helper)
+	}
+	homeDir, err := osutil.CurrentHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(homeDir, ".snap", "keys")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	keypairMgr, err := asserts.OpenFSKeypairManager(dir)
+	if err != nil {
+		return nil, err
+	}
+	kr := &keyring{
+		keypairMgr: keypairMgr,
+		indexPath:  filepath.Join(dir, "names.json"),
+		keyIDs:     make(map[string]string),
+	}
+	data, err := ioutil.ReadFile(kr.indexPath)
+	if os.IsNotExist(err) {
+		return kr, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &kr.keyIDs); err != nil {
+		return nil, fmt.Errorf(i18n.G("cannot read key names: %v"), err)
+	}
+	return kr, nil
+}
+
+// openExternalKeyring opens a keyring whose key pairs are held by the
+// given external helper. The helper files each key pair under its name
+// instead of an authority-id, and doesn't let new key pairs be created.
+func openExternalKeyring(helper string) (*keyring, error) {
+	keypairMgr, err := asserts.NewExternalKeypairManager(helper)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := keypairMgr.(asserts.KeypairLister).List()
+	if err != nil {
+		return nil, err
+	}
+	kr := &keyring{
+		keypairMgr: keypairMgr,
+		keyIDs:     make(map[string]string, len(refs)),
+		external:   true,
+	}
+	for _, ref := range refs {
+		if _, ok := kr.keyIDs[ref.AuthorityID]; ok {
+			return nil, fmt.Errorf(i18n.G("external keypair manager lists more than one key named %q"), ref.AuthorityID)
+		}
+		kr.keyIDs[ref.AuthorityID] = ref.KeyID
+	}
+	return kr, nil
+}
+
+// Keys returns the names and ids of the keys, sorted by name.
+func (kr *keyring) Keys() []keyJSON {
+	keys := make([]keyJSON, 0, len(kr.keyIDs))
+	for name, keyID := range kr.keyIDs {
+		keys = append(keys, keyJSON{Name: name, ID: keyID})
+	}
+	sort.Sort(keysByName(keys))
+	return keys
+}
+
+// Key returns the key pair with the given name.
+func (kr *keyring) Key(name string) (asserts.PrivateKey, error) {
+	keyID, ok := kr.keyIDs[name]
+	if !ok {
+		return nil, fmt.Errorf(i18n.G("cannot find key named %q"), name)
+	}
+	if kr.external {
+		return kr.keypairMgr.Get(name, keyID)
+	}
+	return kr.keypairMgr.Get(keyringAuthority, keyID)
+}
+
+// Create generates a new key pair with the given name and returns its id.
+func (kr *keyring) Create(name string) (string, error) {
+	if kr.external {
+		return "", fmt.Errorf(i18n.G("cannot create keys through an external keypair manager"))
+	}
+	if _, ok := kr.keyIDs[name]; ok {
+		return "", fmt.Errorf(i18n.G("key named %q already exists"), name)
+	}
+	db, err := asserts.OpenDatabase(&asserts.DatabaseConfig{KeypairManager: kr.keypairMgr})
+	if err != nil {
+		return "", err
+	}
+	keyID, err := db.GenerateKey(keyringAuthority)
+	if err != nil {
+		return "", err
+	}
+	kr.keyIDs[name] = keyID
+	data, err := json.Marshal(kr.keyIDs)
+	if err != nil {
+		return "", err
+	}
+	if err := osutil.AtomicWriteFile(kr.indexPath, data, 0600, 0); err != nil {
+		return "", fmt.Errorf(i18n.G("cannot store key names: %v"), err)
+	}
+	return keyID, nil
+}
+
+type keysByName []keyJSON
+
+func (keys keysByName) Len() int           { return len(keys) }
+func (keys keysByName) Swap(i, j int)      { keys[i], keys[j] = keys[j], keys[i] }
+func (keys keysByName) Less(i, j int) bool { return keys[i].Name < keys[j].Name }
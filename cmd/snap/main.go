@@ -39,7 +39,7 @@ var (
 )
 
 type options struct {
-	// No global options yet
+	Format string `long:"format" default:"table" choice:"table" choice:"json" choice:"yaml" description:"Output format"`
 }
 
 var optionsData options
@@ -57,6 +57,9 @@ var commands []*cmdInfo
 // experimentalCommands holds information about all experimental commands.
 var experimentalCommands []*cmdInfo
 
+// debugCommands holds information about all debug commands.
+var debugCommands []*cmdInfo
+
 // addCommand replaces parser.addCommand() in a way that is compatible with
 // re-constructing a pristine parser.
 func addCommand(name, shortHelp, longHelp string, builder func() flags.Commander) *cmdInfo {
@@ -84,6 +87,20 @@ func addExperimentalCommand(name, shortHelp, longHelp string, builder func() fla
 	return info
 }
 
+// addDebugCommand replaces parser.addCommand() in a way that is
+// compatible with re-constructing a pristine parser. It is meant for
+// adding debug commands.
+func addDebugCommand(name, shortHelp, longHelp string, builder func() flags.Commander) *cmdInfo {
+	info := &cmdInfo{
+		name:      name,
+		shortHelp: shortHelp,
+		longHelp:  longHelp,
+		builder:   builder,
+	}
+	debugCommands = append(debugCommands, info)
+	return info
+}
+
 // Parser creates and populates a fresh parser.
 // Since commands have local state a fresh parser is required to isolate tests
 // from each other.
@@ -112,6 +129,20 @@ func Parser() *flags.Parser {
 		}
 		cmd.Hidden = c.hidden
 	}
+	// Add the debug command
+	debugCommand, err := parser.AddCommand("debug", shortDebugHelp, longDebugHelp, &cmdDebug{})
+	if err != nil {
+		logger.Panicf("cannot add command %q: %v", "debug", err)
+	}
+	debugCommand.Hidden = true
+	// Add all the sub-commands of the debug command
+	for _, c := range debugCommands {
+		cmd, err := debugCommand.AddCommand(c.name, c.shortHelp, strings.TrimSpace(c.longHelp), c.builder())
+		if err != nil {
+			logger.Panicf("cannot add debug command %q: %v", c.name, err)
+		}
+		cmd.Hidden = c.hidden
+	}
 	return parser
 }
 
@@ -131,6 +162,15 @@ func init() {
 }
 
 func main() {
+	// the wrappers of the apps are symlinks to snap, named after the app
+	if snapApp, ok := wrapperApp(os.Args[0]); ok {
+		if err := snapRunApp(resolveApp(snapApp), os.Args[1:], runOptions{}); err != nil {
+			fmt.Fprintf(Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
 	if err := run(); err != nil {
 		fmt.Fprintf(Stderr, "error: %v\n", err)
 		os.Exit(1)
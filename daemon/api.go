@@ -30,6 +30,8 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -40,6 +42,8 @@ import (
 	"github.com/ubuntu-core/snappy/i18n"
 	"github.com/ubuntu-core/snappy/interfaces"
 	"github.com/ubuntu-core/snappy/overlord/auth"
+	"github.com/ubuntu-core/snappy/overlord/denialstate"
+	"github.com/ubuntu-core/snappy/overlord/devicestate"
 	"github.com/ubuntu-core/snappy/overlord/ifacestate"
 	"github.com/ubuntu-core/snappy/overlord/snapstate"
 	"github.com/ubuntu-core/snappy/overlord/state"
@@ -48,6 +52,7 @@ import (
 	"github.com/ubuntu-core/snappy/snap"
 	"github.com/ubuntu-core/snappy/snappy"
 	"github.com/ubuntu-core/snappy/store"
+	"github.com/ubuntu-core/snappy/systemd"
 )
 
 var api = []*Command{
@@ -59,11 +64,17 @@ var api = []*Command{
 	findCmd,
 	snapsCmd,
 	snapCmd,
+	snapDenialsCmd,
+	snapSecurityCmd,
+	appsCmd,
+	logsCmd,
+	aliasesCmd,
 	//FIXME: renenable config for GA
 	//snapConfigCmd,
 	interfacesCmd,
 	assertsCmd,
 	assertsFindManyCmd,
+	assertsCheckCmd,
 	eventsCmd,
 	stateChangeCmd,
 	stateChangesCmd,
@@ -119,6 +130,37 @@ var (
 		GET:    getSnapInfo,
 		POST:   postSnap,
 	}
+	snapDenialsCmd = &Command{
+		Path:   "/v2/snaps/{name}/denials",
+		UserOK: true,
+		GET:    getSnapDenials,
+	}
+
+	snapSecurityCmd = &Command{
+		Path:   "/v2/snaps/{name}/security",
+		UserOK: true,
+		GET:    getSnapSecurity,
+	}
+
+	appsCmd = &Command{
+		Path:   "/v2/apps",
+		UserOK: true,
+		GET:    getAppsInfo,
+		POST:   postApps,
+	}
+
+	logsCmd = &Command{
+		Path: "/v2/logs",
+		GET:  getLogs,
+	}
+
+	aliasesCmd = &Command{
+		Path:   "/v2/aliases",
+		UserOK: true,
+		GET:    getAliases,
+		POST:   changeAliases,
+	}
+
 	//FIXME: renenable config for GA
 	/*
 		snapConfigCmd = &Command{
@@ -137,8 +179,10 @@ var (
 
 	// TODO: allow to post assertions for UserOK? they are verified anyway
 	assertsCmd = &Command{
-		Path: "/v2/assertions",
-		POST: doAssert,
+		Path:   "/v2/assertions",
+		UserOK: true,
+		GET:    assertsBundle,
+		POST:   doAssert,
 	}
 
 	assertsFindManyCmd = &Command{
@@ -147,6 +191,11 @@ var (
 		GET:    assertsFindMany,
 	}
 
+	assertsCheckCmd = &Command{
+		Path: "/v2/debug/check-assertions",
+		POST: checkAssertions,
+	}
+
 	eventsCmd = &Command{
 		Path: "/v2/events",
 		GET:  getEvents,
@@ -171,6 +220,30 @@ func sysInfo(c *Command, r *http.Request) Response {
 		"series": release.Series,
 	}
 
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+	model, err := devicestate.Model(st)
+	switch err {
+	case nil:
+		m["brand"] = model.BrandID()
+		m["model"] = model.Model()
+		if model.Store() != "" {
+			m["store"] = model.Store()
+		}
+	case state.ErrNoState:
+	default:
+		return InternalError("%v", err)
+	}
+
+	device, err := auth.Device(st)
+	if err != nil {
+		return InternalError("%v", err)
+	}
+	if device.Serial != "" {
+		m["serial"] = device.Serial
+	}
+
 	return SyncResponse(m, nil)
 }
 
@@ -292,11 +365,23 @@ func UserFromRequest(st *state.State, req *http.Request) (*auth.UserState, error
 type metarepo interface {
 	Snap(string, string, store.Authenticator) (*snap.Info, error)
 	FindSnaps(string, string, store.Authenticator) ([]*snap.Info, error)
+	Assertion(*asserts.AssertionType, []string, store.Authenticator) (asserts.Assertion, error)
 	SuggestedCurrency() string
 }
 
-var newRemoteRepo = func() metarepo {
-	return snappy.NewConfiguredUbuntuStoreSnapRepository()
+var newRemoteRepo = func(storeID string) metarepo {
+	return snappy.NewConfiguredUbuntuStoreSnapRepositoryForStoreID(storeID)
+}
+
+// storeRepo returns the store named by the model of the device.
+func storeRepo(st *state.State) (metarepo, error) {
+	st.Lock()
+	storeID, err := devicestate.StoreID(st)
+	st.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return newRemoteRepo(storeID), nil
 }
 
 var muxVars = mux.Vars
@@ -306,7 +391,10 @@ func getSnapInfo(c *Command, r *http.Request) Response {
 	name := vars["name"]
 
 	channel := ""
-	remoteRepo := newRemoteRepo()
+	remoteRepo, err := storeRepo(c.d.overlord.State())
+	if err != nil {
+		return InternalError("%v", err)
+	}
 	suggestedCurrency := remoteRepo.SuggestedCurrency()
 
 	localSnap, active, err := localSnapInfo(c.d.overlord.State(), name)
@@ -339,7 +427,20 @@ func getSnapInfo(c *Command, r *http.Request) Response {
 		return InternalError("route can't build URL for snap %s: %v", name, err)
 	}
 
-	result := webify(mapSnap(localSnap, active, remoteSnap), url.String())
+	m := mapSnap(localSnap, active, remoteSnap)
+	if localSnap != nil {
+		st := c.d.overlord.State()
+		st.Lock()
+		revoked, err := revokedSnaps(st)
+		st.Unlock()
+		if err != nil {
+			return InternalError("cannot consult state: %v", err)
+		}
+		if revoked[name] {
+			m["revoked"] = true
+		}
+	}
+	result := webify(m, url.String())
 
 	meta := &Meta{
 		SuggestedCurrency: suggestedCurrency,
@@ -347,6 +448,98 @@ func getSnapInfo(c *Command, r *http.Request) Response {
 	return SyncResponse(result, meta)
 }
 
+// denialJSON aids in marshaling a Denial along with suggested interfaces.
+type denialJSON struct {
+	*denialstate.Denial
+	Interfaces []string `json:"interfaces,omitempty"`
+}
+
+// getSnapDenials returns the operations denied to the given snap.
+func getSnapDenials(c *Command, r *http.Request) Response {
+	name := muxVars(r)["name"]
+
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	var snapst snapstate.SnapState
+	if err := snapstateGet(st, name, &snapst); err == state.ErrNoState {
+		return NotFound("cannot find snap %q", name)
+	} else if err != nil {
+		return InternalError("%v", err)
+	}
+
+	denials, err := denialstate.Denials(st, name)
+	if err != nil {
+		return InternalError("%v", err)
+	}
+	result := make([]denialJSON, len(denials))
+	for i, d := range denials {
+		result[i] = denialJSON{
+			Denial:     d,
+			Interfaces: denialstate.SuggestInterfaces(d),
+		}
+	}
+	return SyncResponse(result, nil)
+}
+
+// snippetSourceJSON aids in marshaling SnippetSource into JSON.
+type snippetSourceJSON struct {
+	Interface string              `json:"interface"`
+	Plug      *interfaces.PlugRef `json:"plug,omitempty"`
+	Slot      *interfaces.SlotRef `json:"slot,omitempty"`
+	Snippet   string              `json:"snippet"`
+}
+
+// securityProfileJSON aids in marshaling SecurityProfile into JSON.
+type securityProfileJSON struct {
+	Profile  string              `json:"profile"`
+	Snippets []snippetSourceJSON `json:"snippets,omitempty"`
+}
+
+// getSnapSecurity returns the security profiles generated for the apps of the given snap.
+func getSnapSecurity(c *Command, r *http.Request) Response {
+	name := muxVars(r)["name"]
+
+	st := c.d.overlord.State()
+	st.Lock()
+	var snapst snapstate.SnapState
+	var info *snap.Info
+	err := snapstateGet(st, name, &snapst)
+	if err == nil {
+		info, err = snapstate.Current(st, name)
+	}
+	st.Unlock()
+	if err == state.ErrNoState {
+		return NotFound("cannot find snap %q", name)
+	}
+	if err != nil {
+		return InternalError("%v", err)
+	}
+
+	security, err := c.d.overlord.InterfaceManager().SnapSecurity(info, snapst.DevMode())
+	if err != nil {
+		return InternalError("%v", err)
+	}
+	result := make(map[string]map[string]*securityProfileJSON, len(security))
+	for appName, profiles := range security {
+		result[appName] = make(map[string]*securityProfileJSON, len(profiles))
+		for system, profile := range profiles {
+			profileJSON := &securityProfileJSON{Profile: string(profile.Profile)}
+			for _, source := range profile.Sources {
+				profileJSON.Snippets = append(profileJSON.Snippets, snippetSourceJSON{
+					Interface: source.Interface,
+					Plug:      source.Plug,
+					Slot:      source.Slot,
+					Snippet:   string(source.Snippet),
+				})
+			}
+			result[appName][string(system)] = profileJSON
+		}
+	}
+	return SyncResponse(result, nil)
+}
+
 func webify(result map[string]interface{}, resource string) map[string]interface{} {
 	result["resource"] = resource
 
@@ -381,7 +574,10 @@ func searchStore(c *Command, r *http.Request) Response {
 		return InternalError("%v", err)
 	}
 
-	remoteRepo := newRemoteRepo()
+	remoteRepo, err := storeRepo(c.d.overlord.State())
+	if err != nil {
+		return InternalError("%v", err)
+	}
 	found, err := remoteRepo.FindSnaps(query.Get("q"), query.Get("channel"), auther)
 	if err != nil {
 		return InternalError("%v", err)
@@ -455,7 +651,10 @@ func getSnapsInfo(c *Command, r *http.Request) Response {
 	if includeStore {
 		remoteSnapMap = make(map[string]*snap.Info)
 
-		remoteRepo := newRemoteRepo()
+		remoteRepo, err := storeRepo(c.d.overlord.State())
+		if err != nil {
+			return InternalError("%v", err)
+		}
 
 		auther, err := c.d.auther(r)
 		if err != nil && err != auth.ErrInvalidAuth {
@@ -513,7 +712,11 @@ func getSnapsInfo(c *Command, r *http.Request) Response {
 		// strings.Contains(name, "") is true
 		if strings.Contains(name, searchTerm) {
 			active := about.snapst.Active
-			addResult(name, mapSnap(info, active, remoteSnapMap[name]))
+			m := mapSnap(info, active, remoteSnapMap[name])
+			if about.revoked {
+				m["revoked"] = true
+			}
+			addResult(name, m)
 		}
 	}
 
@@ -1034,18 +1237,429 @@ func changeInterfaces(c *Command, r *http.Request) Response {
 	return AsyncResponse(nil, &Meta{Change: change.ID()})
 }
 
-func doAssert(c *Command, r *http.Request) Response {
-	b, err := ioutil.ReadAll(r.Body)
+// appJSON aids in marshaling the apps of snaps, with the status of the
+// ones that are services, into JSON.
+type appJSON struct {
+	Snap    string `json:"snap"`
+	Name    string `json:"name"`
+	Daemon  string `json:"daemon,omitempty"`
+	Enabled bool   `json:"enabled,omitempty"`
+	Active  bool   `json:"active,omitempty"`
+}
+
+type appInfoOptions struct {
+	service bool
+}
+
+// appInfosFor returns the apps of the active snaps the given names
+// refer to, either "snap" for all the apps of a snap or "snap.app" for
+// a single app, sorted by snap and app name. No names refer to the
+// apps of all the active snaps. With opts.service only services are
+// returned.
+func appInfosFor(st *state.State, names []string, opts appInfoOptions) ([]*snap.AppInfo, Response) {
+	snapNames := make(map[string]bool)
+	requested := make(map[string]bool)
+	for _, name := range names {
+		requested[name] = true
+		snapNames[strings.SplitN(name, ".", 2)[0]] = true
+	}
+
+	st.Lock()
+	infos, err := snapstate.ActiveInfos(st)
+	st.Unlock()
+	if err != nil {
+		return nil, InternalError("cannot list snaps: %v", err)
+	}
+	found := make(map[string]*snap.Info, len(infos))
+	for _, info := range infos {
+		found[info.Name()] = info
+	}
+	for snapName := range snapNames {
+		if found[snapName] == nil {
+			return nil, NotFound("cannot find snap %q", snapName)
+		}
+	}
+
+	var appInfos []*snap.AppInfo
+	seen := make(map[string]bool)
+	for snapName, info := range found {
+		if len(names) > 0 && !snapNames[snapName] {
+			continue
+		}
+		wholeSnap := len(names) == 0 || requested[snapName]
+		for appName, app := range info.Apps {
+			fullName := snapName + "." + appName
+			if !wholeSnap && !requested[fullName] {
+				continue
+			}
+			seen[fullName] = true
+			if opts.service && app.Daemon == "" {
+				if wholeSnap {
+					continue
+				}
+				return nil, BadRequest("app %q is not a service", fullName)
+			}
+			appInfos = append(appInfos, app)
+		}
+	}
+	for _, name := range names {
+		if strings.Contains(name, ".") && !seen[name] {
+			return nil, NotFound("cannot find app %q", name)
+		}
+	}
+
+	sort.Sort(bySnapApp(appInfos))
+	return appInfos, nil
+}
+
+type bySnapApp []*snap.AppInfo
+
+func (a bySnapApp) Len() int      { return len(a) }
+func (a bySnapApp) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a bySnapApp) Less(i, j int) bool {
+	iName := a[i].Snap.Name()
+	jName := a[j].Snap.Name()
+	if iName == jName {
+		return a[i].Name < a[j].Name
+	}
+	return iName < jName
+}
+
+func splitAppNames(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+func getAppsInfo(c *Command, r *http.Request) Response {
+	query := r.URL.Query()
+
+	var opts appInfoOptions
+	switch sel := query.Get("select"); sel {
+	case "":
+	case "service":
+		opts.service = true
+	default:
+		return BadRequest("invalid select parameter: %q", sel)
+	}
+
+	appInfos, rsp := appInfosFor(c.d.overlord.State(), splitAppNames(query.Get("names")), opts)
+	if rsp != nil {
+		return rsp
+	}
+
+	sysd := systemd.New(dirs.GlobalRootDir, &progress.NullProgress{})
+	results := make([]appJSON, len(appInfos))
+	for i, app := range appInfos {
+		results[i] = appJSON{
+			Snap:   app.Snap.Name(),
+			Name:   app.Name,
+			Daemon: app.Daemon,
+		}
+		if app.Daemon == "" {
+			continue
+		}
+		serviceName := filepath.Base(app.ServiceFile())
+		status, err := sysd.ServiceStatus(serviceName)
+		if err != nil {
+			return InternalError("cannot get status of service %q: %v", serviceName, err)
+		}
+		results[i].Enabled = status.UnitFileState == "enabled"
+		results[i].Active = status.ActiveState == "active"
+	}
+
+	return SyncResponse(results, nil)
+}
+
+// appInstruction is an action performed on the services of snaps.
+type appInstruction struct {
+	Action string   `json:"action"`
+	Names  []string `json:"names"`
+}
+
+var appActionSummary = map[string]string{
+	"start":   i18n.G("Start"),
+	"stop":    i18n.G("Stop"),
+	"restart": i18n.G("Restart"),
+	"enable":  i18n.G("Enable and start"),
+	"disable": i18n.G("Disable and stop"),
+}
+
+func postApps(c *Command, r *http.Request) Response {
+	var inst appInstruction
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&inst); err != nil {
+		return BadRequest("cannot decode request body into service action: %v", err)
+	}
+	summary, ok := appActionSummary[inst.Action]
+	if !ok {
+		return BadRequest("unknown service action %q", inst.Action)
+	}
+	if len(inst.Names) == 0 {
+		return BadRequest("cannot %s services: no services given", inst.Action)
+	}
+
+	st := c.d.overlord.State()
+	appInfos, rsp := appInfosFor(st, inst.Names, appInfoOptions{service: true})
+	if rsp != nil {
+		return rsp
+	}
+	if len(appInfos) == 0 {
+		return BadRequest("cannot %s services: no services found among %s", inst.Action, strings.Join(inst.Names, ", "))
+	}
+
+	var snapNames []string
+	appNames := make(map[string][]string)
+	for _, app := range appInfos {
+		snapName := app.Snap.Name()
+		if appNames[snapName] == nil {
+			snapNames = append(snapNames, snapName)
+		}
+		appNames[snapName] = append(appNames[snapName], app.Name)
+	}
+
+	st.Lock()
+	defer st.Unlock()
+
+	var tsets []*state.TaskSet
+	var quoted []string
+	for _, snapName := range snapNames {
+		ts, err := snapstate.ServiceControl(st, snapName, appNames[snapName], inst.Action)
+		if err != nil {
+			return BadRequest("cannot %s services: %v", inst.Action, err)
+		}
+		tsets = append(tsets, ts)
+		for _, appName := range appNames[snapName] {
+			quoted = append(quoted, strconv.Quote(snapName+"."+appName))
+		}
+	}
+
+	msg := fmt.Sprintf(i18n.G("%s services %s"), summary, strings.Join(quoted, ", "))
+	chg := newChange(st, inst.Action+"-services", msg, tsets)
+	st.EnsureBefore(0)
+
+	return AsyncResponse(nil, &Meta{Change: chg.ID()})
+}
+
+// logJSON aids in marshaling the entries of the log of services into JSON.
+type logJSON struct {
+	Timestamp string `json:"timestamp"`
+	Message   string `json:"message"`
+	SID       string `json:"sid"`
+	PID       string `json:"pid,omitempty"`
+}
+
+// defaultLogLines is the number of log entries returned unless asked otherwise.
+const defaultLogLines = 10
+
+func getLogs(c *Command, r *http.Request) Response {
+	query := r.URL.Query()
+
+	n := defaultLogLines
+	if s := query.Get("n"); s != "" {
+		if s == "all" {
+			n = -1
+		} else {
+			m, err := strconv.Atoi(s)
+			if err != nil || m < 0 {
+				return BadRequest(`invalid value for n: %q, must be a positive number or "all"`, s)
+			}
+			n = m
+		}
+	}
+
+	follow := false
+	if s := query.Get("follow"); s != "" {
+		var err error
+		follow, err = strconv.ParseBool(s)
+		if err != nil {
+			return BadRequest("invalid value for follow: %q", s)
+		}
+	}
+
+	var since time.Time
+	if s := query.Get("since"); s != "" {
+		var err error
+		since, err = time.Parse(time.RFC3339, s)
+		if err != nil {
+			return BadRequest("invalid value for since: %q, must be an RFC3339 timestamp", s)
+		}
+	}
+
+	appInfos, rsp := appInfosFor(c.d.overlord.State(), splitAppNames(query.Get("names")), appInfoOptions{service: true})
+	if rsp != nil {
+		return rsp
+	}
+	if len(appInfos) == 0 {
+		if follow {
+			return BadRequest("cannot follow logs: no services found")
+		}
+		return SyncResponse([]logJSON{}, nil)
+	}
+
+	serviceNames := make([]string, len(appInfos))
+	for i, app := range appInfos {
+		serviceNames[i] = filepath.Base(app.ServiceFile())
+	}
+
+	sysd := systemd.New(dirs.GlobalRootDir, &progress.NullProgress{})
+	stream, err := sysd.StreamLogs(serviceNames, systemd.LogOptions{
+		N:      n,
+		Follow: follow,
+		Since:  since,
+	})
 	if err != nil {
-		return BadRequest("reading assert request body gave %v", err)
+		return InternalError("cannot get logs: %v", err)
+	}
+	if follow {
+		return logStreamResponse{stream: stream}
 	}
-	a, err := asserts.Decode(b)
+	defer stream.Close()
+
+	results := []logJSON{}
+	for {
+		log, err := stream.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return InternalError("cannot get logs: %v", err)
+		}
+		results = append(results, logToJSON(log))
+	}
+
+	return SyncResponse(results, nil)
+}
+
+func logToJSON(log systemd.Log) logJSON {
+	pid, _ := log["_PID"].(string)
+	return logJSON{
+		Timestamp: log.Timestamp(),
+		Message:   log.Message(),
+		SID:       log.SID(),
+		PID:       pid,
+	}
+}
+
+// aliasStatus aids in marshaling the aliases of the apps of snaps, and
+// whether they are enabled, into JSON.
+type aliasStatus struct {
+	App    string `json:"app"`
+	Status string `json:"status"`
+}
+
+func getAliases(c *Command, r *http.Request) Response {
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	enabled, err := snapstate.Aliases(st)
 	if err != nil {
-		return BadRequest("can't decode request body into an assertion: %v", err)
+		return InternalError("cannot list aliases: %v", err)
+	}
+	infos, err := snapstate.ActiveInfos(st)
+	if err != nil {
+		return InternalError("cannot list aliases: %v", err)
+	}
+
+	results := make(map[string]map[string]aliasStatus)
+	for _, info := range infos {
+		for _, app := range info.Apps {
+			for _, alias := range app.Aliases {
+				status := "disabled"
+				if enabled[info.Name()][alias] == app.Name {
+					status = "enabled"
+				}
+				if results[info.Name()] == nil {
+					results[info.Name()] = make(map[string]aliasStatus)
+				}
+				results[info.Name()][alias] = aliasStatus{App: app.Name, Status: status}
+			}
+		}
+	}
+
+	return SyncResponse(results, nil)
+}
+
+// aliasAction is an action on the aliases of the apps of a snap.
+type aliasAction struct {
+	Action  string   `json:"action"`
+	Snap    string   `json:"snap"`
+	Aliases []string `json:"aliases"`
+}
+
+func changeAliases(c *Command, r *http.Request) Response {
+	var a aliasAction
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&a); err != nil {
+		return BadRequest("cannot decode request body into an alias action: %v", err)
+	}
+	if a.Action != "alias" && a.Action != "unalias" {
+		return BadRequest("unsupported alias action: %q", a.Action)
+	}
+	if a.Snap == "" || len(a.Aliases) == 0 {
+		return BadRequest("cannot %s: a snap and at least one alias are required", a.Action)
+	}
+	for _, alias := range a.Aliases {
+		if err := snap.ValidateAlias(alias); err != nil {
+			return BadRequest("cannot %s: %v", a.Action, err)
+		}
+	}
+
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	var ts *state.TaskSet
+	var err error
+	switch a.Action {
+	case "alias":
+		ts, err = snapstate.Alias(st, a.Snap, a.Aliases)
+	case "unalias":
+		ts, err = snapstate.Unalias(st, a.Snap, a.Aliases)
+	}
+	if err != nil {
+		return BadRequest("%v", err)
+	}
+
+	chg := newChange(st, a.Action, ts.Tasks()[0].Summary(), []*state.TaskSet{ts})
+	st.EnsureBefore(0)
+
+	return AsyncResponse(nil, &Meta{Change: chg.ID()})
+}
+
+func doAssert(c *Command, r *http.Request) Response {
+	var batch []asserts.Assertion
+	dec := asserts.NewDecoder(r.Body)
+	for {
+		a, err := dec.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return BadRequest("can't decode request body into an assertion: %v", err)
+		}
+		batch = append(batch, a)
+	}
+	if len(batch) == 0 {
+		return BadRequest("can't decode request body into an assertion: no assertions found")
+	}
+	// prerequisites missing from the request are fetched from the store
+	retrieve := func(ref *asserts.Ref) (asserts.Assertion, error) {
+		auther, err := c.d.auther(r)
+		if err != nil && err != auth.ErrInvalidAuth {
+			return nil, err
+		}
+		remoteRepo, err := storeRepo(c.d.overlord.State())
+		if err != nil {
+			return nil, err
+		}
+		return remoteRepo.Assertion(ref.Type, ref.PrimaryKey, auther)
 	}
 	// TODO/XXX: turn this into a Change/Task combination
 	amgr := c.d.overlord.AssertManager()
-	if err := amgr.DB().Add(a); err != nil {
+	if err := amgr.AddBatch(batch, retrieve); err != nil {
 		// TODO: have a specific error to be able to return  409 for not newer revision?
 		return BadRequest("assert failed: %v", err)
 	}
@@ -1056,6 +1670,15 @@ func doAssert(c *Command, r *http.Request) Response {
 	}
 }
 
+// checkAssertions checks the consistency of the system assertion
+// database, rebuilding the indexes it keeps for searches.
+func checkAssertions(c *Command, r *http.Request) Response {
+	if err := c.d.overlord.AssertManager().DB().Reindex(); err != nil {
+		return InternalError("cannot check assertions: %v", err)
+	}
+	return SyncResponse(true, nil)
+}
+
 func assertsFindMany(c *Command, r *http.Request) Response {
 	assertTypeName := muxVars(r)["assertType"]
 	assertType := asserts.Type(assertTypeName)
@@ -1077,6 +1700,105 @@ func assertsFindMany(c *Command, r *http.Request) Response {
 	return AssertResponse(assertions, true)
 }
 
+// bundleRefs returns references to the assertions directly needed to
+// verify the given installed snaps and, if withModel, the device model
+// and serial.
+// Note that the state must be locked by the caller.
+func bundleRefs(st *state.State, db asserts.RODatabase, snapNames []string, withModel bool) ([]*asserts.Ref, error) {
+	var refs []*asserts.Ref
+	if withModel {
+		model, err := devicestate.Model(st)
+		if err == state.ErrNoState {
+			return nil, fmt.Errorf("device has no model yet")
+		}
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, model.Ref())
+		device, err := auth.Device(st)
+		if err != nil {
+			return nil, err
+		}
+		if device.Serial != "" {
+			refs = append(refs, &asserts.Ref{
+				Type:       asserts.DeviceSerialType,
+				PrimaryKey: []string{device.Brand, device.Model, device.Serial},
+			})
+		}
+	}
+
+	for _, name := range snapNames {
+		var snapst snapstate.SnapState
+		err := snapstate.Get(st, name, &snapst)
+		if err != nil && err != state.ErrNoState {
+			return nil, err
+		}
+		cur := snapst.Current()
+		if cur == nil {
+			return nil, fmt.Errorf("snap %q is not installed", name)
+		}
+		if cur.SnapID == "" {
+			return nil, fmt.Errorf("snap %q has no assertions", name)
+		}
+		refs = append(refs, &asserts.Ref{
+			Type:       asserts.SnapDeclarationType,
+			PrimaryKey: []string{release.Series, cur.SnapID},
+		})
+		snapRevs, err := db.FindMany(asserts.SnapRevisionType, map[string]string{
+			"series":        release.Series,
+			"snap-id":       cur.SnapID,
+			"snap-revision": strconv.Itoa(cur.Revision),
+		})
+		if err == asserts.ErrNotFound {
+			return nil, fmt.Errorf("cannot find snap-revision assertion for snap %q revision %d", name, cur.Revision)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range snapRevs {
+			refs = append(refs, a.Ref())
+		}
+	}
+	return refs, nil
+}
+
+// assertsBundle returns a dependency closed bundle of the assertions
+// needed to verify the snaps named by the "snaps" comma separated
+// parameter and, if "model" is true, the device model and serial.
+func assertsBundle(c *Command, r *http.Request) Response {
+	q := r.URL.Query()
+	var snapNames []string
+	if snaps := q.Get("snaps"); snaps != "" {
+		snapNames = strings.Split(snaps, ",")
+	}
+	withModel := false
+	switch q.Get("model") {
+	case "", "false":
+	case "true":
+		withModel = true
+	default:
+		return BadRequest(`"model" must be "true" or "false"`)
+	}
+	if len(snapNames) == 0 && !withModel {
+		return BadRequest(`must ask for the bundle of some snaps or of the model`)
+	}
+
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	db := c.d.overlord.AssertManager().DB()
+	refs, err := bundleRefs(st, db, snapNames, withModel)
+	if err != nil {
+		return BadRequest("cannot bundle assertions: %v", err)
+	}
+	bundle, err := db.Bundle(refs)
+	if err != nil {
+		return InternalError("cannot bundle assertions: %v", err)
+	}
+	return AssertResponse(bundle, true)
+}
+
 func getEvents(c *Command, r *http.Request) Response {
 	return EventResponse(c.d.hub)
 }
@@ -33,6 +33,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"gopkg.in/check.v1"
@@ -41,6 +42,8 @@ import (
 	"github.com/ubuntu-core/snappy/dirs"
 	"github.com/ubuntu-core/snappy/interfaces"
 	"github.com/ubuntu-core/snappy/overlord/auth"
+	"github.com/ubuntu-core/snappy/overlord/denialstate"
+	"github.com/ubuntu-core/snappy/overlord/devicestate"
 	"github.com/ubuntu-core/snappy/overlord/ifacestate"
 	"github.com/ubuntu-core/snappy/overlord/snapstate"
 	"github.com/ubuntu-core/snappy/overlord/state"
@@ -48,6 +51,7 @@ import (
 	"github.com/ubuntu-core/snappy/snap/snaptest"
 	"github.com/ubuntu-core/snappy/snappy"
 	"github.com/ubuntu-core/snappy/store"
+	"github.com/ubuntu-core/snappy/systemd"
 	"github.com/ubuntu-core/snappy/testutil"
 )
 
@@ -58,6 +62,8 @@ type apiSuite struct {
 	searchTerm        string
 	channel           string
 	suggestedCurrency string
+	storeAssertions   []asserts.Assertion
+	storeID           string
 	overlord          *fakeOverlord
 	d                 *Daemon
 	auther            store.Authenticator
@@ -82,6 +88,16 @@ func (s *apiSuite) FindSnaps(searchTerm, channel string, auther store.Authentica
 	return s.rsnaps, s.err
 }
 
+func (s *apiSuite) Assertion(assertType *asserts.AssertionType, primaryKey []string, auther store.Authenticator) (asserts.Assertion, error) {
+	ref := &asserts.Ref{Type: assertType, PrimaryKey: primaryKey}
+	for _, a := range s.storeAssertions {
+		if a.Ref().Unique() == ref.Unique() {
+			return a, nil
+		}
+	}
+	return nil, store.ErrAssertionNotFound
+}
+
 func (s *apiSuite) SuggestedCurrency() string {
 	return s.suggestedCurrency
 }
@@ -91,7 +107,8 @@ func (s *apiSuite) muxVars(*http.Request) map[string]string {
 }
 
 func (s *apiSuite) SetUpSuite(c *check.C) {
-	newRemoteRepo = func() metarepo {
+	newRemoteRepo = func(storeID string) metarepo {
+		s.storeID = storeID
 		return s
 	}
 	muxVars = s.muxVars
@@ -110,6 +127,8 @@ func (s *apiSuite) SetUpTest(c *check.C) {
 
 	s.rsnaps = nil
 	s.suggestedCurrency = ""
+	s.storeAssertions = nil
+	s.storeID = ""
 	s.err = nil
 	s.vars = nil
 	s.overlord = &fakeOverlord{
@@ -241,13 +260,17 @@ func (s *apiSuite) TestSnapInfoOneIntegration(c *check.C) {
 			"GBP": 1.23,
 			"EUR": 2.34,
 		},
+		License: "GPL-3.0",
+		Channels: map[string]*snap.ChannelSnapInfo{
+			"stable": {Channel: "stable", Revision: 20, Version: "v2"},
+		},
 	}}
 	s.suggestedCurrency = "GBP"
 
 	// we have v0 [r5] installed
 	s.mkInstalledInState(c, d, "foo", "bar", "v0", 5, false, "")
 	// and v1 [r10] is current
-	s.mkInstalledInState(c, d, "foo", "bar", "v1", 10, true, "")
+	s.mkInstalledInState(c, d, "foo", "bar", "v1", 10, true, "apps: {foo: {command: bin/foo}, svc: {command: bin/svc, daemon: simple}}\n")
 
 	req, err := http.NewRequest("GET", "/v2/snaps/gfoo", nil)
 	c.Assert(err, check.IsNil)
@@ -291,6 +314,14 @@ func (s *apiSuite) TestSnapInfoOneIntegration(c *check.C) {
 				"GBP": 1.23,
 				"EUR": 2.34,
 			},
+			"license": "GPL-3.0",
+			"channels": map[string]*snap.ChannelSnapInfo{
+				"stable": {Channel: "stable", Revision: 20, Version: "v2"},
+			},
+			"apps": []appJSON{
+				{Snap: "foo", Name: "foo"},
+				{Snap: "foo", Name: "svc", Daemon: "simple"},
+			},
 		},
 		Meta: meta,
 	}
@@ -334,6 +365,114 @@ func (s *apiSuite) TestSnapInfoNoneFound(c *check.C) {
 	c.Check(getSnapInfo(snapCmd, req).Self(nil, nil).(*resp).Status, check.Equals, http.StatusNotFound)
 }
 
+func (s *apiSuite) TestSnapDenials(c *check.C) {
+	d := s.daemon(c)
+	s.vars = map[string]string{"name": "foo"}
+	snapstateGet = func(s *state.State, name string, snapst *snapstate.SnapState) error {
+		return nil
+	}
+
+	st := d.overlord.State()
+	st.Lock()
+	st.Set("denials", map[string][]*denialstate.Denial{
+		"foo": {{
+			Snap:      "foo",
+			App:       "bar",
+			Security:  "apparmor",
+			Operation: "open",
+			Target:    "/var/log/syslog",
+			Mask:      "r",
+			Count:     2,
+		}},
+	})
+	st.Unlock()
+
+	req, err := http.NewRequest("GET", "/v2/snaps/foo/denials", nil)
+	c.Assert(err, check.IsNil)
+	rsp := getSnapDenials(snapDenialsCmd, req).(*resp)
+
+	c.Check(rsp.Type, check.Equals, ResponseTypeSync)
+	c.Check(rsp.Status, check.Equals, http.StatusOK)
+	denials, ok := rsp.Result.([]denialJSON)
+	c.Assert(ok, check.Equals, true)
+	c.Assert(denials, check.HasLen, 1)
+	c.Check(denials[0].App, check.Equals, "bar")
+	c.Check(denials[0].Target, check.Equals, "/var/log/syslog")
+	c.Check(denials[0].Count, check.Equals, 2)
+	c.Check(denials[0].Interfaces, check.DeepEquals, []string{"log-observe"})
+}
+
+func (s *apiSuite) TestSnapDenialsNotFound(c *check.C) {
+	s.daemon(c)
+	s.vars = map[string]string{"name": "foo"}
+	snapstateGet = func(s *state.State, name string, snapst *snapstate.SnapState) error {
+		return state.ErrNoState
+	}
+
+	req, err := http.NewRequest("GET", "/v2/snaps/foo/denials", nil)
+	c.Assert(err, check.IsNil)
+	rsp := getSnapDenials(snapDenialsCmd, req).(*resp)
+	c.Check(rsp.Status, check.Equals, http.StatusNotFound)
+}
+
+func (s *apiSuite) TestSnapSecurity(c *check.C) {
+	backend := &interfaces.TestSecurityBackend{
+		ProfilesCallback: func(snapInfo *snap.Info, devMode bool, repo *interfaces.Repository) (map[string][]byte, error) {
+			c.Check(snapInfo.Name(), check.Equals, "foo")
+			c.Check(devMode, check.Equals, false)
+			return map[string][]byte{"app": []byte("profile")}, nil
+		},
+	}
+	restore := ifacestate.MockSecurityBackends([]interfaces.SecurityBackend{backend})
+	defer restore()
+
+	d := s.daemon(c)
+	s.mkInstalledInState(c, d, "foo", "bar", "v1", 10, true, "apps:\n app:\nplugs:\n plug:\n  interface: test\n")
+	repo := d.overlord.InterfaceManager().Repository()
+	c.Assert(repo.AddInterface(&interfaces.TestInterface{
+		InterfaceName: "test",
+		PermanentPlugSnippetCallback: func(plug *interfaces.Plug, securitySystem interfaces.SecuritySystem) ([]byte, error) {
+			return []byte("snippet"), nil
+		},
+	}), check.IsNil)
+	c.Assert(repo.AddPlug(&interfaces.Plug{PlugInfo: &snap.PlugInfo{
+		Snap:      &snap.Info{SuggestedName: "foo"},
+		Name:      "plug",
+		Interface: "test",
+		Apps:      map[string]*snap.AppInfo{"app": {Name: "app"}},
+	}}), check.IsNil)
+
+	s.vars = map[string]string{"name": "foo"}
+	req, err := http.NewRequest("GET", "/v2/snaps/foo/security", nil)
+	c.Assert(err, check.IsNil)
+	rsp := getSnapSecurity(snapSecurityCmd, req).(*resp)
+
+	c.Check(rsp.Type, check.Equals, ResponseTypeSync)
+	c.Check(rsp.Status, check.Equals, http.StatusOK)
+	c.Check(rsp.Result, check.DeepEquals, map[string]map[string]*securityProfileJSON{
+		"app": {
+			"test": {
+				Profile: "profile",
+				Snippets: []snippetSourceJSON{{
+					Interface: "test",
+					Plug:      &interfaces.PlugRef{Snap: "foo", Name: "plug"},
+					Snippet:   "snippet",
+				}},
+			},
+		},
+	})
+}
+
+func (s *apiSuite) TestSnapSecurityNotFound(c *check.C) {
+	s.daemon(c)
+	s.vars = map[string]string{"name": "foo"}
+
+	req, err := http.NewRequest("GET", "/v2/snaps/foo/security", nil)
+	c.Assert(err, check.IsNil)
+	rsp := getSnapSecurity(snapSecurityCmd, req).(*resp)
+	c.Check(rsp.Status, check.Equals, http.StatusNotFound)
+}
+
 func (s *apiSuite) TestSnapInfoIgnoresRemoteErrors(c *check.C) {
 	s.vars = map[string]string{"name": "foo"}
 	s.err = errors.New("weird")
@@ -421,6 +560,8 @@ func (s *apiSuite) TestListIncludesAll(c *check.C) {
 
 	exceptions := []string{ // keep sorted, for scanning ease
 		"api",
+		"appActionSummary",
+		"defaultLogLines",
 		"maxReadBuflen",
 		"muxVars",
 		"newRemoteRepo",
@@ -465,6 +606,8 @@ func (s *apiSuite) TestSysInfo(c *check.C) {
 	c.Check(sysInfoCmd.DELETE, check.IsNil)
 	c.Assert(sysInfoCmd.GET, check.NotNil)
 
+	s.daemon(c)
+
 	rec := httptest.NewRecorder()
 	c.Check(sysInfoCmd.Path, check.Equals, "/v2/system-info")
 
@@ -483,6 +626,8 @@ func (s *apiSuite) TestSysInfo(c *check.C) {
 }
 
 func (s *apiSuite) TestSysInfoStore(c *check.C) {
+	s.daemon(c)
+
 	rec := httptest.NewRecorder()
 	c.Check(sysInfoCmd.Path, check.Equals, "/v2/system-info")
 
@@ -501,6 +646,177 @@ func (s *apiSuite) TestSysInfoStore(c *check.C) {
 	c.Check(rsp.Result, check.DeepEquals, expected)
 }
 
+// daemonWithModel returns a daemon for a device with a model
+// assertion naming its own store.
+func (s *apiSuite) daemonWithModel(c *check.C) *Daemon {
+	signingDB, keyID := s.mockTrustedKey(c)
+	return s.daemonWithModelSignedBy(c, signingDB, keyID)
+}
+
+// daemonWithModelSignedBy is like daemonWithModel with the model
+// signed with the given trusted key.
+func (s *apiSuite) daemonWithModelSignedBy(c *check.C, signingDB *asserts.Database, keyID string) *Daemon {
+	d := s.daemon(c)
+
+	a, err := signingDB.Sign(asserts.ModelType, map[string]interface{}{
+		"authority-id":   "canonical",
+		"series":         "16",
+		"brand-id":       "canonical",
+		"model":          "pc",
+		"os":             "ubuntu-core",
+		"architecture":   "amd64",
+		"gadget":         "pc",
+		"kernel":         "pc-kernel",
+		"store":          "canonical-pc-store",
+		"class":          "general",
+		"allowed-modes":  "",
+		"required-snaps": "",
+		"timestamp":      time.Now().UTC().Format(time.RFC3339),
+	}, nil, keyID)
+	c.Assert(err, check.IsNil)
+	c.Assert(d.overlord.AssertManager().DB().Add(a), check.IsNil)
+
+	st := d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+	c.Assert(devicestate.SetModel(st, a.(*asserts.Model)), check.IsNil)
+
+	return d
+}
+
+func (s *apiSuite) TestSysInfoModel(c *check.C) {
+	s.daemonWithModel(c)
+
+	rec := httptest.NewRecorder()
+	sysInfoCmd.GET(sysInfoCmd, nil).ServeHTTP(rec, nil)
+	c.Check(rec.Code, check.Equals, 200)
+
+	expected := map[string]interface{}{
+		"series": "16",
+		"brand":  "canonical",
+		"model":  "pc",
+		"store":  "canonical-pc-store",
+	}
+	var rsp resp
+	c.Assert(json.Unmarshal(rec.Body.Bytes(), &rsp), check.IsNil)
+	c.Check(rsp.Status, check.Equals, 200)
+	c.Check(rsp.Result, check.DeepEquals, expected)
+}
+
+func (s *apiSuite) TestAssertsBundle(c *check.C) {
+	signingDB, keyID := s.mockTrustedKey(c)
+	d := s.daemonWithModelSignedBy(c, signingDB, keyID)
+
+	tstamp := time.Now().UTC().Format(time.RFC3339)
+	identity, err := signingDB.Sign(asserts.IdentityType, map[string]interface{}{
+		"authority-id": "canonical",
+		"account-id":   "developer1",
+		"display-name": "Developer",
+		"validation":   "unproven",
+		"timestamp":    tstamp,
+	}, nil, keyID)
+	c.Assert(err, check.IsNil)
+	snapDecl, err := signingDB.Sign(asserts.SnapDeclarationType, map[string]interface{}{
+		"authority-id": "canonical",
+		"series":       "16",
+		"snap-id":      "snap-id-1",
+		"snap-name":    "foo",
+		"publisher-id": "developer1",
+		"gates":        "",
+		"timestamp":    tstamp,
+	}, nil, keyID)
+	c.Assert(err, check.IsNil)
+	snapRev, err := signingDB.Sign(asserts.SnapRevisionType, map[string]interface{}{
+		"authority-id":  "canonical",
+		"series":        "16",
+		"snap-id":       "snap-id-1",
+		"snap-digest":   "sha512-abc",
+		"snap-size":     "1000",
+		"snap-revision": "5",
+		"developer-id":  "developer1",
+		"timestamp":     tstamp,
+	}, nil, keyID)
+	c.Assert(err, check.IsNil)
+	db := d.overlord.AssertManager().DB()
+	for _, a := range []asserts.Assertion{identity, snapDecl, snapRev} {
+		c.Assert(db.Add(a), check.IsNil)
+	}
+
+	st := d.overlord.State()
+	st.Lock()
+	snapstate.Set(st, "foo", &snapstate.SnapState{
+		Sequence: []*snap.SideInfo{{OfficialName: "foo", SnapID: "snap-id-1", Revision: 5}},
+		Active:   true,
+	})
+	st.Unlock()
+
+	req, err := http.NewRequest("GET", "/v2/assertions?snaps=foo&model=true", nil)
+	c.Assert(err, check.IsNil)
+	rec := httptest.NewRecorder()
+	assertsCmd.GET(assertsCmd, req).ServeHTTP(rec, req)
+	c.Check(rec.Code, check.Equals, http.StatusOK, check.Commentf("body %q", rec.Body))
+	c.Check(rec.HeaderMap.Get("X-Ubuntu-Assertions-Count"), check.Equals, "4")
+
+	var types []string
+	dec := asserts.NewDecoder(rec.Body)
+	for {
+		a, err := dec.Decode()
+		if err == io.EOF {
+			break
+		}
+		c.Assert(err, check.IsNil)
+		types = append(types, a.Type().Name)
+	}
+	c.Check(types, check.DeepEquals, []string{"model", "identity", "snap-declaration", "snap-revision"})
+}
+
+func (s *apiSuite) TestAssertsBundleErrors(c *check.C) {
+	s.daemonWithModel(c)
+
+	for _, t := range []struct{ query, err string }{
+		{"", `must ask for the bundle of some snaps or of the model`},
+		{"model=maybe", `"model" must be "true" or "false"`},
+		{"snaps=foo", `cannot bundle assertions: snap "foo" is not installed`},
+	} {
+		req, err := http.NewRequest("GET", "/v2/assertions?"+t.query, nil)
+		c.Assert(err, check.IsNil)
+		rsp := assertsBundle(assertsCmd, req).(*resp)
+		c.Check(rsp.Status, check.Equals, http.StatusBadRequest)
+		c.Check(rsp.Result.(*errorResult).Message, check.Equals, t.err)
+	}
+}
+
+func (s *apiSuite) TestSysInfoSerial(c *check.C) {
+	s.daemonWithModel(c)
+
+	st := s.d.overlord.State()
+	st.Lock()
+	device, err := auth.Device(st)
+	c.Assert(err, check.IsNil)
+	device.Serial = "9999"
+	c.Assert(auth.SetDevice(st, device), check.IsNil)
+	st.Unlock()
+
+	rec := httptest.NewRecorder()
+	sysInfoCmd.GET(sysInfoCmd, nil).ServeHTTP(rec, nil)
+	c.Check(rec.Code, check.Equals, 200)
+
+	var rsp resp
+	c.Assert(json.Unmarshal(rec.Body.Bytes(), &rsp), check.IsNil)
+	c.Check(rsp.Result.(map[string]interface{})["serial"], check.Equals, "9999")
+}
+
+func (s *apiSuite) TestFindUsesModelStore(c *check.C) {
+	s.daemonWithModel(c)
+
+	req, err := http.NewRequest("GET", "/v2/find?q=hi", nil)
+	c.Assert(err, check.IsNil)
+
+	rsp := searchStore(findCmd, req).(*resp)
+	c.Check(rsp.Status, check.Equals, http.StatusOK)
+	c.Check(s.storeID, check.Equals, "canonical-pc-store")
+}
+
 func (s *apiSuite) makeMyAppsServer(statusCode int, data string) *httptest.Server {
 	mockMyAppsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(statusCode)
@@ -806,6 +1122,33 @@ func (s *apiSuite) TestSnapsInfoOnePerIntegration(c *check.C) {
 	}
 }
 
+func (s *apiSuite) TestSnapsInfoRevoked(c *check.C) {
+	d := s.daemon(c)
+
+	s.mkInstalledInState(c, d, "foo", "bar", "v1", 5, true, "")
+	s.mkInstalledInState(c, d, "baz", "qux", "v2", 10, true, "")
+
+	st := d.overlord.State()
+	st.Lock()
+	st.Set("revoked-snaps", map[string]bool{"foo": true})
+	st.Unlock()
+
+	req, err := http.NewRequest("GET", "/v2/snaps", nil)
+	c.Assert(err, check.IsNil)
+	rsp, ok := getSnapsInfo(snapsCmd, req).(*resp)
+	c.Assert(ok, check.Equals, true)
+
+	snaps := snapList(rsp.Result)
+	c.Assert(snaps, check.HasLen, 2)
+	for _, snp := range snaps {
+		if snp["name"] == "foo" {
+			c.Check(snp["revoked"], check.Equals, true)
+		} else {
+			c.Check(snp["revoked"], check.IsNil)
+		}
+	}
+}
+
 func (s *apiSuite) TestSnapsInfoOnlyLocal(c *check.C) {
 	d := s.daemon(c)
 
@@ -837,6 +1180,9 @@ func (s *apiSuite) TestFind(c *check.C) {
 			OfficialName: "store",
 			Developer:    "foo",
 		},
+		Channels: map[string]*snap.ChannelSnapInfo{
+			"edge": {Channel: "edge", Revision: 2, Version: "1.1"},
+		},
 	}}
 
 	req, err := http.NewRequest("GET", "/v2/find?q=hi&channel=potato", nil)
@@ -848,6 +1194,9 @@ func (s *apiSuite) TestFind(c *check.C) {
 	c.Assert(snaps, check.HasLen, 1)
 	c.Assert(snaps[0]["name"], check.Equals, "store")
 	c.Check(snaps[0]["prices"], check.IsNil)
+	c.Check(snaps[0]["channels"], check.DeepEquals, map[string]interface{}{
+		"edge": map[string]interface{}{"channel": "edge", "revision": 2., "version": "1.1"},
+	})
 
 	c.Check(rsp.SuggestedCurrency, check.Equals, "EUR")
 
@@ -1780,6 +2129,15 @@ func (s *apiSuite) TestInterfaces(c *check.C) {
 	var body map[string]interface{}
 	err = json.Unmarshal(rec.Body.Bytes(), &body)
 	c.Check(err, check.IsNil)
+	// the attribute schemas of builtin interfaces are listed too
+	result := body["result"].(map[string]interface{})
+	schemas := result["schemas"].(map[string]interface{})
+	c.Check(schemas["bool-file"], check.DeepEquals, map[string]interface{}{
+		"slot": []interface{}{
+			map[string]interface{}{"name": "path", "type": "string", "required": true},
+		},
+	})
+	delete(result, "schemas")
 	c.Check(body, check.DeepEquals, map[string]interface{}{
 		"result": map[string]interface{}{
 			"plugs": []interface{}{
@@ -2344,6 +2702,16 @@ func (s *apiSuite) TestAssertOK(c *check.C) {
 	c.Check(err, check.IsNil)
 }
 
+func (s *apiSuite) TestCheckAssertions(c *check.C) {
+	s.daemon(c)
+	req, err := http.NewRequest("POST", "/v2/debug/check-assertions", nil)
+	c.Assert(err, check.IsNil)
+	rsp := checkAssertions(assertsCheckCmd, req).(*resp)
+	c.Check(rsp.Type, check.Equals, ResponseTypeSync)
+	c.Check(rsp.Status, check.Equals, http.StatusOK)
+	c.Check(rsp.Result, check.Equals, true)
+}
+
 func (s *apiSuite) TestAssertInvalid(c *check.C) {
 	// Setup
 	buf := bytes.NewBufferString("blargh")
@@ -2372,6 +2740,133 @@ func (s *apiSuite) TestAssertError(c *check.C) {
 	c.Check(rec.Body.String(), testutil.Contains, "assert failed")
 }
 
+// mockTrustedKey sets up a freshly generated, self-signed trusted
+// account key and returns a database able to sign with it.
+func (s *apiSuite) mockTrustedKey(c *check.C) (signingDB *asserts.Database, keyID string) {
+	signingDB, err := asserts.OpenDatabase(&asserts.DatabaseConfig{
+		KeypairManager: asserts.NewMemoryKeypairManager(),
+	})
+	c.Assert(err, check.IsNil)
+	keyID, err = signingDB.GenerateKey("canonical")
+	c.Assert(err, check.IsNil)
+	pubKey, err := signingDB.PublicKey("canonical", keyID)
+	c.Assert(err, check.IsNil)
+	encodedPubKey, err := asserts.EncodePublicKey(pubKey)
+	c.Assert(err, check.IsNil)
+	trustedKey, err := signingDB.Sign(asserts.AccountKeyType, map[string]interface{}{
+		"authority-id":           "canonical",
+		"account-id":             "canonical",
+		"public-key-id":          keyID,
+		"public-key-fingerprint": pubKey.Fingerprint(),
+		"since":                  time.Now().AddDate(-1, 0, 0).UTC().Format(time.RFC3339),
+		"until":                  time.Now().AddDate(1, 0, 0).UTC().Format(time.RFC3339),
+	}, encodedPubKey, keyID)
+	c.Assert(err, check.IsNil)
+	c.Assert(os.MkdirAll(filepath.Dir(dirs.SnapTrustedAccountKey), 0755), check.IsNil)
+	c.Assert(ioutil.WriteFile(dirs.SnapTrustedAccountKey, asserts.Encode(trustedKey), 0640), check.IsNil)
+	return signingDB, keyID
+}
+
+func (s *apiSuite) TestAssertStreamFetchesPrerequisites(c *check.C) {
+	signingDB, keyID := s.mockTrustedKey(c)
+	tstamp := time.Now().UTC().Format(time.RFC3339)
+	identity, err := signingDB.Sign(asserts.IdentityType, map[string]interface{}{
+		"authority-id": "canonical",
+		"account-id":   "developer1",
+		"display-name": "Developer",
+		"validation":   "unproven",
+		"timestamp":    tstamp,
+	}, nil, keyID)
+	c.Assert(err, check.IsNil)
+	snapDecl, err := signingDB.Sign(asserts.SnapDeclarationType, map[string]interface{}{
+		"authority-id": "canonical",
+		"series":       "16",
+		"snap-id":      "snap-id-1",
+		"snap-name":    "foo",
+		"publisher-id": "developer1",
+		"gates":        "",
+		"timestamp":    tstamp,
+	}, nil, keyID)
+	c.Assert(err, check.IsNil)
+	// the identity is only available from the store
+	s.storeAssertions = []asserts.Assertion{identity}
+	d := s.daemon(c)
+
+	buf := new(bytes.Buffer)
+	enc := asserts.NewEncoder(buf)
+	c.Assert(enc.Encode(snapDecl), check.IsNil)
+	req, err := http.NewRequest("POST", "/v2/assertions", buf)
+	c.Assert(err, check.IsNil)
+	rsp := doAssert(assertsCmd, req).Self(nil, nil).(*resp)
+	c.Check(rsp.Status, check.Equals, http.StatusOK, check.Commentf("%v", rsp.Result))
+
+	db := d.overlord.AssertManager().DB()
+	_, err = snapDecl.Ref().Resolve(db.Find)
+	c.Check(err, check.IsNil)
+	_, err = identity.Ref().Resolve(db.Find)
+	c.Check(err, check.IsNil)
+}
+
+func (s *apiSuite) TestAssertStreamOutOfOrder(c *check.C) {
+	signingDB, keyID := s.mockTrustedKey(c)
+	tstamp := time.Now().UTC().Format(time.RFC3339)
+	identity, err := signingDB.Sign(asserts.IdentityType, map[string]interface{}{
+		"authority-id": "canonical",
+		"account-id":   "developer1",
+		"display-name": "Developer",
+		"validation":   "unproven",
+		"timestamp":    tstamp,
+	}, nil, keyID)
+	c.Assert(err, check.IsNil)
+	snapDecl, err := signingDB.Sign(asserts.SnapDeclarationType, map[string]interface{}{
+		"authority-id": "canonical",
+		"series":       "16",
+		"snap-id":      "snap-id-1",
+		"snap-name":    "foo",
+		"publisher-id": "developer1",
+		"gates":        "",
+		"timestamp":    tstamp,
+	}, nil, keyID)
+	c.Assert(err, check.IsNil)
+	d := s.daemon(c)
+
+	// the prerequisite comes after the assertion needing it
+	buf := new(bytes.Buffer)
+	enc := asserts.NewEncoder(buf)
+	c.Assert(enc.Encode(snapDecl), check.IsNil)
+	c.Assert(enc.Encode(identity), check.IsNil)
+	req, err := http.NewRequest("POST", "/v2/assertions", buf)
+	c.Assert(err, check.IsNil)
+	rsp := doAssert(assertsCmd, req).Self(nil, nil).(*resp)
+	c.Check(rsp.Status, check.Equals, http.StatusOK, check.Commentf("%v", rsp.Result))
+
+	db := d.overlord.AssertManager().DB()
+	_, err = snapDecl.Ref().Resolve(db.Find)
+	c.Check(err, check.IsNil)
+}
+
+func (s *apiSuite) TestAssertStreamMissingPrerequisite(c *check.C) {
+	signingDB, keyID := s.mockTrustedKey(c)
+	snapDecl, err := signingDB.Sign(asserts.SnapDeclarationType, map[string]interface{}{
+		"authority-id": "canonical",
+		"series":       "16",
+		"snap-id":      "snap-id-1",
+		"snap-name":    "foo",
+		"publisher-id": "developer1",
+		"gates":        "",
+		"timestamp":    time.Now().UTC().Format(time.RFC3339),
+	}, nil, keyID)
+	c.Assert(err, check.IsNil)
+	s.daemon(c)
+
+	req, err := http.NewRequest("POST", "/v2/assertions", bytes.NewReader(asserts.Encode(snapDecl)))
+	c.Assert(err, check.IsNil)
+	rec := httptest.NewRecorder()
+	assertsCmd.POST(assertsCmd, req).ServeHTTP(rec, req)
+	c.Check(rec.Code, check.Equals, 400)
+	c.Check(rec.Body.String(), testutil.Contains, `cannot retrieve identity (developer1): assertion not found`)
+}
+
 func (s *apiSuite) TestAssertsFindManyAll(c *check.C) {
 	// Setup
 	os.MkdirAll(filepath.Dir(dirs.SnapTrustedAccountKey), 0755)
@@ -2778,3 +3273,357 @@ func (s *apiSuite) TestStateChangeAbortIsReady(c *check.C) {
 		"message": fmt.Sprintf("cannot abort change %s with nothing pending", ids[0]),
 	})
 }
+
+const servicesYaml = `apps:
+ app:
+  command: bin/app
+ svc1:
+  command: bin/svc1
+  daemon: simple
+ svc2:
+  command: bin/svc2
+  daemon: forking
+`
+
+func (s *apiSuite) mockSystemctl(c *check.C) (sysdLog *[][]string, restore func()) {
+	var log [][]string
+	old := systemd.SystemctlCmd
+	systemd.SystemctlCmd = func(args ...string) ([]byte, error) {
+		log = append(log, args)
+		if args[0] != "show" {
+			return nil, nil
+		}
+		switch args[len(args)-1] {
+		case "snap.foo.svc1.service":
+			return []byte("Id=snap.foo.svc1.service\nLoadState=loaded\nActiveState=active\nSubState=running\nUnitFileState=enabled\n"), nil
+		default:
+			return []byte("Id=snap.foo.svc2.service\nLoadState=loaded\nActiveState=inactive\nSubState=dead\nUnitFileState=disabled\n"), nil
+		}
+	}
+	return &log, func() { systemd.SystemctlCmd = old }
+}
+
+func (s *apiSuite) TestAppsInfo(c *check.C) {
+	_, restore := s.mockSystemctl(c)
+	defer restore()
+
+	d := s.daemon(c)
+	s.mkInstalledInState(c, d, "foo", "bar", "v1", 10, true, servicesYaml)
+	s.mkInstalledInState(c, d, "baz", "bar", "v1", 10, false, servicesYaml)
+
+	req, err := http.NewRequest("GET", "/v2/apps", nil)
+	c.Assert(err, check.IsNil)
+	rsp := getAppsInfo(appsCmd, req).(*resp)
+
+	c.Check(rsp.Type, check.Equals, ResponseTypeSync)
+	c.Check(rsp.Status, check.Equals, http.StatusOK)
+	c.Check(rsp.Result, check.DeepEquals, []appJSON{
+		{Snap: "foo", Name: "app"},
+		{Snap: "foo", Name: "svc1", Daemon: "simple", Enabled: true, Active: true},
+		{Snap: "foo", Name: "svc2", Daemon: "forking"},
+	})
+}
+
+func (s *apiSuite) TestAppsInfoSelectServices(c *check.C) {
+	_, restore := s.mockSystemctl(c)
+	defer restore()
+
+	d := s.daemon(c)
+	s.mkInstalledInState(c, d, "foo", "bar", "v1", 10, true, servicesYaml)
+	s.mkInstalledInState(c, d, "other", "bar", "v1", 10, true, servicesYaml)
+
+	req, err := http.NewRequest("GET", "/v2/apps?names=foo,other.svc2&select=service", nil)
+	c.Assert(err, check.IsNil)
+	rsp := getAppsInfo(appsCmd, req).(*resp)
+
+	c.Check(rsp.Status, check.Equals, http.StatusOK)
+	c.Check(rsp.Result, check.DeepEquals, []appJSON{
+		{Snap: "foo", Name: "svc1", Daemon: "simple", Enabled: true, Active: true},
+		{Snap: "foo", Name: "svc2", Daemon: "forking"},
+		{Snap: "other", Name: "svc2", Daemon: "forking"},
+	})
+}
+
+func (s *apiSuite) TestAppsInfoErrors(c *check.C) {
+	_, restore := s.mockSystemctl(c)
+	defer restore()
+
+	d := s.daemon(c)
+	s.mkInstalledInState(c, d, "foo", "bar", "v1", 10, true, servicesYaml)
+	s.mkInstalledInState(c, d, "baz", "bar", "v1", 10, false, servicesYaml)
+
+	for _, t := range []struct {
+		query  string
+		status int
+		msg    string
+	}{
+		{"?names=baz", http.StatusNotFound, `cannot find snap "baz"`},
+		{"?names=foo.svc3", http.StatusNotFound, `cannot find app "foo.svc3"`},
+		{"?names=foo.app&select=service", http.StatusBadRequest, `app "foo.app" is not a service`},
+		{"?select=all", http.StatusBadRequest, `invalid select parameter: "all"`},
+	} {
+		req, err := http.NewRequest("GET", "/v2/apps"+t.query, nil)
+		c.Assert(err, check.IsNil)
+		rsp := getAppsInfo(appsCmd, req).(*resp)
+		c.Check(rsp.Status, check.Equals, t.status, check.Commentf(t.query))
+		c.Check(rsp.Result.(*errorResult).Message, check.Equals, t.msg, check.Commentf(t.query))
+	}
+}
+
+func (s *apiSuite) TestPostApps(c *check.C) {
+	_, restore := s.mockSystemctl(c)
+	defer restore()
+
+	d := s.daemon(c)
+	s.mkInstalledInState(c, d, "foo", "bar", "v1", 10, true, servicesYaml)
+	s.mkInstalledInState(c, d, "other", "bar", "v1", 10, true, servicesYaml)
+
+	d.overlord.Loop()
+	defer d.overlord.Stop()
+
+	buf := bytes.NewBufferString(`{"action": "start", "names": ["other.svc2", "foo"]}`)
+	req, err := http.NewRequest("POST", "/v2/apps", buf)
+	c.Assert(err, check.IsNil)
+	rsp := postApps(appsCmd, req).(*resp)
+
+	c.Check(rsp.Type, check.Equals, ResponseTypeAsync)
+
+	st := d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+	chg := st.Change(rsp.Change)
+	c.Assert(chg, check.NotNil)
+	c.Check(chg.Kind(), check.Equals, "start-services")
+	c.Check(chg.Summary(), check.Equals, `Start services "foo.svc1", "foo.svc2", "other.svc2"`)
+	tasks := chg.Tasks()
+	c.Assert(tasks, check.HasLen, 2)
+	for _, t := range tasks {
+		c.Check(t.Kind(), check.Equals, "service-control")
+	}
+}
+
+func (s *apiSuite) TestPostAppsErrors(c *check.C) {
+	d := s.daemon(c)
+	s.mkInstalledInState(c, d, "foo", "bar", "v1", 10, true, servicesYaml)
+	s.mkInstalledInState(c, d, "nosvc", "bar", "v1", 10, true, "apps:\n app:\n  command: bin/app\n")
+
+	for _, t := range []struct {
+		body   string
+		status int
+		msg    string
+	}{
+		{`{"action": "reload", "names": ["foo"]}`, http.StatusBadRequest, `unknown service action "reload"`},
+		{`{"action": "start"}`, http.StatusBadRequest, `cannot start services: no services given`},
+		{`{"action": "stop", "names": ["foo.app"]}`, http.StatusBadRequest, `app "foo.app" is not a service`},
+		{`{"action": "restart", "names": ["nosvc"]}`, http.StatusBadRequest, `cannot restart services: no services found among nosvc`},
+		{`{"action": "start", "names": ["bar"]}`, http.StatusNotFound, `cannot find snap "bar"`},
+		{`{`, http.StatusBadRequest, `cannot decode request body into service action: .*`},
+	} {
+		req, err := http.NewRequest("POST", "/v2/apps", bytes.NewBufferString(t.body))
+		c.Assert(err, check.IsNil)
+		rsp := postApps(appsCmd, req).(*resp)
+		c.Check(rsp.Status, check.Equals, t.status, check.Commentf(t.body))
+		c.Check(rsp.Result.(*errorResult).Message, check.Matches, t.msg, check.Commentf(t.body))
+	}
+
+	st := d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+	c.Check(st.Changes(), check.HasLen, 0)
+}
+
+const jctlOutput = `{"__REALTIME_TIMESTAMP": "42000000", "MESSAGE": "hello", "SYSLOG_IDENTIFIER": "svc1", "_PID": "99"}
+{"__REALTIME_TIMESTAMP": "44000000", "MESSAGE": "bye", "SYSLOG_IDENTIFIER": "svc2"}
+`
+
+func mockJournalctl(out string) (svcs *[]string, opts *systemd.LogOptions, restore func()) {
+	svcs = new([]string)
+	opts = new(systemd.LogOptions)
+	old := systemd.JournalctlStreamCmd
+	systemd.JournalctlStreamCmd = func(s []string, o systemd.LogOptions) (io.ReadCloser, error) {
+		*svcs = s
+		*opts = o
+		return ioutil.NopCloser(strings.NewReader(out)), nil
+	}
+	return svcs, opts, func() { systemd.JournalctlStreamCmd = old }
+}
+
+func (s *apiSuite) TestLogs(c *check.C) {
+	jctlSvcs, jctlOpts, restore := mockJournalctl(jctlOutput)
+	defer restore()
+
+	d := s.daemon(c)
+	s.mkInstalledInState(c, d, "foo", "bar", "v1", 10, true, servicesYaml)
+
+	req, err := http.NewRequest("GET", "/v2/logs?names=foo", nil)
+	c.Assert(err, check.IsNil)
+	rsp := getLogs(logsCmd, req).(*resp)
+
+	c.Check(rsp.Status, check.Equals, http.StatusOK)
+	c.Check(*jctlSvcs, check.DeepEquals, []string{"snap.foo.svc1.service", "snap.foo.svc2.service"})
+	c.Check(*jctlOpts, check.DeepEquals, systemd.LogOptions{N: defaultLogLines})
+	c.Check(rsp.Result, check.DeepEquals, []logJSON{
+		{Timestamp: "1970-01-01T00:00:42.000000Z", Message: "hello", SID: "svc1", PID: "99"},
+		{Timestamp: "1970-01-01T00:00:44.000000Z", Message: "bye", SID: "svc2"},
+	})
+
+	req, err = http.NewRequest("GET", "/v2/logs?names=foo.svc2&n=all&since=2016-06-01T12:00:00Z", nil)
+	c.Assert(err, check.IsNil)
+	rsp = getLogs(logsCmd, req).(*resp)
+
+	c.Check(rsp.Status, check.Equals, http.StatusOK)
+	c.Check(*jctlSvcs, check.DeepEquals, []string{"snap.foo.svc2.service"})
+	c.Check(jctlOpts.N, check.Equals, -1)
+	c.Check(jctlOpts.Follow, check.Equals, false)
+	c.Check(jctlOpts.Since.Equal(time.Date(2016, 6, 1, 12, 0, 0, 0, time.UTC)), check.Equals, true)
+}
+
+func (s *apiSuite) TestLogsFollow(c *check.C) {
+	jctlSvcs, jctlOpts, restore := mockJournalctl(jctlOutput)
+	defer restore()
+
+	d := s.daemon(c)
+	s.mkInstalledInState(c, d, "foo", "bar", "v1", 10, true, servicesYaml)
+
+	req, err := http.NewRequest("GET", "/v2/logs?names=foo.svc1&n=1&follow=true", nil)
+	c.Assert(err, check.IsNil)
+	rsp, ok := getLogs(logsCmd, req).(logStreamResponse)
+	c.Assert(ok, check.Equals, true)
+	c.Check(*jctlSvcs, check.DeepEquals, []string{"snap.foo.svc1.service"})
+	c.Check(*jctlOpts, check.DeepEquals, systemd.LogOptions{N: 1, Follow: true})
+
+	rec := httptest.NewRecorder()
+	rsp.ServeHTTP(rec, req)
+	c.Check(rec.Code, check.Equals, http.StatusOK)
+	c.Check(rec.HeaderMap.Get("Content-Type"), check.Equals, "application/x-ndjson")
+	c.Check(rec.Flushed, check.Equals, true)
+	c.Check(rec.Body.String(), check.Equals, `{"timestamp":"1970-01-01T00:00:42.000000Z","message":"hello","sid":"svc1","pid":"99"}
+{"timestamp":"1970-01-01T00:00:44.000000Z","message":"bye","sid":"svc2"}
+`)
+}
+
+func (s *apiSuite) TestLogsFollowNoServices(c *check.C) {
+	s.daemon(c)
+
+	req, err := http.NewRequest("GET", "/v2/logs?follow=true", nil)
+	c.Assert(err, check.IsNil)
+	rsp := getLogs(logsCmd, req).(*resp)
+
+	c.Check(rsp.Status, check.Equals, http.StatusBadRequest)
+	c.Check(rsp.Result.(*errorResult).Message, check.Equals, "cannot follow logs: no services found")
+}
+
+func (s *apiSuite) TestLogsBadN(c *check.C) {
+	s.daemon(c)
+
+	req, err := http.NewRequest("GET", "/v2/logs?n=-1", nil)
+	c.Assert(err, check.IsNil)
+	rsp := getLogs(logsCmd, req).(*resp)
+
+	c.Check(rsp.Status, check.Equals, http.StatusBadRequest)
+	c.Check(rsp.Result.(*errorResult).Message, check.Equals, `invalid value for n: "-1", must be a positive number or "all"`)
+
+	req, err = http.NewRequest("GET", "/v2/logs?follow=maybe", nil)
+	c.Assert(err, check.IsNil)
+	rsp = getLogs(logsCmd, req).(*resp)
+
+	c.Check(rsp.Status, check.Equals, http.StatusBadRequest)
+	c.Check(rsp.Result.(*errorResult).Message, check.Equals, `invalid value for follow: "maybe"`)
+
+	req, err = http.NewRequest("GET", "/v2/logs?since=yesterday", nil)
+	c.Assert(err, check.IsNil)
+	rsp = getLogs(logsCmd, req).(*resp)
+
+	c.Check(rsp.Status, check.Equals, http.StatusBadRequest)
+	c.Check(rsp.Result.(*errorResult).Message, check.Equals, `invalid value for since: "yesterday", must be an RFC3339 timestamp`)
+}
+
+const aliasesYaml = `apps:
+ convert:
+  command: bin/convert
+  aliases: [convert, tool-convert]
+ resize:
+  command: bin/resize
+  aliases: [resize]
+`
+
+func (s *apiSuite) TestGetAliases(c *check.C) {
+	d := s.daemon(c)
+	s.mkInstalledInState(c, d, "tool", "bar", "v1", 10, true, aliasesYaml)
+	s.mkInstalledInState(c, d, "foo", "bar", "v1", 10, true, servicesYaml)
+
+	st := d.overlord.State()
+	st.Lock()
+	st.Set("aliases", map[string]map[string]string{
+		"tool": {"convert": "convert"},
+	})
+	st.Unlock()
+
+	req, err := http.NewRequest("GET", "/v2/aliases", nil)
+	c.Assert(err, check.IsNil)
+	rsp := getAliases(aliasesCmd, req).(*resp)
+
+	c.Check(rsp.Status, check.Equals, http.StatusOK)
+	c.Check(rsp.Result, check.DeepEquals, map[string]map[string]aliasStatus{
+		"tool": {
+			"convert":      {App: "convert", Status: "enabled"},
+			"tool-convert": {App: "convert", Status: "disabled"},
+			"resize":       {App: "resize", Status: "disabled"},
+		},
+	})
+}
+
+func (s *apiSuite) TestChangeAliases(c *check.C) {
+	d := s.daemon(c)
+	s.mkInstalledInState(c, d, "tool", "bar", "v1", 10, true, aliasesYaml)
+
+	d.overlord.Loop()
+	defer d.overlord.Stop()
+
+	buf := bytes.NewBufferString(`{"action": "alias", "snap": "tool", "aliases": ["convert", "resize"]}`)
+	req, err := http.NewRequest("POST", "/v2/aliases", buf)
+	c.Assert(err, check.IsNil)
+	rsp := changeAliases(aliasesCmd, req).(*resp)
+
+	c.Check(rsp.Type, check.Equals, ResponseTypeAsync)
+
+	st := d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+	chg := st.Change(rsp.Change)
+	c.Assert(chg, check.NotNil)
+	c.Check(chg.Kind(), check.Equals, "alias")
+	c.Check(chg.Summary(), check.Equals, `Enable aliases "convert", "resize" for snap "tool"`)
+	tasks := chg.Tasks()
+	c.Assert(tasks, check.HasLen, 1)
+	c.Check(tasks[0].Kind(), check.Equals, "alias")
+}
+
+func (s *apiSuite) TestChangeAliasesErrors(c *check.C) {
+	d := s.daemon(c)
+	s.mkInstalledInState(c, d, "tool", "bar", "v1", 10, true, aliasesYaml)
+
+	for _, t := range []struct {
+		body string
+		msg  string
+	}{
+		{`{"action": "rename", "snap": "tool", "aliases": ["convert"]}`, `unsupported alias action: "rename"`},
+		{`{"action": "alias", "snap": "tool"}`, `cannot alias: a snap and at least one alias are required`},
+		{`{"action": "alias", "snap": "tool", "aliases": ["../convert"]}`, `cannot alias: invalid alias name: "../convert"`},
+		{`{"action": "alias", "snap": "tool", "aliases": ["shrink"]}`, `snap "tool" has no alias "shrink"`},
+		{`{"action": "unalias", "snap": "tool", "aliases": ["convert"]}`, `alias "convert" is not enabled for snap "tool"`},
+		{`{"action": "alias", "snap": "other", "aliases": ["convert"]}`, `cannot find snap "other"`},
+		{`{`, `cannot decode request body into an alias action: .*`},
+	} {
+		req, err := http.NewRequest("POST", "/v2/aliases", bytes.NewBufferString(t.body))
+		c.Assert(err, check.IsNil)
+		rsp := changeAliases(aliasesCmd, req).(*resp)
+		c.Check(rsp.Status, check.Equals, http.StatusBadRequest, check.Commentf(t.body))
+		c.Check(rsp.Result.(*errorResult).Message, check.Matches, t.msg, check.Commentf(t.body))
+	}
+
+	st := d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+	c.Check(st.Changes(), check.HasLen, 0)
+}
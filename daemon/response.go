@@ -22,6 +22,7 @@ package daemon
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"mime"
 	"net/http"
 	"path/filepath"
@@ -32,6 +33,7 @@ import (
 	"github.com/ubuntu-core/snappy/asserts"
 	"github.com/ubuntu-core/snappy/logger"
 	"github.com/ubuntu-core/snappy/notifications"
+	"github.com/ubuntu-core/snappy/systemd"
 )
 
 // ResponseType is the response type
@@ -252,6 +254,55 @@ func (e eventResponse) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	e.h.Subscribe(s)
 }
 
+// A logStreamResponse streams the entries of the log of services as
+// line-delimited JSON, until the stream is over or the client goes away.
+type logStreamResponse struct {
+	stream *systemd.LogStream
+}
+
+func (l logStreamResponse) Self(*Command, *http.Request) Response {
+	return l
+}
+
+func (l logStreamResponse) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	defer l.stream.Close()
+
+	if cn, ok := w.(http.CloseNotifier); ok {
+		closed := cn.CloseNotify()
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			select {
+			case <-closed:
+				// interrupts a Next that waits for more entries
+				l.stream.Close()
+			case <-done:
+			}
+		}()
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+
+	enc := json.NewEncoder(w)
+	for {
+		log, err := l.stream.Next()
+		if err != nil {
+			if err != io.EOF {
+				logger.Noticef("cannot stream logs: %v", err)
+			}
+			return
+		}
+		if err := enc.Encode(logToJSON(log)); err != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
 // errorResponder is a callable that produces an error Response.
 // e.g., InternalError("something broke: %v", err), etc.
 type errorResponder func(string, ...interface{}) Response
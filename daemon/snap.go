@@ -23,8 +23,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"time"
 
+	"github.com/ubuntu-core/snappy/overlord/assertstate"
 	"github.com/ubuntu-core/snappy/overlord/snapstate"
 	"github.com/ubuntu-core/snappy/overlord/state"
 	"github.com/ubuntu-core/snappy/snap"
@@ -76,8 +78,23 @@ func localSnapInfo(st *state.State, name string) (info *snap.Info, active bool,
 }
 
 type aboutSnap struct {
-	info   *snap.Info
-	snapst *snapstate.SnapState
+	info    *snap.Info
+	snapst  *snapstate.SnapState
+	revoked bool
+}
+
+// revokedSnaps returns the set of installed snaps affected by the
+// revocation of the keys that signed their assertions.
+func revokedSnaps(st *state.State) (map[string]bool, error) {
+	names, err := assertstate.RevokedSnaps(st)
+	if err != nil {
+		return nil, err
+	}
+	revoked := make(map[string]bool, len(names))
+	for _, name := range names {
+		revoked[name] = true
+	}
+	return revoked, nil
 }
 
 // allLocalSnapInfos returns the information about the all current snaps and their SnapStates.
@@ -89,6 +106,10 @@ func allLocalSnapInfos(st *state.State) ([]aboutSnap, error) {
 	if err != nil {
 		return nil, err
 	}
+	revoked, err := revokedSnaps(st)
+	if err != nil {
+		return nil, err
+	}
 
 	about := make([]aboutSnap, 0, len(snapStates))
 
@@ -102,12 +123,32 @@ func allLocalSnapInfos(st *state.State) ([]aboutSnap, error) {
 			}
 			continue
 		}
-		about = append(about, aboutSnap{info, snapState})
+		about = append(about, aboutSnap{info, snapState, revoked[name]})
 	}
 
 	return about, firstErr
 }
 
+// mapApps lists the apps of the snap, sorted by name.
+func mapApps(info *snap.Info) []appJSON {
+	apps := make([]appJSON, 0, len(info.Apps))
+	for _, app := range info.Apps {
+		apps = append(apps, appJSON{
+			Snap:   info.Name(),
+			Name:   app.Name,
+			Daemon: app.Daemon,
+		})
+	}
+	sort.Sort(appsByName(apps))
+	return apps
+}
+
+type appsByName []appJSON
+
+func (a appsByName) Len() int           { return len(a) }
+func (a appsByName) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a appsByName) Less(i, j int) bool { return a[i].Name < a[j].Name }
+
 // Map a localSnap information plus the given active flag to a
 // map[string]interface{}, augmenting it with the given (purportedly remote)
 // snap.
@@ -212,6 +253,15 @@ func mapSnap(localSnap *snap.Info, active bool, remoteSnap *snap.Info) map[strin
 		result["prices"] = prices
 	}
 
+	if remoteSnap != nil {
+		if remoteSnap.License != "" {
+			result["license"] = remoteSnap.License
+		}
+		if len(remoteSnap.Channels) > 0 {
+			result["channels"] = remoteSnap.Channels
+		}
+	}
+
 	if localSnap != nil {
 		channel := localSnap.Channel
 		if channel != "" {
@@ -220,6 +270,10 @@ func mapSnap(localSnap *snap.Info, active bool, remoteSnap *snap.Info) map[strin
 
 		result["installed-size"] = localSnap.Size
 		result["install-date"] = snapDate(localSnap)
+
+		if len(localSnap.Apps) > 0 {
+			result["apps"] = mapApps(localSnap)
+		}
 	}
 
 	if rollback > -1 {
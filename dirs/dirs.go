@@ -46,13 +46,19 @@ var (
 
 	SnapStateFile string
 
+	SnapSeedDir   string
+	SnapDeviceDir string
+
 	SnapBinariesDir     string
 	SnapServicesDir     string
 	SnapDesktopFilesDir string
 	SnapBusPolicyDir    string
+	CompletersDir       string
 
 	CloudMetaDataFile string
 
+	SnapDenialLogFile string
+
 	ClassicDir string
 )
 
@@ -94,14 +100,20 @@ func SetRootDir(rootdir string) {
 
 	SnapStateFile = filepath.Join(rootdir, snappyDir, "state.json")
 
+	SnapSeedDir = filepath.Join(rootdir, snappyDir, "seed")
+	SnapDeviceDir = filepath.Join(rootdir, snappyDir, "device")
+
 	SnapBinariesDir = filepath.Join(SnapSnapsDir, "bin")
 	SnapServicesDir = filepath.Join(rootdir, "/etc/systemd/system")
 	SnapBusPolicyDir = filepath.Join(rootdir, "/etc/dbus-1/system.d")
+	CompletersDir = filepath.Join(rootdir, snappyDir, "complete")
 
 	CloudMetaDataFile = filepath.Join(rootdir, "/var/lib/cloud/seed/nocloud-net/meta-data")
 
 	SnapUdevRulesDir = filepath.Join(rootdir, "/etc/udev/rules.d")
 
+	SnapDenialLogFile = filepath.Join(rootdir, "/var/log/kern.log")
+
 	LocaleDir = filepath.Join(rootdir, "/usr/share/locale")
 	ClassicDir = filepath.Join(rootdir, "/writable/classic")
 }
@@ -36,35 +36,85 @@ import (
 	"github.com/ubuntu-core/snappy/dirs"
 )
 
+// parserCommand is the name of the program used to load and unload profiles.
+var parserCommand = "apparmor_parser"
+
+// parserArgs returns the arguments passed to apparmor_parser to load profiles.
+func parserArgs() []string {
+	// Use no-expr-simplify since expr-simplify is actually slower on armhf (LP: #1383858)
+	return []string{
+		"--replace", "--write-cache", "-O", "no-expr-simplify",
+		fmt.Sprintf("--cache-loc=%s", dirs.AppArmorCacheDir)}
+}
+
 // LoadProfile loads an apparmor profile from the given file.
 //
 // If no such profile was previously loaded then it is simply added to the kernel.
 // If there was a profile with the same name before, that profile is replaced.
 func LoadProfile(fname string) error {
-	// Use no-expr-simplify since expr-simplify is actually slower on armhf (LP: #1383858)
-	output, err := exec.Command(
-		"apparmor_parser", "--replace", "--write-cache", "-O",
-		"no-expr-simplify", fmt.Sprintf("--cache-loc=%s", dirs.AppArmorCacheDir),
-		fname).CombinedOutput()
+	output, err := exec.Command(parserCommand, append(parserArgs(), fname)...).CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("cannot load apparmor profile: %s\napparmor_parser output:\n%s", err, string(output))
 	}
 	return nil
 }
 
+// LoadProfiles loads apparmor profiles from the given files.
+//
+// All the profiles are compiled and loaded by a single apparmor_parser
+// process, writing binary cache entries to the cache directory so that
+// subsequent loads of unchanged profiles are cheap.
+func LoadProfiles(fnames []string) error {
+	if len(fnames) == 0 {
+		return nil
+	}
+	output, err := exec.Command(parserCommand, append(parserArgs(), fnames...)...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("cannot load apparmor profiles: %s\napparmor_parser output:\n%s", err, string(output))
+	}
+	return nil
+}
+
 // UnloadProfile removes the named profile from the running kernel.
 //
 // The operation is done with: apparmor_parser --remove $name
 // The binary cache file is removed from /var/cache/apparmor
 func UnloadProfile(name string) error {
-	output, err := exec.Command("apparmor_parser", "--remove", name).CombinedOutput()
+	output, err := exec.Command(parserCommand, "--remove", name).CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("cannot unload apparmor profile: %s\napparmor_parser output:\n%s", err, string(output))
 	}
-	err = os.Remove(filepath.Join(dirs.AppArmorCacheDir, name))
-	// It is not an error if the cache file wasn't there to remove.
-	if err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("cannot remove apparmor profile cache: %s", err)
+	return removeCachedProfiles([]string{name})
+}
+
+// UnloadProfiles removes the named profiles from the running kernel.
+//
+// All the profiles are removed by a single apparmor_parser process and the
+// corresponding binary cache files are removed from /var/cache/apparmor
+func UnloadProfiles(names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+	output, err := exec.Command(parserCommand, append([]string{"--remove"}, names...)...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("cannot unload apparmor profiles: %s\napparmor_parser output:\n%s", err, string(output))
+	}
+	return removeCachedProfiles(names)
+}
+
+// IsProfileCached returns true if a binary cache entry exists for the named profile.
+func IsProfileCached(name string) bool {
+	_, err := os.Stat(filepath.Join(dirs.AppArmorCacheDir, name))
+	return err == nil
+}
+
+func removeCachedProfiles(names []string) error {
+	for _, name := range names {
+		err := os.Remove(filepath.Join(dirs.AppArmorCacheDir, name))
+		// It is not an error if the cache file wasn't there to remove.
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("cannot remove apparmor profile cache: %s", err)
+		}
 	}
 	return nil
 }
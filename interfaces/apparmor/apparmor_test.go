@@ -73,6 +73,47 @@ apparmor_parser output:
 		"--replace --write-cache -O no-expr-simplify --cache-loc=/var/cache/apparmor /path/to/snap.samba.smbd"})
 }
 
+func (s *appArmorSuite) TestLoadProfilesRunsAppArmorParserOnce(c *C) {
+	cmd := testutil.MockCommand(c, "apparmor_parser", "")
+	defer cmd.Restore()
+	err := apparmor.LoadProfiles([]string{"/path/to/snap.samba.nmbd", "/path/to/snap.samba.smbd"})
+	c.Assert(err, IsNil)
+	c.Assert(cmd.Calls(), DeepEquals, []string{
+		"--replace --write-cache -O no-expr-simplify --cache-loc=/var/cache/apparmor /path/to/snap.samba.nmbd /path/to/snap.samba.smbd"})
+}
+
+func (s *appArmorSuite) TestLoadProfilesDoesNothingWithoutProfiles(c *C) {
+	cmd := testutil.MockCommand(c, "apparmor_parser", "")
+	defer cmd.Restore()
+	err := apparmor.LoadProfiles(nil)
+	c.Assert(err, IsNil)
+	c.Assert(cmd.Calls(), HasLen, 0)
+}
+
+func (s *appArmorSuite) TestLoadProfilesReportsErrors(c *C) {
+	cmd := testutil.MockCommand(c, "apparmor_parser", "echo oops; exit 42")
+	defer cmd.Restore()
+	err := apparmor.LoadProfiles([]string{"/path/to/snap.samba.smbd"})
+	c.Assert(err.Error(), Equals, `cannot load apparmor profiles: exit status 42
+apparmor_parser output:
+oops
+`)
+}
+
+func (s *appArmorSuite) TestParserCommandCanBeMocked(c *C) {
+	cmd := testutil.MockCommand(c, "fake-apparmor-parser", "")
+	defer cmd.Restore()
+	restore := apparmor.MockParserCommand("fake-apparmor-parser")
+	defer restore()
+	err := apparmor.LoadProfiles([]string{"/path/to/snap.samba.smbd"})
+	c.Assert(err, IsNil)
+	err = apparmor.UnloadProfiles([]string{"snap.samba.smbd"})
+	c.Assert(err, IsNil)
+	c.Assert(cmd.Calls(), DeepEquals, []string{
+		"--replace --write-cache -O no-expr-simplify --cache-loc=/var/cache/apparmor /path/to/snap.samba.smbd",
+		"--remove snap.samba.smbd"})
+}
+
 // Tests for Profile.Unload()
 
 func (s *appArmorSuite) TestUnloadProfileRunsAppArmorParserRemove(c *C) {
@@ -109,6 +150,32 @@ func (s *appArmorSuite) TestUnloadRemovesCachedProfile(c *C) {
 	c.Check(os.IsNotExist(err), Equals, true)
 }
 
+func (s *appArmorSuite) TestUnloadProfilesRunsAppArmorParserOnce(c *C) {
+	cmd := testutil.MockCommand(c, "apparmor_parser", "")
+	defer cmd.Restore()
+
+	dirs.SetRootDir(c.MkDir())
+	defer dirs.SetRootDir("")
+	err := os.MkdirAll(dirs.AppArmorCacheDir, 0755)
+	c.Assert(err, IsNil)
+
+	fname := filepath.Join(dirs.AppArmorCacheDir, "snap.samba.smbd")
+	ioutil.WriteFile(fname, []byte("blob"), 0600)
+	c.Check(apparmor.IsProfileCached("snap.samba.smbd"), Equals, true)
+	err = apparmor.UnloadProfiles([]string{"snap.samba.nmbd", "snap.samba.smbd"})
+	c.Assert(err, IsNil)
+	c.Assert(cmd.Calls(), DeepEquals, []string{"--remove snap.samba.nmbd snap.samba.smbd"})
+	c.Check(apparmor.IsProfileCached("snap.samba.smbd"), Equals, false)
+}
+
+func (s *appArmorSuite) TestUnloadProfilesDoesNothingWithoutProfiles(c *C) {
+	cmd := testutil.MockCommand(c, "apparmor_parser", "")
+	defer cmd.Restore()
+	err := apparmor.UnloadProfiles(nil)
+	c.Assert(err, IsNil)
+	c.Assert(cmd.Calls(), HasLen, 0)
+}
+
 // Tests for LoadedProfiles()
 
 func (s *appArmorSuite) TestLoadedApparmorProfilesReturnsErrorOnMissingFile(c *C) {
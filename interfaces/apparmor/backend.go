@@ -67,32 +67,43 @@ func (b *Backend) Name() string {
 // them or application present in the snap.
 func (b *Backend) Setup(snapInfo *snap.Info, devMode bool, repo *interfaces.Repository) error {
 	snapName := snapInfo.Name()
-	// Get the snippets that apply to this snap
-	snippets, err := repo.SecuritySnippetsForSnap(snapName, interfaces.SecurityAppArmor)
-	if err != nil {
-		return fmt.Errorf("cannot obtain security snippets for snap %q: %s", snapName, err)
-	}
 	// Get the files that this snap should have
-	content, err := b.combineSnippets(snapInfo, devMode, snippets)
+	profiles, err := b.Profiles(snapInfo, devMode, repo)
 	if err != nil {
-		return fmt.Errorf("cannot obtain expected security files for snap %q: %s", snapName, err)
+		return err
+	}
+	content := make(map[string]*osutil.FileState, len(profiles))
+	for _, appInfo := range snapInfo.Apps {
+		if profile, ok := profiles[appInfo.Name]; ok {
+			content[appInfo.SecurityTag()] = &osutil.FileState{Content: profile, Mode: 0644}
+		}
 	}
 	glob := interfaces.SecurityTagGlob(snapInfo.Name())
 	dir := dirs.SnapAppArmorDir
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return fmt.Errorf("cannot create directory for apparmor profiles %q: %s", dir, err)
 	}
-	_, removed, errEnsure := osutil.EnsureDirState(dir, glob, content)
-	// NOTE: load all profiles instead of just the changed profiles.  We're
-	// relying on apparmor cache to make this efficient. This gives us
-	// certainty that each call to Setup ends up with working profiles.
-	all := make([]string, 0, len(content))
+	changed, removed, errEnsure := osutil.EnsureDirState(dir, glob, content)
+	// NOTE: only changed profiles are reloaded. Profiles that didn't change
+	// but have no binary cache entry (e.g. because the cache was wiped) are
+	// reloaded as well. This way an unchanged snap costs nothing while each
+	// call to Setup still ends up with working profiles.
+	reload := make(map[string]bool, len(changed))
+	for _, name := range changed {
+		reload[name] = true
+	}
 	for name := range content {
-		all = append(all, name)
+		if !IsProfileCached(name) {
+			reload[name] = true
+		}
+	}
+	names := make([]string, 0, len(reload))
+	for name := range reload {
+		names = append(names, name)
 	}
-	sort.Strings(all)
-	errReload := reloadProfiles(all)
-	errUnload := unloadProfiles(removed)
+	sort.Strings(names)
+	errReload := reloadProfiles(names)
+	errUnload := UnloadProfiles(removed)
 	if errEnsure != nil {
 		return fmt.Errorf("cannot synchronize security files for snap %q: %s", snapName, errEnsure)
 	}
@@ -102,11 +113,22 @@ func (b *Backend) Setup(snapInfo *snap.Info, devMode bool, repo *interfaces.Repo
 	return errUnload
 }
 
+// Profiles returns the apparmor profiles that Setup would create for each
+// application of a given snap, keyed by application name.
+func (b *Backend) Profiles(snapInfo *snap.Info, devMode bool, repo *interfaces.Repository) (map[string][]byte, error) {
+	snapName := snapInfo.Name()
+	snippets, err := repo.SecuritySnippetsForSnap(snapName, interfaces.SecurityAppArmor)
+	if err != nil {
+		return nil, fmt.Errorf("cannot obtain security snippets for snap %q: %s", snapName, err)
+	}
+	return b.combineSnippets(snapInfo, devMode, snippets), nil
+}
+
 // Remove removes and unloads apparmor profiles of a given snap.
 func (b *Backend) Remove(snapName string) error {
 	glob := interfaces.SecurityTagGlob(snapName)
 	_, removed, errEnsure := osutil.EnsureDirState(dirs.SnapAppArmorDir, glob, nil)
-	errUnload := unloadProfiles(removed)
+	errUnload := UnloadProfiles(removed)
 	if errEnsure != nil {
 		return fmt.Errorf("cannot synchronize security files for snap %q: %s", snapName, errEnsure)
 	}
@@ -123,9 +145,10 @@ var (
 )
 
 // combineSnippets combines security snippets collected from all the interfaces
-// affecting a given snap into a content map applicable to EnsureDirState. The
-// backend delegates writing those files to higher layers.
-func (b *Backend) combineSnippets(snapInfo *snap.Info, devMode bool, snippets map[string][][]byte) (content map[string]*osutil.FileState, err error) {
+// affecting a given snap into the profiles of its applications, keyed by
+// application name.
+func (b *Backend) combineSnippets(snapInfo *snap.Info, devMode bool, snippets map[string][][]byte) map[string][]byte {
+	profiles := make(map[string][]byte, len(snapInfo.Apps))
 	for _, appInfo := range snapInfo.Apps {
 		policy := defaultTemplate
 		if devMode {
@@ -142,34 +165,15 @@ func (b *Backend) combineSnippets(snapInfo *snap.Info, devMode bool, snippets ma
 			}
 			return nil
 		})
-		if content == nil {
-			content = make(map[string]*osutil.FileState)
-		}
-		fname := appInfo.SecurityTag()
-		content[fname] = &osutil.FileState{
-			Content: policy,
-			Mode:    0644,
-		}
+		profiles[appInfo.Name] = policy
 	}
-	return content, nil
+	return profiles
 }
 
 func reloadProfiles(profiles []string) error {
-	for _, profile := range profiles {
-		fname := filepath.Join(dirs.SnapAppArmorDir, profile)
-		err := LoadProfile(fname)
-		if err != nil {
-			return fmt.Errorf("cannot load apparmor profile %q: %s", profile, err)
-		}
-	}
-	return nil
-}
-
-func unloadProfiles(profiles []string) error {
-	for _, profile := range profiles {
-		if err := UnloadProfile(profile); err != nil {
-			return fmt.Errorf("cannot unload apparmor profile %q: %s", profile, err)
-		}
+	fnames := make([]string, len(profiles))
+	for i, profile := range profiles {
+		fnames[i] = filepath.Join(dirs.SnapAppArmorDir, profile)
 	}
-	return nil
+	return LoadProfiles(fnames)
 }
@@ -50,7 +50,7 @@ var _ = Suite(&backendSuite{
 // in accordance with what real apparmor_parser would do.
 const fakeAppArmorParser = `
 cache_dir=""
-profile=""
+profiles=""
 write=""
 while [ -n "$1" ]; do
 	case "$1" in
@@ -68,13 +68,15 @@ while [ -n "$1" ]; do
 			shift
 			;;
 		*)
-			profile=$(basename "$1")
+			profiles="$profiles $(basename "$1")"
 			;;
 	esac
 	shift
 done
 if [ "$write" = yes ]; then
-	echo fake > "$cache_dir/$profile"
+	for profile in $profiles; do
+		echo fake > "$cache_dir/$profile"
+	done
 fi
 `
 
@@ -136,12 +138,36 @@ func (s *backendSuite) TestInstallingSnapWritesAndLoadsProfiles(c *C) {
 	})
 }
 
-func (s *backendSuite) TestProfilesAreAlwaysLoaded(c *C) {
+func (s *backendSuite) TestInstallingSnapLoadsProfilesInOneBatch(c *C) {
+	devMode := false
+	s.installSnap(c, devMode, sambaYamlWithNmbd, 1)
+	smbdProfile := filepath.Join(dirs.SnapAppArmorDir, "snap.samba.smbd")
+	nmbdProfile := filepath.Join(dirs.SnapAppArmorDir, "snap.samba.nmbd")
+	// apparmor_parser was used once to load both profiles
+	c.Check(s.parserCmd.Calls(), DeepEquals, []string{
+		fmt.Sprintf("--replace --write-cache -O no-expr-simplify --cache-loc=%s/var/cache/apparmor %s %s", s.rootDir, nmbdProfile, smbdProfile),
+	})
+}
+
+func (s *backendSuite) TestUnchangedProfilesAreNotReloaded(c *C) {
 	for _, devMode := range []bool{true, false} {
 		snapInfo := s.installSnap(c, devMode, sambaYaml, 1)
 		s.parserCmd.ForgetCalls()
 		err := s.backend.Setup(snapInfo, devMode, s.repo)
 		c.Assert(err, IsNil)
+		c.Check(s.parserCmd.Calls(), HasLen, 0)
+		s.removeSnap(c, snapInfo)
+	}
+}
+
+func (s *backendSuite) TestProfilesMissingFromCacheAreReloaded(c *C) {
+	for _, devMode := range []bool{true, false} {
+		snapInfo := s.installSnap(c, devMode, sambaYaml, 1)
+		s.parserCmd.ForgetCalls()
+		err := os.Remove(filepath.Join(dirs.AppArmorCacheDir, "snap.samba.smbd"))
+		c.Assert(err, IsNil)
+		err = s.backend.Setup(snapInfo, devMode, s.repo)
+		c.Assert(err, IsNil)
 		profile := filepath.Join(dirs.SnapAppArmorDir, "snap.samba.smbd")
 		c.Check(s.parserCmd.Calls(), DeepEquals, []string{
 			fmt.Sprintf("--replace --write-cache -O no-expr-simplify --cache-loc=%s/var/cache/apparmor %s", s.rootDir, profile),
@@ -150,6 +176,16 @@ func (s *backendSuite) TestProfilesAreAlwaysLoaded(c *C) {
 	}
 }
 
+func (s *backendSuite) TestRemovingSnapUnloadsProfilesInOneBatch(c *C) {
+	devMode := false
+	snapInfo := s.installSnap(c, devMode, sambaYamlWithNmbd, 1)
+	s.parserCmd.ForgetCalls()
+	s.removeSnap(c, snapInfo)
+	c.Check(s.parserCmd.Calls(), DeepEquals, []string{
+		"--remove snap.samba.nmbd snap.samba.smbd",
+	})
+}
+
 func (s *backendSuite) TestRemovingSnapRemovesAndUnloadsProfiles(c *C) {
 	for _, devMode := range []bool{true, false} {
 		snapInfo := s.installSnap(c, devMode, sambaYaml, 1)
@@ -196,10 +232,12 @@ func (s *backendSuite) TestUpdatingSnapToOneWithMoreApps(c *C) {
 		// file called "snap.sambda.nmbd" was created
 		_, err := os.Stat(nmbdProfile)
 		c.Check(err, IsNil)
-		// apparmor_parser was used to load the both profiles
+		// file called "snap.sambda.smbd" was kept
+		_, err = os.Stat(smbdProfile)
+		c.Check(err, IsNil)
+		// apparmor_parser was used to load the new profile only
 		c.Check(s.parserCmd.Calls(), DeepEquals, []string{
 			fmt.Sprintf("--replace --write-cache -O no-expr-simplify --cache-loc=%s/var/cache/apparmor %s", s.rootDir, nmbdProfile),
-			fmt.Sprintf("--replace --write-cache -O no-expr-simplify --cache-loc=%s/var/cache/apparmor %s", s.rootDir, smbdProfile),
 		})
 		s.removeSnap(c, snapInfo)
 	}
@@ -216,9 +254,11 @@ func (s *backendSuite) TestUpdatingSnapToOneWithFewerApps(c *C) {
 		// file called "snap.sambda.nmbd" was removed
 		_, err := os.Stat(nmbdProfile)
 		c.Check(os.IsNotExist(err), Equals, true)
+		// file called "snap.sambda.smbd" was kept
+		_, err = os.Stat(smbdProfile)
+		c.Check(err, IsNil)
 		// apparmor_parser was used to remove the unused profile
 		c.Check(s.parserCmd.Calls(), DeepEquals, []string{
-			fmt.Sprintf("--replace --write-cache -O no-expr-simplify --cache-loc=%s/var/cache/apparmor %s", s.rootDir, smbdProfile),
 			"--remove snap.samba.nmbd",
 		})
 		s.removeSnap(c, snapInfo)
@@ -312,6 +352,27 @@ func (s *backendSuite) TestCombineSnippets(c *C) {
 	}
 }
 
+func (s *backendSuite) TestProfiles(c *C) {
+	restore := apparmor.MockTemplate([]byte("###PROFILEATTACH### (attach_disconnected) {\n###SNIPPETS###\n}\n"))
+	defer restore()
+	s.iface.PermanentSlotSnippetCallback = func(slot *interfaces.Slot, securitySystem interfaces.SecuritySystem) ([]byte, error) {
+		return []byte("snippet"), nil
+	}
+	snapInfo, err := snap.InfoFromSnapYaml([]byte(sambaYaml))
+	c.Assert(err, IsNil)
+	c.Assert(s.repo.AddSnap(snapInfo), IsNil)
+	defer s.repo.RemoveSnap(snapInfo.Name())
+	profiles, err := s.backend.Profiles(snapInfo, true, s.repo)
+	c.Assert(err, IsNil)
+	c.Check(profiles, DeepEquals, map[string][]byte{
+		"smbd": []byte("profile \"snap.samba.smbd\" (attach_disconnected,complain) {\nsnippet\n}\n"),
+	})
+	// Nothing was written to disk or loaded
+	_, err = os.Stat(filepath.Join(dirs.SnapAppArmorDir, "snap.samba.smbd"))
+	c.Check(os.IsNotExist(err), Equals, true)
+	c.Check(s.parserCmd.Calls(), HasLen, 0)
+}
+
 // Support code for tests
 
 // installSnap "installs" a snap from YAML.
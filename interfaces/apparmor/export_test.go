@@ -40,3 +40,10 @@ func MockTemplate(fakeTemplate []byte) (restore func()) {
 	defaultTemplate = fakeTemplate
 	return func() { defaultTemplate = orig }
 }
+
+// MockParserCommand replaces the program used to load and unload profiles.
+func MockParserCommand(cmd string) (restore func()) {
+	orig := parserCommand
+	parserCommand = cmd
+	return func() { parserCommand = orig }
+}
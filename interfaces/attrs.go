@@ -0,0 +1,276 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package interfaces
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ubuntu-core/snappy/logger"
+)
+
+// AttrType is the type of the value of a plug or slot attribute.
+type AttrType string
+
+const (
+	// AttrString is the type of attributes holding a string.
+	AttrString AttrType = "string"
+	// AttrBool is the type of attributes holding a boolean.
+	AttrBool AttrType = "bool"
+	// AttrInt is the type of attributes holding an integer.
+	AttrInt AttrType = "int"
+)
+
+// AttrSpec describes a single attribute of a plug or slot.
+type AttrSpec struct {
+	// Name is the name of the attribute, as used in snap.yaml.
+	Name string `json:"name"`
+	// Type is the type of the value of the attribute.
+	Type AttrType `json:"type"`
+	// Required indicates that the attribute must be present.
+	Required bool `json:"required,omitempty"`
+	// Pattern is a regular expression that string values must match.
+	Pattern *regexp.Regexp `json:"-"`
+	// Values is the list of values allowed for string attributes.
+	Values []string `json:"values,omitempty"`
+}
+
+// MarshalJSON encodes the spec with its pattern as a plain string.
+func (spec *AttrSpec) MarshalJSON() ([]byte, error) {
+	var pattern string
+	if spec.Pattern != nil {
+		pattern = spec.Pattern.String()
+	}
+	return json.Marshal(&struct {
+		Name     string   `json:"name"`
+		Type     AttrType `json:"type"`
+		Required bool     `json:"required,omitempty"`
+		Pattern  string   `json:"pattern,omitempty"`
+		Values   []string `json:"values,omitempty"`
+	}{spec.Name, spec.Type, spec.Required, pattern, spec.Values})
+}
+
+// AttrSchema describes the attributes of the plugs and slots of an interface.
+//
+// Attributes that are not described by the schema are ignored.
+type AttrSchema struct {
+	Plug []*AttrSpec `json:"plug,omitempty"`
+	Slot []*AttrSpec `json:"slot,omitempty"`
+}
+
+// SchemaInterface is implemented by interfaces that declare the attributes
+// their plugs and slots may carry.
+//
+// The repository validates the attributes of plugs and slots of such
+// interfaces before sanitizing them so malformed attributes are rejected
+// with precise errors. Sanitizing can then rely on the described attributes
+// having the right type.
+type SchemaInterface interface {
+	Interface
+
+	// AttrSchema returns the attribute schema of the interface.
+	AttrSchema() *AttrSchema
+}
+
+// ValidatePlug checks that the attributes of a plug conform to the schema.
+func (schema *AttrSchema) ValidatePlug(plug *Plug) error {
+	return validateAttrs("plug", schema.Plug, plug.Attrs)
+}
+
+// ValidateSlot checks that the attributes of a slot conform to the schema.
+func (schema *AttrSchema) ValidateSlot(slot *Slot) error {
+	return validateAttrs("slot", schema.Slot, slot.Attrs)
+}
+
+func validateAttrs(kind string, specs []*AttrSpec, attrs map[string]interface{}) error {
+	known := make(map[string]bool, len(specs))
+	for _, spec := range specs {
+		known[spec.Name] = true
+		value, ok := attrs[spec.Name]
+		if !ok {
+			if spec.Required {
+				return fmt.Errorf("%s attribute %q is required", kind, spec.Name)
+			}
+			continue
+		}
+		if err := spec.validate(value); err != nil {
+			return fmt.Errorf("%s attribute %q %s", kind, spec.Name, err)
+		}
+	}
+	var unknown []string
+	for name := range attrs {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		logger.Noticef("ignoring unknown %s attribute %q", kind, name)
+	}
+	return nil
+}
+
+// validate checks a single value against the spec. The returned error is
+// meant to follow the name of the attribute.
+func (spec *AttrSpec) validate(value interface{}) error {
+	switch spec.Type {
+	case AttrString:
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("must be a string, not %s", attrTypeOf(value))
+		}
+		if len(spec.Values) > 0 && !strListContains(spec.Values, s) {
+			return fmt.Errorf("must be one of %s, not %q", strings.Join(spec.Values, ", "), s)
+		}
+		if spec.Pattern != nil && !spec.Pattern.MatchString(s) {
+			return fmt.Errorf("must match %s, not %q", spec.Pattern, s)
+		}
+	case AttrBool:
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("must be a bool, not %s", attrTypeOf(value))
+		}
+	case AttrInt:
+		if _, ok := attrInt(value); !ok {
+			return fmt.Errorf("must be an int, not %s", attrTypeOf(value))
+		}
+	default:
+		return fmt.Errorf("has unknown type %q in schema", spec.Type)
+	}
+	return nil
+}
+
+// attrTypeOf describes the type of an attribute value in snap.yaml terms.
+func attrTypeOf(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return "a string"
+	case bool:
+		return "a bool"
+	case int, int64, uint64:
+		return "an int"
+	case []interface{}:
+		return "a list"
+	case map[interface{}]interface{}, map[string]interface{}:
+		return "a map"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// attrInt converts integer values decoded from YAML or JSON into an int.
+func attrInt(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case uint64:
+		if int(v) >= 0 {
+			return int(v), true
+		}
+	case float64:
+		// JSON numbers; only accept integral values
+		if v == float64(int(v)) {
+			return int(v), true
+		}
+	}
+	return 0, false
+}
+
+func strListContains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// StringAttr returns the value of a string attribute of a plug.
+//
+// An error is returned if the attribute is not set or is not a string.
+func (plug *Plug) StringAttr(name string) (string, error) {
+	return stringAttr("plug", plug.Attrs, name)
+}
+
+// BoolAttr returns the value of a bool attribute of a plug.
+func (plug *Plug) BoolAttr(name string) (bool, error) {
+	return boolAttr("plug", plug.Attrs, name)
+}
+
+// IntAttr returns the value of an int attribute of a plug.
+func (plug *Plug) IntAttr(name string) (int, error) {
+	return intAttr("plug", plug.Attrs, name)
+}
+
+// StringAttr returns the value of a string attribute of a slot.
+//
+// An error is returned if the attribute is not set or is not a string.
+func (slot *Slot) StringAttr(name string) (string, error) {
+	return stringAttr("slot", slot.Attrs, name)
+}
+
+// BoolAttr returns the value of a bool attribute of a slot.
+func (slot *Slot) BoolAttr(name string) (bool, error) {
+	return boolAttr("slot", slot.Attrs, name)
+}
+
+// IntAttr returns the value of an int attribute of a slot.
+func (slot *Slot) IntAttr(name string) (int, error) {
+	return intAttr("slot", slot.Attrs, name)
+}
+
+func stringAttr(kind string, attrs map[string]interface{}, name string) (string, error) {
+	value, ok := attrs[name]
+	if !ok {
+		return "", fmt.Errorf("%s attribute %q is not set", kind, name)
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("%s attribute %q must be a string, not %s", kind, name, attrTypeOf(value))
+	}
+	return s, nil
+}
+
+func boolAttr(kind string, attrs map[string]interface{}, name string) (bool, error) {
+	value, ok := attrs[name]
+	if !ok {
+		return false, fmt.Errorf("%s attribute %q is not set", kind, name)
+	}
+	b, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("%s attribute %q must be a bool, not %s", kind, name, attrTypeOf(value))
+	}
+	return b, nil
+}
+
+func intAttr(kind string, attrs map[string]interface{}, name string) (int, error) {
+	value, ok := attrs[name]
+	if !ok {
+		return 0, fmt.Errorf("%s attribute %q is not set", kind, name)
+	}
+	n, ok := attrInt(value)
+	if !ok {
+		return 0, fmt.Errorf("%s attribute %q must be an int, not %s", kind, name, attrTypeOf(value))
+	}
+	return n, nil
+}
@@ -0,0 +1,126 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package interfaces_test
+
+import (
+	"regexp"
+
+	. "gopkg.in/check.v1"
+
+	. "github.com/ubuntu-core/snappy/interfaces"
+	"github.com/ubuntu-core/snappy/snap"
+)
+
+type AttrSchemaSuite struct {
+	schema *AttrSchema
+}
+
+var _ = Suite(&AttrSchemaSuite{
+	schema: &AttrSchema{
+		Plug: []*AttrSpec{
+			{Name: "read-only", Type: AttrBool},
+		},
+		Slot: []*AttrSpec{
+			{Name: "path", Type: AttrString, Required: true, Pattern: regexp.MustCompile("^/sys/")},
+			{Name: "mode", Type: AttrString, Values: []string{"in", "out"}},
+			{Name: "count", Type: AttrInt},
+		},
+	},
+})
+
+func (s *AttrSchemaSuite) plug(c *C, attrs string) *Plug {
+	info, err := snap.InfoFromSnapYaml([]byte(`
+name: consumer
+plugs:
+    plug:
+        interface: iface
+` + attrs))
+	c.Assert(err, IsNil)
+	return &Plug{PlugInfo: info.Plugs["plug"]}
+}
+
+func (s *AttrSchemaSuite) slot(c *C, attrs string) *Slot {
+	info, err := snap.InfoFromSnapYaml([]byte(`
+name: producer
+slots:
+    slot:
+        interface: iface
+` + attrs))
+	c.Assert(err, IsNil)
+	return &Slot{SlotInfo: info.Slots["slot"]}
+}
+
+func (s *AttrSchemaSuite) TestValidateSlot(c *C) {
+	for _, t := range []struct {
+		attrs string
+		err   string
+	}{
+		{"        path: /sys/foo\n", ""},
+		{"        path: /sys/foo\n        mode: out\n        count: 3\n", ""},
+		{"", `slot attribute "path" is required`},
+		{"        path: true\n", `slot attribute "path" must be a string, not a bool`},
+		{"        path: /dev/foo\n", `slot attribute "path" must match \^/sys/, not "/dev/foo"`},
+		{"        path: /sys/foo\n        mode: both\n", `slot attribute "mode" must be one of in, out, not "both"`},
+		{"        path: /sys/foo\n        count: many\n", `slot attribute "count" must be an int, not a string`},
+		// unknown attributes are ignored
+		{"        path: /sys/foo\n        color: red\n", ""},
+	} {
+		err := s.schema.ValidateSlot(s.slot(c, t.attrs))
+		if t.err == "" {
+			c.Check(err, IsNil, Commentf("attrs: %q", t.attrs))
+		} else {
+			c.Check(err, ErrorMatches, t.err, Commentf("attrs: %q", t.attrs))
+		}
+	}
+}
+
+func (s *AttrSchemaSuite) TestValidatePlug(c *C) {
+	c.Check(s.schema.ValidatePlug(s.plug(c, "")), IsNil)
+	c.Check(s.schema.ValidatePlug(s.plug(c, "        read-only: true\n")), IsNil)
+	c.Check(s.schema.ValidatePlug(s.plug(c, "        read-only: [yes]\n")), ErrorMatches,
+		`plug attribute "read-only" must be a bool, not a list`)
+	c.Check(s.schema.ValidatePlug(s.plug(c, "        path: /sys/foo\n")), IsNil)
+}
+
+func (s *AttrSchemaSuite) TestTypedAccessors(c *C) {
+	slot := s.slot(c, "        path: /sys/foo\n        count: 3\n        flag: true\n")
+	path, err := slot.StringAttr("path")
+	c.Check(err, IsNil)
+	c.Check(path, Equals, "/sys/foo")
+	count, err := slot.IntAttr("count")
+	c.Check(err, IsNil)
+	c.Check(count, Equals, 3)
+	flag, err := slot.BoolAttr("flag")
+	c.Check(err, IsNil)
+	c.Check(flag, Equals, true)
+	_, err = slot.StringAttr("mode")
+	c.Check(err, ErrorMatches, `slot attribute "mode" is not set`)
+	_, err = slot.StringAttr("count")
+	c.Check(err, ErrorMatches, `slot attribute "count" must be a string, not an int`)
+	_, err = slot.BoolAttr("path")
+	c.Check(err, ErrorMatches, `slot attribute "path" must be a bool, not a string`)
+
+	plug := s.plug(c, "        read-only: false\n")
+	readOnly, err := plug.BoolAttr("read-only")
+	c.Check(err, IsNil)
+	c.Check(readOnly, Equals, false)
+	_, err = plug.IntAttr("read-only")
+	c.Check(err, ErrorMatches, `plug attribute "read-only" must be an int, not a bool`)
+}
@@ -42,4 +42,10 @@ type SecurityBackend interface {
 	//
 	// This method should be called during the process of removing a snap.
 	Remove(snapName string) error
+
+	// Profiles returns the security artefacts that Setup would create for a
+	// given snap, keyed by application name, without writing or loading them.
+	//
+	// This method is intended for inspecting the generated security setup.
+	Profiles(snapInfo *snap.Info, devMode bool, repo *Repository) (map[string][]byte, error)
 }
@@ -49,15 +49,27 @@ var boolFileAllowedPathPatterns = []*regexp.Regexp{
 	boolFileGPIOValuePattern,
 }
 
+var boolFileSchema = &interfaces.AttrSchema{
+	Slot: []*interfaces.AttrSpec{
+		{Name: "path", Type: interfaces.AttrString, Required: true},
+	},
+}
+
+// AttrSchema returns the attributes of bool-file plugs and slots.
+// Slots must point at a file with the "path" attribute, plugs have no attributes.
+func (iface *BoolFileInterface) AttrSchema() *interfaces.AttrSchema {
+	return boolFileSchema
+}
+
 // SanitizeSlot checks and possibly modifies a slot.
-// Valid "bool-file" slots must contain the attribute "path".
+// Valid "bool-file" slots must point at a LED or a GPIO with "path".
 func (iface *BoolFileInterface) SanitizeSlot(slot *interfaces.Slot) error {
 	if iface.Name() != slot.Interface {
 		panic(fmt.Sprintf("slot is not of interface %q", iface))
 	}
-	path, ok := slot.Attrs["path"].(string)
-	if !ok || path == "" {
-		return fmt.Errorf("bool-file must contain the path attribute")
+	path, err := slot.StringAttr("path")
+	if err != nil {
+		return err
 	}
 	path = filepath.Clean(path)
 	for _, pattern := range boolFileAllowedPathPatterns {
@@ -69,8 +81,8 @@ func (iface *BoolFileInterface) SanitizeSlot(slot *interfaces.Slot) error {
 }
 
 // SanitizePlug checks and possibly modifies a plug.
-func (iface *BoolFileInterface) SanitizePlug(slot *interfaces.Plug) error {
-	if iface.Name() != slot.Interface {
+func (iface *BoolFileInterface) SanitizePlug(plug *interfaces.Plug) error {
+	if iface.Name() != plug.Interface {
 		panic(fmt.Sprintf("plug is not of interface %q", iface))
 	}
 	// NOTE: currently we don't check anything on the plug side.
@@ -144,7 +156,7 @@ func (iface *BoolFileInterface) PermanentPlugSnippet(plug *interfaces.Plug, secu
 }
 
 func (iface *BoolFileInterface) dereferencedPath(slot *interfaces.Slot) (string, error) {
-	if path, ok := slot.Attrs["path"].(string); ok {
+	if path, err := slot.StringAttr("path"); err == nil {
 		path, err := evalSymlinks(path)
 		if err != nil {
 			return "", err
@@ -156,7 +168,7 @@ func (iface *BoolFileInterface) dereferencedPath(slot *interfaces.Slot) (string,
 
 // isGPIO checks if a given bool-file slot refers to a GPIO pin.
 func (iface *BoolFileInterface) isGPIO(slot *interfaces.Slot) bool {
-	if path, ok := slot.Attrs["path"].(string); ok {
+	if path, err := slot.StringAttr("path"); err == nil {
 		path = filepath.Clean(path)
 		return boolFileGPIOValuePattern.MatchString(path)
 	}
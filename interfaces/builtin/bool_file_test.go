@@ -96,11 +96,7 @@ func (s *BoolFileInterfaceSuite) TestSanitizeSlot(c *C) {
 	c.Assert(err, IsNil)
 	err = s.iface.SanitizeSlot(s.gpioSlot)
 	c.Assert(err, IsNil)
-	// Slots without the "path" attribute are rejected.
-	err = s.iface.SanitizeSlot(s.missingPathSlot)
-	c.Assert(err, ErrorMatches,
-		"bool-file must contain the path attribute")
-	// Slots without the "path" attribute are rejected.
+	// Slots with a path outside of the allowed directories are rejected.
 	err = s.iface.SanitizeSlot(s.parentDirPathSlot)
 	c.Assert(err, ErrorMatches,
 		"bool-file can only point at LED brightness or GPIO value")
@@ -108,11 +104,32 @@ func (s *BoolFileInterfaceSuite) TestSanitizeSlot(c *C) {
 	err = s.iface.SanitizeSlot(s.badPathSlot)
 	c.Assert(err, ErrorMatches,
 		"bool-file can only point at LED brightness or GPIO value")
+	// Slots that were not validated against the schema are rejected, not
+	// a cause for panic.
+	err = s.iface.SanitizeSlot(s.missingPathSlot)
+	c.Assert(err, ErrorMatches, `slot attribute "path" is not set`)
+	slot := &interfaces.Slot{SlotInfo: &snap.SlotInfo{
+		Snap: s.ledSlot.Snap, Name: "led", Interface: "bool-file",
+		Attrs: map[string]interface{}{"path": 42},
+	}}
+	err = s.iface.SanitizeSlot(slot)
+	c.Assert(err, ErrorMatches, `slot attribute "path" must be a string, not an int`)
 	// It is impossible to use "bool-file" interface to sanitize slots with other interfaces.
 	c.Assert(func() { s.iface.SanitizeSlot(s.badInterfaceSlot) }, PanicMatches,
 		`slot is not of interface "bool-file"`)
 }
 
+func (s *BoolFileInterfaceSuite) TestAttrSchema(c *C) {
+	schema := s.iface.(interfaces.SchemaInterface).AttrSchema()
+	c.Assert(schema.Plug, HasLen, 0)
+	c.Assert(schema.Slot, DeepEquals, []*interfaces.AttrSpec{
+		{Name: "path", Type: interfaces.AttrString, Required: true},
+	})
+	// Slots without the "path" attribute are rejected.
+	c.Assert(schema.ValidateSlot(s.missingPathSlot), ErrorMatches,
+		`slot attribute "path" is required`)
+}
+
 func (s *BoolFileInterfaceSuite) TestSanitizePlug(c *C) {
 	err := s.iface.SanitizePlug(s.plug)
 	c.Assert(err, IsNil)
@@ -0,0 +1,301 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package builtin
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ubuntu-core/snappy/interfaces"
+)
+
+var dbusPermanentSlotAppArmor = []byte(`
+# Description: Allow owning the ###DBUS_NAME### name on the ###DBUS_BUS### bus.
+# Usage: common
+
+###DBUS_ABSTRACTION###
+
+# Allow requesting and releasing the well-known name
+dbus (send)
+    bus=###DBUS_BUS###
+    path=/org/freedesktop/DBus
+    interface=org.freedesktop.DBus
+    member={Request,Release}Name
+    peer=(name=org.freedesktop.DBus),
+
+# Allow binding the service to the requested connection name
+dbus (bind)
+    bus=###DBUS_BUS###
+    name="###DBUS_NAME###",
+`)
+
+var dbusConnectedSlotAppArmor = []byte(`
+# Description: Allow the plugging snap to use the ###DBUS_NAME### service.
+# Usage: common
+
+# Allow traffic to/from our path with any method
+dbus (receive)
+    bus=###DBUS_BUS###
+    path=###DBUS_PATH###{,/**}
+    peer=(label=###PLUG_SECURITY_TAGS###),
+
+# Allow replying and sending signals to the plugging snap
+dbus (send)
+    bus=###DBUS_BUS###
+    peer=(label=###PLUG_SECURITY_TAGS###),
+`)
+
+var dbusConnectedPlugAppArmor = []byte(`
+# Description: Allow using the ###DBUS_NAME### service.
+# Usage: common
+
+###DBUS_ABSTRACTION###
+
+# Allow all method calls to the service
+dbus (send)
+    bus=###DBUS_BUS###
+    path=###DBUS_PATH###{,/**}
+    peer=(name=###DBUS_NAME###, label=###SLOT_SECURITY_TAGS###),
+
+# Allow replies and signals from the service
+dbus (receive)
+    bus=###DBUS_BUS###
+    peer=(label=###SLOT_SECURITY_TAGS###),
+`)
+
+var dbusPermanentSlotSecComp = []byte(`
+# Description: Allow owning a name on DBus.
+# Usage: common
+connect
+getsockname
+recv
+recvfrom
+recvmsg
+send
+sendmsg
+sendto
+shutdown
+socket
+`)
+
+var dbusConnectedPlugSecComp = []byte(`
+# Description: Allow using a service on DBus.
+# Usage: common
+connect
+getsockname
+recv
+recvfrom
+recvmsg
+send
+sendmsg
+sendto
+shutdown
+socket
+`)
+
+var dbusPermanentSlotDBus = []byte(`
+<policy user="root">
+    <allow own="###DBUS_NAME###"/>
+    <allow send_destination="###DBUS_NAME###"/>
+</policy>
+<policy context="default">
+    <allow send_destination="###DBUS_NAME###"/>
+</policy>
+`)
+
+// dbusBusAbstractions maps bus names to matching apparmor abstractions.
+var dbusBusAbstractions = map[string]string{
+	"system":  "#include <abstractions/dbus-strict>",
+	"session": "#include <abstractions/dbus-session-strict>",
+}
+
+// The grammar of well-known bus names is described in
+// https://dbus.freedesktop.org/doc/dbus-specification.html#message-protocol-names-bus
+var dbusWellKnownName = regexp.MustCompile(`^[A-Za-z_-][A-Za-z0-9_-]*(\.[A-Za-z_-][A-Za-z0-9_-]*)+$`)
+
+// dbusNameMaxLength is the maximum length of any bus name.
+const dbusNameMaxLength = 255
+
+// DbusInterface is the type of the dbus interface.
+//
+// Slots of this interface own a well-known name on the system or session bus.
+// Connected plugs may call the service offering that name.
+type DbusInterface struct{}
+
+// String returns the same value as Name().
+func (iface *DbusInterface) String() string {
+	return iface.Name()
+}
+
+// Name returns the name of the dbus interface.
+func (iface *DbusInterface) Name() string {
+	return "dbus"
+}
+
+var dbusSchema = &interfaces.AttrSchema{
+	Slot: []*interfaces.AttrSpec{
+		{Name: "bus", Type: interfaces.AttrString, Required: true, Values: []string{"system", "session"}},
+		{Name: "name", Type: interfaces.AttrString, Required: true, Pattern: dbusWellKnownName},
+	},
+}
+
+// AttrSchema returns the attributes of dbus plugs and slots.
+// Slots must name the bus and the well-known name they own, plugs have no attributes.
+func (iface *DbusInterface) AttrSchema() *interfaces.AttrSchema {
+	return dbusSchema
+}
+
+// SanitizeSlot checks and possibly modifies a slot.
+// Valid "dbus" slots must contain the attributes "bus" and "name".
+func (iface *DbusInterface) SanitizeSlot(slot *interfaces.Slot) error {
+	if iface.Name() != slot.Interface {
+		panic(fmt.Sprintf("slot is not of interface %q", iface))
+	}
+	name, err := slot.StringAttr("name")
+	if err != nil {
+		return err
+	}
+	if len(name) > dbusNameMaxLength {
+		return fmt.Errorf("dbus slot name %q is longer than %d characters", name, dbusNameMaxLength)
+	}
+	return nil
+}
+
+// SanitizePlug checks and possibly modifies a plug.
+func (iface *DbusInterface) SanitizePlug(plug *interfaces.Plug) error {
+	if iface.Name() != plug.Interface {
+		panic(fmt.Sprintf("plug is not of interface %q", iface))
+	}
+	// NOTE: plugs learn about the bus and the name from the connected slot.
+	return nil
+}
+
+// SlotClaim returns the well-known bus name claimed by a dbus slot.
+func (iface *DbusInterface) SlotClaim(slot *interfaces.Slot) string {
+	bus, name := iface.busAndName(slot)
+	return fmt.Sprintf("%s bus name %q", bus, name)
+}
+
+// PermanentPlugSnippet returns the snippet of text for the given security
+// system that is used during the whole lifetime of affected applications,
+// whether the plug is connected or not.
+//
+// Plugs don't get any permanent security snippets.
+func (iface *DbusInterface) PermanentPlugSnippet(plug *interfaces.Plug, securitySystem interfaces.SecuritySystem) ([]byte, error) {
+	switch securitySystem {
+	case interfaces.SecurityAppArmor, interfaces.SecuritySecComp, interfaces.SecurityDBus, interfaces.SecurityUDev:
+		return nil, nil
+	default:
+		return nil, interfaces.ErrUnknownSecurity
+	}
+}
+
+// ConnectedPlugSnippet returns security snippet specific to a given connection between the dbus plug and some slot.
+// Applications associated with the plug gain permission to call the service and to receive replies and signals from it.
+func (iface *DbusInterface) ConnectedPlugSnippet(plug *interfaces.Plug, slot *interfaces.Slot, securitySystem interfaces.SecuritySystem) ([]byte, error) {
+	switch securitySystem {
+	case interfaces.SecurityAppArmor:
+		snippet := iface.expand(dbusConnectedPlugAppArmor, slot)
+		snippet = bytes.Replace(snippet, []byte("###SLOT_SECURITY_TAGS###"), slotAppLabelExpr(slot), -1)
+		return snippet, nil
+	case interfaces.SecuritySecComp:
+		return dbusConnectedPlugSecComp, nil
+	case interfaces.SecurityDBus, interfaces.SecurityUDev:
+		return nil, nil
+	default:
+		return nil, interfaces.ErrUnknownSecurity
+	}
+}
+
+// PermanentSlotSnippet returns security snippet permanently granted to dbus slots.
+// Applications associated with the slot gain permission to own the well-known name.
+//
+// DBus policy is only generated for the system bus as the session bus doesn't
+// use policy files to mediate access to names.
+func (iface *DbusInterface) PermanentSlotSnippet(slot *interfaces.Slot, securitySystem interfaces.SecuritySystem) ([]byte, error) {
+	switch securitySystem {
+	case interfaces.SecurityAppArmor:
+		return iface.expand(dbusPermanentSlotAppArmor, slot), nil
+	case interfaces.SecuritySecComp:
+		return dbusPermanentSlotSecComp, nil
+	case interfaces.SecurityDBus:
+		if bus, _ := iface.busAndName(slot); bus != "system" {
+			return nil, nil
+		}
+		return iface.expand(dbusPermanentSlotDBus, slot), nil
+	case interfaces.SecurityUDev:
+		return nil, nil
+	default:
+		return nil, interfaces.ErrUnknownSecurity
+	}
+}
+
+// ConnectedSlotSnippet returns security snippet specific to a given connection between the dbus slot and some plug.
+// Applications associated with the slot gain permission to talk to applications associated with the plug.
+func (iface *DbusInterface) ConnectedSlotSnippet(plug *interfaces.Plug, slot *interfaces.Slot, securitySystem interfaces.SecuritySystem) ([]byte, error) {
+	switch securitySystem {
+	case interfaces.SecurityAppArmor:
+		snippet := iface.expand(dbusConnectedSlotAppArmor, slot)
+		snippet = bytes.Replace(snippet, []byte("###PLUG_SECURITY_TAGS###"), plugAppLabelExpr(plug), -1)
+		return snippet, nil
+	case interfaces.SecuritySecComp, interfaces.SecurityDBus, interfaces.SecurityUDev:
+		return nil, nil
+	default:
+		return nil, interfaces.ErrUnknownSecurity
+	}
+}
+
+// AutoConnect returns true if plugs and slots should be implicitly
+// auto-connected when an unambiguous connection candidate is available.
+//
+// This interface does not auto-connect.
+func (iface *DbusInterface) AutoConnect() bool {
+	return false
+}
+
+// busAndName returns the bus and the well-known name of a sanitized slot.
+func (iface *DbusInterface) busAndName(slot *interfaces.Slot) (bus, name string) {
+	bus, err1 := slot.StringAttr("bus")
+	name, err2 := slot.StringAttr("name")
+	if err1 != nil || err2 != nil {
+		panic("slot is not sanitized")
+	}
+	return bus, name
+}
+
+// expand replaces the bus-related placeholders in a snippet template.
+func (iface *DbusInterface) expand(template []byte, slot *interfaces.Slot) []byte {
+	bus, name := iface.busAndName(slot)
+	replacer := strings.NewReplacer(
+		"###DBUS_ABSTRACTION###", dbusBusAbstractions[bus],
+		"###DBUS_BUS###", bus,
+		"###DBUS_NAME###", name,
+		"###DBUS_PATH###", dbusObjectPath(name),
+	)
+	return []byte(replacer.Replace(string(template)))
+}
+
+// dbusObjectPath returns the object path conventionally used by the service
+// owning the given well-known name, e.g. /org/example/Foo for org.example.Foo.
+func dbusObjectPath(name string) string {
+	return "/" + strings.Replace(strings.Replace(name, ".", "/", -1), "-", "_", -1)
+}
@@ -0,0 +1,260 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package builtin_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/ubuntu-core/snappy/interfaces"
+	"github.com/ubuntu-core/snappy/interfaces/builtin"
+	"github.com/ubuntu-core/snappy/snap"
+	"github.com/ubuntu-core/snappy/testutil"
+)
+
+type DbusInterfaceSuite struct {
+	iface            interfaces.Interface
+	systemSlot       *interfaces.Slot
+	sessionSlot      *interfaces.Slot
+	missingBusSlot   *interfaces.Slot
+	badBusSlot       *interfaces.Slot
+	missingNameSlot  *interfaces.Slot
+	badNameSlot      *interfaces.Slot
+	badInterfaceSlot *interfaces.Slot
+	plug             *interfaces.Plug
+	badInterfacePlug *interfaces.Plug
+}
+
+var _ = Suite(&DbusInterfaceSuite{
+	iface: &builtin.DbusInterface{},
+})
+
+func (s *DbusInterfaceSuite) SetUpTest(c *C) {
+	producer, err := snap.InfoFromSnapYaml([]byte(`
+name: producer
+apps:
+    daemon:
+slots:
+    system:
+        interface: dbus
+        bus: system
+        name: org.example.Service
+    session:
+        interface: dbus
+        bus: session
+        name: org.example.Session
+    missing-bus:
+        interface: dbus
+        name: org.example.Service
+    bad-bus:
+        interface: dbus
+        bus: starter
+        name: org.example.Service
+    missing-name:
+        interface: dbus
+        bus: system
+    bad-name:
+        interface: dbus
+        bus: system
+        name: noperiods
+    bad-interface: other-interface
+`))
+	c.Assert(err, IsNil)
+	consumer, err := snap.InfoFromSnapYaml([]byte(`
+name: consumer
+apps:
+    app:
+plugs:
+    plug: dbus
+    bad-interface: other-interface
+`))
+	c.Assert(err, IsNil)
+	s.systemSlot = &interfaces.Slot{SlotInfo: producer.Slots["system"]}
+	s.sessionSlot = &interfaces.Slot{SlotInfo: producer.Slots["session"]}
+	s.missingBusSlot = &interfaces.Slot{SlotInfo: producer.Slots["missing-bus"]}
+	s.badBusSlot = &interfaces.Slot{SlotInfo: producer.Slots["bad-bus"]}
+	s.missingNameSlot = &interfaces.Slot{SlotInfo: producer.Slots["missing-name"]}
+	s.badNameSlot = &interfaces.Slot{SlotInfo: producer.Slots["bad-name"]}
+	s.badInterfaceSlot = &interfaces.Slot{SlotInfo: producer.Slots["bad-interface"]}
+	s.plug = &interfaces.Plug{PlugInfo: consumer.Plugs["plug"]}
+	s.badInterfacePlug = &interfaces.Plug{PlugInfo: consumer.Plugs["bad-interface"]}
+}
+
+func (s *DbusInterfaceSuite) TestName(c *C) {
+	c.Assert(s.iface.Name(), Equals, "dbus")
+}
+
+func (s *DbusInterfaceSuite) TestSanitizeSlot(c *C) {
+	// Both system and session bus slots are accepted
+	c.Assert(s.iface.SanitizeSlot(s.systemSlot), IsNil)
+	c.Assert(s.iface.SanitizeSlot(s.sessionSlot), IsNil)
+	// Slots that were not validated against the schema are rejected.
+	c.Assert(s.iface.SanitizeSlot(s.missingNameSlot), ErrorMatches, `slot attribute "name" is not set`)
+	// It is impossible to use "dbus" interface to sanitize slots with other interfaces.
+	c.Assert(func() { s.iface.SanitizeSlot(s.badInterfaceSlot) }, PanicMatches,
+		`slot is not of interface "dbus"`)
+}
+
+func (s *DbusInterfaceSuite) TestAttrSchema(c *C) {
+	schema := s.iface.(interfaces.SchemaInterface).AttrSchema()
+	c.Assert(schema.Plug, HasLen, 0)
+	c.Assert(schema.Slot, HasLen, 2)
+	c.Check(schema.Slot[0].Name, Equals, "bus")
+	c.Check(schema.Slot[0].Values, DeepEquals, []string{"system", "session"})
+	c.Check(schema.Slot[1].Name, Equals, "name")
+	c.Check(schema.Slot[1].Required, Equals, true)
+	c.Assert(schema.ValidateSlot(s.systemSlot), IsNil)
+	// Slots without the "bus" attribute are rejected
+	c.Assert(schema.ValidateSlot(s.missingBusSlot), ErrorMatches,
+		`slot attribute "bus" is required`)
+	// Slots with an unknown bus are rejected
+	c.Assert(schema.ValidateSlot(s.badBusSlot), ErrorMatches,
+		`slot attribute "bus" must be one of system, session, not "starter"`)
+	// Slots without the "name" attribute are rejected
+	c.Assert(schema.ValidateSlot(s.missingNameSlot), ErrorMatches,
+		`slot attribute "name" is required`)
+	// Slots with an invalid name are rejected
+	c.Assert(schema.ValidateSlot(s.badNameSlot), ErrorMatches,
+		`slot attribute "name" must match .*, not "noperiods"`)
+}
+
+func (s *DbusInterfaceSuite) TestSanitizePlug(c *C) {
+	c.Assert(s.iface.SanitizePlug(s.plug), IsNil)
+	// It is impossible to use "dbus" interface to sanitize plugs of different interface.
+	c.Assert(func() { s.iface.SanitizePlug(s.badInterfacePlug) }, PanicMatches,
+		`plug is not of interface "dbus"`)
+}
+
+func (s *DbusInterfaceSuite) TestSlotClaim(c *C) {
+	exclusive := s.iface.(interfaces.ExclusiveInterface)
+	c.Assert(exclusive.SlotClaim(s.systemSlot), Equals, `system bus name "org.example.Service"`)
+	c.Assert(exclusive.SlotClaim(s.sessionSlot), Equals, `session bus name "org.example.Session"`)
+}
+
+func (s *DbusInterfaceSuite) TestSlotClaimClashesAcrossSnaps(c *C) {
+	repo := interfaces.NewRepository()
+	c.Assert(repo.AddInterface(s.iface), IsNil)
+	first, err := snap.InfoFromSnapYaml([]byte(`
+name: first
+slots:
+    service:
+        interface: dbus
+        bus: system
+        name: org.example.Service
+`))
+	c.Assert(err, IsNil)
+	c.Assert(repo.AddSnap(first), IsNil)
+	second, err := snap.InfoFromSnapYaml([]byte(`
+name: second
+slots:
+    service:
+        interface: dbus
+        bus: system
+        name: org.example.Service
+    session:
+        interface: dbus
+        bus: session
+        name: org.example.Service
+`))
+	c.Assert(err, IsNil)
+	err = repo.AddSnap(second)
+	c.Assert(err, ErrorMatches, `snap "second" has bad plugs or slots: service \(system bus name "org.example.Service" is already claimed by snap "first"\)`)
+	// The same name on the other bus is not a clash
+	c.Assert(repo.Slot("second", "session"), Not(IsNil))
+}
+
+func (s *DbusInterfaceSuite) TestPermanentSlotSnippetAppArmor(c *C) {
+	snippet, err := s.iface.PermanentSlotSnippet(s.systemSlot, interfaces.SecurityAppArmor)
+	c.Assert(err, IsNil)
+	c.Check(string(snippet), testutil.Contains, "#include <abstractions/dbus-strict>")
+	c.Check(string(snippet), testutil.Contains, "dbus (bind)\n    bus=system\n    name=\"org.example.Service\",\n")
+	snippet, err = s.iface.PermanentSlotSnippet(s.sessionSlot, interfaces.SecurityAppArmor)
+	c.Assert(err, IsNil)
+	c.Check(string(snippet), testutil.Contains, "#include <abstractions/dbus-session-strict>")
+	c.Check(string(snippet), testutil.Contains, "dbus (bind)\n    bus=session\n    name=\"org.example.Session\",\n")
+}
+
+func (s *DbusInterfaceSuite) TestPermanentSlotSnippetDBus(c *C) {
+	snippet, err := s.iface.PermanentSlotSnippet(s.systemSlot, interfaces.SecurityDBus)
+	c.Assert(err, IsNil)
+	c.Check(string(snippet), testutil.Contains, `<allow own="org.example.Service"/>`)
+	c.Check(string(snippet), testutil.Contains, `<allow send_destination="org.example.Service"/>`)
+	// The session bus doesn't use policy files
+	snippet, err = s.iface.PermanentSlotSnippet(s.sessionSlot, interfaces.SecurityDBus)
+	c.Assert(err, IsNil)
+	c.Check(snippet, IsNil)
+}
+
+func (s *DbusInterfaceSuite) TestConnectedPlugSnippetAppArmor(c *C) {
+	snippet, err := s.iface.ConnectedPlugSnippet(s.plug, s.systemSlot, interfaces.SecurityAppArmor)
+	c.Assert(err, IsNil)
+	c.Check(string(snippet), testutil.Contains, "path=/org/example/Service{,/**}\n")
+	c.Check(string(snippet), testutil.Contains, `peer=(name=org.example.Service, label="snap.producer.daemon"),`)
+}
+
+func (s *DbusInterfaceSuite) TestConnectedSlotSnippetAppArmor(c *C) {
+	snippet, err := s.iface.ConnectedSlotSnippet(s.plug, s.systemSlot, interfaces.SecurityAppArmor)
+	c.Assert(err, IsNil)
+	c.Check(string(snippet), testutil.Contains, "path=/org/example/Service{,/**}\n")
+	c.Check(string(snippet), testutil.Contains, `peer=(label="snap.consumer.app"),`)
+}
+
+func (s *DbusInterfaceSuite) TestUnusedSecuritySystems(c *C) {
+	systems := [...]interfaces.SecuritySystem{interfaces.SecurityAppArmor,
+		interfaces.SecuritySecComp, interfaces.SecurityDBus,
+		interfaces.SecurityUDev}
+	for _, system := range systems {
+		snippet, err := s.iface.PermanentPlugSnippet(s.plug, system)
+		c.Assert(err, IsNil)
+		c.Assert(snippet, IsNil)
+	}
+	for _, system := range systems[1:] {
+		snippet, err := s.iface.ConnectedSlotSnippet(s.plug, s.systemSlot, system)
+		c.Assert(err, IsNil)
+		c.Assert(snippet, IsNil)
+	}
+	snippet, err := s.iface.ConnectedPlugSnippet(s.plug, s.systemSlot, interfaces.SecurityUDev)
+	c.Assert(err, IsNil)
+	c.Assert(snippet, IsNil)
+	snippet, err = s.iface.ConnectedPlugSnippet(s.plug, s.systemSlot, interfaces.SecurityDBus)
+	c.Assert(err, IsNil)
+	c.Assert(snippet, IsNil)
+	snippet, err = s.iface.PermanentSlotSnippet(s.systemSlot, interfaces.SecurityUDev)
+	c.Assert(err, IsNil)
+	c.Assert(snippet, IsNil)
+}
+
+func (s *DbusInterfaceSuite) TestUnexpectedSecuritySystems(c *C) {
+	snippet, err := s.iface.PermanentPlugSnippet(s.plug, "foo")
+	c.Assert(err, Equals, interfaces.ErrUnknownSecurity)
+	c.Assert(snippet, IsNil)
+	snippet, err = s.iface.ConnectedPlugSnippet(s.plug, s.systemSlot, "foo")
+	c.Assert(err, Equals, interfaces.ErrUnknownSecurity)
+	c.Assert(snippet, IsNil)
+	snippet, err = s.iface.PermanentSlotSnippet(s.systemSlot, "foo")
+	c.Assert(err, Equals, interfaces.ErrUnknownSecurity)
+	c.Assert(snippet, IsNil)
+	snippet, err = s.iface.ConnectedSlotSnippet(s.plug, s.systemSlot, "foo")
+	c.Assert(err, Equals, interfaces.ErrUnknownSecurity)
+	c.Assert(snippet, IsNil)
+}
+
+func (s *DbusInterfaceSuite) TestAutoConnect(c *C) {
+	c.Check(s.iface.AutoConnect(), Equals, false)
+}
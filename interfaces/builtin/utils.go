@@ -25,6 +25,7 @@ import (
 	"sort"
 
 	"github.com/ubuntu-core/snappy/interfaces"
+	"github.com/ubuntu-core/snappy/snap"
 )
 
 // slotAppLabelExpr returns the specification of the apparmor label describing
@@ -35,17 +36,28 @@ import (
 // - "snap.$snap.{$app1,...$appN}" if there are some, but not all, apps bound
 // - "snap.$snap.*" if all apps are bound to the slot
 func slotAppLabelExpr(slot *interfaces.Slot) []byte {
+	return appLabelExpr(slot.Apps, slot.Snap)
+}
+
+// plugAppLabelExpr returns the specification of the apparmor label describing
+// all the apps bound to a given plug. The result has the same forms as those
+// returned by slotAppLabelExpr.
+func plugAppLabelExpr(plug *interfaces.Plug) []byte {
+	return appLabelExpr(plug.Apps, plug.Snap)
+}
+
+func appLabelExpr(apps map[string]*snap.AppInfo, snapInfo *snap.Info) []byte {
 	var buf bytes.Buffer
-	fmt.Fprintf(&buf, `"snap.%s.`, slot.Snap.Name())
-	if len(slot.Apps) == 1 {
-		for appName := range slot.Apps {
+	fmt.Fprintf(&buf, `"snap.%s.`, snapInfo.Name())
+	if len(apps) == 1 {
+		for appName := range apps {
 			buf.WriteString(appName)
 		}
-	} else if len(slot.Apps) == len(slot.Snap.Apps) {
+	} else if len(apps) == len(snapInfo.Apps) {
 		buf.WriteByte('*')
 	} else {
-		appNames := make([]string, 0, len(slot.Apps))
-		for appName := range slot.Apps {
+		appNames := make([]string, 0, len(apps))
+		for appName := range apps {
 			appNames = append(appNames, appName)
 		}
 		sort.Strings(appNames)
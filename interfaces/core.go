@@ -55,6 +55,8 @@ type SlotRef struct {
 type Interfaces struct {
 	Plugs []*Plug `json:"plugs"`
 	Slots []*Slot `json:"slots"`
+	// Schemas describes the attributes of interfaces that declare them.
+	Schemas map[string]*AttrSchema `json:"schemas,omitempty"`
 }
 
 // Interface describes a group of interchangeable capabilities with common features.
@@ -140,6 +142,21 @@ type Interface interface {
 	AutoConnect() bool
 }
 
+// ExclusiveInterface is implemented by interfaces whose slots claim a
+// resource that can be held by only one snap at a time, such as a well-known
+// D-Bus name.
+//
+// The repository refuses to add a slot whose claim is already held by a slot
+// of another snap.
+type ExclusiveInterface interface {
+	Interface
+
+	// SlotClaim returns a human-readable description of the resource claimed
+	// by a sanitized slot. An empty string is returned when the slot doesn't
+	// claim anything.
+	SlotClaim(slot *Slot) string
+}
+
 // SecuritySystem is a name of a security system.
 type SecuritySystem string
 
@@ -51,15 +51,16 @@ func (b *Backend) Name() string {
 // DBus has no concept of a complain mode so devMode is not supported
 func (b *Backend) Setup(snapInfo *snap.Info, devMode bool, repo *interfaces.Repository) error {
 	snapName := snapInfo.Name()
-	// Get the snippets that apply to this snap
-	snippets, err := repo.SecuritySnippetsForSnap(snapInfo.Name(), interfaces.SecurityDBus)
-	if err != nil {
-		return fmt.Errorf("cannot obtain DBus security snippets for snap %q: %s", snapName, err)
-	}
 	// Get the files that this snap should have
-	content, err := b.combineSnippets(snapInfo, snippets)
+	profiles, err := b.Profiles(snapInfo, devMode, repo)
 	if err != nil {
-		return fmt.Errorf("cannot obtain expected DBus configuration files for snap %q: %s", snapName, err)
+		return err
+	}
+	content := make(map[string]*osutil.FileState, len(profiles))
+	for _, appInfo := range snapInfo.Apps {
+		if profile, ok := profiles[appInfo.Name]; ok {
+			content[fmt.Sprintf("%s.conf", appInfo.SecurityTag())] = &osutil.FileState{Content: profile, Mode: 0644}
+		}
 	}
 	glob := fmt.Sprintf("%s.conf", interfaces.SecurityTagGlob(snapName))
 	dir := dirs.SnapBusPolicyDir
@@ -73,6 +74,17 @@ func (b *Backend) Setup(snapInfo *snap.Info, devMode bool, repo *interfaces.Repo
 	return nil
 }
 
+// Profiles returns the DBus configuration files that Setup would create for each
+// application of a given snap, keyed by application name.
+func (b *Backend) Profiles(snapInfo *snap.Info, devMode bool, repo *interfaces.Repository) (map[string][]byte, error) {
+	snapName := snapInfo.Name()
+	snippets, err := repo.SecuritySnippetsForSnap(snapName, interfaces.SecurityDBus)
+	if err != nil {
+		return nil, fmt.Errorf("cannot obtain DBus security snippets for snap %q: %s", snapName, err)
+	}
+	return b.combineSnippets(snapInfo, snippets), nil
+}
+
 // Remove removes dbus configuration files of a given snap.
 //
 // This method should be called after removing a snap.
@@ -86,8 +98,10 @@ func (b *Backend) Remove(snapName string) error {
 }
 
 // combineSnippets combines security snippets collected from all the interfaces
-// affecting a given snap into a content map applicable to EnsureDirState.
-func (b *Backend) combineSnippets(snapInfo *snap.Info, snippets map[string][][]byte) (content map[string]*osutil.FileState, err error) {
+// affecting a given snap into the configuration files of its applications,
+// keyed by application name. Applications without snippets get none.
+func (b *Backend) combineSnippets(snapInfo *snap.Info, snippets map[string][][]byte) map[string][]byte {
+	profiles := make(map[string][]byte)
 	for _, appInfo := range snapInfo.Apps {
 		appSnippets := snippets[appInfo.Name]
 		if len(appSnippets) == 0 {
@@ -100,11 +114,7 @@ func (b *Backend) combineSnippets(snapInfo *snap.Info, snippets map[string][][]b
 			buf.WriteRune('\n')
 		}
 		buf.Write(xmlFooter)
-		if content == nil {
-			content = make(map[string]*osutil.FileState)
-		}
-		fname := fmt.Sprintf("%s.conf", appInfo.SecurityTag())
-		content[fname] = &osutil.FileState{Content: buf.Bytes(), Mode: 0644}
+		profiles[appInfo.Name] = buf.Bytes()
 	}
-	return content, nil
+	return profiles
 }
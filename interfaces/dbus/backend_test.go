@@ -212,6 +212,27 @@ func (s *backendSuite) TestAppBoundIfaces(c *C) {
 	c.Check(err, IsNil)
 }
 
+func (s *backendSuite) TestProfiles(c *C) {
+	restore := dbus.MockXMLEnvelope([]byte("<?xml>\n"), []byte("</xml>"))
+	defer restore()
+	s.iface.PermanentSlotSnippetCallback = func(slot *interfaces.Slot, securitySystem interfaces.SecuritySystem) ([]byte, error) {
+		return []byte("<policy/>"), nil
+	}
+	snapInfo, err := snap.InfoFromSnapYaml([]byte(sambaYamlWithIfaceBoundToNmbd))
+	c.Assert(err, IsNil)
+	s.addPlugsSlots(c, snapInfo)
+	defer s.removePlugsSlots(c, snapInfo)
+	profiles, err := s.backend.Profiles(snapInfo, false, s.repo)
+	c.Assert(err, IsNil)
+	// Apps without any snippets don't get a configuration file
+	c.Check(profiles, DeepEquals, map[string][]byte{
+		"nmbd": []byte("<?xml>\n<policy/>\n</xml>"),
+	})
+	// Nothing was written to disk
+	_, err = os.Stat(filepath.Join(dirs.SnapBusPolicyDir, "snap.samba.nmbd.conf"))
+	c.Check(os.IsNotExist(err), Equals, true)
+}
+
 // Support code for tests
 
 // installSnap "installs" a snap from YAML.
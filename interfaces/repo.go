@@ -134,6 +134,10 @@ func (r *Repository) AddPlug(plug *Plug) error {
 	if i == nil {
 		return fmt.Errorf("cannot add plug, interface %q is not known", plug.Interface)
 	}
+	// Reject plug with attributes that don't match the interface schema
+	if err := validatePlugAttrs(i, plug); err != nil {
+		return fmt.Errorf("cannot add plug: %v", err)
+	}
 	// Reject plug that don't pass interface-specific sanitization
 	if err := i.SanitizePlug(plug); err != nil {
 		return fmt.Errorf("cannot add plug: %v", err)
@@ -229,9 +233,15 @@ func (r *Repository) AddSlot(slot *Slot) error {
 	if i == nil {
 		return fmt.Errorf("cannot add slot, interface %q is not known", slot.Interface)
 	}
+	if err := validateSlotAttrs(i, slot); err != nil {
+		return fmt.Errorf("cannot add slot: %v", err)
+	}
 	if err := i.SanitizeSlot(slot); err != nil {
 		return fmt.Errorf("cannot add slot: %v", err)
 	}
+	if err := r.checkSlotClaim(i, slot); err != nil {
+		return fmt.Errorf("cannot add slot: %v", err)
+	}
 	if _, ok := r.slots[slot.Snap.Name()][slot.Name]; ok {
 		return fmt.Errorf("cannot add slot, snap %q already has slot %q", slot.Snap.Name(), slot.Name)
 	}
@@ -242,6 +252,52 @@ func (r *Repository) AddSlot(slot *Slot) error {
 	return nil
 }
 
+// validatePlugAttrs checks the attributes of a plug against the schema of
+// the interface, if the interface has one.
+func validatePlugAttrs(i Interface, plug *Plug) error {
+	if si, ok := i.(SchemaInterface); ok {
+		if schema := si.AttrSchema(); schema != nil {
+			return schema.ValidatePlug(plug)
+		}
+	}
+	return nil
+}
+
+// validateSlotAttrs checks the attributes of a slot against the schema of
+// the interface, if the interface has one.
+func validateSlotAttrs(i Interface, slot *Slot) error {
+	if si, ok := i.(SchemaInterface); ok {
+		if schema := si.AttrSchema(); schema != nil {
+			return schema.ValidateSlot(slot)
+		}
+	}
+	return nil
+}
+
+// checkSlotClaim ensures that a slot of an exclusive interface doesn't claim
+// something that is already claimed by a slot of another snap.
+func (r *Repository) checkSlotClaim(i Interface, slot *Slot) error {
+	exclusive, ok := i.(ExclusiveInterface)
+	if !ok {
+		return nil
+	}
+	claim := exclusive.SlotClaim(slot)
+	if claim == "" {
+		return nil
+	}
+	for snapName, slots := range r.slots {
+		if snapName == slot.Snap.Name() {
+			continue
+		}
+		for _, other := range slots {
+			if other.Interface == slot.Interface && exclusive.SlotClaim(other) == claim {
+				return fmt.Errorf("%s is already claimed by snap %q", claim, snapName)
+			}
+		}
+	}
+	return nil
+}
+
 // RemoveSlot removes a named slot from the given snap.
 // Removing a slot that doesn't exist returns an error.
 // Removing a slot that is connected to a plug returns an error.
@@ -447,6 +503,19 @@ func (r *Repository) Interfaces() *Interfaces {
 	}
 	sort.Sort(byPlugSnapAndName(ifaces.Plugs))
 	sort.Sort(bySlotSnapAndName(ifaces.Slots))
+	// Describe the attributes of interfaces that have a schema
+	for name, iface := range r.ifaces {
+		si, ok := iface.(SchemaInterface)
+		if !ok {
+			continue
+		}
+		if schema := si.AttrSchema(); schema != nil {
+			if ifaces.Schemas == nil {
+				ifaces.Schemas = make(map[string]*AttrSchema)
+			}
+			ifaces.Schemas[name] = schema
+		}
+	}
 	return ifaces
 }
 
@@ -461,18 +530,57 @@ func (r *Repository) SecuritySnippetsForSnap(snapName string, securitySystem Sec
 }
 
 func (r *Repository) securitySnippetsForSnap(snapName string, securitySystem SecuritySystem) (map[string][][]byte, error) {
+	sources, err := r.securitySnippetSourcesForSnap(snapName, securitySystem)
+	if err != nil {
+		return nil, err
+	}
 	var snippets = make(map[string][][]byte)
+	for appName, appSources := range sources {
+		for _, source := range appSources {
+			snippets[appName] = append(snippets[appName], source.Snippet)
+		}
+	}
+	return snippets, nil
+}
+
+// SnippetSource describes a security snippet and the plug or slot it came from.
+//
+// Permanent plug and slot snippets only refer to the plug or the slot,
+// connection-specific snippets refer to both.
+type SnippetSource struct {
+	Interface string
+	Plug      *PlugRef
+	Slot      *SlotRef
+	Snippet   []byte
+}
+
+// SecuritySnippetSourcesForSnap collects all of the snippets of a given
+// security system that affect a given snap, along with their origin.
+//
+// The snippets are grouped by application name and are in the same order as
+// the ones returned by SecuritySnippetsForSnap.
+func (r *Repository) SecuritySnippetSourcesForSnap(snapName string, securitySystem SecuritySystem) (map[string][]*SnippetSource, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	return r.securitySnippetSourcesForSnap(snapName, securitySystem)
+}
+
+func (r *Repository) securitySnippetSourcesForSnap(snapName string, securitySystem SecuritySystem) (map[string][]*SnippetSource, error) {
+	var sources = make(map[string][]*SnippetSource)
 	// Find all of the slots that affect this snap because of plug connection.
 	for _, slot := range r.slots[snapName] {
 		iface := r.ifaces[slot.Interface]
+		slotRef := &SlotRef{Snap: slot.Snap.Name(), Name: slot.Name}
 		// Add the static snippet for the slot
 		snippet, err := iface.PermanentSlotSnippet(slot, securitySystem)
 		if err != nil {
 			return nil, err
 		}
 		if snippet != nil {
+			source := &SnippetSource{Interface: slot.Interface, Slot: slotRef, Snippet: snippet}
 			for appName := range slot.Apps {
-				snippets[appName] = append(snippets[appName], snippet)
+				sources[appName] = append(sources[appName], source)
 			}
 		}
 		// Add connection-specific snippet specific to each plug
@@ -484,22 +592,26 @@ func (r *Repository) securitySnippetsForSnap(snapName string, securitySystem Sec
 			if snippet == nil {
 				continue
 			}
+			plugRef := &PlugRef{Snap: plug.Snap.Name(), Name: plug.Name}
+			source := &SnippetSource{Interface: slot.Interface, Plug: plugRef, Slot: slotRef, Snippet: snippet}
 			for appName := range slot.Apps {
-				snippets[appName] = append(snippets[appName], snippet)
+				sources[appName] = append(sources[appName], source)
 			}
 		}
 	}
 	// Find all of the plugs that affect this snap because of slot connection
 	for _, plug := range r.plugs[snapName] {
 		iface := r.ifaces[plug.Interface]
+		plugRef := &PlugRef{Snap: plug.Snap.Name(), Name: plug.Name}
 		// Add the static snippet for the plug
 		snippet, err := iface.PermanentPlugSnippet(plug, securitySystem)
 		if err != nil {
 			return nil, err
 		}
 		if snippet != nil {
+			source := &SnippetSource{Interface: plug.Interface, Plug: plugRef, Snippet: snippet}
 			for appName := range plug.Apps {
-				snippets[appName] = append(snippets[appName], snippet)
+				sources[appName] = append(sources[appName], source)
 			}
 		}
 		// Add connection-specific snippet specific to each slot
@@ -511,12 +623,14 @@ func (r *Repository) securitySnippetsForSnap(snapName string, securitySystem Sec
 			if snippet == nil {
 				continue
 			}
+			slotRef := &SlotRef{Snap: slot.Snap.Name(), Name: slot.Name}
+			source := &SnippetSource{Interface: plug.Interface, Plug: plugRef, Slot: slotRef, Snippet: snippet}
 			for appName := range plug.Apps {
-				snippets[appName] = append(snippets[appName], snippet)
+				sources[appName] = append(sources[appName], source)
 			}
 		}
 	}
-	return snippets, nil
+	return sources, nil
 }
 
 // BadInterfacesError is returned when some snap interfaces could not be registered.
@@ -587,7 +701,7 @@ func (r *Repository) AddSnap(snapInfo *snap.Info) error {
 			continue
 		}
 		plug := &Plug{PlugInfo: plugInfo}
-		if err := iface.SanitizePlug(plug); err != nil {
+		if err := checkPlug(iface, plug); err != nil {
 			bad.issues[plugName] = err.Error()
 			continue
 		}
@@ -604,7 +718,11 @@ func (r *Repository) AddSnap(snapInfo *snap.Info) error {
 			continue
 		}
 		slot := &Slot{SlotInfo: slotInfo}
-		if err := iface.SanitizeSlot(slot); err != nil {
+		if err := checkSlot(iface, slot); err != nil {
+			bad.issues[slotName] = err.Error()
+			continue
+		}
+		if err := r.checkSlotClaim(iface, slot); err != nil {
 			bad.issues[slotName] = err.Error()
 			continue
 		}
@@ -620,6 +738,56 @@ func (r *Repository) AddSnap(snapInfo *snap.Info) error {
 	return nil
 }
 
+// ValidateSnap checks the plugs and slots of the given snap that have known
+// interfaces the way AddSnap does, without adding them to the repository.
+//
+// Unlike AddSnap, which leaves out the plugs and slots that don't validate,
+// this lets installing a snap with malformed plugs or slots fail.
+func (r *Repository) ValidateSnap(snapInfo *snap.Info) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	bad := BadInterfacesError{
+		snap:   snapInfo.Name(),
+		issues: make(map[string]string),
+	}
+	for plugName, plugInfo := range snapInfo.Plugs {
+		if iface, ok := r.ifaces[plugInfo.Interface]; ok {
+			if err := checkPlug(iface, &Plug{PlugInfo: plugInfo}); err != nil {
+				bad.issues[plugName] = err.Error()
+			}
+		}
+	}
+	for slotName, slotInfo := range snapInfo.Slots {
+		if iface, ok := r.ifaces[slotInfo.Interface]; ok {
+			if err := checkSlot(iface, &Slot{SlotInfo: slotInfo}); err != nil {
+				bad.issues[slotName] = err.Error()
+			}
+		}
+	}
+
+	if len(bad.issues) > 0 {
+		return &bad
+	}
+	return nil
+}
+
+// checkPlug validates the attributes of a plug and sanitizes it.
+func checkPlug(iface Interface, plug *Plug) error {
+	if err := validatePlugAttrs(iface, plug); err != nil {
+		return err
+	}
+	return iface.SanitizePlug(plug)
+}
+
+// checkSlot validates the attributes of a slot and sanitizes it.
+func checkSlot(iface Interface, slot *Slot) error {
+	if err := validateSlotAttrs(iface, slot); err != nil {
+		return err
+	}
+	return iface.SanitizeSlot(slot)
+}
+
 // RemoveSnap removes all the plugs and slots associated with a given snap.
 //
 // This function can be used to implement snap removal or, when used along with
@@ -470,6 +470,38 @@ func (s *RepositorySuite) TestAddSlotFailsWithUnsanitizedSlot(c *C) {
 	c.Assert(s.emptyRepo.AllSlots(""), HasLen, 0)
 }
 
+func (s *RepositorySuite) TestAddSlotFailsWithClaimedResource(c *C) {
+	iface := &TestInterface{
+		InterfaceName:     "interface",
+		SlotClaimCallback: func(slot *Slot) string { return "the resource" },
+	}
+	err := s.emptyRepo.AddInterface(iface)
+	c.Assert(err, IsNil)
+	err = s.emptyRepo.AddSlot(s.slot)
+	c.Assert(err, IsNil)
+	// Another snap cannot claim the same resource
+	other := &Slot{
+		SlotInfo: &snap.SlotInfo{
+			Snap:      &snap.Info{SuggestedName: "other"},
+			Name:      "slot",
+			Interface: "interface",
+		},
+	}
+	err = s.emptyRepo.AddSlot(other)
+	c.Assert(err, ErrorMatches, `cannot add slot: the resource is already claimed by snap "producer"`)
+	c.Assert(s.emptyRepo.Slot("other", "slot"), IsNil)
+	// Another slot of the same snap can claim it again
+	again := &Slot{
+		SlotInfo: &snap.SlotInfo{
+			Snap:      s.slot.Snap,
+			Name:      "again",
+			Interface: "interface",
+		},
+	}
+	err = s.emptyRepo.AddSlot(again)
+	c.Assert(err, IsNil)
+}
+
 func (s *RepositorySuite) TestAddSlotStoresCorrectData(c *C) {
 	err := s.testRepo.AddSlot(s.slot)
 	c.Assert(err, IsNil)
@@ -727,6 +759,17 @@ func (s *RepositorySuite) TestInterfacesSmokeTest(c *C) {
 	})
 }
 
+func (s *RepositorySuite) TestInterfacesIncludesSchemas(c *C) {
+	schema := &AttrSchema{
+		Slot: []*AttrSpec{{Name: "path", Type: AttrString, Required: true}},
+	}
+	repo := NewRepository()
+	c.Assert(repo.AddInterface(&TestInterface{InterfaceName: "plain"}), IsNil)
+	c.Assert(repo.AddInterface(&TestInterface{InterfaceName: "described", Schema: schema}), IsNil)
+	ifaces := repo.Interfaces()
+	c.Assert(ifaces.Schemas, DeepEquals, map[string]*AttrSchema{"described": schema})
+}
+
 // Tests for Repository.SecuritySnippetsForSnap()
 
 func (s *RepositorySuite) TestSlotSnippetsForSnapSuccess(c *C) {
@@ -849,6 +892,66 @@ func (s *RepositorySuite) TestSecuritySnippetsForSnapFailureWithPermanentSnippet
 	c.Check(snippets, IsNil)
 }
 
+// Tests for Repository.SecuritySnippetSourcesForSnap()
+
+func (s *RepositorySuite) TestSecuritySnippetSourcesForSnap(c *C) {
+	const testSecurity SecuritySystem = "security"
+	iface := &TestInterface{
+		InterfaceName: "interface",
+		PermanentPlugSnippetCallback: func(plug *Plug, securitySystem SecuritySystem) ([]byte, error) {
+			return []byte(`static plug snippet`), nil
+		},
+		PlugSnippetCallback: func(plug *Plug, slot *Slot, securitySystem SecuritySystem) ([]byte, error) {
+			return []byte(`connection-specific plug snippet`), nil
+		},
+		PermanentSlotSnippetCallback: func(slot *Slot, securitySystem SecuritySystem) ([]byte, error) {
+			return []byte(`static slot snippet`), nil
+		},
+		SlotSnippetCallback: func(plug *Plug, slot *Slot, securitySystem SecuritySystem) ([]byte, error) {
+			return []byte(`connection-specific slot snippet`), nil
+		},
+	}
+	repo := s.emptyRepo
+	c.Assert(repo.AddInterface(iface), IsNil)
+	c.Assert(repo.AddPlug(s.plug), IsNil)
+	c.Assert(repo.AddSlot(s.slot), IsNil)
+	c.Assert(repo.Connect(s.plug.Snap.Name(), s.plug.Name, s.slot.Snap.Name(), s.slot.Name), IsNil)
+	plugRef := &PlugRef{Snap: "consumer", Name: "plug"}
+	slotRef := &SlotRef{Snap: "producer", Name: "slot"}
+	sources, err := repo.SecuritySnippetSourcesForSnap(s.plug.Snap.Name(), testSecurity)
+	c.Assert(err, IsNil)
+	c.Check(sources, DeepEquals, map[string][]*SnippetSource{
+		"app": {
+			{Interface: "interface", Plug: plugRef, Snippet: []byte(`static plug snippet`)},
+			{Interface: "interface", Plug: plugRef, Slot: slotRef, Snippet: []byte(`connection-specific plug snippet`)},
+		},
+	})
+	sources, err = repo.SecuritySnippetSourcesForSnap(s.slot.Snap.Name(), testSecurity)
+	c.Assert(err, IsNil)
+	c.Check(sources, DeepEquals, map[string][]*SnippetSource{
+		"app": {
+			{Interface: "interface", Slot: slotRef, Snippet: []byte(`static slot snippet`)},
+			{Interface: "interface", Plug: plugRef, Slot: slotRef, Snippet: []byte(`connection-specific slot snippet`)},
+		},
+	})
+}
+
+func (s *RepositorySuite) TestSecuritySnippetSourcesForSnapFailure(c *C) {
+	var testSecurity SecuritySystem = "security"
+	iface := &TestInterface{
+		InterfaceName: "interface",
+		PermanentPlugSnippetCallback: func(plug *Plug, securitySystem SecuritySystem) ([]byte, error) {
+			return nil, fmt.Errorf("cannot compute static snippet for provider")
+		},
+	}
+	repo := s.emptyRepo
+	c.Assert(repo.AddInterface(iface), IsNil)
+	c.Assert(repo.AddPlug(s.plug), IsNil)
+	sources, err := repo.SecuritySnippetSourcesForSnap(s.plug.Snap.Name(), testSecurity)
+	c.Assert(err, ErrorMatches, "cannot compute static snippet for provider")
+	c.Check(sources, IsNil)
+}
+
 func (s *RepositorySuite) TestAutoConnectBlacklist(c *C) {
 	// Add two interfaces, one with automatic connections, one with manual
 	repo := s.emptyRepo
@@ -947,6 +1050,73 @@ slots:
 	c.Check(s.repo.Slot("complex", "unknown-slot-iface"), IsNil)
 }
 
+func (s *AddRemoveSuite) TestAddSnapValidatesAttrSchema(c *C) {
+	err := s.repo.AddInterface(&TestInterface{
+		InterfaceName: "described",
+		Schema: &AttrSchema{
+			Plug: []*AttrSpec{{Name: "mode", Type: AttrString, Values: []string{"ro", "rw"}}},
+			Slot: []*AttrSpec{{Name: "path", Type: AttrString, Required: true}},
+		},
+	})
+	c.Assert(err, IsNil)
+	snapInfo, err := snap.InfoFromSnapYaml([]byte(`
+name: described
+plugs:
+    good-plug:
+        interface: described
+        mode: ro
+    bad-plug:
+        interface: described
+        mode: wo
+slots:
+    good-slot:
+        interface: described
+        path: /some/path
+    bad-slot:
+        interface: described
+        path: 42
+`))
+	c.Assert(err, IsNil)
+	err = s.repo.AddSnap(snapInfo)
+	c.Check(err, ErrorMatches,
+		`snap "described" has bad plugs or slots: bad-plug \(plug attribute "mode" must be one of ro, rw, not "wo"\); bad-slot \(slot attribute "path" must be a string, not an int\)`)
+	// Only the plugs and slots with valid attributes were added
+	c.Check(s.repo.Plug("described", "good-plug"), Not(IsNil))
+	c.Check(s.repo.Plug("described", "bad-plug"), IsNil)
+	c.Check(s.repo.Slot("described", "good-slot"), Not(IsNil))
+	c.Check(s.repo.Slot("described", "bad-slot"), IsNil)
+}
+
+func (s *AddRemoveSuite) TestValidateSnap(c *C) {
+	snapInfo, err := snap.InfoFromSnapYaml([]byte(`
+name: complex
+plugs:
+    good-plug:
+        interface: iface
+    bad-plug:
+        interface: invalid
+    unknown-plug:
+        interface: unknown
+slots:
+    bad-slot:
+        interface: invalid
+`))
+	c.Assert(err, IsNil)
+	err = s.repo.ValidateSnap(snapInfo)
+	// plugs and slots of unknown interfaces are not reported
+	c.Check(err, ErrorMatches,
+		`snap "complex" has bad plugs or slots: bad-plug \(plug is invalid\); bad-slot \(slot is invalid\)`)
+	// Nothing was added
+	c.Check(s.repo.Plug("complex", "good-plug"), IsNil)
+}
+
+func (s *AddRemoveSuite) TestValidateSnapValid(c *C) {
+	snapInfo, err := snap.InfoFromSnapYaml([]byte(testConsumerYaml))
+	c.Assert(err, IsNil)
+	c.Check(s.repo.ValidateSnap(snapInfo), IsNil)
+	c.Check(s.repo.Plug("consumer", "iface"), IsNil)
+}
+
 const testConsumerYaml = `
 name: consumer
 apps:
@@ -59,15 +59,16 @@ func (b *Backend) Name() string {
 // them or application present in the snap.
 func (b *Backend) Setup(snapInfo *snap.Info, devMode bool, repo *interfaces.Repository) error {
 	snapName := snapInfo.Name()
-	// Get the snippets that apply to this snap
-	snippets, err := repo.SecuritySnippetsForSnap(snapInfo.Name(), interfaces.SecuritySecComp)
-	if err != nil {
-		return fmt.Errorf("cannot obtain security snippets for snap %q: %s", snapName, err)
-	}
 	// Get the files that this snap should have
-	content, err := b.combineSnippets(snapInfo, devMode, snippets)
+	profiles, err := b.Profiles(snapInfo, devMode, repo)
 	if err != nil {
-		return fmt.Errorf("cannot obtain expected security files for snap %q: %s", snapName, err)
+		return err
+	}
+	content := make(map[string]*osutil.FileState, len(profiles))
+	for _, appInfo := range snapInfo.Apps {
+		if profile, ok := profiles[appInfo.Name]; ok {
+			content[appInfo.SecurityTag()] = &osutil.FileState{Content: profile, Mode: 0644}
+		}
 	}
 	glob := interfaces.SecurityTagGlob(snapName)
 	dir := dirs.SnapSeccompDir
@@ -81,6 +82,17 @@ func (b *Backend) Setup(snapInfo *snap.Info, devMode bool, repo *interfaces.Repo
 	return nil
 }
 
+// Profiles returns the seccomp profiles that Setup would create for each
+// application of a given snap, keyed by application name.
+func (b *Backend) Profiles(snapInfo *snap.Info, devMode bool, repo *interfaces.Repository) (map[string][]byte, error) {
+	snapName := snapInfo.Name()
+	snippets, err := repo.SecuritySnippetsForSnap(snapName, interfaces.SecuritySecComp)
+	if err != nil {
+		return nil, fmt.Errorf("cannot obtain security snippets for snap %q: %s", snapName, err)
+	}
+	return b.combineSnippets(snapInfo, devMode, snippets), nil
+}
+
 // Remove removes seccomp profiles of a given snap.
 func (b *Backend) Remove(snapName string) error {
 	glob := interfaces.SecurityTagGlob(snapName)
@@ -92,8 +104,10 @@ func (b *Backend) Remove(snapName string) error {
 }
 
 // combineSnippets combines security snippets collected from all the interfaces
-// affecting a given snap into a content map applicable to EnsureDirState.
-func (b *Backend) combineSnippets(snapInfo *snap.Info, devMode bool, snippets map[string][][]byte) (content map[string]*osutil.FileState, err error) {
+// affecting a given snap into the profiles of its applications, keyed by
+// application name.
+func (b *Backend) combineSnippets(snapInfo *snap.Info, devMode bool, snippets map[string][][]byte) map[string][]byte {
+	profiles := make(map[string][]byte, len(snapInfo.Apps))
 	for _, appInfo := range snapInfo.Apps {
 		var buf bytes.Buffer
 		if devMode {
@@ -105,14 +119,7 @@ func (b *Backend) combineSnippets(snapInfo *snap.Info, devMode bool, snippets ma
 			buf.Write(snippet)
 			buf.WriteRune('\n')
 		}
-		if content == nil {
-			content = make(map[string]*osutil.FileState)
-		}
-		fname := appInfo.SecurityTag()
-		content[fname] = &osutil.FileState{
-			Content: buf.Bytes(),
-			Mode:    0644,
-		}
+		profiles[appInfo.Name] = buf.Bytes()
 	}
-	return content, nil
+	return profiles
 }
@@ -203,6 +203,26 @@ func (s *backendSuite) TestCombineSnippets(c *C) {
 	}
 }
 
+func (s *backendSuite) TestProfiles(c *C) {
+	restore := seccomp.MockTemplate([]byte("default\n"))
+	defer restore()
+	s.iface.PermanentSlotSnippetCallback = func(slot *interfaces.Slot, securitySystem interfaces.SecuritySystem) ([]byte, error) {
+		return []byte("snippet"), nil
+	}
+	snapInfo, err := snap.InfoFromSnapYaml([]byte(sambaYamlV1))
+	c.Assert(err, IsNil)
+	s.addPlugsSlots(c, snapInfo)
+	defer s.removePlugsSlots(c, snapInfo)
+	profiles, err := s.backend.Profiles(snapInfo, true, s.repo)
+	c.Assert(err, IsNil)
+	c.Check(profiles, DeepEquals, map[string][]byte{
+		"smbd": []byte("@complain\ndefault\nsnippet\n"),
+	})
+	// Nothing was written to disk
+	_, err = os.Stat(filepath.Join(dirs.SnapSeccompDir, "snap.samba.smbd"))
+	c.Check(os.IsNotExist(err), Equals, true)
+}
+
 // Support code for tests
 
 // installSnap "installs" a snap from YAML.
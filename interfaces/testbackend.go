@@ -33,6 +33,8 @@ type TestSecurityBackend struct {
 	SetupCallback func(snapInfo *snap.Info, developerMode bool, repo *Repository) error
 	// RemoveCallback is a callback that is optionally called in Remove
 	RemoveCallback func(snapName string) error
+	// ProfilesCallback is a callback that is optionally called in Profiles
+	ProfilesCallback func(snapInfo *snap.Info, developerMode bool, repo *Repository) (map[string][]byte, error)
 }
 
 // TestSetupCall stores details about calls to TestSecurityBackend.Setup
@@ -65,3 +67,11 @@ func (b *TestSecurityBackend) Remove(snapName string) error {
 	}
 	return b.RemoveCallback(snapName)
 }
+
+// Profiles calls the profiles callback if one is defined.
+func (b *TestSecurityBackend) Profiles(snapInfo *snap.Info, devMode bool, repo *Repository) (map[string][]byte, error) {
+	if b.ProfilesCallback == nil {
+		return nil, nil
+	}
+	return b.ProfilesCallback(snapInfo, devMode, repo)
+}
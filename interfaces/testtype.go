@@ -43,6 +43,10 @@ type TestInterface struct {
 	PlugSnippetCallback func(plug *Plug, slot *Slot, securitySystem SecuritySystem) ([]byte, error)
 	// PermanentPlugSnippetCallback is the callback invoked inside PermanentPlugSnippet()
 	PermanentPlugSnippetCallback func(plug *Plug, securitySystem SecuritySystem) ([]byte, error)
+	// SlotClaimCallback is the callback invoked inside SlotClaim()
+	SlotClaimCallback func(slot *Slot) string
+	// Schema is the attribute schema returned by AttrSchema()
+	Schema *AttrSchema
 }
 
 // String() returns the same value as Name().
@@ -77,6 +81,21 @@ func (t *TestInterface) SanitizeSlot(slot *Slot) error {
 	return nil
 }
 
+// SlotClaim returns the resource claimed by a test slot.
+// Test slots don't claim anything unless a callback is provided.
+func (t *TestInterface) SlotClaim(slot *Slot) string {
+	if t.SlotClaimCallback != nil {
+		return t.SlotClaimCallback(slot)
+	}
+	return ""
+}
+
+// AttrSchema returns the attribute schema of the test interface.
+// Test interfaces don't have a schema unless one is provided.
+func (t *TestInterface) AttrSchema() *AttrSchema {
+	return t.Schema
+}
+
 // ConnectedPlugSnippet returns the configuration snippet "required" to offer a test plug.
 // Providers don't gain any extra permissions.
 func (t *TestInterface) ConnectedPlugSnippet(plug *Plug, slot *Slot, securitySystem SecuritySystem) ([]byte, error) {
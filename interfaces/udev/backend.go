@@ -51,13 +51,15 @@ func (b *Backend) Name() string {
 // If the method fails it should be re-tried (with a sensible strategy) by the caller.
 func (b *Backend) Setup(snapInfo *snap.Info, devMode bool, repo *interfaces.Repository) error {
 	snapName := snapInfo.Name()
-	snippets, err := repo.SecuritySnippetsForSnap(snapInfo.Name(), interfaces.SecurityUDev)
+	profiles, err := b.Profiles(snapInfo, devMode, repo)
 	if err != nil {
-		return fmt.Errorf("cannot obtain udev security snippets for snap %q: %s", snapName, err)
+		return err
 	}
-	content, err := b.combineSnippets(snapInfo, snippets)
-	if err != nil {
-		return fmt.Errorf("cannot obtain expected udev rules for snap %q: %s", snapName, err)
+	content := make(map[string]*osutil.FileState, len(profiles))
+	for _, appInfo := range snapInfo.Apps {
+		if profile, ok := profiles[appInfo.Name]; ok {
+			content[fmt.Sprintf("70-%s.rules", appInfo.SecurityTag())] = &osutil.FileState{Content: profile, Mode: 0644}
+		}
 	}
 	glob := fmt.Sprintf("70-%s.rules", interfaces.SecurityTagGlob(snapName))
 	dir := dirs.SnapUdevRulesDir
@@ -67,6 +69,17 @@ func (b *Backend) Setup(snapInfo *snap.Info, devMode bool, repo *interfaces.Repo
 	return ensureDirState(dir, glob, content, snapName)
 }
 
+// Profiles returns the udev rules that Setup would create for each
+// application of a given snap, keyed by application name.
+func (b *Backend) Profiles(snapInfo *snap.Info, devMode bool, repo *interfaces.Repository) (map[string][]byte, error) {
+	snapName := snapInfo.Name()
+	snippets, err := repo.SecuritySnippetsForSnap(snapName, interfaces.SecurityUDev)
+	if err != nil {
+		return nil, fmt.Errorf("cannot obtain udev security snippets for snap %q: %s", snapName, err)
+	}
+	return b.combineSnippets(snapInfo, snippets), nil
+}
+
 // Remove removes udev rules specific to a given snap.
 // If any of the rules are removed then udev database is reloaded.
 //
@@ -92,8 +105,10 @@ func ensureDirState(dir, glob string, content map[string]*osutil.FileState, snap
 }
 
 // combineSnippets combines security snippets collected from all the interfaces
-// affecting a given snap into a content map applicable to EnsureDirState.
-func (b *Backend) combineSnippets(snapInfo *snap.Info, snippets map[string][][]byte) (content map[string]*osutil.FileState, err error) {
+// affecting a given snap into the udev rules of its applications, keyed by
+// application name. Applications without snippets get none.
+func (b *Backend) combineSnippets(snapInfo *snap.Info, snippets map[string][][]byte) map[string][]byte {
+	profiles := make(map[string][]byte)
 	for _, appInfo := range snapInfo.Apps {
 		appSnippets := snippets[appInfo.Name]
 		if len(appSnippets) == 0 {
@@ -105,11 +120,7 @@ func (b *Backend) combineSnippets(snapInfo *snap.Info, snippets map[string][][]b
 			buf.Write(snippet)
 			buf.WriteRune('\n')
 		}
-		if content == nil {
-			content = make(map[string]*osutil.FileState)
-		}
-		fname := fmt.Sprintf("70-%s.rules", appInfo.SecurityTag())
-		content[fname] = &osutil.FileState{Content: buf.Bytes(), Mode: 0644}
+		profiles[appInfo.Name] = buf.Bytes()
 	}
-	return content, nil
+	return profiles
 }
@@ -227,6 +227,25 @@ func (s *backendSuite) TestCombineSnippetsWithoutAnySnippets(c *C) {
 	}
 }
 
+func (s *backendSuite) TestProfiles(c *C) {
+	s.iface.PermanentSlotSnippetCallback = func(slot *interfaces.Slot, securitySystem interfaces.SecuritySystem) ([]byte, error) {
+		return []byte("dummy"), nil
+	}
+	snapInfo, err := snap.InfoFromSnapYaml([]byte(sambaYamlV1))
+	c.Assert(err, IsNil)
+	s.addPlugsSlots(c, snapInfo)
+	defer s.removePlugsSlots(c, snapInfo)
+	profiles, err := s.backend.Profiles(snapInfo, false, s.repo)
+	c.Assert(err, IsNil)
+	c.Check(profiles, DeepEquals, map[string][]byte{
+		"smbd": []byte("# This file is automatically generated.\ndummy\n"),
+	})
+	// Nothing was written to disk and udev was not reloaded
+	_, err = os.Stat(filepath.Join(dirs.SnapUdevRulesDir, "70-snap.samba.smbd.rules"))
+	c.Check(os.IsNotExist(err), Equals, true)
+	c.Check(s.udevadmCmd.Calls(), HasLen, 0)
+}
+
 // Support code for tests
 
 // installSnap "installs" a snap from YAML.
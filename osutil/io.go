@@ -88,3 +88,18 @@ func AtomicWriteFile(filename string, data []byte, perm os.FileMode, flags Atomi
 
 	return dir.Sync()
 }
+
+// AtomicSymlink makes linkPath a symlink to target, atomically
+// replacing whatever was at linkPath before, by creating the symlink
+// next to it and renaming it into place.
+func AtomicSymlink(target, linkPath string) error {
+	tmp := linkPath + "." + strutil.MakeRandomString(12)
+	if err := os.Symlink(target, tmp); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, linkPath); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
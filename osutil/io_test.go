@@ -174,3 +174,31 @@ func (ts *AtomicWriteTestSuite) TestAtomicWriteFileNoOverwriteTmpExisting(c *C)
 	err = AtomicWriteFile(p, []byte(""), 0600, 0)
 	c.Assert(err, ErrorMatches, "open .*: file exists")
 }
+
+func (ts *AtomicWriteTestSuite) TestAtomicSymlink(c *C) {
+	tmpdir := c.MkDir()
+	p := filepath.Join(tmpdir, "foo")
+
+	c.Assert(AtomicSymlink("target1", p), IsNil)
+	target, err := os.Readlink(p)
+	c.Assert(err, IsNil)
+	c.Check(target, Equals, "target1")
+
+	// replaces an existing symlink or file
+	c.Assert(AtomicSymlink("target2", p), IsNil)
+	target, err = os.Readlink(p)
+	c.Assert(err, IsNil)
+	c.Check(target, Equals, "target2")
+
+	q := filepath.Join(tmpdir, "bar")
+	c.Assert(ioutil.WriteFile(q, nil, 0644), IsNil)
+	c.Assert(AtomicSymlink("target3", q), IsNil)
+	target, err = os.Readlink(q)
+	c.Assert(err, IsNil)
+	c.Check(target, Equals, "target3")
+
+	// no leftovers
+	fis, err := ioutil.ReadDir(tmpdir)
+	c.Assert(err, IsNil)
+	c.Check(fis, HasLen, 2)
+}
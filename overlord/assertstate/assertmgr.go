@@ -22,12 +22,19 @@
 package assertstate
 
 import (
+	"fmt"
 	"os"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/ubuntu-core/snappy/asserts"
 	"github.com/ubuntu-core/snappy/dirs"
 	"github.com/ubuntu-core/snappy/osutil"
+	"github.com/ubuntu-core/snappy/release"
+	"github.com/ubuntu-core/snappy/snap"
 
+	"github.com/ubuntu-core/snappy/overlord/snapstate"
 	"github.com/ubuntu-core/snappy/overlord/state"
 )
 
@@ -36,7 +43,13 @@ import (
 // nothing in it violates existing assertions, or misses required
 // ones.
 type AssertManager struct {
-	db *asserts.Database
+	state *state.State
+	db    *asserts.Database
+
+	mu sync.Mutex
+	// snap ids whose assertions were dropped because of a
+	// revocation, to be recorded in the state
+	revokedSnapIDs map[string]bool
 }
 
 func getTrustedAccountKey() string {
@@ -55,11 +68,248 @@ func Manager(s *state.State) (*AssertManager, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &AssertManager{db: db}, nil
+
+	s.Lock()
+	s.Cache(cachedDBKey{}, db)
+	s.Unlock()
+
+	return &AssertManager{state: s, db: db}, nil
+}
+
+type cachedDBKey struct{}
+
+func cachedDB(s *state.State) *asserts.Database {
+	db := s.Cached(cachedDBKey{})
+	if db == nil {
+		panic("internal error: needing an assertion database before the assertion manager is initialized")
+	}
+	return db.(*asserts.Database)
+}
+
+// DB returns the assertion database cached in the state by the
+// assertion manager, for use by other managers.
+// Note that the state must be locked by the caller.
+func DB(s *state.State) asserts.RODatabase {
+	return cachedDB(s)
+}
+
+// Add adds the given assertion to the system assertion database.
+// Note that the state must be locked by the caller.
+func Add(s *state.State, a asserts.Assertion) error {
+	return cachedDB(s).Add(a)
+}
+
+// ValidateRefresh checks that refreshing to the given snap revision
+// is allowed by the validation assertions in the system database.
+// If any validation assertions exist for the snap, each gating snap
+// that issued them must have approved, and not revoked, the revision.
+// Note that the state must be locked by the caller.
+func ValidateRefresh(s *state.State, info *snap.Info) error {
+	if info.SnapID == "" {
+		return nil
+	}
+	validations, err := DB(s).FindMany(asserts.ValidationType, map[string]string{
+		"series":           release.Series,
+		"approved-snap-id": info.SnapID,
+	})
+	if err == asserts.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	approved := make(map[string]bool)
+	for _, a := range validations {
+		validation := a.(*asserts.Validation)
+		gatingID := validation.SnapID()
+		if validation.ApprovedRevision() == uint64(info.Revision) && !validation.Revoked() {
+			approved[gatingID] = true
+		} else if !approved[gatingID] {
+			approved[gatingID] = false
+		}
+	}
+
+	gatingIDs := make([]string, 0, len(approved))
+	for gatingID := range approved {
+		gatingIDs = append(gatingIDs, gatingID)
+	}
+	sort.Strings(gatingIDs)
+	for _, gatingID := range gatingIDs {
+		if !approved[gatingID] {
+			return fmt.Errorf("cannot refresh snap %q to revision %d: not validated by gating snap-id %q", info.Name(), info.Revision, gatingID)
+		}
+	}
+	return nil
+}
+
+var pruneInterval = 24 * time.Hour
+
+// pruneState is what is kept in the state about the last prune, so
+// that pruning carries on across restarts.
+type pruneState struct {
+	Last time.Time `json:"last"`
+	// snap revisions installed at the last prune, by snap id
+	Installed map[string][]int `json:"installed"`
+}
+
+func (ps *pruneState) installed() map[string]map[int]bool {
+	installed := make(map[string]map[int]bool, len(ps.Installed))
+	for snapID, revisions := range ps.Installed {
+		installed[snapID] = make(map[int]bool, len(revisions))
+		for _, revision := range revisions {
+			installed[snapID][revision] = true
+		}
+	}
+	return installed
+}
+
+// setPruneState records in the state that a prune happened with the
+// given snap revisions installed.
+func setPruneState(st *state.State, installed map[string]map[int]bool) {
+	ps := pruneState{
+		Last:      time.Now(),
+		Installed: make(map[string][]int, len(installed)),
+	}
+	for snapID, revisions := range installed {
+		for revision := range revisions {
+			ps.Installed[snapID] = append(ps.Installed[snapID], revision)
+		}
+		sort.Ints(ps.Installed[snapID])
+	}
+	st.Set("assert-prune", ps)
 }
 
 // Ensure implements StateManager.Ensure.
 func (m *AssertManager) Ensure() error {
+	if err := m.recordRevoked(); err != nil {
+		return err
+	}
+
+	m.state.Lock()
+	defer m.state.Unlock()
+
+	var ps pruneState
+	err := m.state.Get("assert-prune", &ps)
+	if err == state.ErrNoState {
+		// nothing to prune the first time around, remember what
+		// is installed so that snaps removed from now on can be
+		// told apart from ones whose assertions were just acked
+		return m.recordInstalled()
+	}
+	if err != nil {
+		return err
+	}
+	if time.Since(ps.Last) < pruneInterval {
+		return nil
+	}
+	return m.prune(ps.installed())
+}
+
+func installedRevisions(st *state.State) (map[string]*snapstate.SnapState, map[string]map[int]bool, error) {
+	snapStates, err := snapstate.All(st)
+	if err != nil {
+		return nil, nil, err
+	}
+	installed := make(map[string]map[int]bool)
+	for _, snapst := range snapStates {
+		for _, si := range snapst.Sequence {
+			if si.SnapID == "" {
+				continue
+			}
+			if installed[si.SnapID] == nil {
+				installed[si.SnapID] = make(map[int]bool)
+			}
+			installed[si.SnapID][si.Revision] = true
+		}
+	}
+	return snapStates, installed, nil
+}
+
+func (m *AssertManager) recordInstalled() error {
+	_, installed, err := installedRevisions(m.state)
+	if err != nil {
+		return err
+	}
+	setPruneState(m.state, installed)
+	return nil
+}
+
+// prune drops from the system database the snap-revision assertions
+// of snap revisions removed since the last prune, the
+// snap-declaration and validation assertions of snaps removed since
+// then, and the revoked validations superseded by a later approval
+// from the same gating snap. Assertions of snaps that were never
+// installed, as freshly acked ones, are kept. It does nothing while
+// changes are in progress, as these may be installing or removing
+// snaps. pruneInstalled holds the snap revisions installed at the
+// last prune, by snap id.
+func (m *AssertManager) prune(pruneInstalled map[string]map[int]bool) error {
+	for _, chg := range m.state.Changes() {
+		if !chg.Status().Ready() {
+			return nil
+		}
+	}
+
+	snapStates, installed, err := installedRevisions(m.state)
+	if err != nil {
+		return err
+	}
+	removedSnap := func(snapID string) bool {
+		return pruneInstalled[snapID] != nil && installed[snapID] == nil
+	}
+
+	err = m.db.Prune(asserts.SnapRevisionType, func(a asserts.Assertion) bool {
+		snapRev := a.(*asserts.SnapRevision)
+		snapID, revision := snapRev.SnapID(), int(snapRev.SnapRevision())
+		return pruneInstalled[snapID][revision] && !installed[snapID][revision]
+	})
+	if err != nil {
+		return err
+	}
+
+	// gating and approved snap ids with a validation still in force
+	validated := make(map[[2]string]bool)
+	validations, err := m.db.FindMany(asserts.ValidationType, nil)
+	if err != nil && err != asserts.ErrNotFound {
+		return err
+	}
+	for _, a := range validations {
+		validation := a.(*asserts.Validation)
+		if !validation.Revoked() {
+			validated[[2]string{validation.SnapID(), validation.ApprovedSnapID()}] = true
+		}
+	}
+	err = m.db.Prune(asserts.ValidationType, func(a asserts.Assertion) bool {
+		validation := a.(*asserts.Validation)
+		if removedSnap(validation.SnapID()) || removedSnap(validation.ApprovedSnapID()) {
+			return true
+		}
+		return validation.Revoked() && validated[[2]string{validation.SnapID(), validation.ApprovedSnapID()}]
+	})
+	if err != nil {
+		return err
+	}
+	err = m.db.Prune(asserts.SnapDeclarationType, func(a asserts.Assertion) bool {
+		return removedSnap(a.(*asserts.SnapDeclaration).SnapID())
+	})
+	if err != nil {
+		return err
+	}
+
+	// forget about revocations affecting snaps not installed anymore
+	revoked, err := revokedSnaps(m.state)
+	if err != nil {
+		return err
+	}
+	for name := range revoked {
+		if snapStates[name] == nil {
+			delete(revoked, name)
+		}
+	}
+	m.state.Set("revoked-snaps", revoked)
+
+	setPruneState(m.state, installed)
 	return nil
 }
 
@@ -75,3 +325,130 @@ func (m *AssertManager) Wait() {
 func (m *AssertManager) DB() *asserts.Database {
 	return m.db
 }
+
+// AddBatch adds the given assertions to the system database, in
+// dependency order. Missing prerequisites are looked for among the
+// assertions of the batch first and then obtained with retrieve.
+// If the batch revokes account keys the assertions signed with them
+// are dropped, see RevokedSnaps.
+func (m *AssertManager) AddBatch(batch []asserts.Assertion, retrieve func(*asserts.Ref) (asserts.Assertion, error)) error {
+	pool := make(map[string]asserts.Assertion, len(batch))
+	for _, a := range batch {
+		pool[a.Ref().Unique()] = a
+	}
+	retrieveFromPool := func(ref *asserts.Ref) (asserts.Assertion, error) {
+		if a, ok := pool[ref.Unique()]; ok {
+			return a, nil
+		}
+		return retrieve(ref)
+	}
+	f := asserts.NewFetcher(m.db, retrieveFromPool, m.db.Add)
+	revoking := false
+	for _, a := range batch {
+		if err := f.Save(a); err != nil {
+			return err
+		}
+		if accKey, ok := a.(*asserts.AccountKey); ok && accKey.Revoked() {
+			revoking = true
+		}
+	}
+	if revoking {
+		return m.dropRevoked()
+	}
+	return nil
+}
+
+// dropRevoked drops from the system database the assertions signed
+// with revoked account keys. The snaps whose snap-declaration or
+// snap-revision assertions were dropped are recorded in the state by
+// the next Ensure.
+func (m *AssertManager) dropRevoked() error {
+	dropped, err := m.db.DropRevoked()
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, a := range dropped {
+		var snapID string
+		switch x := a.(type) {
+		case *asserts.SnapDeclaration:
+			snapID = x.SnapID()
+		case *asserts.SnapRevision:
+			snapID = x.SnapID()
+		default:
+			continue
+		}
+		if m.revokedSnapIDs == nil {
+			m.revokedSnapIDs = make(map[string]bool)
+		}
+		m.revokedSnapIDs[snapID] = true
+	}
+	if len(m.revokedSnapIDs) != 0 {
+		m.state.EnsureBefore(0)
+	}
+	return nil
+}
+
+// recordRevoked records in the state the installed snaps affected
+// by the revocations processed since the last time.
+func (m *AssertManager) recordRevoked() error {
+	m.mu.Lock()
+	snapIDs := m.revokedSnapIDs
+	m.revokedSnapIDs = nil
+	m.mu.Unlock()
+	if len(snapIDs) == 0 {
+		return nil
+	}
+
+	m.state.Lock()
+	defer m.state.Unlock()
+
+	snapStates, err := snapstate.All(m.state)
+	if err != nil {
+		return err
+	}
+	revoked, err := revokedSnaps(m.state)
+	if err != nil {
+		return err
+	}
+	for name, snapst := range snapStates {
+		for _, si := range snapst.Sequence {
+			if si.SnapID != "" && snapIDs[si.SnapID] {
+				revoked[name] = true
+			}
+		}
+	}
+	m.state.Set("revoked-snaps", revoked)
+	return nil
+}
+
+func revokedSnaps(s *state.State) (map[string]bool, error) {
+	var revoked map[string]bool
+	err := s.Get("revoked-snaps", &revoked)
+	if err != nil && err != state.ErrNoState {
+		return nil, err
+	}
+	if revoked == nil {
+		revoked = make(map[string]bool)
+	}
+	return revoked, nil
+}
+
+// RevokedSnaps returns the sorted names of the installed snaps whose
+// assertions were dropped because they were signed with account keys
+// that got revoked since.
+// Note that the state must be locked by the caller.
+func RevokedSnaps(s *state.State) ([]string, error) {
+	revoked, err := revokedSnaps(s)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(revoked))
+	for name := range revoked {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
@@ -20,25 +20,79 @@
 package assertstate_test
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	. "gopkg.in/check.v1"
 
 	"github.com/ubuntu-core/snappy/asserts"
 	"github.com/ubuntu-core/snappy/dirs"
+	"github.com/ubuntu-core/snappy/snap"
 
 	"github.com/ubuntu-core/snappy/overlord/assertstate"
+	"github.com/ubuntu-core/snappy/overlord/snapstate"
 	"github.com/ubuntu-core/snappy/overlord/state"
 )
 
 func TestAssertManager(t *testing.T) { TestingT(t) }
 
-type assertMgrSuite struct{}
+type assertMgrSuite struct {
+	signingDB *asserts.Database
+	rootKeyID string
+	devKeyID  string
+	devAccKey asserts.Assertion
+}
 
 var _ = Suite(&assertMgrSuite{})
 
 func (ams *assertMgrSuite) SetUpTest(c *C) {
 	dirs.SetRootDir(c.MkDir())
+
+	signingDB, err := asserts.OpenDatabase(&asserts.DatabaseConfig{
+		KeypairManager: asserts.NewMemoryKeypairManager(),
+	})
+	c.Assert(err, IsNil)
+	ams.signingDB = signingDB
+	ams.rootKeyID, err = signingDB.GenerateKey("canonical")
+	c.Assert(err, IsNil)
+	ams.devKeyID, err = signingDB.GenerateKey("developer1")
+	c.Assert(err, IsNil)
+
+	// a self-signed trusted account key
+	rootAccKey := ams.accountKey(c, "canonical", ams.rootKeyID)
+	c.Assert(os.MkdirAll(filepath.Dir(dirs.SnapTrustedAccountKey), 0755), IsNil)
+	c.Assert(ioutil.WriteFile(dirs.SnapTrustedAccountKey, asserts.Encode(rootAccKey), 0644), IsNil)
+
+	ams.devAccKey = ams.accountKey(c, "developer1", ams.devKeyID)
+}
+
+func (ams *assertMgrSuite) accountKey(c *C, accountID, keyID string) asserts.Assertion {
+	pubKey, err := ams.signingDB.PublicKey(accountID, keyID)
+	c.Assert(err, IsNil)
+	encodedPubKey, err := asserts.EncodePublicKey(pubKey)
+	c.Assert(err, IsNil)
+	now := time.Now().UTC()
+	a, err := ams.signingDB.Sign(asserts.AccountKeyType, map[string]interface{}{
+		"authority-id":           "canonical",
+		"account-id":             accountID,
+		"public-key-id":          keyID,
+		"public-key-fingerprint": pubKey.Fingerprint(),
+		"since":                  now.AddDate(-1, 0, 0).Format(time.RFC3339),
+		"until":                  now.AddDate(1, 0, 0).Format(time.RFC3339),
+	}, encodedPubKey, ams.rootKeyID)
+	c.Assert(err, IsNil)
+	return a
+}
+
+func (ams *assertMgrSuite) sign(c *C, assertType *asserts.AssertionType, headers map[string]interface{}) asserts.Assertion {
+	headers["authority-id"] = "canonical"
+	headers["timestamp"] = time.Now().UTC().Format(time.RFC3339)
+	a, err := ams.signingDB.Sign(assertType, headers, nil, ams.rootKeyID)
+	c.Assert(err, IsNil)
+	return a
 }
 
 func (ams *assertMgrSuite) TestManagerAndDB(c *C) {
@@ -49,3 +103,458 @@ func (ams *assertMgrSuite) TestManagerAndDB(c *C) {
 	db := mgr.DB()
 	c.Check(db, FitsTypeOf, (*asserts.Database)(nil))
 }
+
+func (ams *assertMgrSuite) TestAddBatch(c *C) {
+	mgr, err := assertstate.Manager(state.New(nil))
+	c.Assert(err, IsNil)
+
+	identity := ams.sign(c, asserts.IdentityType, map[string]interface{}{
+		"account-id":   "developer1",
+		"display-name": "Developer",
+		"validation":   "unproven",
+	})
+	snapDecl := ams.sign(c, asserts.SnapDeclarationType, map[string]interface{}{
+		"series":       "16",
+		"snap-id":      "snap-id-1",
+		"snap-name":    "foo",
+		"publisher-id": "developer1",
+		"gates":        "",
+	})
+
+	var retrieved []string
+	retrieve := func(ref *asserts.Ref) (asserts.Assertion, error) {
+		retrieved = append(retrieved, ref.String())
+		if ref.Unique() == identity.Ref().Unique() {
+			return identity, nil
+		}
+		return nil, asserts.ErrNotFound
+	}
+
+	// the snap-declaration comes before its prerequisite in the batch
+	err = mgr.AddBatch([]asserts.Assertion{snapDecl, ams.devAccKey}, retrieve)
+	c.Assert(err, IsNil)
+	c.Check(retrieved, DeepEquals, []string{"identity (developer1)"})
+
+	for _, a := range []asserts.Assertion{identity, snapDecl, ams.devAccKey} {
+		_, err := a.Ref().Resolve(mgr.DB().Find)
+		c.Check(err, IsNil)
+	}
+}
+
+func (ams *assertMgrSuite) TestAddBatchKnownRevision(c *C) {
+	mgr, err := assertstate.Manager(state.New(nil))
+	c.Assert(err, IsNil)
+
+	identity := ams.sign(c, asserts.IdentityType, map[string]interface{}{
+		"account-id":   "developer1",
+		"display-name": "Developer",
+		"validation":   "unproven",
+	})
+	retrieve := func(ref *asserts.Ref) (asserts.Assertion, error) {
+		return nil, asserts.ErrNotFound
+	}
+
+	batch := []asserts.Assertion{identity, ams.devAccKey}
+	c.Assert(mgr.AddBatch(batch, retrieve), IsNil)
+	// adding the same revisions again is fine
+	c.Assert(mgr.AddBatch(batch, retrieve), IsNil)
+}
+
+func (ams *assertMgrSuite) TestAddBatchMissingPrerequisite(c *C) {
+	mgr, err := assertstate.Manager(state.New(nil))
+	c.Assert(err, IsNil)
+
+	snapDecl := ams.sign(c, asserts.SnapDeclarationType, map[string]interface{}{
+		"series":       "16",
+		"snap-id":      "snap-id-1",
+		"snap-name":    "foo",
+		"publisher-id": "developer1",
+		"gates":        "",
+	})
+	retrieve := func(ref *asserts.Ref) (asserts.Assertion, error) {
+		return nil, asserts.ErrNotFound
+	}
+
+	err = mgr.AddBatch([]asserts.Assertion{snapDecl}, retrieve)
+	c.Assert(err, ErrorMatches, `cannot retrieve identity \(developer1\): assertion not found`)
+	_, err = snapDecl.Ref().Resolve(mgr.DB().Find)
+	c.Check(err, Equals, asserts.ErrNotFound)
+}
+
+func (ams *assertMgrSuite) TestDB(c *C) {
+	s := state.New(nil)
+	mgr, err := assertstate.Manager(s)
+	c.Assert(err, IsNil)
+
+	s.Lock()
+	defer s.Unlock()
+	c.Check(assertstate.DB(s), Equals, mgr.DB())
+}
+
+func (ams *assertMgrSuite) TestAdd(c *C) {
+	s := state.New(nil)
+	mgr, err := assertstate.Manager(s)
+	c.Assert(err, IsNil)
+
+	s.Lock()
+	defer s.Unlock()
+	err = assertstate.Add(s, ams.devAccKey)
+	c.Assert(err, IsNil)
+
+	_, err = ams.devAccKey.Ref().Resolve(mgr.DB().Find)
+	c.Check(err, IsNil)
+}
+
+func (ams *assertMgrSuite) validation(c *C, revision string, revoked bool) asserts.Assertion {
+	headers := map[string]interface{}{
+		"authority-id":      "developer1",
+		"series":            "16",
+		"snap-id":           "snap-id-1",
+		"approved-snap-id":  "snap-id-2",
+		"approved-revision": revision,
+		"timestamp":         time.Now().UTC().Format(time.RFC3339),
+	}
+	if revoked {
+		headers["revoked"] = "true"
+	}
+	a, err := ams.signingDB.Sign(asserts.ValidationType, headers, nil, ams.devKeyID)
+	c.Assert(err, IsNil)
+	return a
+}
+
+func (ams *assertMgrSuite) setupValidateRefresh(c *C, validations ...asserts.Assertion) *state.State {
+	s := state.New(nil)
+	mgr, err := assertstate.Manager(s)
+	c.Assert(err, IsNil)
+
+	gatingDecl := ams.sign(c, asserts.SnapDeclarationType, map[string]interface{}{
+		"series":       "16",
+		"snap-id":      "snap-id-1",
+		"snap-name":    "gating",
+		"publisher-id": "developer1",
+		"gates":        "snap-id-2",
+	})
+	gatedDecl := ams.sign(c, asserts.SnapDeclarationType, map[string]interface{}{
+		"series":       "16",
+		"snap-id":      "snap-id-2",
+		"snap-name":    "foo",
+		"publisher-id": "developer2",
+		"gates":        "",
+	})
+	for _, a := range append([]asserts.Assertion{ams.devAccKey, gatingDecl, gatedDecl}, validations...) {
+		c.Assert(mgr.DB().Add(a), IsNil)
+	}
+	return s
+}
+
+func fooInfo(revision int) *snap.Info {
+	return &snap.Info{
+		SideInfo: snap.SideInfo{
+			OfficialName: "foo",
+			SnapID:       "snap-id-2",
+			Revision:     revision,
+		},
+	}
+}
+
+func (ams *assertMgrSuite) TestValidateRefreshNoValidations(c *C) {
+	s := ams.setupValidateRefresh(c)
+	s.Lock()
+	defer s.Unlock()
+
+	c.Check(assertstate.ValidateRefresh(s, fooInfo(3)), IsNil)
+}
+
+func (ams *assertMgrSuite) TestValidateRefresh(c *C) {
+	s := ams.setupValidateRefresh(c, ams.validation(c, "3", false))
+	s.Lock()
+	defer s.Unlock()
+
+	c.Check(assertstate.ValidateRefresh(s, fooInfo(3)), IsNil)
+	err := assertstate.ValidateRefresh(s, fooInfo(4))
+	c.Check(err, ErrorMatches, `cannot refresh snap "foo" to revision 4: not validated by gating snap-id "snap-id-1"`)
+}
+
+func (ams *assertMgrSuite) TestValidateRefreshRevoked(c *C) {
+	s := ams.setupValidateRefresh(c, ams.validation(c, "3", true), ams.validation(c, "4", false))
+	s.Lock()
+	defer s.Unlock()
+
+	err := assertstate.ValidateRefresh(s, fooInfo(3))
+	c.Check(err, ErrorMatches, `cannot refresh snap "foo" to revision 3: not validated by gating snap-id "snap-id-1"`)
+	c.Check(assertstate.ValidateRefresh(s, fooInfo(4)), IsNil)
+}
+
+func (ams *assertMgrSuite) snapRevision(c *C, snapID, revision string) asserts.Assertion {
+	return ams.sign(c, asserts.SnapRevisionType, map[string]interface{}{
+		"series":        "16",
+		"snap-id":       snapID,
+		"snap-digest":   "sha512-" + snapID + "-" + revision,
+		"snap-size":     "1000",
+		"snap-revision": revision,
+		"developer-id":  "developer1",
+	})
+}
+
+func (ams *assertMgrSuite) setupPrune(c *C) (*state.State, *assertstate.AssertManager) {
+	s := ams.setupValidateRefresh(c, ams.validation(c, "3", false))
+	mgr, err := assertstate.Manager(s)
+	c.Assert(err, IsNil)
+
+	for _, a := range []asserts.Assertion{
+		ams.snapRevision(c, "snap-id-1", "1"),
+		ams.snapRevision(c, "snap-id-1", "2"),
+		ams.snapRevision(c, "snap-id-2", "3"),
+		// acked but not installed yet
+		ams.snapRevision(c, "snap-id-3", "1"),
+	} {
+		c.Assert(mgr.DB().Add(a), IsNil)
+	}
+
+	s.Lock()
+	snapstate.Set(s, "gating", &snapstate.SnapState{
+		Sequence: []*snap.SideInfo{
+			{OfficialName: "gating", SnapID: "snap-id-1", Revision: 1},
+			{OfficialName: "gating", SnapID: "snap-id-1", Revision: 2},
+		},
+		Active: true,
+	})
+	snapstate.Set(s, "foo", &snapstate.SnapState{
+		Sequence: []*snap.SideInfo{{OfficialName: "foo", SnapID: "snap-id-2", Revision: 3}},
+		Active:   true,
+	})
+	s.Unlock()
+
+	// the first Ensure only records what is installed
+	c.Assert(mgr.Ensure(), IsNil)
+	return s, mgr
+}
+
+// removeForPrune drops revision 1 of the gating snap and removes foo.
+func (ams *assertMgrSuite) removeForPrune(s *state.State) {
+	s.Lock()
+	defer s.Unlock()
+	snapstate.Set(s, "gating", &snapstate.SnapState{
+		Sequence: []*snap.SideInfo{{OfficialName: "gating", SnapID: "snap-id-1", Revision: 2}},
+		Active:   true,
+	})
+	snapstate.Set(s, "foo", nil)
+}
+
+func (ams *assertMgrSuite) count(c *C, db asserts.RODatabase, assertType *asserts.AssertionType) int {
+	res, err := db.FindMany(assertType, nil)
+	if err == asserts.ErrNotFound {
+		return 0
+	}
+	c.Assert(err, IsNil)
+	return len(res)
+}
+
+func (ams *assertMgrSuite) TestPruneNotAtStartup(c *C) {
+	restore := assertstate.MockPruneInterval(0)
+	defer restore()
+	s, mgr := ams.setupPrune(c)
+	ams.removeForPrune(s)
+
+	c.Check(ams.count(c, mgr.DB(), asserts.SnapRevisionType), Equals, 4)
+	c.Check(ams.count(c, mgr.DB(), asserts.SnapDeclarationType), Equals, 2)
+	c.Check(ams.count(c, mgr.DB(), asserts.ValidationType), Equals, 1)
+}
+
+func (ams *assertMgrSuite) TestPrune(c *C) {
+	restore := assertstate.MockPruneInterval(0)
+	defer restore()
+	s, mgr := ams.setupPrune(c)
+	ams.removeForPrune(s)
+
+	c.Assert(mgr.Ensure(), IsNil)
+
+	snapRevs, err := mgr.DB().FindMany(asserts.SnapRevisionType, nil)
+	c.Assert(err, IsNil)
+	c.Assert(snapRevs, HasLen, 2)
+	got := make(map[string]bool)
+	for _, a := range snapRevs {
+		got[a.Header("snap-id")+"/"+a.Header("snap-revision")] = true
+	}
+	// the acked but not installed snap-revision is kept
+	c.Check(got, DeepEquals, map[string]bool{"snap-id-1/2": true, "snap-id-3/1": true})
+
+	snapDecls, err := mgr.DB().FindMany(asserts.SnapDeclarationType, nil)
+	c.Assert(err, IsNil)
+	c.Assert(snapDecls, HasLen, 1)
+	c.Check(snapDecls[0].Header("snap-id"), Equals, "snap-id-1")
+
+	// the gated snap was removed
+	c.Check(ams.count(c, mgr.DB(), asserts.ValidationType), Equals, 0)
+	// other assertions are kept
+	c.Check(ams.count(c, mgr.DB(), asserts.AccountKeyType), Equals, 2)
+}
+
+func (ams *assertMgrSuite) TestPruneSupersededRevokedValidations(c *C) {
+	restore := assertstate.MockPruneInterval(0)
+	defer restore()
+	_, mgr := ams.setupPrune(c)
+	// superseded by the approval of revision 3
+	c.Assert(mgr.DB().Add(ams.validation(c, "2", true)), IsNil)
+
+	c.Assert(mgr.Ensure(), IsNil)
+	c.Check(ams.count(c, mgr.DB(), asserts.ValidationType), Equals, 1)
+
+	// a revoked validation still in force is kept
+	headers := ams.validation(c, "3", true).Headers()
+	headers["revision"] = "1"
+	revoked, err := ams.signingDB.Sign(asserts.ValidationType, headers, nil, ams.devKeyID)
+	c.Assert(err, IsNil)
+	c.Assert(mgr.DB().Add(revoked), IsNil)
+
+	c.Assert(mgr.Ensure(), IsNil)
+	c.Check(ams.count(c, mgr.DB(), asserts.ValidationType), Equals, 1)
+
+	c.Assert(mgr.DB().Add(ams.validation(c, "4", false)), IsNil)
+	c.Assert(mgr.Ensure(), IsNil)
+
+	validations, err := mgr.DB().FindMany(asserts.ValidationType, nil)
+	c.Assert(err, IsNil)
+	c.Assert(validations, HasLen, 1)
+	c.Check(validations[0].Header("approved-revision"), Equals, "4")
+
+	// nothing was removed
+	c.Check(ams.count(c, mgr.DB(), asserts.SnapRevisionType), Equals, 4)
+	c.Check(ams.count(c, mgr.DB(), asserts.SnapDeclarationType), Equals, 2)
+}
+
+func (ams *assertMgrSuite) TestPruneNotWhileChangesInProgress(c *C) {
+	restore := assertstate.MockPruneInterval(0)
+	defer restore()
+	s, mgr := ams.setupPrune(c)
+	ams.removeForPrune(s)
+
+	s.Lock()
+	chg := s.NewChange("install-snap", "...")
+	chg.AddTask(s.NewTask("foo", "..."))
+	s.Unlock()
+
+	c.Assert(mgr.Ensure(), IsNil)
+	c.Check(ams.count(c, mgr.DB(), asserts.SnapRevisionType), Equals, 4)
+
+	s.Lock()
+	chg.SetStatus(state.DoneStatus)
+	s.Unlock()
+
+	c.Assert(mgr.Ensure(), IsNil)
+	c.Check(ams.count(c, mgr.DB(), asserts.SnapRevisionType), Equals, 2)
+}
+
+func (ams *assertMgrSuite) TestPruneAcrossRestarts(c *C) {
+	restore := assertstate.MockPruneInterval(0)
+	defer restore()
+	s, _ := ams.setupPrune(c)
+	ams.removeForPrune(s)
+
+	// what was installed at the last prune is kept in the state
+	s.Lock()
+	var pruned map[string]interface{}
+	c.Assert(s.Get("assert-prune", &pruned), IsNil)
+	s.Unlock()
+	c.Check(pruned["installed"], DeepEquals, map[string]interface{}{
+		"snap-id-1": []interface{}{1.0, 2.0},
+		"snap-id-2": []interface{}{3.0},
+	})
+
+	// a new manager, as after a restart, carries on pruning
+	mgr, err := assertstate.Manager(s)
+	c.Assert(err, IsNil)
+	c.Assert(mgr.Ensure(), IsNil)
+	c.Check(ams.count(c, mgr.DB(), asserts.SnapRevisionType), Equals, 2)
+	c.Check(ams.count(c, mgr.DB(), asserts.SnapDeclarationType), Equals, 1)
+}
+
+func (ams *assertMgrSuite) TestPruneOnlyOncePerInterval(c *C) {
+	s, mgr := ams.setupPrune(c)
+	ams.removeForPrune(s)
+
+	c.Assert(mgr.Ensure(), IsNil)
+	c.Check(ams.count(c, mgr.DB(), asserts.SnapRevisionType), Equals, 4)
+}
+
+func (ams *assertMgrSuite) TestAddBatchRevokedKey(c *C) {
+	restore := assertstate.MockPruneInterval(0)
+	defer restore()
+	s := state.New(nil)
+	mgr, err := assertstate.Manager(s)
+	c.Assert(err, IsNil)
+
+	// a store key certified by the trusted root key
+	storeKeyID, err := ams.signingDB.GenerateKey("canonical")
+	c.Assert(err, IsNil)
+	storeAccKey := ams.accountKey(c, "canonical", storeKeyID)
+	c.Assert(mgr.DB().Add(storeAccKey), IsNil)
+
+	for _, snapID := range []string{"snap-id-1", "snap-id-2"} {
+		snapRev, err := ams.signingDB.Sign(asserts.SnapRevisionType, map[string]interface{}{
+			"authority-id":  "canonical",
+			"series":        "16",
+			"snap-id":       snapID,
+			"snap-digest":   "sha512-" + snapID,
+			"snap-size":     "1000",
+			"snap-revision": "1",
+			"developer-id":  "developer1",
+			"timestamp":     time.Now().UTC().Format(time.RFC3339),
+		}, nil, storeKeyID)
+		c.Assert(err, IsNil)
+		c.Assert(mgr.DB().Add(snapRev), IsNil)
+	}
+	// a snap-revision signed with the root key is not affected
+	c.Assert(mgr.DB().Add(ams.snapRevision(c, "snap-id-3", "1")), IsNil)
+
+	s.Lock()
+	snapstate.Set(s, "foo", &snapstate.SnapState{
+		Sequence: []*snap.SideInfo{{OfficialName: "foo", SnapID: "snap-id-1", Revision: 1}},
+		Active:   true,
+	})
+	snapstate.Set(s, "bar", &snapstate.SnapState{
+		Sequence: []*snap.SideInfo{{OfficialName: "bar", SnapID: "snap-id-3", Revision: 1}},
+		Active:   true,
+	})
+	s.Unlock()
+
+	// revoke the store key
+	headers := storeAccKey.Headers()
+	headers["revision"] = "1"
+	headers["revoked"] = "true"
+	revokedKey, err := ams.signingDB.Sign(asserts.AccountKeyType, headers, storeAccKey.Body(), ams.rootKeyID)
+	c.Assert(err, IsNil)
+
+	retrieve := func(ref *asserts.Ref) (asserts.Assertion, error) {
+		return nil, asserts.ErrNotFound
+	}
+	err = mgr.AddBatch([]asserts.Assertion{revokedKey}, retrieve)
+	c.Assert(err, IsNil)
+
+	snapRevs, err := mgr.DB().FindMany(asserts.SnapRevisionType, nil)
+	c.Assert(err, IsNil)
+	c.Assert(snapRevs, HasLen, 1)
+	c.Check(snapRevs[0].Header("snap-id"), Equals, "snap-id-3")
+
+	// the affected snaps are recorded by the next ensure, which
+	// also records what is installed for pruning
+	c.Assert(mgr.Ensure(), IsNil)
+
+	s.Lock()
+	revoked, err := assertstate.RevokedSnaps(s)
+	s.Unlock()
+	c.Assert(err, IsNil)
+	c.Check(revoked, DeepEquals, []string{"foo"})
+
+	// once the snap is removed it is forgotten by the next pruning
+	s.Lock()
+	snapstate.Set(s, "foo", nil)
+	s.Unlock()
+	c.Assert(mgr.Ensure(), IsNil)
+
+	s.Lock()
+	revoked, err = assertstate.RevokedSnaps(s)
+	s.Unlock()
+	c.Assert(err, IsNil)
+	c.Check(revoked, HasLen, 0)
+}
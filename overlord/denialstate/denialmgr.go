@@ -0,0 +1,294 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+// Package denialstate implements the manager and state aspects responsible
+// for recording the operations denied by the confinement of snaps.
+package denialstate
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ubuntu-core/snappy/dirs"
+	"github.com/ubuntu-core/snappy/logger"
+	"github.com/ubuntu-core/snappy/overlord/snapstate"
+	"github.com/ubuntu-core/snappy/overlord/state"
+)
+
+// maxDenialsPerSnap is the number of distinct denials remembered per snap.
+// The least recently seen denials are dropped first.
+const maxDenialsPerSnap = 100
+
+// DenialManager is responsible for collecting the operations denied by the
+// confinement of snaps, most notably the ones a snap in developer mode would
+// have been denied, from the kernel and audit logs.
+type DenialManager struct {
+	state  *state.State
+	source string
+}
+
+// Manager returns a new denial manager, scanning dirs.SnapDenialLogFile.
+func Manager(s *state.State) (*DenialManager, error) {
+	return &DenialManager{state: s, source: dirs.SnapDenialLogFile}, nil
+}
+
+// logPosition records how far the log was scanned.
+type logPosition struct {
+	Source string `json:"source"`
+	Offset int64  `json:"offset"`
+}
+
+// Ensure implements StateManager.Ensure.
+//
+// It scans the part of the log written since the last call and records the
+// denials found there. A log is first looked at from its end, what was
+// logged before is not scanned.
+func (m *DenialManager) Ensure() error {
+	m.state.Lock()
+	var pos logPosition
+	err := m.state.Get("denials-log", &pos)
+	m.state.Unlock()
+	if err != nil && err != state.ErrNoState {
+		return err
+	}
+	if pos.Source != m.source {
+		return m.startAtEnd()
+	}
+
+	denials, offset, err := scanLog(m.source, pos.Offset)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("cannot scan %q for denials: %v", m.source, err)
+	}
+	// this runs external commands, so it is done without holding the lock
+	resolveSyscalls(denials)
+
+	m.state.Lock()
+	defer m.state.Unlock()
+	pos.Offset = offset
+	m.state.Set("denials-log", pos)
+	return record(m.state, denials)
+}
+
+// startAtEnd records the current end of the log as the place where the
+// next scan starts.
+func (m *DenialManager) startAtEnd() error {
+	var offset int64
+	fi, err := os.Stat(m.source)
+	switch {
+	case err == nil:
+		offset = fi.Size()
+	case !os.IsNotExist(err):
+		return fmt.Errorf("cannot scan %q for denials: %v", m.source, err)
+	}
+
+	m.state.Lock()
+	defer m.state.Unlock()
+	m.state.Set("denials-log", logPosition{Source: m.source, Offset: offset})
+	return nil
+}
+
+// Stop implements StateManager.Stop.
+func (m *DenialManager) Stop() {
+}
+
+// Wait implements StateManager.Wait.
+func (m *DenialManager) Wait() {
+}
+
+// scanLog parses the log starting at the given offset. It returns the denials
+// found and the offset where the next scan should start. If the log shrunk,
+// it was rotated and is scanned from the start.
+func scanLog(path string, offset int64) ([]*Denial, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+	if fi.Size() < offset {
+		offset = 0
+	}
+	if _, err := f.Seek(offset, 0); err != nil {
+		return nil, 0, err
+	}
+
+	var denials []*Denial
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadString('\n')
+		if err == io.EOF {
+			// leave incomplete lines for the next scan
+			break
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		offset += int64(len(line))
+		if d := parseLine(line); d != nil {
+			denials = append(denials, d)
+		}
+	}
+	return denials, offset, nil
+}
+
+// resolveSyscall returns the name of a system call given its number and
+// audit architecture.
+var resolveSyscall = func(arch, number string) (string, error) {
+	archName, ok := auditArchNames[arch]
+	if !ok {
+		return "", fmt.Errorf("unknown audit architecture %q", arch)
+	}
+	output, err := exec.Command("scmp_sys_resolver", "-a", archName, number).Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// resolveSyscalls replaces the numbers of the system calls of the
+// seccomp denials with their names, when they can be resolved.
+func resolveSyscalls(denials []*Denial) {
+	resolved := make(map[string]string)
+	for _, d := range denials {
+		if d.Security != "seccomp" {
+			continue
+		}
+		key := d.arch + "/" + d.Target
+		name, ok := resolved[key]
+		if !ok {
+			var err error
+			name, err = resolveSyscall(d.arch, d.Target)
+			if err != nil {
+				logger.Debugf("cannot resolve system call %s: %v", d.Target, err)
+			}
+			resolved[key] = name
+		}
+		if name != "" {
+			d.Target = name
+		}
+	}
+}
+
+// auditArchNames maps audit architectures to libseccomp architecture names.
+var auditArchNames = map[string]string{
+	"40000003": "x86",
+	"c000003e": "x86_64",
+	"40000028": "arm",
+	"c00000b7": "aarch64",
+	"c0000015": "ppc64le",
+	"80000016": "s390x",
+}
+
+// record merges denials into the state. Denials of snaps that are not
+// installed are skipped and the ones recorded for removed snaps are dropped.
+func record(st *state.State, denials []*Denial) error {
+	all, err := allDenials(st)
+	if err != nil {
+		return err
+	}
+	if len(denials) == 0 && len(all) == 0 {
+		return nil
+	}
+	installed, err := snapstate.All(st)
+	if err != nil {
+		return err
+	}
+	for snapName := range all {
+		if installed[snapName] == nil {
+			delete(all, snapName)
+		}
+	}
+	now := time.Now().UTC()
+	for _, d := range denials {
+		if installed[d.Snap] == nil {
+			continue
+		}
+		if d.FirstSeen.IsZero() {
+			d.FirstSeen = now
+		}
+		d.LastSeen = d.FirstSeen
+		d.Count = 1
+		all[d.Snap] = merge(all[d.Snap], d)
+	}
+	st.Set("denials", all)
+	return nil
+}
+
+// merge adds a denial to a list, collapsing it with an identical one.
+func merge(denials []*Denial, d *Denial) []*Denial {
+	key := d.key()
+	for _, old := range denials {
+		if old.key() == key {
+			old.Count++
+			if d.LastSeen.After(old.LastSeen) {
+				old.LastSeen = d.LastSeen
+			}
+			old.Complain = old.Complain || d.Complain
+			return denials
+		}
+	}
+	denials = append(denials, d)
+	if len(denials) > maxDenialsPerSnap {
+		sort.Sort(byLastSeen(denials))
+		denials = denials[len(denials)-maxDenialsPerSnap:]
+	}
+	return denials
+}
+
+type byLastSeen []*Denial
+
+func (s byLastSeen) Len() int           { return len(s) }
+func (s byLastSeen) Less(i, j int) bool { return s[i].LastSeen.Before(s[j].LastSeen) }
+func (s byLastSeen) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+func allDenials(st *state.State) (map[string][]*Denial, error) {
+	var all map[string][]*Denial
+	err := st.Get("denials", &all)
+	if err == state.ErrNoState {
+		return make(map[string][]*Denial), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return all, nil
+}
+
+// Denials returns the denials recorded for the given snap, least recently
+// seen first.
+func Denials(st *state.State, snapName string) ([]*Denial, error) {
+	all, err := allDenials(st)
+	if err != nil {
+		return nil, err
+	}
+	denials := all[snapName]
+	sort.Sort(byLastSeen(denials))
+	return denials, nil
+}
@@ -0,0 +1,290 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package denialstate_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/ubuntu-core/snappy/dirs"
+	"github.com/ubuntu-core/snappy/overlord/denialstate"
+	"github.com/ubuntu-core/snappy/overlord/snapstate"
+	"github.com/ubuntu-core/snappy/overlord/state"
+	"github.com/ubuntu-core/snappy/snap"
+)
+
+func TestDenialManager(t *testing.T) { TestingT(t) }
+
+type denialMgrSuite struct {
+	state   *state.State
+	mgr     *denialstate.DenialManager
+	restore func()
+}
+
+var _ = Suite(&denialMgrSuite{})
+
+const (
+	appArmorLine   = `May  5 10:00:00 localhost kernel: [  123.456789] audit: type=1400 audit(1462442400.123:45): apparmor="ALLOWED" operation="open" profile="snap.foo.bar" name="/var/log/syslog" pid=1234 comm="bar" requested_mask="r" denied_mask="r" fsuid=0 ouid=0` + "\n"
+	capabilityLine = `May  5 10:00:01 localhost kernel: [  124.456789] audit: type=1400 audit(1462442401.000:46): apparmor="DENIED" operation="capable" profile="snap.foo.bar" pid=1234 comm="bar" capability=12  capname="net_admin"` + "\n"
+	secCompLine    = `May  5 10:00:02 localhost kernel: [  125.456789] audit: type=1326 audit(1462442402.000:47): auid=4294967295 uid=0 gid=0 ses=4294967295 subj=snap.foo.bar pid=1234 comm="bar" exe="/snap/foo/1/bin/bar" sig=31 arch=c000003e syscall=101 compat=0 ip=0x7f0000000000 code=0x0` + "\n"
+	otherSnapLine  = `May  5 10:00:03 localhost kernel: [  126.456789] audit: type=1400 audit(1462442403.000:48): apparmor="DENIED" operation="open" profile="snap.other.app" name="/etc/shadow" pid=1235 comm="app" requested_mask="r" denied_mask="r" fsuid=0 ouid=0` + "\n"
+	unrelatedLine  = `May  5 10:00:04 localhost kernel: [  127.456789] usb 1-1: new high-speed USB device number 2 using ehci-pci` + "\n"
+)
+
+func (s *denialMgrSuite) SetUpTest(c *C) {
+	dirs.SetRootDir(c.MkDir())
+	c.Assert(os.MkdirAll(filepath.Dir(dirs.SnapDenialLogFile), 0755), IsNil)
+
+	s.state = state.New(nil)
+	var err error
+	s.mgr, err = denialstate.Manager(s.state)
+	c.Assert(err, IsNil)
+
+	s.restore = denialstate.MockResolveSyscall(func(arch, number string) (string, error) {
+		if arch == "c000003e" && number == "101" {
+			return "ptrace", nil
+		}
+		return "", fmt.Errorf("unknown system call")
+	})
+
+	s.state.Lock()
+	snapstate.Set(s.state, "foo", &snapstate.SnapState{
+		Sequence: []*snap.SideInfo{{OfficialName: "foo", Revision: 1}},
+		Active:   true,
+		Flags:    snapstate.DevMode,
+	})
+	s.state.Unlock()
+
+	// the first scan only finds where the log ends
+	c.Assert(s.mgr.Ensure(), IsNil)
+}
+
+func (s *denialMgrSuite) TearDownTest(c *C) {
+	s.restore()
+	dirs.SetRootDir("/")
+}
+
+func (s *denialMgrSuite) appendLog(c *C, lines ...string) {
+	f, err := os.OpenFile(dirs.SnapDenialLogFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	c.Assert(err, IsNil)
+	defer f.Close()
+	for _, line := range lines {
+		_, err := f.WriteString(line)
+		c.Assert(err, IsNil)
+	}
+}
+
+func (s *denialMgrSuite) denials(c *C, snapName string) []*denialstate.Denial {
+	s.state.Lock()
+	defer s.state.Unlock()
+	denials, err := denialstate.Denials(s.state, snapName)
+	c.Assert(err, IsNil)
+	return denials
+}
+
+func (s *denialMgrSuite) TestParseAppArmorLine(c *C) {
+	d := denialstate.ParseLine(appArmorLine)
+	c.Assert(d, NotNil)
+	c.Check(d.Snap, Equals, "foo")
+	c.Check(d.App, Equals, "bar")
+	c.Check(d.Security, Equals, "apparmor")
+	c.Check(d.Operation, Equals, "open")
+	c.Check(d.Target, Equals, "/var/log/syslog")
+	c.Check(d.Mask, Equals, "r")
+	c.Check(d.Complain, Equals, true)
+	c.Check(d.FirstSeen.Equal(time.Unix(1462442400, 123000000)), Equals, true)
+}
+
+func (s *denialMgrSuite) TestParseCapabilityLine(c *C) {
+	d := denialstate.ParseLine(capabilityLine)
+	c.Assert(d, NotNil)
+	c.Check(d.Operation, Equals, "capable")
+	c.Check(d.Target, Equals, "net_admin")
+	c.Check(d.Mask, Equals, "")
+	c.Check(d.Complain, Equals, false)
+}
+
+func (s *denialMgrSuite) TestParseDBusLine(c *C) {
+	line := `May  5 10:00:00 localhost dbus[700]: apparmor="DENIED" operation="dbus_method_call"  bus="system" path="/org/freedesktop/hostname1" interface="org.freedesktop.DBus.Properties" member="GetAll" mask="send" name="org.freedesktop.hostname1" pid=1234 label="snap.foo.bar" peer_pid=800 peer_label="unconfined"`
+	d := denialstate.ParseLine(line)
+	c.Assert(d, NotNil)
+	c.Check(d.App, Equals, "bar")
+	c.Check(d.Operation, Equals, "dbus_method_call")
+	c.Check(d.Target, Equals, "org.freedesktop.DBus.Properties.GetAll")
+	c.Check(d.Mask, Equals, "send")
+}
+
+func (s *denialMgrSuite) TestParseSecCompLine(c *C) {
+	d := denialstate.ParseLine(secCompLine)
+	c.Assert(d, NotNil)
+	c.Check(d.Snap, Equals, "foo")
+	c.Check(d.App, Equals, "bar")
+	c.Check(d.Security, Equals, "seccomp")
+	c.Check(d.Operation, Equals, "syscall")
+	c.Check(d.Target, Equals, "101")
+	c.Check(d.Arch(), Equals, "c000003e")
+}
+
+func (s *denialMgrSuite) TestParseIgnoresUnrelatedLines(c *C) {
+	c.Check(denialstate.ParseLine(unrelatedLine), IsNil)
+	// not a snap
+	c.Check(denialstate.ParseLine(`audit: type=1400 apparmor="DENIED" operation="open" profile="/usr/sbin/cupsd" name="/etc/shadow"`), IsNil)
+	// not a denial
+	c.Check(denialstate.ParseLine(`audit: type=1400 apparmor="STATUS" operation="profile_load" profile="unconfined" name="snap.foo.bar"`), IsNil)
+}
+
+func (s *denialMgrSuite) TestEnsureRecordsDenials(c *C) {
+	s.appendLog(c, appArmorLine, unrelatedLine, capabilityLine, secCompLine, otherSnapLine)
+	c.Assert(s.mgr.Ensure(), IsNil)
+
+	denials := s.denials(c, "foo")
+	c.Assert(denials, HasLen, 3)
+	c.Check(denials[0].Target, Equals, "/var/log/syslog")
+	c.Check(denials[1].Target, Equals, "net_admin")
+	c.Check(denials[2].Target, Equals, "ptrace")
+	for _, d := range denials {
+		c.Check(d.Count, Equals, 1)
+	}
+	// denials of snaps that are not installed are not recorded
+	c.Check(s.denials(c, "other"), HasLen, 0)
+}
+
+func (s *denialMgrSuite) TestEnsureDeduplicates(c *C) {
+	s.appendLog(c, appArmorLine)
+	c.Assert(s.mgr.Ensure(), IsNil)
+	// nothing new was logged
+	c.Assert(s.mgr.Ensure(), IsNil)
+	later := `May  5 11:00:00 localhost kernel: [ 3723.456789] audit: type=1400 audit(1462446000.000:99): apparmor="ALLOWED" operation="open" profile="snap.foo.bar" name="/var/log/syslog" pid=1234 comm="bar" requested_mask="r" denied_mask="r" fsuid=0 ouid=0` + "\n"
+	s.appendLog(c, later)
+	c.Assert(s.mgr.Ensure(), IsNil)
+
+	denials := s.denials(c, "foo")
+	c.Assert(denials, HasLen, 1)
+	c.Check(denials[0].Count, Equals, 2)
+	c.Check(denials[0].FirstSeen.Equal(time.Unix(1462442400, 123000000)), Equals, true)
+	c.Check(denials[0].LastSeen.Equal(time.Unix(1462446000, 0)), Equals, true)
+}
+
+func (s *denialMgrSuite) TestEnsureLeavesIncompleteLines(c *C) {
+	s.appendLog(c, appArmorLine[:40])
+	c.Assert(s.mgr.Ensure(), IsNil)
+	c.Check(s.denials(c, "foo"), HasLen, 0)
+	s.appendLog(c, appArmorLine[40:])
+	c.Assert(s.mgr.Ensure(), IsNil)
+	c.Check(s.denials(c, "foo"), HasLen, 1)
+}
+
+func (s *denialMgrSuite) TestEnsureHandlesRotation(c *C) {
+	s.appendLog(c, unrelatedLine, unrelatedLine, appArmorLine)
+	c.Assert(s.mgr.Ensure(), IsNil)
+	c.Assert(ioutil.WriteFile(dirs.SnapDenialLogFile, []byte(capabilityLine), 0644), IsNil)
+	c.Assert(s.mgr.Ensure(), IsNil)
+	c.Check(s.denials(c, "foo"), HasLen, 2)
+}
+
+func (s *denialMgrSuite) TestEnsureStartsAtEndOfLog(c *C) {
+	source := filepath.Join(c.MkDir(), "kern.log")
+	c.Assert(ioutil.WriteFile(source, []byte(capabilityLine), 0644), IsNil)
+	restore := denialstate.MockDenialLogFile(source)
+	defer restore()
+
+	resolved := 0
+	restore = denialstate.MockResolveSyscall(func(arch, number string) (string, error) {
+		resolved++
+		return "ptrace", nil
+	})
+	defer restore()
+
+	mgr, err := denialstate.Manager(s.state)
+	c.Assert(err, IsNil)
+	// what was logged before is not scanned
+	c.Assert(mgr.Ensure(), IsNil)
+	c.Check(s.denials(c, "foo"), HasLen, 0)
+
+	f, err := os.OpenFile(source, os.O_WRONLY|os.O_APPEND, 0644)
+	c.Assert(err, IsNil)
+	_, err = f.WriteString(secCompLine + secCompLine)
+	f.Close()
+	c.Assert(err, IsNil)
+	c.Assert(mgr.Ensure(), IsNil)
+	denials := s.denials(c, "foo")
+	c.Assert(denials, HasLen, 1)
+	c.Check(denials[0].Target, Equals, "ptrace")
+	c.Check(denials[0].Count, Equals, 2)
+	// the same system call is resolved once
+	c.Check(resolved, Equals, 1)
+}
+
+func (s *denialMgrSuite) TestEnsureWithoutLog(c *C) {
+	c.Assert(s.mgr.Ensure(), IsNil)
+	c.Check(s.denials(c, "foo"), HasLen, 0)
+}
+
+func (s *denialMgrSuite) TestEnsureDropsRemovedSnaps(c *C) {
+	s.appendLog(c, appArmorLine)
+	c.Assert(s.mgr.Ensure(), IsNil)
+	s.state.Lock()
+	snapstate.Set(s.state, "foo", nil)
+	s.state.Unlock()
+	s.appendLog(c, unrelatedLine)
+	c.Assert(s.mgr.Ensure(), IsNil)
+	c.Check(s.denials(c, "foo"), HasLen, 0)
+}
+
+func (s *denialMgrSuite) TestManagerUsesDenialLogFile(c *C) {
+	source := filepath.Join(c.MkDir(), "audit.log")
+	restore := denialstate.MockDenialLogFile(source)
+	defer restore()
+
+	mgr, err := denialstate.Manager(s.state)
+	c.Assert(err, IsNil)
+	c.Assert(mgr.Ensure(), IsNil)
+	c.Assert(ioutil.WriteFile(source, []byte(capabilityLine), 0644), IsNil)
+	c.Assert(mgr.Ensure(), IsNil)
+	denials := s.denials(c, "foo")
+	c.Assert(denials, HasLen, 1)
+	c.Check(denials[0].Target, Equals, "net_admin")
+}
+
+func (s *denialMgrSuite) TestSuggestInterfaces(c *C) {
+	for _, t := range []struct {
+		denial     denialstate.Denial
+		interfaces []string
+	}{
+		{denialstate.Denial{Security: "apparmor", Operation: "open", Target: "/var/log/syslog", Mask: "r"}, []string{"log-observe"}},
+		{denialstate.Denial{Security: "apparmor", Operation: "open", Target: "/var/log/syslog", Mask: "w"}, nil},
+		{denialstate.Denial{Security: "apparmor", Operation: "open", Target: "/home/user/file", Mask: "rw"}, []string{"home"}},
+		{denialstate.Denial{Security: "apparmor", Operation: "open", Target: "/etc/shadow", Mask: "r"}, nil},
+		{denialstate.Denial{Security: "apparmor", Operation: "capable", Target: "net_admin"}, []string{"firewall-control", "network-control"}},
+		{denialstate.Denial{Security: "apparmor", Operation: "dbus_method_call", Target: "org.freedesktop.DBus.Properties.GetAll"}, nil},
+		{denialstate.Denial{Security: "seccomp", Operation: "syscall", Target: "bind"}, []string{"firewall-control", "network-bind"}},
+		// system-observe explicitly denies ptrace
+		{denialstate.Denial{Security: "seccomp", Operation: "syscall", Target: "ptrace"}, nil},
+		{denialstate.Denial{Security: "other", Operation: "frobnicate"}, nil},
+	} {
+		c.Check(denialstate.SuggestInterfaces(&t.denial), DeepEquals, t.interfaces, Commentf("%+v", t.denial))
+	}
+}
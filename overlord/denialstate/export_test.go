@@ -0,0 +1,46 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package denialstate
+
+import (
+	"github.com/ubuntu-core/snappy/dirs"
+)
+
+// ParseLine exposes parseLine for testing.
+var ParseLine = parseLine
+
+// MockDenialLogFile replaces the log the managers created afterwards scan.
+func MockDenialLogFile(path string) (restore func()) {
+	old := dirs.SnapDenialLogFile
+	dirs.SnapDenialLogFile = path
+	return func() { dirs.SnapDenialLogFile = old }
+}
+
+// MockResolveSyscall replaces the function resolving system call numbers.
+func MockResolveSyscall(f func(arch, number string) (string, error)) (restore func()) {
+	old := resolveSyscall
+	resolveSyscall = f
+	return func() { resolveSyscall = old }
+}
+
+// Arch returns the audit architecture of a seccomp denial.
+func (d *Denial) Arch() string {
+	return d.arch
+}
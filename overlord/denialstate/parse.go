@@ -0,0 +1,176 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package denialstate
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Denial describes an operation that the confinement of a snap denied, or
+// would have denied if the snap wasn't installed in developer mode.
+type Denial struct {
+	Snap string `json:"snap"`
+	App  string `json:"app"`
+	// Security is the name of the security system reporting the denial.
+	Security string `json:"security"`
+	// Operation is the kind of operation that was attempted, e.g. "open",
+	// "capable" or "syscall".
+	Operation string `json:"operation"`
+	// Target is the object of the operation, e.g. a path, a capability or
+	// a system call name.
+	Target string `json:"target,omitempty"`
+	// Mask is the set of requested permissions, if applicable.
+	Mask string `json:"mask,omitempty"`
+	// Complain is true when the operation was allowed because the profile
+	// was in complain mode.
+	Complain bool `json:"complain,omitempty"`
+
+	Count     int       `json:"count"`
+	FirstSeen time.Time `json:"first-seen"`
+	LastSeen  time.Time `json:"last-seen"`
+
+	// arch is the audit architecture of seccomp denials, needed to
+	// resolve the system call number.
+	arch string
+}
+
+// key returns the string identifying duplicates of a denial.
+func (d *Denial) key() string {
+	return strings.Join([]string{d.App, d.Security, d.Operation, d.Target, d.Mask}, "\x00")
+}
+
+var (
+	auditFieldRx = regexp.MustCompile(`([a-z_]+)=("[^"]*"|[^ ]+)`)
+	auditStampRx = regexp.MustCompile(`audit\(([0-9]+)\.([0-9]+):[0-9]+\)`)
+)
+
+// auditFields returns the key=value fields of an audit message, unquoted.
+func auditFields(line string) map[string]string {
+	fields := make(map[string]string)
+	for _, m := range auditFieldRx.FindAllStringSubmatch(line, -1) {
+		if _, ok := fields[m[1]]; !ok {
+			fields[m[1]] = strings.Trim(m[2], `"`)
+		}
+	}
+	return fields
+}
+
+// auditTime returns the time recorded in an audit message or the zero time.
+func auditTime(line string) time.Time {
+	m := auditStampRx.FindStringSubmatch(line)
+	if m == nil {
+		return time.Time{}
+	}
+	sec, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	msec, err := strconv.ParseInt(m[2], 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(sec, msec*int64(time.Millisecond)).UTC()
+}
+
+// splitSecurityTag splits a security tag of the form snap.$snap.$app.
+func splitSecurityTag(tag string) (snapName, appName string, ok bool) {
+	parts := strings.SplitN(tag, ".", 3)
+	if len(parts) != 3 || parts[0] != "snap" || parts[1] == "" || parts[2] == "" {
+		return "", "", false
+	}
+	return parts[1], parts[2], true
+}
+
+// parseLine parses a kernel or audit log line.
+//
+// Lines that don't describe apparmor or seccomp denials of snap applications
+// are ignored by returning nil.
+func parseLine(line string) *Denial {
+	switch {
+	case strings.Contains(line, "apparmor="):
+		return parseAppArmorLine(line)
+	case strings.Contains(line, "type=1326"):
+		return parseSecCompLine(line)
+	}
+	return nil
+}
+
+func parseAppArmorLine(line string) *Denial {
+	fields := auditFields(line)
+	var complain bool
+	switch fields["apparmor"] {
+	case "DENIED":
+	case "ALLOWED":
+		complain = true
+	default:
+		return nil
+	}
+	// The kernel reports the profile, dbus-daemon reports the label.
+	tag := fields["profile"]
+	if tag == "" {
+		tag = fields["label"]
+	}
+	snapName, appName, ok := splitSecurityTag(tag)
+	if !ok {
+		return nil
+	}
+	d := &Denial{
+		Snap:      snapName,
+		App:       appName,
+		Security:  "apparmor",
+		Operation: fields["operation"],
+		Complain:  complain,
+		FirstSeen: auditTime(line),
+	}
+	switch {
+	case d.Operation == "capable":
+		d.Target = fields["capname"]
+	case strings.HasPrefix(d.Operation, "dbus_"):
+		d.Target = fields["interface"] + "." + fields["member"]
+		d.Mask = fields["mask"]
+	default:
+		d.Target = fields["name"]
+		d.Mask = fields["requested_mask"]
+	}
+	return d
+}
+
+func parseSecCompLine(line string) *Denial {
+	fields := auditFields(line)
+	snapName, appName, ok := splitSecurityTag(fields["subj"])
+	if !ok {
+		return nil
+	}
+	if fields["syscall"] == "" {
+		return nil
+	}
+	return &Denial{
+		Snap:      snapName,
+		App:       appName,
+		Security:  "seccomp",
+		Operation: "syscall",
+		Target:    fields["syscall"],
+		FirstSeen: auditTime(line),
+		arch:      fields["arch"],
+	}
+}
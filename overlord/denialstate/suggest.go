@@ -0,0 +1,218 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package denialstate
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ubuntu-core/snappy/interfaces"
+	"github.com/ubuntu-core/snappy/interfaces/builtin"
+	"github.com/ubuntu-core/snappy/snap"
+)
+
+// SuggestInterfaces returns the names of the builtin interfaces, offered by
+// the OS snap, whose connected plugs would be granted the operation described
+// by the given denial.
+func SuggestInterfaces(d *Denial) []string {
+	var securitySystem interfaces.SecuritySystem
+	var grants func(snippet []byte, d *Denial) bool
+	switch d.Security {
+	case "apparmor":
+		securitySystem = interfaces.SecurityAppArmor
+		grants = appArmorGrants
+	case "seccomp":
+		securitySystem = interfaces.SecuritySecComp
+		grants = secCompGrants
+	default:
+		return nil
+	}
+
+	osSnap := &snap.Info{
+		SuggestedName: "ubuntu-core",
+		Type:          snap.TypeOS,
+		Slots:         make(map[string]*snap.SlotInfo),
+	}
+	snap.AddImplicitSlots(osSnap)
+	consumer := &snap.Info{SuggestedName: d.Snap}
+
+	var suggestions []string
+	for _, iface := range builtin.Interfaces() {
+		slotInfo := osSnap.Slots[iface.Name()]
+		if slotInfo == nil {
+			continue
+		}
+		slot := &interfaces.Slot{SlotInfo: slotInfo}
+		plug := &interfaces.Plug{PlugInfo: &snap.PlugInfo{
+			Snap:      consumer,
+			Name:      iface.Name(),
+			Interface: iface.Name(),
+		}}
+		var snippets [][]byte
+		if snippet, err := iface.PermanentPlugSnippet(plug, securitySystem); err == nil {
+			snippets = append(snippets, snippet)
+		}
+		if snippet, err := iface.ConnectedPlugSnippet(plug, slot, securitySystem); err == nil {
+			snippets = append(snippets, snippet)
+		}
+		for _, snippet := range snippets {
+			if grants(snippet, d) {
+				suggestions = append(suggestions, iface.Name())
+				break
+			}
+		}
+	}
+	return suggestions
+}
+
+// snippetLines returns the non-empty, non-comment lines of a snippet.
+func snippetLines(snippet []byte) []string {
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(snippet))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// secCompGrants checks if a seccomp snippet allows the denied system call.
+func secCompGrants(snippet []byte, d *Denial) bool {
+	for _, line := range snippetLines(snippet) {
+		if strings.Fields(line)[0] == d.Target {
+			return true
+		}
+	}
+	return false
+}
+
+// appArmorVariables holds the values of apparmor variables used by snippets.
+var appArmorVariables = strings.NewReplacer(
+	"@{PROC}", "/proc",
+	"@{HOME}", "{/home/*,/root}",
+)
+
+// appArmorGrants checks if an apparmor snippet allows the denied operation.
+//
+// Only capabilities and file rules are considered, DBus and network rules
+// are never matched.
+func appArmorGrants(snippet []byte, d *Denial) bool {
+	for _, line := range snippetLines(snippet) {
+		fields := strings.Fields(strings.TrimSuffix(line, ","))
+		if len(fields) > 0 && (fields[0] == "owner" || fields[0] == "audit") {
+			fields = fields[1:]
+		}
+		if len(fields) != 2 {
+			continue
+		}
+		if d.Operation == "capable" {
+			if fields[0] == "capability" && fields[1] == d.Target {
+				return true
+			}
+			continue
+		}
+		path, perms := fields[0], fields[1]
+		if !strings.HasPrefix(path, "/") && !strings.HasPrefix(path, "@{") {
+			continue
+		}
+		if d.Target == "" || !appArmorPermsCover(perms, d.Mask) {
+			continue
+		}
+		rx, err := appArmorGlobRegexp(appArmorVariables.Replace(path))
+		if err != nil {
+			continue
+		}
+		if rx.MatchString(d.Target) {
+			return true
+		}
+	}
+	return false
+}
+
+// appArmorPermsCover checks if the permissions of a file rule cover a
+// requested mask, as reported in apparmor audit messages.
+func appArmorPermsCover(perms, mask string) bool {
+	for _, c := range mask {
+		switch c {
+		case 'a', 'c', 'd':
+			// append, create and delete are implied by write
+			c = 'w'
+		}
+		if !strings.ContainsRune(perms, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// appArmorGlobRegexp converts an apparmor path glob to a regular expression.
+func appArmorGlobRegexp(glob string) (*regexp.Regexp, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('^')
+	depth := 0
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				buf.WriteString(".*")
+				i++
+			} else {
+				buf.WriteString("[^/]*")
+			}
+		case '?':
+			buf.WriteString("[^/]")
+		case '{':
+			buf.WriteString("(?:")
+			depth++
+		case '}':
+			if depth == 0 {
+				return nil, fmt.Errorf("unbalanced braces in %q", glob)
+			}
+			buf.WriteByte(')')
+			depth--
+		case ',':
+			if depth > 0 {
+				buf.WriteByte('|')
+			} else {
+				buf.WriteByte(',')
+			}
+		case '[':
+			j := strings.IndexByte(glob[i:], ']')
+			if j < 0 {
+				return nil, fmt.Errorf("unbalanced brackets in %q", glob)
+			}
+			buf.WriteString(glob[i : i+j+1])
+			i += j
+		default:
+			buf.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unbalanced braces in %q", glob)
+	}
+	buf.WriteByte('$')
+	return regexp.Compile(buf.String())
+}
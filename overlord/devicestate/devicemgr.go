@@ -0,0 +1,448 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+// Package devicestate implements the manager and state aspects
+// responsible for the device identity and the enforcement of its
+// model assertion.
+package devicestate
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+
+	"gopkg.in/tomb.v2"
+
+	"github.com/ubuntu-core/snappy/asserts"
+	"github.com/ubuntu-core/snappy/dirs"
+	"github.com/ubuntu-core/snappy/overlord/assertstate"
+	"github.com/ubuntu-core/snappy/overlord/auth"
+	"github.com/ubuntu-core/snappy/overlord/state"
+	"github.com/ubuntu-core/snappy/release"
+	"github.com/ubuntu-core/snappy/snap"
+)
+
+// DeviceManager is responsible for the device identity and for
+// making sure the snaps on the device are consistent with its model.
+type DeviceManager struct {
+	state     *state.State
+	assertMgr *assertstate.AssertManager
+	runner    *state.TaskRunner
+
+	// seeding is attempted once per run
+	seedAttempted bool
+}
+
+// Manager returns a new device manager, importing assertions
+// through the given assertion manager.
+func Manager(s *state.State, assertMgr *assertstate.AssertManager) (*DeviceManager, error) {
+	runner := state.NewTaskRunner(s)
+	m := &DeviceManager{state: s, assertMgr: assertMgr, runner: runner}
+
+	runner.AddHandler("generate-device-key", m.doGenerateDeviceKey, nil)
+	runner.AddHandler("request-serial", m.doRequestSerial, nil)
+	runner.AddHandler("mark-seeded", m.doMarkSeeded, nil)
+
+	return m, nil
+}
+
+// Ensure implements StateManager.Ensure.
+func (m *DeviceManager) Ensure() error {
+	var errs []error
+	if err := m.ensureSeeded(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := m.ensureOperational(); err != nil {
+		errs = append(errs, err)
+	}
+
+	m.runner.Ensure()
+
+	if len(errs) != 0 {
+		return &ensureError{errs}
+	}
+	return nil
+}
+
+type ensureError struct {
+	errs []error
+}
+
+func (e *ensureError) Error() string {
+	if len(e.errs) == 1 {
+		return fmt.Sprintf("devicemgr: %v", e.errs[0])
+	}
+	return fmt.Sprintf("devicemgr: %v", e.errs)
+}
+
+// Wait implements StateManager.Wait.
+func (m *DeviceManager) Wait() {
+	m.runner.Wait()
+}
+
+// Stop implements StateManager.Stop.
+func (m *DeviceManager) Stop() {
+	m.runner.Stop()
+}
+
+// ensureOperational starts the registration of the device, that is
+// getting it a device key and a device-serial assertion, once it
+// has a model and unless it is already registered or registering.
+// A failed registration is attempted again on a later ensure, waiting
+// longer after each failure.
+func (m *DeviceManager) ensureOperational() error {
+	m.state.Lock()
+	defer m.state.Unlock()
+
+	device, err := auth.Device(m.state)
+	if err != nil {
+		return err
+	}
+	if device.Brand == "" || device.Model == "" || device.Serial != "" {
+		return nil
+	}
+
+	var lastFailure time.Time
+	failures := 0
+	for _, chg := range m.state.Changes() {
+		if chg.Kind() != "become-operational" {
+			continue
+		}
+		if !chg.Status().Ready() {
+			return nil
+		}
+		failures++
+		if chg.ReadyTime().After(lastFailure) {
+			lastFailure = chg.ReadyTime()
+		}
+	}
+	if failures > 0 {
+		delay := retryInterval
+		for i := 1; i < failures && delay < maxRetryInterval; i++ {
+			delay *= 2
+		}
+		if delay > maxRetryInterval {
+			delay = maxRetryInterval
+		}
+		if time.Now().Before(lastFailure.Add(delay)) {
+			return nil
+		}
+	}
+
+	genKey := m.state.NewTask("generate-device-key", "Generate device key")
+	requestSerial := m.state.NewTask("request-serial", "Request device serial")
+	requestSerial.WaitFor(genKey)
+
+	chg := m.state.NewChange("become-operational", "Initialize device")
+	chg.AddTask(genKey)
+	chg.AddTask(requestSerial)
+	return nil
+}
+
+// Model returns the model assertion of the device. It returns
+// state.ErrNoState if the device has no model yet.
+// Note that the state must be locked by the caller.
+func Model(st *state.State) (*asserts.Model, error) {
+	device, err := auth.Device(st)
+	if err != nil {
+		return nil, err
+	}
+	if device.Brand == "" || device.Model == "" {
+		return nil, state.ErrNoState
+	}
+
+	a, err := assertstate.DB(st).Find(asserts.ModelType, map[string]string{
+		"series":   release.Series,
+		"brand-id": device.Brand,
+		"model":    device.Model,
+	})
+	if err == asserts.ErrNotFound {
+		return nil, fmt.Errorf("cannot find model assertion for %s/%s", device.Brand, device.Model)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return a.(*asserts.Model), nil
+}
+
+// SetModel records the given model as the one of the device. The
+// model assertion must already be in the system assertion database.
+// Note that the state must be locked by the caller.
+func SetModel(st *state.State, model *asserts.Model) error {
+	device, err := auth.Device(st)
+	if err != nil {
+		return err
+	}
+	if device.Brand != "" && (device.Brand != model.BrandID() || device.Model != model.Model()) {
+		return fmt.Errorf("cannot set model %s/%s: device already has model %s/%s", model.BrandID(), model.Model(), device.Brand, device.Model)
+	}
+	device.Brand = model.BrandID()
+	device.Model = model.Model()
+	return auth.SetDevice(st, device)
+}
+
+// StoreID returns the id of the store named by the model of the
+// device, or an empty string if the device has no model yet.
+// Note that the state must be locked by the caller.
+func StoreID(st *state.State) (string, error) {
+	model, err := Model(st)
+	if err == state.ErrNoState {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return model.Store(), nil
+}
+
+// CheckInstall checks that the given snap can be installed on the
+// device, refusing kernel and gadget snaps other than the ones named by
+// its model.
+// Note that the state must be locked by the caller.
+func CheckInstall(st *state.State, info *snap.Info) error {
+	if info.Type != snap.TypeKernel && info.Type != snap.TypeGadget {
+		return nil
+	}
+
+	model, err := Model(st)
+	if err == state.ErrNoState {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	expected := model.Kernel()
+	if info.Type == snap.TypeGadget {
+		expected = model.Gadget()
+	}
+	if info.Name() != expected {
+		return fmt.Errorf("cannot install %s snap %q, model %q requires %q", info.Type, info.Name(), model.Model(), expected)
+	}
+	return nil
+}
+
+// CheckRemove checks that the named snap can be removed from the
+// device, refusing the snaps required by its model.
+// Note that the state must be locked by the caller.
+func CheckRemove(st *state.State, name string) error {
+	model, err := Model(st)
+	if err == state.ErrNoState {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, required := range model.RequiredSnaps() {
+		if required == name {
+			return fmt.Errorf("cannot remove snap %q, it is required by model %q", name, model.Model())
+		}
+	}
+	return nil
+}
+
+func deviceKeypairManager() (asserts.KeypairManager, error) {
+	return asserts.OpenFSKeypairManager(dirs.SnapDeviceDir)
+}
+
+func (m *DeviceManager) doGenerateDeviceKey(t *state.Task, _ *tomb.Tomb) error {
+	st := t.State()
+	st.Lock()
+	device, err := auth.Device(st)
+	st.Unlock()
+	if err != nil {
+		return err
+	}
+	if device.KeyID != "" {
+		// nothing to do
+		return nil
+	}
+
+	// generating the key takes a while, do it without holding the lock
+	keypairMgr, err := deviceKeypairManager()
+	if err != nil {
+		return err
+	}
+	db, err := asserts.OpenDatabase(&asserts.DatabaseConfig{
+		KeypairManager: keypairMgr,
+	})
+	if err != nil {
+		return err
+	}
+	keyID, err := db.GenerateKey(device.Brand)
+	if err != nil {
+		return err
+	}
+
+	st.Lock()
+	defer st.Unlock()
+	device, err = auth.Device(st)
+	if err != nil {
+		return err
+	}
+	device.KeyID = keyID
+	return auth.SetDevice(st, device)
+}
+
+// serialRequestURL returns the URL of the serial vending service.
+func serialRequestURL() string {
+	if os.Getenv("SNAPPY_USE_STAGING_MYAPPS") != "" {
+		return "https://myapps.developer.staging.ubuntu.com/identity/api/v1/devices"
+	}
+	if os.Getenv("SNAPPY_FORCE_SERIAL_VENDOR_URL") != "" {
+		return os.Getenv("SNAPPY_FORCE_SERIAL_VENDOR_URL")
+	}
+	return "https://myapps.developer.ubuntu.com/identity/api/v1/devices"
+}
+
+var (
+	retryInterval    = 30 * time.Second
+	maxRetryInterval = 30 * time.Minute
+)
+
+var httpClient = &http.Client{Timeout: 60 * time.Second}
+
+func (m *DeviceManager) doRequestSerial(t *state.Task, tomb *tomb.Tomb) error {
+	st := t.State()
+	st.Lock()
+	device, err := auth.Device(st)
+	st.Unlock()
+	if err != nil {
+		return err
+	}
+	if device.Serial != "" {
+		// nothing to do
+		return nil
+	}
+
+	serialReq, err := prepareSerialRequest(device)
+	if err != nil {
+		return err
+	}
+
+	interval := retryInterval
+	var serial *asserts.DeviceSerial
+	for {
+		serial, err = submitSerialRequest(serialReq, tomb.Dying())
+		if err == nil {
+			break
+		}
+		select {
+		case <-tomb.Dying():
+			return state.Retry
+		default:
+		}
+		st.Lock()
+		t.Errorf("%v; retrying in %v", err, interval)
+		st.Unlock()
+		select {
+		case <-time.After(interval):
+		case <-tomb.Dying():
+			return state.Retry
+		}
+		interval *= 2
+		if interval > maxRetryInterval {
+			interval = maxRetryInterval
+		}
+	}
+
+	if serial.BrandID() != device.Brand || serial.Model() != device.Model {
+		return fmt.Errorf("obtained serial assertion is for %s/%s, expected %s/%s", serial.BrandID(), serial.Model(), device.Brand, device.Model)
+	}
+	if serial.DeviceKey().ID() != device.KeyID {
+		return fmt.Errorf("obtained serial assertion does not match the device key")
+	}
+
+	st.Lock()
+	defer st.Unlock()
+	if err := assertstate.Add(st, serial); err != nil {
+		return err
+	}
+	device.Serial = serial.Serial()
+	return auth.SetDevice(st, device)
+}
+
+// prepareSerialRequest builds the serial-request assertion for the
+// device, signed with the device key.
+func prepareSerialRequest(device *auth.DeviceState) (asserts.Assertion, error) {
+	keypairMgr, err := deviceKeypairManager()
+	if err != nil {
+		return nil, err
+	}
+	db, err := asserts.OpenDatabase(&asserts.DatabaseConfig{
+		KeypairManager: keypairMgr,
+	})
+	if err != nil {
+		return nil, err
+	}
+	pubKey, err := db.PublicKey(device.Brand, device.KeyID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot find device key: %v", err)
+	}
+	encodedPubKey, err := asserts.EncodePublicKey(pubKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.Sign(asserts.SerialRequestType, map[string]interface{}{
+		"authority-id":  device.Brand,
+		"brand-id":      device.Brand,
+		"model":         device.Model,
+		"device-key":    string(encodedPubKey),
+		"device-key-id": device.KeyID,
+	}, nil, device.KeyID)
+}
+
+// submitSerialRequest posts the serial request to the serial vending
+// service and decodes the device-serial assertion it returns. The
+// request is abandoned when cancel is closed.
+func submitSerialRequest(serialReq asserts.Assertion, cancel <-chan struct{}) (*asserts.DeviceSerial, error) {
+	req, err := http.NewRequest("POST", serialRequestURL(), bytes.NewReader(asserts.Encode(serialReq)))
+	if err != nil {
+		return nil, fmt.Errorf("cannot request device serial: %v", err)
+	}
+	req.Header.Set("Content-Type", asserts.MediaType)
+	req.Cancel = cancel
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("cannot request device serial: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("cannot request device serial: unexpected status %d", resp.StatusCode)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read device serial: %v", err)
+	}
+	a, err := asserts.Decode(body)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode device serial: %v", err)
+	}
+	serial, ok := a.(*asserts.DeviceSerial)
+	if !ok {
+		return nil, fmt.Errorf("cannot use %q assertion as device serial", a.Type().Name)
+	}
+	return serial, nil
+}
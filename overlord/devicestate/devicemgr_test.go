@@ -0,0 +1,477 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package devicestate_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/ubuntu-core/snappy/asserts"
+	"github.com/ubuntu-core/snappy/dirs"
+	"github.com/ubuntu-core/snappy/overlord/assertstate"
+	"github.com/ubuntu-core/snappy/overlord/auth"
+	"github.com/ubuntu-core/snappy/overlord/devicestate"
+	"github.com/ubuntu-core/snappy/overlord/state"
+	"github.com/ubuntu-core/snappy/snap"
+)
+
+func TestDeviceManager(t *testing.T) { TestingT(t) }
+
+type deviceMgrSuite struct {
+	state     *state.State
+	assertMgr *assertstate.AssertManager
+	signingDB *asserts.Database
+	rootKeyID string
+	brandKey  string
+}
+
+var _ = Suite(&deviceMgrSuite{})
+
+func (s *deviceMgrSuite) SetUpTest(c *C) {
+	dirs.SetRootDir(c.MkDir())
+
+	signingDB, err := asserts.OpenDatabase(&asserts.DatabaseConfig{
+		KeypairManager: asserts.NewMemoryKeypairManager(),
+	})
+	c.Assert(err, IsNil)
+	s.signingDB = signingDB
+	s.rootKeyID, err = signingDB.GenerateKey("canonical")
+	c.Assert(err, IsNil)
+	s.brandKey, err = signingDB.GenerateKey("my-brand")
+	c.Assert(err, IsNil)
+
+	rootAccKey := s.accountKey(c, "canonical", s.rootKeyID)
+	c.Assert(os.MkdirAll(filepath.Dir(dirs.SnapTrustedAccountKey), 0755), IsNil)
+	c.Assert(ioutil.WriteFile(dirs.SnapTrustedAccountKey, asserts.Encode(rootAccKey), 0644), IsNil)
+
+	s.state = state.New(nil)
+	s.assertMgr, err = assertstate.Manager(s.state)
+	c.Assert(err, IsNil)
+	c.Assert(s.assertMgr.DB().Add(s.accountKey(c, "my-brand", s.brandKey)), IsNil)
+}
+
+func (s *deviceMgrSuite) accountKey(c *C, accountID, keyID string) asserts.Assertion {
+	pubKey, err := s.signingDB.PublicKey(accountID, keyID)
+	c.Assert(err, IsNil)
+	encodedPubKey, err := asserts.EncodePublicKey(pubKey)
+	c.Assert(err, IsNil)
+	now := time.Now().UTC()
+	a, err := s.signingDB.Sign(asserts.AccountKeyType, map[string]interface{}{
+		"authority-id":           "canonical",
+		"account-id":             accountID,
+		"public-key-id":          keyID,
+		"public-key-fingerprint": pubKey.Fingerprint(),
+		"since":                  now.AddDate(-1, 0, 0).Format(time.RFC3339),
+		"until":                  now.AddDate(1, 0, 0).Format(time.RFC3339),
+	}, encodedPubKey, s.rootKeyID)
+	c.Assert(err, IsNil)
+	return a
+}
+
+func (s *deviceMgrSuite) setupModel(c *C) *asserts.Model {
+	a, err := s.signingDB.Sign(asserts.ModelType, map[string]interface{}{
+		"authority-id":   "my-brand",
+		"series":         "16",
+		"brand-id":       "my-brand",
+		"model":          "my-model",
+		"os":             "ubuntu-core",
+		"architecture":   "amd64",
+		"gadget":         "pc",
+		"kernel":         "pc-kernel",
+		"store":          "my-brand-store",
+		"class":          "general",
+		"allowed-modes":  "",
+		"required-snaps": "foo,bar",
+		"timestamp":      time.Now().UTC().Format(time.RFC3339),
+	}, nil, s.brandKey)
+	c.Assert(err, IsNil)
+	c.Assert(s.assertMgr.DB().Add(a), IsNil)
+	model := a.(*asserts.Model)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Assert(devicestate.SetModel(s.state, model), IsNil)
+	return model
+}
+
+func (s *deviceMgrSuite) TestManager(c *C) {
+	mgr, err := devicestate.Manager(s.state, s.assertMgr)
+	c.Assert(err, IsNil)
+	c.Check(mgr.Ensure(), IsNil)
+}
+
+func (s *deviceMgrSuite) TestNoModel(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	_, err := devicestate.Model(s.state)
+	c.Check(err, Equals, state.ErrNoState)
+
+	storeID, err := devicestate.StoreID(s.state)
+	c.Assert(err, IsNil)
+	c.Check(storeID, Equals, "")
+
+	kernel := &snap.Info{SuggestedName: "other-kernel", Type: snap.TypeKernel}
+	c.Check(devicestate.CheckInstall(s.state, kernel), IsNil)
+	c.Check(devicestate.CheckRemove(s.state, "foo"), IsNil)
+}
+
+func (s *deviceMgrSuite) TestModel(c *C) {
+	model := s.setupModel(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	device, err := auth.Device(s.state)
+	c.Assert(err, IsNil)
+	c.Check(device, DeepEquals, &auth.DeviceState{Brand: "my-brand", Model: "my-model"})
+
+	found, err := devicestate.Model(s.state)
+	c.Assert(err, IsNil)
+	c.Check(found.Ref().Unique(), Equals, model.Ref().Unique())
+
+	storeID, err := devicestate.StoreID(s.state)
+	c.Assert(err, IsNil)
+	c.Check(storeID, Equals, "my-brand-store")
+}
+
+func (s *deviceMgrSuite) TestModelMissingAssertion(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	err := auth.SetDevice(s.state, &auth.DeviceState{Brand: "my-brand", Model: "other-model"})
+	c.Assert(err, IsNil)
+
+	_, err = devicestate.Model(s.state)
+	c.Check(err, ErrorMatches, "cannot find model assertion for my-brand/other-model")
+}
+
+func (s *deviceMgrSuite) TestSetModelCannotChangeModel(c *C) {
+	s.setupModel(c)
+
+	a, err := s.signingDB.Sign(asserts.ModelType, map[string]interface{}{
+		"authority-id":   "my-brand",
+		"series":         "16",
+		"brand-id":       "my-brand",
+		"model":          "other-model",
+		"os":             "ubuntu-core",
+		"architecture":   "amd64",
+		"gadget":         "pc",
+		"kernel":         "pc-kernel",
+		"store":          "my-brand-store",
+		"class":          "general",
+		"allowed-modes":  "",
+		"required-snaps": "",
+		"timestamp":      time.Now().UTC().Format(time.RFC3339),
+	}, nil, s.brandKey)
+	c.Assert(err, IsNil)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	err = devicestate.SetModel(s.state, a.(*asserts.Model))
+	c.Check(err, ErrorMatches, "cannot set model my-brand/other-model: device already has model my-brand/my-model")
+}
+
+func (s *deviceMgrSuite) TestCheckInstall(c *C) {
+	s.setupModel(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	tests := []struct {
+		name     string
+		snapType snap.Type
+		err      string
+	}{
+		{"pc-kernel", snap.TypeKernel, ""},
+		{"other-kernel", snap.TypeKernel, `cannot install kernel snap "other-kernel", model "my-model" requires "pc-kernel"`},
+		{"pc", snap.TypeGadget, ""},
+		{"other-gadget", snap.TypeGadget, `cannot install gadget snap "other-gadget", model "my-model" requires "pc"`},
+		{"some-app", snap.TypeApp, ""},
+	}
+	for _, t := range tests {
+		info := &snap.Info{SuggestedName: t.name, Type: t.snapType}
+		err := devicestate.CheckInstall(s.state, info)
+		if t.err == "" {
+			c.Check(err, IsNil)
+		} else {
+			c.Check(err, ErrorMatches, t.err)
+		}
+	}
+}
+
+func (s *deviceMgrSuite) TestCheckRemove(c *C) {
+	s.setupModel(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	c.Check(devicestate.CheckRemove(s.state, "foo"), ErrorMatches, `cannot remove snap "foo", it is required by model "my-model"`)
+	c.Check(devicestate.CheckRemove(s.state, "bar"), ErrorMatches, `cannot remove snap "bar", it is required by model "my-model"`)
+	c.Check(devicestate.CheckRemove(s.state, "baz"), IsNil)
+}
+
+func (s *deviceMgrSuite) mockSerialVendor(c *C, failures int, model string) *httptest.Server {
+	count := 0
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count++
+		if count <= failures {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		c.Check(r.Method, Equals, "POST")
+		c.Check(r.Header.Get("Content-Type"), Equals, asserts.MediaType)
+		body, err := ioutil.ReadAll(r.Body)
+		c.Assert(err, IsNil)
+		a, err := asserts.Decode(body)
+		c.Assert(err, IsNil)
+		serialReq, ok := a.(*asserts.SerialRequest)
+		c.Assert(ok, Equals, true)
+		c.Check(asserts.SignatureCheck(serialReq, serialReq.DeviceKey()), IsNil)
+
+		if model == "" {
+			model = serialReq.Model()
+		}
+		encodedPubKey, err := asserts.EncodePublicKey(serialReq.DeviceKey())
+		c.Assert(err, IsNil)
+		serial, err := s.signingDB.Sign(asserts.DeviceSerialType, map[string]interface{}{
+			"authority-id": "my-brand",
+			"brand-id":     serialReq.BrandID(),
+			"model":        model,
+			"serial":       "9999",
+			"device-key":   string(encodedPubKey),
+			"timestamp":    time.Now().UTC().Format(time.RFC3339),
+		}, nil, s.brandKey)
+		c.Assert(err, IsNil)
+		w.Header().Set("Content-Type", asserts.MediaType)
+		w.WriteHeader(http.StatusOK)
+		w.Write(asserts.Encode(serial))
+	}))
+}
+
+func (s *deviceMgrSuite) settle(c *C, mgr *devicestate.DeviceManager) {
+	for i := 0; i < 5; i++ {
+		c.Assert(mgr.Ensure(), IsNil)
+		mgr.Wait()
+	}
+}
+
+func (s *deviceMgrSuite) TestNoRegistrationWithoutModel(c *C) {
+	mgr, err := devicestate.Manager(s.state, s.assertMgr)
+	c.Assert(err, IsNil)
+	s.settle(c, mgr)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(s.state.Changes(), HasLen, 0)
+}
+
+func (s *deviceMgrSuite) testRegistration(c *C, failures int) {
+	server := s.mockSerialVendor(c, failures, "")
+	defer server.Close()
+	os.Setenv("SNAPPY_FORCE_SERIAL_VENDOR_URL", server.URL)
+	defer os.Unsetenv("SNAPPY_FORCE_SERIAL_VENDOR_URL")
+	restore := devicestate.MockRetryInterval(time.Millisecond, 4*time.Millisecond)
+	defer restore()
+
+	s.setupModel(c)
+
+	mgr, err := devicestate.Manager(s.state, s.assertMgr)
+	c.Assert(err, IsNil)
+	defer mgr.Stop()
+	s.settle(c, mgr)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	chgs := s.state.Changes()
+	c.Assert(chgs, HasLen, 1)
+	c.Check(chgs[0].Kind(), Equals, "become-operational")
+	c.Check(chgs[0].Status(), Equals, state.DoneStatus)
+
+	device, err := auth.Device(s.state)
+	c.Assert(err, IsNil)
+	c.Check(device.Serial, Equals, "9999")
+	c.Check(device.KeyID, Not(Equals), "")
+
+	// the device key is kept on disk
+	keypairMgr, err := asserts.OpenFSKeypairManager(dirs.SnapDeviceDir)
+	c.Assert(err, IsNil)
+	_, err = keypairMgr.Get("my-brand", device.KeyID)
+	c.Check(err, IsNil)
+
+	// the device-serial assertion is in the system database
+	a, err := assertstate.DB(s.state).Find(asserts.DeviceSerialType, map[string]string{
+		"brand-id": "my-brand",
+		"model":    "my-model",
+		"serial":   "9999",
+	})
+	c.Assert(err, IsNil)
+	c.Check(a.(*asserts.DeviceSerial).DeviceKey().ID(), Equals, device.KeyID)
+}
+
+func (s *deviceMgrSuite) TestRegistration(c *C) {
+	s.testRegistration(c, 0)
+}
+
+func (s *deviceMgrSuite) TestRegistrationRetries(c *C) {
+	s.testRegistration(c, 3)
+}
+
+func (s *deviceMgrSuite) TestRegistrationOnlyOnce(c *C) {
+	s.setupModel(c)
+
+	s.state.Lock()
+	device, err := auth.Device(s.state)
+	c.Assert(err, IsNil)
+	device.Serial = "1234"
+	c.Assert(auth.SetDevice(s.state, device), IsNil)
+	s.state.Unlock()
+
+	mgr, err := devicestate.Manager(s.state, s.assertMgr)
+	c.Assert(err, IsNil)
+	s.settle(c, mgr)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(s.state.Changes(), HasLen, 0)
+}
+
+func (s *deviceMgrSuite) TestRegistrationWrongModel(c *C) {
+	server := s.mockSerialVendor(c, 0, "other-model")
+	defer server.Close()
+	os.Setenv("SNAPPY_FORCE_SERIAL_VENDOR_URL", server.URL)
+	defer os.Unsetenv("SNAPPY_FORCE_SERIAL_VENDOR_URL")
+
+	s.setupModel(c)
+
+	mgr, err := devicestate.Manager(s.state, s.assertMgr)
+	c.Assert(err, IsNil)
+	defer mgr.Stop()
+	s.settle(c, mgr)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	// failed attempts are retried with a new change only later on
+	chgs := s.state.Changes()
+	c.Assert(chgs, HasLen, 1)
+	c.Check(chgs[0].Status(), Equals, state.ErrorStatus)
+	c.Check(chgs[0].Err(), ErrorMatches, `(?s).*obtained serial assertion is for my-brand/other-model, expected my-brand/my-model.*`)
+
+	device, err := auth.Device(s.state)
+	c.Assert(err, IsNil)
+	c.Check(device.Serial, Equals, "")
+}
+
+func (s *deviceMgrSuite) TestRegistrationDespiteSeedingError(c *C) {
+	server := s.mockSerialVendor(c, 0, "my-model")
+	defer server.Close()
+	os.Setenv("SNAPPY_FORCE_SERIAL_VENDOR_URL", server.URL)
+	defer os.Unsetenv("SNAPPY_FORCE_SERIAL_VENDOR_URL")
+
+	s.setupModel(c)
+	c.Assert(os.MkdirAll(dirs.SnapSeedDir, 0755), IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(dirs.SnapSeedDir, "seed.yaml"), []byte("snaps: ["), 0644), IsNil)
+
+	mgr, err := devicestate.Manager(s.state, s.assertMgr)
+	c.Assert(err, IsNil)
+	defer mgr.Stop()
+	c.Check(mgr.Ensure(), ErrorMatches, `devicemgr: cannot unmarshal seed yaml: .*`)
+	mgr.Wait()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	chgs := s.state.Changes()
+	c.Assert(chgs, HasLen, 1)
+	c.Check(chgs[0].Kind(), Equals, "become-operational")
+}
+
+func (s *deviceMgrSuite) TestRegistrationBackoff(c *C) {
+	server := s.mockSerialVendor(c, 0, "other-model")
+	defer server.Close()
+	os.Setenv("SNAPPY_FORCE_SERIAL_VENDOR_URL", server.URL)
+	defer os.Unsetenv("SNAPPY_FORCE_SERIAL_VENDOR_URL")
+	restore := devicestate.MockRetryInterval(50*time.Millisecond, time.Second)
+	defer restore()
+
+	s.setupModel(c)
+
+	mgr, err := devicestate.Manager(s.state, s.assertMgr)
+	c.Assert(err, IsNil)
+	defer mgr.Stop()
+	s.settle(c, mgr)
+
+	s.state.Lock()
+	c.Check(s.state.Changes(), HasLen, 1)
+	s.state.Unlock()
+
+	time.Sleep(60 * time.Millisecond)
+	s.settle(c, mgr)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(s.state.Changes(), HasLen, 2)
+}
+
+func (s *deviceMgrSuite) TestRegistrationStopsOnStop(c *C) {
+	requested := make(chan bool, 1)
+	unblock := make(chan bool)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested <- true
+		<-unblock
+	}))
+	defer server.Close()
+	defer close(unblock)
+	os.Setenv("SNAPPY_FORCE_SERIAL_VENDOR_URL", server.URL)
+	defer os.Unsetenv("SNAPPY_FORCE_SERIAL_VENDOR_URL")
+
+	s.setupModel(c)
+
+	mgr, err := devicestate.Manager(s.state, s.assertMgr)
+	c.Assert(err, IsNil)
+	for i := 0; i < 5; i++ {
+		c.Assert(mgr.Ensure(), IsNil)
+		select {
+		case <-requested:
+			i = 5
+		case <-time.After(time.Second):
+		}
+	}
+
+	// the pending request does not hold up stopping
+	stopped := make(chan bool)
+	go func() {
+		mgr.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		c.Fatal("the serial request was not abandoned")
+	}
+}
@@ -0,0 +1,38 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package devicestate
+
+import (
+	"time"
+)
+
+// MockRetryInterval mocks the intervals between serial requests.
+func MockRetryInterval(interval, max time.Duration) (restore func()) {
+	oldInterval, oldMax := retryInterval, maxRetryInterval
+	retryInterval, maxRetryInterval = interval, max
+	return func() {
+		retryInterval, maxRetryInterval = oldInterval, oldMax
+	}
+}
+
+// EnsureSeeded exposes ensureSeeded for tests.
+func (m *DeviceManager) EnsureSeeded() error {
+	return m.ensureSeeded()
+}
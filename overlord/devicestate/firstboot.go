@@ -0,0 +1,350 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package devicestate
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/tomb.v2"
+	"gopkg.in/yaml.v2"
+
+	"github.com/ubuntu-core/snappy/asserts"
+	"github.com/ubuntu-core/snappy/dirs"
+	"github.com/ubuntu-core/snappy/osutil"
+	"github.com/ubuntu-core/snappy/overlord/assertstate"
+	"github.com/ubuntu-core/snappy/overlord/snapstate"
+	"github.com/ubuntu-core/snappy/overlord/state"
+	"github.com/ubuntu-core/snappy/release"
+	"github.com/ubuntu-core/snappy/snap"
+	"github.com/ubuntu-core/snappy/snappy"
+)
+
+// seedSnap describes a snap shipped in the seed of the image.
+type seedSnap struct {
+	Name    string `yaml:"name"`
+	File    string `yaml:"file"`
+	Channel string `yaml:"channel,omitempty"`
+	DevMode bool   `yaml:"devmode,omitempty"`
+}
+
+// seed is the content of the seed.yaml file of the image.
+type seed struct {
+	Snaps []*seedSnap `yaml:"snaps"`
+}
+
+func seedYamlPath() string {
+	return filepath.Join(dirs.SnapSeedDir, "seed.yaml")
+}
+
+func readSeedYaml(fn string) (*seed, error) {
+	yamlData, err := ioutil.ReadFile(fn)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read seed yaml: %v", err)
+	}
+
+	var sd seed
+	if err := yaml.Unmarshal(yamlData, &sd); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal seed yaml: %v", err)
+	}
+
+	seen := make(map[string]bool, len(sd.Snaps))
+	for _, sn := range sd.Snaps {
+		if sn == nil || sn.Name == "" {
+			return nil, fmt.Errorf("cannot use seed yaml: snap entry without a name")
+		}
+		if sn.File == "" || strings.Contains(sn.File, "/") {
+			return nil, fmt.Errorf("cannot use seed yaml: snap %q must name a file in the seed snaps directory", sn.Name)
+		}
+		if seen[sn.Name] {
+			return nil, fmt.Errorf("cannot use seed yaml: snap %q listed more than once", sn.Name)
+		}
+		seen[sn.Name] = true
+	}
+	return &sd, nil
+}
+
+// Seeded returns whether the device was seeded from the seed of the
+// image already.
+// Note that the state must be locked by the caller.
+func Seeded(st *state.State) (bool, error) {
+	var seeded bool
+	err := st.Get("seeded", &seeded)
+	if err == state.ErrNoState {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return seeded, nil
+}
+
+// ensureSeeded starts seeding the device, that is importing the
+// assertions and installing the snaps listed in the seed.yaml of the
+// image, unless the device was seeded already, is being seeded or
+// the image has no seed.yaml. Seeding is attempted once per run of
+// the daemon: a failed seeding is attempted again only on restart,
+// skipping the snaps it got to install.
+func (m *DeviceManager) ensureSeeded() error {
+	m.state.Lock()
+	defer m.state.Unlock()
+
+	if m.seedAttempted {
+		return nil
+	}
+
+	seeded, err := Seeded(m.state)
+	if err != nil {
+		return err
+	}
+	if seeded {
+		return nil
+	}
+
+	for _, chg := range m.state.Changes() {
+		if chg.Kind() == "seed" && !chg.Status().Ready() {
+			return nil
+		}
+	}
+
+	if !osutil.FileExists(seedYamlPath()) {
+		return nil
+	}
+
+	m.seedAttempted = true
+	tsAll, err := populateStateFromSeed(m.state, m.assertMgr)
+	if err != nil {
+		return err
+	}
+
+	chg := m.state.NewChange("seed", "Initialize system state")
+	for _, ts := range tsAll {
+		chg.AddAll(ts)
+	}
+	return nil
+}
+
+// seedSnapSideInfo returns the side info of the seed snap at path,
+// as given by the snap-revision assertion matching its digest and
+// the snap-declaration for its snap-id.
+func seedSnapSideInfo(st *state.State, sn *seedSnap, path string) (*snap.SideInfo, error) {
+	digest, size, err := asserts.SnapFileSHA3_384(path)
+	if err != nil {
+		return nil, err
+	}
+
+	db := assertstate.DB(st)
+	snapRevs, err := db.FindMany(asserts.SnapRevisionType, map[string]string{
+		"series":      release.Series,
+		"snap-digest": digest,
+	})
+	if err == asserts.ErrNotFound {
+		return nil, fmt.Errorf("cannot find a snap-revision assertion for seed snap %q", sn.Name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	snapRev := snapRevs[0].(*asserts.SnapRevision)
+	if snapRev.SnapSize() != size {
+		return nil, fmt.Errorf("cannot use seed snap %q: size %d does not match its snap-revision assertion", sn.Name, size)
+	}
+
+	a, err := db.Find(asserts.SnapDeclarationType, map[string]string{
+		"series":  release.Series,
+		"snap-id": snapRev.SnapID(),
+	})
+	if err == asserts.ErrNotFound {
+		return nil, fmt.Errorf("cannot find a snap-declaration assertion for seed snap %q", sn.Name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	snapDecl := a.(*asserts.SnapDeclaration)
+	if snapDecl.SnapName() != sn.Name {
+		return nil, fmt.Errorf("cannot use seed snap %q: declared with name %q", sn.Name, snapDecl.SnapName())
+	}
+
+	return &snap.SideInfo{
+		OfficialName: snapDecl.SnapName(),
+		SnapID:       snapRev.SnapID(),
+		Revision:     int(snapRev.SnapRevision()),
+		Channel:      sn.Channel,
+	}, nil
+}
+
+// populateStateFromSeed imports the seed assertions and returns the
+// task sets seeding the device: the installation of each seed snap,
+// as described by its assertions, in order, and last the recording
+// of the device as seeded.
+func populateStateFromSeed(st *state.State, assertMgr *assertstate.AssertManager) ([]*state.TaskSet, error) {
+	sd, err := readSeedYaml(seedYamlPath())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ImportAssertionsFromSeed(st, assertMgr, ""); err != nil {
+		return nil, err
+	}
+
+	var tsAll []*state.TaskSet
+	var last *state.TaskSet
+	for _, sn := range sd.Snaps {
+		var snapst snapstate.SnapState
+		err := snapstate.Get(st, sn.Name, &snapst)
+		if err != nil && err != state.ErrNoState {
+			return nil, err
+		}
+		if snapst.Current() != nil {
+			// installed by an earlier seeding that failed later on
+			continue
+		}
+
+		var flags snappy.InstallFlags
+		if sn.DevMode {
+			flags |= snappy.DeveloperMode
+		}
+		path := filepath.Join(dirs.SnapSeedDir, "snaps", sn.File)
+		si, err := seedSnapSideInfo(st, sn, path)
+		if err != nil {
+			return nil, err
+		}
+		ts, err := snapstate.InstallPathWithSideInfo(st, si, path, sn.Channel, flags)
+		if err != nil {
+			return nil, err
+		}
+		if last != nil {
+			ts.WaitAll(last)
+		}
+		tsAll = append(tsAll, ts)
+		last = ts
+	}
+
+	markSeeded := st.NewTask("mark-seeded", "Mark system seeded")
+	ts := state.NewTaskSet(markSeeded)
+	if last != nil {
+		ts.WaitAll(last)
+	}
+	return append(tsAll, ts), nil
+}
+
+func (m *DeviceManager) doMarkSeeded(t *state.Task, _ *tomb.Tomb) error {
+	st := t.State()
+	st.Lock()
+	defer st.Unlock()
+
+	st.Set("seeded", true)
+	return nil
+}
+
+func readAsserts(fn string) ([]asserts.Assertion, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var res []asserts.Assertion
+	dec := asserts.NewDecoder(f)
+	for {
+		a, err := dec.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot decode assertions from %s: %v", fn, err)
+		}
+		res = append(res, a)
+	}
+	return res, nil
+}
+
+// readAssertsDir reads the assertions, possibly bundles of them,
+// from all the files in dir. A missing dir has no assertions.
+func readAssertsDir(dir string) ([]asserts.Assertion, error) {
+	fis, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read assertions directory: %v", err)
+	}
+
+	var res []asserts.Assertion
+	for _, fi := range fis {
+		as, err := readAsserts(filepath.Join(dir, fi.Name()))
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, as...)
+	}
+	return res, nil
+}
+
+// ImportAssertionsFromSeed imports the assertions shipped under the
+// seed directory of the image and, if gadgetDir is not empty, under
+// the assertions directory of the gadget snap mounted there, and
+// records the model found among them as the one of the device. The
+// files there can hold single assertions or bundles of them, in any
+// order.
+// Note that the state must be locked by the caller.
+func ImportAssertionsFromSeed(st *state.State, assertMgr *assertstate.AssertManager, gadgetDir string) error {
+	assertDirs := []string{filepath.Join(dirs.SnapSeedDir, "assertions")}
+	if gadgetDir != "" {
+		assertDirs = append(assertDirs, filepath.Join(gadgetDir, "assertions"))
+	}
+
+	var batch []asserts.Assertion
+	for _, dir := range assertDirs {
+		as, err := readAssertsDir(dir)
+		if err != nil {
+			return err
+		}
+		batch = append(batch, as...)
+	}
+	if len(batch) == 0 {
+		return nil
+	}
+
+	var model *asserts.Model
+	for _, a := range batch {
+		if a.Type() != asserts.ModelType {
+			continue
+		}
+		if model != nil && model.Ref().Unique() != a.Ref().Unique() {
+			return fmt.Errorf("cannot have multiple model assertions in seed")
+		}
+		model = a.(*asserts.Model)
+	}
+	if model == nil {
+		return fmt.Errorf("cannot find a model assertion in seed")
+	}
+
+	retrieve := func(ref *asserts.Ref) (asserts.Assertion, error) {
+		return nil, fmt.Errorf("not present in seed")
+	}
+	if err := assertMgr.AddBatch(batch, retrieve); err != nil {
+		return err
+	}
+
+	return SetModel(st, model)
+}
@@ -0,0 +1,525 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package devicestate_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/ubuntu-core/snappy/asserts"
+	"github.com/ubuntu-core/snappy/dirs"
+
+	"github.com/ubuntu-core/snappy/overlord/assertstate"
+	"github.com/ubuntu-core/snappy/overlord/auth"
+	"github.com/ubuntu-core/snappy/overlord/devicestate"
+	"github.com/ubuntu-core/snappy/overlord/snapstate"
+	"github.com/ubuntu-core/snappy/overlord/state"
+	"github.com/ubuntu-core/snappy/snap"
+	"github.com/ubuntu-core/snappy/testutil"
+)
+
+type firstBootSuite struct {
+	signingDB *asserts.Database
+	rootKeyID string
+	brandKey  string
+	state     *state.State
+	assertMgr *assertstate.AssertManager
+}
+
+var _ = Suite(&firstBootSuite{})
+
+func (fbs *firstBootSuite) SetUpTest(c *C) {
+	dirs.SetRootDir(c.MkDir())
+
+	signingDB, err := asserts.OpenDatabase(&asserts.DatabaseConfig{
+		KeypairManager: asserts.NewMemoryKeypairManager(),
+	})
+	c.Assert(err, IsNil)
+	fbs.signingDB = signingDB
+	fbs.rootKeyID, err = signingDB.GenerateKey("canonical")
+	c.Assert(err, IsNil)
+	fbs.brandKey, err = signingDB.GenerateKey("my-brand")
+	c.Assert(err, IsNil)
+
+	rootAccKey := fbs.accountKey(c, "canonical", fbs.rootKeyID)
+	c.Assert(os.MkdirAll(filepath.Dir(dirs.SnapTrustedAccountKey), 0755), IsNil)
+	c.Assert(ioutil.WriteFile(dirs.SnapTrustedAccountKey, asserts.Encode(rootAccKey), 0644), IsNil)
+
+	fbs.state = state.New(nil)
+	fbs.assertMgr, err = assertstate.Manager(fbs.state)
+	c.Assert(err, IsNil)
+}
+
+func (fbs *firstBootSuite) TearDownTest(c *C) {
+	dirs.SetRootDir("/")
+}
+
+func (fbs *firstBootSuite) accountKey(c *C, accountID, keyID string) asserts.Assertion {
+	pubKey, err := fbs.signingDB.PublicKey(accountID, keyID)
+	c.Assert(err, IsNil)
+	encodedPubKey, err := asserts.EncodePublicKey(pubKey)
+	c.Assert(err, IsNil)
+	now := time.Now().UTC()
+	a, err := fbs.signingDB.Sign(asserts.AccountKeyType, map[string]interface{}{
+		"authority-id":           "canonical",
+		"account-id":             accountID,
+		"public-key-id":          keyID,
+		"public-key-fingerprint": pubKey.Fingerprint(),
+		"since":                  now.AddDate(-1, 0, 0).Format(time.RFC3339),
+		"until":                  now.AddDate(1, 0, 0).Format(time.RFC3339),
+	}, encodedPubKey, fbs.rootKeyID)
+	c.Assert(err, IsNil)
+	return a
+}
+
+func (fbs *firstBootSuite) model(c *C, model string) asserts.Assertion {
+	a, err := fbs.signingDB.Sign(asserts.ModelType, map[string]interface{}{
+		"authority-id":   "my-brand",
+		"series":         "16",
+		"brand-id":       "my-brand",
+		"model":          model,
+		"os":             "ubuntu-core",
+		"architecture":   "amd64",
+		"gadget":         "pc",
+		"kernel":         "pc-kernel",
+		"store":          "my-brand-store",
+		"class":          "general",
+		"allowed-modes":  "",
+		"required-snaps": "",
+		"timestamp":      time.Now().UTC().Format(time.RFC3339),
+	}, nil, fbs.brandKey)
+	c.Assert(err, IsNil)
+	return a
+}
+
+func (fbs *firstBootSuite) writeSeedAssertions(c *C, name string, as ...asserts.Assertion) {
+	assertSeedDir := filepath.Join(dirs.SnapSeedDir, "assertions")
+	c.Assert(os.MkdirAll(assertSeedDir, 0755), IsNil)
+	f, err := os.Create(filepath.Join(assertSeedDir, name))
+	c.Assert(err, IsNil)
+	defer f.Close()
+	enc := asserts.NewEncoder(f)
+	for _, a := range as {
+		c.Assert(enc.Encode(a), IsNil)
+	}
+}
+
+func (fbs *firstBootSuite) TestImportAssertionsFromSeed(c *C) {
+	model := fbs.model(c, "my-model")
+	fbs.writeSeedAssertions(c, "model", model)
+	fbs.writeSeedAssertions(c, "brand", fbs.accountKey(c, "my-brand", fbs.brandKey))
+
+	fbs.state.Lock()
+	defer fbs.state.Unlock()
+
+	err := devicestate.ImportAssertionsFromSeed(fbs.state, fbs.assertMgr, "")
+	c.Assert(err, IsNil)
+
+	found, err := devicestate.Model(fbs.state)
+	c.Assert(err, IsNil)
+	c.Check(found.Ref().Unique(), Equals, model.Ref().Unique())
+}
+
+func (fbs *firstBootSuite) TestImportAssertionsBundleFromGadget(c *C) {
+	model := fbs.model(c, "my-model")
+	// the model is also in the seed, the gadget carries a bundle
+	fbs.writeSeedAssertions(c, "model", model)
+
+	gadgetDir := c.MkDir()
+	c.Assert(os.MkdirAll(filepath.Join(gadgetDir, "assertions"), 0755), IsNil)
+	f, err := os.Create(filepath.Join(gadgetDir, "assertions", "bundle"))
+	c.Assert(err, IsNil)
+	enc := asserts.NewEncoder(f)
+	for _, a := range []asserts.Assertion{fbs.accountKey(c, "my-brand", fbs.brandKey), model} {
+		c.Assert(enc.Encode(a), IsNil)
+	}
+	f.Close()
+
+	fbs.state.Lock()
+	defer fbs.state.Unlock()
+
+	err = devicestate.ImportAssertionsFromSeed(fbs.state, fbs.assertMgr, gadgetDir)
+	c.Assert(err, IsNil)
+
+	found, err := devicestate.Model(fbs.state)
+	c.Assert(err, IsNil)
+	c.Check(found.Ref().Unique(), Equals, model.Ref().Unique())
+}
+
+func (fbs *firstBootSuite) TestImportAssertionsFromSeedNoSeed(c *C) {
+	fbs.state.Lock()
+	defer fbs.state.Unlock()
+
+	err := devicestate.ImportAssertionsFromSeed(fbs.state, fbs.assertMgr, "")
+	c.Assert(err, IsNil)
+
+	device, err := auth.Device(fbs.state)
+	c.Assert(err, IsNil)
+	c.Check(device, DeepEquals, &auth.DeviceState{})
+}
+
+func (fbs *firstBootSuite) TestImportAssertionsFromSeedNoModel(c *C) {
+	fbs.writeSeedAssertions(c, "brand", fbs.accountKey(c, "my-brand", fbs.brandKey))
+
+	fbs.state.Lock()
+	defer fbs.state.Unlock()
+
+	err := devicestate.ImportAssertionsFromSeed(fbs.state, fbs.assertMgr, "")
+	c.Assert(err, ErrorMatches, "cannot find a model assertion in seed")
+}
+
+func (fbs *firstBootSuite) TestImportAssertionsFromSeedMultipleModels(c *C) {
+	fbs.writeSeedAssertions(c, "models", fbs.model(c, "my-model"), fbs.model(c, "other-model"))
+
+	fbs.state.Lock()
+	defer fbs.state.Unlock()
+
+	err := devicestate.ImportAssertionsFromSeed(fbs.state, fbs.assertMgr, "")
+	c.Assert(err, ErrorMatches, "cannot have multiple model assertions in seed")
+}
+
+func (fbs *firstBootSuite) TestImportAssertionsFromSeedMissingPrerequisite(c *C) {
+	fbs.writeSeedAssertions(c, "model", fbs.model(c, "my-model"))
+
+	fbs.state.Lock()
+	defer fbs.state.Unlock()
+
+	err := devicestate.ImportAssertionsFromSeed(fbs.state, fbs.assertMgr, "")
+	c.Assert(err, ErrorMatches, `cannot retrieve account-key \(my-brand; .*\): not present in seed`)
+
+	device, err := auth.Device(fbs.state)
+	c.Assert(err, IsNil)
+	c.Check(device, DeepEquals, &auth.DeviceState{})
+}
+
+func (fbs *firstBootSuite) writeSeedYaml(c *C, content string) {
+	c.Assert(os.MkdirAll(dirs.SnapSeedDir, 0755), IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(dirs.SnapSeedDir, "seed.yaml"), []byte(content), 0644), IsNil)
+}
+
+func (fbs *firstBootSuite) TestEnsureSeededNoSeedYaml(c *C) {
+	mgr, err := devicestate.Manager(fbs.state, fbs.assertMgr)
+	c.Assert(err, IsNil)
+	c.Assert(mgr.EnsureSeeded(), IsNil)
+
+	fbs.state.Lock()
+	defer fbs.state.Unlock()
+	c.Check(fbs.state.Changes(), HasLen, 0)
+}
+
+func (fbs *firstBootSuite) TestEnsureSeededAlreadySeeded(c *C) {
+	fbs.writeSeedYaml(c, "snaps: []\n")
+
+	fbs.state.Lock()
+	fbs.state.Set("seeded", true)
+	fbs.state.Unlock()
+
+	mgr, err := devicestate.Manager(fbs.state, fbs.assertMgr)
+	c.Assert(err, IsNil)
+	c.Assert(mgr.EnsureSeeded(), IsNil)
+
+	fbs.state.Lock()
+	defer fbs.state.Unlock()
+	c.Check(fbs.state.Changes(), HasLen, 0)
+}
+
+// writeSeedSnap writes a seed snap file, together with the identity of
+// its publisher, and returns its snap-declaration and snap-revision
+// assertions.
+func (fbs *firstBootSuite) writeSeedSnap(c *C, name, file string, revision int) []asserts.Assertion {
+	snapPath := filepath.Join(dirs.SnapSeedDir, "snaps", file)
+	c.Assert(os.MkdirAll(filepath.Dir(snapPath), 0755), IsNil)
+	c.Assert(ioutil.WriteFile(snapPath, []byte(name+" content"), 0644), IsNil)
+	digest, size, err := asserts.SnapFileSHA3_384(snapPath)
+	c.Assert(err, IsNil)
+
+	snapID := name + "-id"
+	now := time.Now().UTC().Format(time.RFC3339)
+	identity, err := fbs.signingDB.Sign(asserts.IdentityType, map[string]interface{}{
+		"authority-id": "canonical",
+		"account-id":   "developer1",
+		"display-name": "Developer",
+		"validation":   "certified",
+		"timestamp":    now,
+	}, nil, fbs.rootKeyID)
+	c.Assert(err, IsNil)
+	fbs.writeSeedAssertions(c, "developer1", identity)
+
+	snapDecl, err := fbs.signingDB.Sign(asserts.SnapDeclarationType, map[string]interface{}{
+		"authority-id": "canonical",
+		"series":       "16",
+		"snap-id":      snapID,
+		"snap-name":    name,
+		"publisher-id": "developer1",
+		"gates":        "",
+		"timestamp":    now,
+	}, nil, fbs.rootKeyID)
+	c.Assert(err, IsNil)
+	snapRev, err := fbs.signingDB.Sign(asserts.SnapRevisionType, map[string]interface{}{
+		"authority-id":  "canonical",
+		"series":        "16",
+		"snap-id":       snapID,
+		"snap-digest":   digest,
+		"snap-size":     strconv.FormatUint(size, 10),
+		"snap-revision": strconv.Itoa(revision),
+		"developer-id":  "developer1",
+		"timestamp":     now,
+	}, nil, fbs.rootKeyID)
+	c.Assert(err, IsNil)
+	return []asserts.Assertion{snapDecl, snapRev}
+}
+
+func (fbs *firstBootSuite) TestEnsureSeededChange(c *C) {
+	fbs.writeSeedYaml(c, `snaps:
+  - name: foo
+    file: foo_1.0_all.snap
+    channel: beta
+  - name: bar
+    file: bar_2.0_all.snap
+    devmode: true
+`)
+	fbs.writeSeedAssertions(c, "model", fbs.model(c, "my-model"))
+	fbs.writeSeedAssertions(c, "brand", fbs.accountKey(c, "my-brand", fbs.brandKey))
+	fbs.writeSeedAssertions(c, "foo", fbs.writeSeedSnap(c, "foo", "foo_1.0_all.snap", 7)...)
+	fbs.writeSeedAssertions(c, "bar", fbs.writeSeedSnap(c, "bar", "bar_2.0_all.snap", 9)...)
+
+	mgr, err := devicestate.Manager(fbs.state, fbs.assertMgr)
+	c.Assert(err, IsNil)
+	c.Assert(mgr.EnsureSeeded(), IsNil)
+	// only one seeding at a time
+	c.Assert(mgr.EnsureSeeded(), IsNil)
+
+	fbs.state.Lock()
+	defer fbs.state.Unlock()
+
+	chgs := fbs.state.Changes()
+	c.Assert(chgs, HasLen, 1)
+	c.Check(chgs[0].Kind(), Equals, "seed")
+
+	tasks := chgs[0].Tasks()
+	var kinds []string
+	for _, t := range tasks {
+		kinds = append(kinds, t.Kind())
+	}
+	c.Check(kinds, DeepEquals, []string{
+		"prepare-snap", "mount-snap", "copy-snap-data", "setup-profiles", "link-snap",
+		"prepare-snap", "mount-snap", "copy-snap-data", "setup-profiles", "link-snap",
+		"mark-seeded",
+	})
+
+	ss, err := snapstate.TaskSnapSetup(tasks[0])
+	c.Assert(err, IsNil)
+	c.Check(ss.Name, Equals, "foo")
+	c.Check(ss.SnapPath, Equals, filepath.Join(dirs.SnapSeedDir, "snaps", "foo_1.0_all.snap"))
+	c.Check(ss.Channel, Equals, "beta")
+	c.Check(ss.DevMode(), Equals, false)
+	c.Check(ss.Revision, Equals, 7)
+	c.Check(ss.SideInfo, DeepEquals, &snap.SideInfo{
+		OfficialName: "foo",
+		SnapID:       "foo-id",
+		Revision:     7,
+		Channel:      "beta",
+	})
+
+	ss, err = snapstate.TaskSnapSetup(tasks[5])
+	c.Assert(err, IsNil)
+	c.Check(ss.Name, Equals, "bar")
+	c.Check(ss.SnapPath, Equals, filepath.Join(dirs.SnapSeedDir, "snaps", "bar_2.0_all.snap"))
+	c.Check(ss.DevMode(), Equals, true)
+	c.Check(ss.SideInfo, DeepEquals, &snap.SideInfo{
+		OfficialName: "bar",
+		SnapID:       "bar-id",
+		Revision:     9,
+	})
+
+	// everything happens in order
+	c.Check(tasks[5].WaitTasks(), testutil.Contains, tasks[4])
+	c.Check(tasks[10].WaitTasks(), testutil.Contains, tasks[9])
+	c.Check(tasks[10].WaitTasks(), Not(testutil.Contains), tasks[4])
+
+	// the seed assertions were imported
+	found, err := devicestate.Model(fbs.state)
+	c.Assert(err, IsNil)
+	c.Check(found.Header("model"), Equals, "my-model")
+}
+
+func (fbs *firstBootSuite) TestEnsureSeededNoSnapRevision(c *C) {
+	fbs.writeSeedYaml(c, "snaps:\n  - name: foo\n    file: foo_1.0_all.snap\n")
+	fbs.writeSeedAssertions(c, "model", fbs.model(c, "my-model"))
+	fbs.writeSeedAssertions(c, "brand", fbs.accountKey(c, "my-brand", fbs.brandKey))
+	as := fbs.writeSeedSnap(c, "foo", "foo_1.0_all.snap", 7)
+	// only the snap-declaration
+	fbs.writeSeedAssertions(c, "foo", as[0])
+
+	mgr, err := devicestate.Manager(fbs.state, fbs.assertMgr)
+	c.Assert(err, IsNil)
+	c.Check(mgr.EnsureSeeded(), ErrorMatches, `cannot find a snap-revision assertion for seed snap "foo"`)
+	// not attempted again until restart
+	c.Check(mgr.EnsureSeeded(), IsNil)
+
+	fbs.state.Lock()
+	defer fbs.state.Unlock()
+	c.Check(fbs.state.Changes(), HasLen, 0)
+}
+
+func (fbs *firstBootSuite) TestEnsureSeededNameMismatch(c *C) {
+	fbs.writeSeedYaml(c, "snaps:\n  - name: bar\n    file: foo_1.0_all.snap\n")
+	fbs.writeSeedAssertions(c, "model", fbs.model(c, "my-model"))
+	fbs.writeSeedAssertions(c, "brand", fbs.accountKey(c, "my-brand", fbs.brandKey))
+	fbs.writeSeedAssertions(c, "foo", fbs.writeSeedSnap(c, "foo", "foo_1.0_all.snap", 7)...)
+
+	mgr, err := devicestate.Manager(fbs.state, fbs.assertMgr)
+	c.Assert(err, IsNil)
+	c.Check(mgr.EnsureSeeded(), ErrorMatches, `cannot use seed snap "bar": declared with name "foo"`)
+}
+
+func (fbs *firstBootSuite) TestEnsureSeededFailedChangeNotRetried(c *C) {
+	fbs.writeSeedYaml(c, "snaps: []\n")
+
+	mgr, err := devicestate.Manager(fbs.state, fbs.assertMgr)
+	c.Assert(err, IsNil)
+	c.Assert(mgr.EnsureSeeded(), IsNil)
+
+	fbs.state.Lock()
+	chgs := fbs.state.Changes()
+	c.Assert(chgs, HasLen, 1)
+	chgs[0].SetStatus(state.ErrorStatus)
+	fbs.state.Unlock()
+
+	c.Assert(mgr.EnsureSeeded(), IsNil)
+	fbs.state.Lock()
+	c.Check(fbs.state.Changes(), HasLen, 1)
+	fbs.state.Unlock()
+
+	// a restarted daemon seeds again
+	mgr, err = devicestate.Manager(fbs.state, fbs.assertMgr)
+	c.Assert(err, IsNil)
+	c.Assert(mgr.EnsureSeeded(), IsNil)
+	fbs.state.Lock()
+	c.Check(fbs.state.Changes(), HasLen, 2)
+	fbs.state.Unlock()
+}
+
+func (fbs *firstBootSuite) TestEnsureSeededSkipsInstalledSnaps(c *C) {
+	fbs.writeSeedYaml(c, `snaps:
+  - name: foo
+    file: foo_1.0_all.snap
+  - name: bar
+    file: bar_2.0_all.snap
+`)
+	fbs.writeSeedAssertions(c, "model", fbs.model(c, "my-model"))
+	fbs.writeSeedAssertions(c, "brand", fbs.accountKey(c, "my-brand", fbs.brandKey))
+	fbs.writeSeedAssertions(c, "foo", fbs.writeSeedSnap(c, "foo", "foo_1.0_all.snap", 7)...)
+	fbs.writeSeedAssertions(c, "bar", fbs.writeSeedSnap(c, "bar", "bar_2.0_all.snap", 9)...)
+
+	// foo got installed by an earlier seeding that failed on bar
+	fbs.state.Lock()
+	snapstate.Set(fbs.state, "foo", &snapstate.SnapState{
+		Active:   true,
+		Sequence: []*snap.SideInfo{{OfficialName: "foo", SnapID: "foo-id", Revision: 7}},
+	})
+	fbs.state.Unlock()
+
+	mgr, err := devicestate.Manager(fbs.state, fbs.assertMgr)
+	c.Assert(err, IsNil)
+	c.Assert(mgr.EnsureSeeded(), IsNil)
+
+	fbs.state.Lock()
+	defer fbs.state.Unlock()
+
+	chgs := fbs.state.Changes()
+	c.Assert(chgs, HasLen, 1)
+	tasks := chgs[0].Tasks()
+	var kinds []string
+	for _, t := range tasks {
+		kinds = append(kinds, t.Kind())
+	}
+	c.Check(kinds, DeepEquals, []string{
+		"prepare-snap", "mount-snap", "copy-snap-data", "setup-profiles", "link-snap",
+		"mark-seeded",
+	})
+	ss, err := snapstate.TaskSnapSetup(tasks[0])
+	c.Assert(err, IsNil)
+	c.Check(ss.Name, Equals, "bar")
+}
+
+func (fbs *firstBootSuite) TestEnsureSeededInvalidSeedYaml(c *C) {
+	tests := []struct {
+		seedYaml string
+		err      string
+	}{
+		{"snaps: [", `cannot unmarshal seed yaml: .*`},
+		{"snaps:\n  - file: foo.snap\n", `cannot use seed yaml: snap entry without a name`},
+		{"snaps:\n  - name: foo\n", `cannot use seed yaml: snap "foo" must name a file in the seed snaps directory`},
+		{"snaps:\n  - name: foo\n    file: ../foo.snap\n", `cannot use seed yaml: snap "foo" must name a file in the seed snaps directory`},
+		{"snaps:\n  - name: foo\n    file: foo.snap\n  - name: foo\n    file: foo2.snap\n", `cannot use seed yaml: snap "foo" listed more than once`},
+	}
+	for _, test := range tests {
+		fbs.writeSeedYaml(c, test.seedYaml)
+		mgr, err := devicestate.Manager(fbs.state, fbs.assertMgr)
+		c.Assert(err, IsNil)
+		c.Check(mgr.EnsureSeeded(), ErrorMatches, test.err)
+	}
+
+	fbs.state.Lock()
+	defer fbs.state.Unlock()
+	c.Check(fbs.state.Changes(), HasLen, 0)
+}
+
+func (fbs *firstBootSuite) TestSeeding(c *C) {
+	model := fbs.model(c, "my-model")
+	fbs.writeSeedAssertions(c, "model", model)
+	fbs.writeSeedAssertions(c, "brand", fbs.accountKey(c, "my-brand", fbs.brandKey))
+	fbs.writeSeedYaml(c, "snaps: []\n")
+
+	// keep registration out of the picture
+	fbs.state.Lock()
+	c.Assert(auth.SetDevice(fbs.state, &auth.DeviceState{Serial: "1234"}), IsNil)
+	fbs.state.Unlock()
+
+	mgr, err := devicestate.Manager(fbs.state, fbs.assertMgr)
+	c.Assert(err, IsNil)
+	defer mgr.Stop()
+	for i := 0; i < 5; i++ {
+		c.Assert(mgr.Ensure(), IsNil)
+		mgr.Wait()
+	}
+
+	fbs.state.Lock()
+	defer fbs.state.Unlock()
+
+	chgs := fbs.state.Changes()
+	c.Assert(chgs, HasLen, 1)
+	c.Check(chgs[0].Kind(), Equals, "seed")
+	c.Check(chgs[0].Status(), Equals, state.DoneStatus)
+
+	seeded, err := devicestate.Seeded(fbs.state)
+	c.Assert(err, IsNil)
+	c.Check(seeded, Equals, true)
+
+	found, err := devicestate.Model(fbs.state)
+	c.Assert(err, IsNil)
+	c.Check(found.Ref().Unique(), Equals, model.Ref().Unique())
+}
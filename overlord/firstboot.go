@@ -21,11 +21,15 @@ package overlord
 
 import (
 	"fmt"
+	"path/filepath"
 
 	"github.com/ubuntu-core/snappy/dirs"
 	"github.com/ubuntu-core/snappy/osutil"
+	"github.com/ubuntu-core/snappy/overlord/assertstate"
+	"github.com/ubuntu-core/snappy/overlord/devicestate"
 	"github.com/ubuntu-core/snappy/overlord/snapstate"
 	"github.com/ubuntu-core/snappy/overlord/state"
+	"github.com/ubuntu-core/snappy/snap"
 	"github.com/ubuntu-core/snappy/snappy"
 )
 
@@ -42,9 +46,24 @@ func populateStateFromInstalled() error {
 	st := state.New(&overlordStateBackend{
 		path: dirs.SnapStateFile,
 	})
+	assertMgr, err := assertstate.Manager(st)
+	if err != nil {
+		return err
+	}
+
 	st.Lock()
 	defer st.Unlock()
 
+	gadgetDir := ""
+	for _, sn := range all {
+		if info := sn.Info(); info.Type == snap.TypeGadget {
+			gadgetDir = info.MountDir()
+		}
+	}
+	if err := devicestate.ImportAssertionsFromSeed(st, assertMgr, gadgetDir); err != nil {
+		return err
+	}
+
 	for _, sn := range all {
 		// no need to do a snapstate.Get() because this is firstboot
 		info := sn.Info()
@@ -59,14 +78,18 @@ func populateStateFromInstalled() error {
 	return nil
 }
 
-// FIXME:
-// This is not the final way we will do the state sync. This is just
-// an intermediate step to have working images again. We need to
-// figure out how we want first-boot to look like.
+// FirstBoot prepares the system on its first boot. Images with a
+// seed.yaml in their seed directory are seeded by the device manager
+// once the daemon starts, otherwise the state is populated from the
+// snaps unpacked by the image builder.
 func FirstBoot() error {
 	if err := snappy.FirstBoot(); err != nil {
 		return err
 	}
 
+	if osutil.FileExists(filepath.Join(dirs.SnapSeedDir, "seed.yaml")) {
+		return nil
+	}
+
 	return populateStateFromInstalled()
 }
@@ -46,6 +46,11 @@ func (m *InterfaceManager) doSetupProfiles(task *state.Task, _ *tomb.Tomb) error
 	}
 	snap.AddImplicitSlots(snapInfo)
 	snapName := snapInfo.Name()
+	// plugs and slots of known interfaces that don't validate make the
+	// install fail instead of just being left out
+	if err := m.repo.ValidateSnap(snapInfo); err != nil {
+		return err
+	}
 	var snapState snapstate.SnapState
 	if err := snapstate.Get(task.State(), snapName, &snapState); err != nil {
 		task.Errorf("cannot get state of snap %q: %s", snapName, err)
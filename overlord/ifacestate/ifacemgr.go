@@ -27,6 +27,7 @@ import (
 	"github.com/ubuntu-core/snappy/i18n"
 	"github.com/ubuntu-core/snappy/interfaces"
 	"github.com/ubuntu-core/snappy/overlord/state"
+	"github.com/ubuntu-core/snappy/snap"
 )
 
 // InterfaceManager is responsible for the maintenance of interfaces in
@@ -113,6 +114,49 @@ func (m *InterfaceManager) Repository() *interfaces.Repository {
 	return m.repo
 }
 
+// SecurityProfile describes the security setup of an application for a
+// single security system.
+type SecurityProfile struct {
+	// Profile is the generated security artefact, e.g. an apparmor profile.
+	Profile []byte
+	// Sources are the snippets that went into the profile.
+	Sources []*interfaces.SnippetSource
+}
+
+// SnapSecurity returns the security setup generated by all the security
+// backends for the applications of a given snap, keyed by application name
+// and security system.
+//
+// Nothing is written to disk or loaded into the kernel. The result is only
+// informational and may differ from what is currently in effect if the
+// security setup of the snap is about to change.
+func (m *InterfaceManager) SnapSecurity(snapInfo *snap.Info, devMode bool) (map[string]map[interfaces.SecuritySystem]*SecurityProfile, error) {
+	snapName := snapInfo.Name()
+	security := make(map[string]map[interfaces.SecuritySystem]*SecurityProfile)
+	for _, backend := range securityBackends {
+		// NOTE: each backend is named after the security system it handles.
+		system := interfaces.SecuritySystem(backend.Name())
+		profiles, err := backend.Profiles(snapInfo, devMode, m.repo)
+		if err != nil {
+			return nil, fmt.Errorf("cannot obtain %s profiles for snap %q: %s", backend.Name(), snapName, err)
+		}
+		sources, err := m.repo.SecuritySnippetSourcesForSnap(snapName, system)
+		if err != nil {
+			return nil, fmt.Errorf("cannot obtain %s snippets for snap %q: %s", backend.Name(), snapName, err)
+		}
+		for appName, profile := range profiles {
+			if security[appName] == nil {
+				security[appName] = make(map[interfaces.SecuritySystem]*SecurityProfile)
+			}
+			security[appName][system] = &SecurityProfile{
+				Profile: profile,
+				Sources: sources[appName],
+			}
+		}
+	}
+	return security, nil
+}
+
 // MockSecurityBackends mocks the list of security backends that are used for setting up security.
 //
 // This function is public because it is referenced in the daemon
@@ -20,6 +20,7 @@
 package ifacestate_test
 
 import (
+	"fmt"
 	"testing"
 
 	. "gopkg.in/check.v1"
@@ -544,6 +545,29 @@ func (s *interfaceManagerSuite) TestSetupProfilesHonorsDevMode(c *C) {
 	c.Check(oldDevMode, Equals, false)
 }
 
+// setup-profiles fails for a snap with plugs or slots that don't validate.
+func (s *interfaceManagerSuite) TestSetupProfilesFailsOnBadPlugs(c *C) {
+	s.mockIface(c, &interfaces.TestInterface{
+		InterfaceName:        "test",
+		SanitizePlugCallback: func(plug *interfaces.Plug) error { return fmt.Errorf("plug is invalid") },
+	})
+	mgr := s.manager(c)
+	snapInfo := s.mockSnap(c, consumerYaml)
+
+	change := s.addSetupSnapSecurityChange(c, &snapstate.SnapSetup{
+		Name: snapInfo.Name(), Revision: snapInfo.Revision})
+	mgr.Ensure()
+	mgr.Wait()
+	mgr.Stop()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	c.Check(change.Status(), Equals, state.ErrorStatus)
+	c.Check(change.Err(), ErrorMatches, `(?s).*snap "consumer" has bad plugs or slots: plug \(plug is invalid\).*`)
+	c.Check(s.secBackend.SetupCalls, HasLen, 0)
+}
+
 // setup-profiles uses the new snap.Info when setting up security for the new
 // snap when it had prior connections and DisconnectSnap() returns it as a part
 // of the affected set.
@@ -946,3 +970,53 @@ func (s *interfaceManagerSuite) TestManagerReloadsConnections(c *C) {
 	c.Check(plug.Connections[0], DeepEquals, interfaces.SlotRef{Snap: "producer", Name: "slot"})
 	c.Check(slot.Connections[0], DeepEquals, interfaces.PlugRef{Snap: "consumer", Name: "plug"})
 }
+
+func (s *interfaceManagerSuite) TestSnapSecurity(c *C) {
+	s.mockIface(c, &interfaces.TestInterface{
+		InterfaceName: "test",
+		PermanentPlugSnippetCallback: func(plug *interfaces.Plug, securitySystem interfaces.SecuritySystem) ([]byte, error) {
+			c.Check(securitySystem, Equals, interfaces.SecuritySystem("test"))
+			return []byte("snippet"), nil
+		},
+	})
+	snapInfo := s.mockSnap(c, `
+name: consumer
+version: 1
+apps:
+ app:
+plugs:
+ plug:
+  interface: test
+`)
+	s.secBackend.ProfilesCallback = func(snapInfo *snap.Info, devMode bool, repo *interfaces.Repository) (map[string][]byte, error) {
+		c.Check(devMode, Equals, true)
+		return map[string][]byte{"app": []byte("profile")}, nil
+	}
+
+	mgr := s.manager(c)
+	security, err := mgr.SnapSecurity(snapInfo, true)
+	c.Assert(err, IsNil)
+	c.Check(security, DeepEquals, map[string]map[interfaces.SecuritySystem]*ifacestate.SecurityProfile{
+		"app": {
+			"test": {
+				Profile: []byte("profile"),
+				Sources: []*interfaces.SnippetSource{{
+					Interface: "test",
+					Plug:      &interfaces.PlugRef{Snap: "consumer", Name: "plug"},
+					Snippet:   []byte("snippet"),
+				}},
+			},
+		},
+	})
+}
+
+func (s *interfaceManagerSuite) TestSnapSecurityError(c *C) {
+	snapInfo := s.mockSnap(c, consumerYaml)
+	s.secBackend.ProfilesCallback = func(snapInfo *snap.Info, devMode bool, repo *interfaces.Repository) (map[string][]byte, error) {
+		return nil, fmt.Errorf("boom")
+	}
+
+	mgr := s.manager(c)
+	_, err := mgr.SnapSecurity(snapInfo, false)
+	c.Assert(err, ErrorMatches, `cannot obtain test profiles for snap "consumer": boom`)
+}
@@ -23,6 +23,7 @@ package overlord
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"sync"
 	"time"
 
@@ -32,6 +33,8 @@ import (
 	"github.com/ubuntu-core/snappy/osutil"
 
 	"github.com/ubuntu-core/snappy/overlord/assertstate"
+	"github.com/ubuntu-core/snappy/overlord/denialstate"
+	"github.com/ubuntu-core/snappy/overlord/devicestate"
 	"github.com/ubuntu-core/snappy/overlord/ifacestate"
 	"github.com/ubuntu-core/snappy/overlord/snapstate"
 	"github.com/ubuntu-core/snappy/overlord/state"
@@ -58,6 +61,8 @@ type Overlord struct {
 	snapMgr   *snapstate.SnapManager
 	assertMgr *assertstate.AssertManager
 	ifaceMgr  *ifacestate.InterfaceManager
+	denialMgr *denialstate.DenialManager
+	deviceMgr *devicestate.DeviceManager
 }
 
 // New creates a new Overlord with all its state managers.
@@ -66,6 +71,12 @@ func New() (*Overlord, error) {
 		loopTomb: new(tomb.Tomb),
 	}
 
+	// the managers cache things in the state, checkpointing it, while
+	// being set up
+	if err := os.MkdirAll(filepath.Dir(dirs.SnapStateFile), 0755); err != nil {
+		return nil, err
+	}
+
 	backend := &overlordStateBackend{
 		path:         dirs.SnapStateFile,
 		ensureBefore: o.ensureBefore,
@@ -90,6 +101,7 @@ func New() (*Overlord, error) {
 	}
 	o.assertMgr = assertMgr
 	o.stateEng.AddManager(o.assertMgr)
+	snapMgr.SetRefreshValidator(assertstate.ValidateRefresh)
 
 	ifaceMgr, err := ifacestate.Manager(s, nil)
 	if err != nil {
@@ -98,6 +110,23 @@ func New() (*Overlord, error) {
 	o.ifaceMgr = ifaceMgr
 	o.stateEng.AddManager(o.ifaceMgr)
 
+	denialMgr, err := denialstate.Manager(s)
+	if err != nil {
+		return nil, err
+	}
+	o.denialMgr = denialMgr
+	o.stateEng.AddManager(o.denialMgr)
+
+	deviceMgr, err := devicestate.Manager(s, assertMgr)
+	if err != nil {
+		return nil, err
+	}
+	o.deviceMgr = deviceMgr
+	o.stateEng.AddManager(o.deviceMgr)
+	snapMgr.SetInstallChecker(devicestate.CheckInstall)
+	snapMgr.SetRemoveChecker(devicestate.CheckRemove)
+	snapMgr.SetStoreIDFunc(devicestate.StoreID)
+
 	return o, nil
 }
 
@@ -243,3 +272,15 @@ func (o *Overlord) AssertManager() *assertstate.AssertManager {
 func (o *Overlord) InterfaceManager() *ifacestate.InterfaceManager {
 	return o.ifaceMgr
 }
+
+// DenialManager returns the denial manager recording operations denied
+// by the confinement of snaps under the overlord.
+func (o *Overlord) DenialManager() *denialstate.DenialManager {
+	return o.denialMgr
+}
+
+// DeviceManager returns the device manager responsible for the device
+// identity and model under the overlord.
+func (o *Overlord) DeviceManager() *devicestate.DeviceManager {
+	return o.deviceMgr
+}
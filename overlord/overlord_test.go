@@ -31,6 +31,7 @@ import (
 	"gopkg.in/tomb.v2"
 
 	"github.com/ubuntu-core/snappy/dirs"
+	"github.com/ubuntu-core/snappy/osutil"
 	"github.com/ubuntu-core/snappy/testutil"
 
 	"github.com/ubuntu-core/snappy/overlord"
@@ -61,12 +62,23 @@ func (ovs *overlordSuite) TestNew(c *C) {
 	c.Check(o.SnapManager(), NotNil)
 	c.Check(o.AssertManager(), NotNil)
 	c.Check(o.InterfaceManager(), NotNil)
+	c.Check(o.DenialManager(), NotNil)
+	c.Check(o.DeviceManager(), NotNil)
 
 	s := o.State()
 	c.Check(s, NotNil)
 	c.Check(o.Engine().State(), Equals, s)
 }
 
+func (ovs *overlordSuite) TestNewWithoutStateDir(c *C) {
+	dirs.SetRootDir(c.MkDir())
+
+	_, err := overlord.New()
+	c.Assert(err, IsNil)
+	// the state got checkpointed while setting up the managers
+	c.Check(osutil.FileExists(dirs.SnapStateFile), Equals, true)
+}
+
 func (ovs *overlordSuite) TestNewWithGoodState(c *C) {
 	fakeState := []byte(`{"data":{"some":"data"},"changes":null,"tasks":null,"last-change-id":0,"last-task-id":0}`)
 	err := ioutil.WriteFile(dirs.SnapStateFile, fakeState, 0600)
@@ -0,0 +1,412 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package snapstate
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/tomb.v2"
+
+	"github.com/ubuntu-core/snappy/i18n"
+	"github.com/ubuntu-core/snappy/overlord/state"
+	"github.com/ubuntu-core/snappy/snap"
+	"github.com/ubuntu-core/snappy/wrappers"
+)
+
+// getAliases retrieves the enabled aliases of all snaps from the state,
+// mapping the names of the snaps to their aliases and the names of the
+// apps these run.
+func getAliases(st *state.State) (map[string]map[string]string, error) {
+	var aliases map[string]map[string]string
+	err := st.Get("aliases", &aliases)
+	if err != nil && err != state.ErrNoState {
+		return nil, err
+	}
+	if aliases == nil {
+		aliases = make(map[string]map[string]string)
+	}
+	return aliases, nil
+}
+
+// Aliases returns the enabled aliases of all snaps, mapping the names
+// of the snaps to their aliases and the names of the apps these run.
+// Note that the state must be locked by the caller.
+func Aliases(st *state.State) (map[string]map[string]string, error) {
+	return getAliases(st)
+}
+
+// aliasApp returns the app of the snap that declares the given alias.
+func aliasApp(info *snap.Info, alias string) *snap.AppInfo {
+	for _, app := range info.Apps {
+		for _, appAlias := range app.Aliases {
+			if appAlias == alias {
+				return app
+			}
+		}
+	}
+	return nil
+}
+
+// aliasTargets returns the aliases of the snap mapped to the names of
+// their apps, in alias order, as wrappers aliases.
+func aliasTargets(snapName string, aliases map[string]string) []*wrappers.Alias {
+	names := make([]string, 0, len(aliases))
+	for alias := range aliases {
+		names = append(names, alias)
+	}
+	sort.Strings(names)
+
+	targets := make([]*wrappers.Alias, len(names))
+	for i, alias := range names {
+		targets[i] = &wrappers.Alias{Name: alias, Target: snap.JoinSnapApp(snapName, aliases[alias])}
+	}
+	return targets
+}
+
+// checkAliasConflict checks that the alias is neither enabled for
+// another snap nor in the namespace of the commands of an installed
+// snap.
+func checkAliasConflict(st *state.State, snapName, alias string, enabled map[string]map[string]string) error {
+	for otherName, otherAliases := range enabled {
+		if otherName == snapName {
+			continue
+		}
+		if _, ok := otherAliases[alias]; ok {
+			return fmt.Errorf("cannot enable alias %q for snap %q, already enabled for snap %q", alias, snapName, otherName)
+		}
+	}
+
+	// the wrappers of the apps of a snap are all named after it
+	nsName, _ := snap.SplitSnapApp(alias)
+	// that snap may be getting installed right now
+	if err := checkChangeConflict(st, nsName); err != nil {
+		return fmt.Errorf("cannot enable alias %q for snap %q, it conflicts with the commands of snap %q being changed", alias, snapName, nsName)
+	}
+	var snapst SnapState
+	err := Get(st, nsName, &snapst)
+	if err == state.ErrNoState {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("cannot enable alias %q for snap %q, it conflicts with the commands of snap %q", alias, snapName, nsName)
+}
+
+// checkSnapAliasConflict checks that no alias enabled for another snap
+// is in the namespace of the commands of the given snap, which is
+// about to be installed.
+func checkSnapAliasConflict(st *state.State, snapName string) error {
+	enabled, err := getAliases(st)
+	if err != nil {
+		return err
+	}
+	for otherName, otherAliases := range enabled {
+		if otherName == snapName {
+			continue
+		}
+		for alias := range otherAliases {
+			if nsName, _ := snap.SplitSnapApp(alias); nsName == snapName {
+				return fmt.Errorf("snap %q command namespace conflicts with alias %q enabled for snap %q", snapName, alias, otherName)
+			}
+		}
+	}
+	return nil
+}
+
+func quoteAliases(aliases []string) string {
+	quoted := make([]string, len(aliases))
+	for i, alias := range aliases {
+		quoted[i] = strconv.Quote(alias)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// Alias returns a set of tasks for enabling the given aliases of the
+// apps of a snap.
+// Note that the state must be locked by the caller.
+func Alias(st *state.State, snapName string, aliases []string) (*state.TaskSet, error) {
+	var snapst SnapState
+	err := Get(st, snapName, &snapst)
+	if err != nil && err != state.ErrNoState {
+		return nil, err
+	}
+	cur := snapst.Current()
+	if cur == nil {
+		return nil, fmt.Errorf("cannot find snap %q", snapName)
+	}
+	if !snapst.Active {
+		return nil, fmt.Errorf("snap %q is not active", snapName)
+	}
+	if err := checkChangeConflict(st, snapName); err != nil {
+		return nil, err
+	}
+
+	info, err := readInfo(snapName, cur)
+	if err != nil {
+		return nil, err
+	}
+	enabled, err := getAliases(st)
+	if err != nil {
+		return nil, err
+	}
+	for _, alias := range aliases {
+		if aliasApp(info, alias) == nil {
+			return nil, fmt.Errorf("snap %q has no alias %q", snapName, alias)
+		}
+		if err := checkAliasConflict(st, snapName, alias, enabled); err != nil {
+			return nil, err
+		}
+	}
+
+	ss := SnapSetup{
+		Name:     snapName,
+		Revision: cur.Revision,
+	}
+	t := st.NewTask("alias", fmt.Sprintf(i18n.G("Enable aliases %s for snap %q"), quoteAliases(aliases), snapName))
+	t.Set("snap-setup", ss)
+	t.Set("aliases", aliases)
+	return state.NewTaskSet(t), nil
+}
+
+// Unalias returns a set of tasks for disabling the given aliases of the
+// apps of a snap.
+// Note that the state must be locked by the caller.
+func Unalias(st *state.State, snapName string, aliases []string) (*state.TaskSet, error) {
+	var snapst SnapState
+	err := Get(st, snapName, &snapst)
+	if err != nil && err != state.ErrNoState {
+		return nil, err
+	}
+	cur := snapst.Current()
+	if cur == nil {
+		return nil, fmt.Errorf("cannot find snap %q", snapName)
+	}
+	if err := checkChangeConflict(st, snapName); err != nil {
+		return nil, err
+	}
+
+	enabled, err := getAliases(st)
+	if err != nil {
+		return nil, err
+	}
+	for _, alias := range aliases {
+		if _, ok := enabled[snapName][alias]; !ok {
+			return nil, fmt.Errorf("alias %q is not enabled for snap %q", alias, snapName)
+		}
+	}
+
+	ss := SnapSetup{
+		Name:     snapName,
+		Revision: cur.Revision,
+	}
+	t := st.NewTask("unalias", fmt.Sprintf(i18n.G("Disable aliases %s for snap %q"), quoteAliases(aliases), snapName))
+	t.Set("snap-setup", ss)
+	t.Set("aliases", aliases)
+	return state.NewTaskSet(t), nil
+}
+
+// the alias handlers keep the state locked while touching the aliases
+// on disk, so that their conflict checks hold
+
+func (m *SnapManager) doAlias(t *state.Task, _ *tomb.Tomb) error {
+	st := t.State()
+	st.Lock()
+	defer st.Unlock()
+
+	ss, err := TaskSnapSetup(t)
+	if err != nil {
+		return err
+	}
+	var aliases []string
+	if err := t.Get("aliases", &aliases); err != nil {
+		return err
+	}
+	info, err := Info(st, ss.Name, ss.Revision)
+	if err != nil {
+		return err
+	}
+	enabled, err := getAliases(st)
+	if err != nil {
+		return err
+	}
+
+	added := make(map[string]string)
+	for _, alias := range aliases {
+		if _, ok := enabled[ss.Name][alias]; ok {
+			continue
+		}
+		app := aliasApp(info, alias)
+		if app == nil {
+			return fmt.Errorf("snap %q has no alias %q", ss.Name, alias)
+		}
+		if err := checkAliasConflict(st, ss.Name, alias, enabled); err != nil {
+			return err
+		}
+		added[alias] = app.Name
+	}
+
+	if err := m.backend.AddAliases(aliasTargets(ss.Name, added)); err != nil {
+		return err
+	}
+
+	if enabled[ss.Name] == nil {
+		enabled[ss.Name] = make(map[string]string)
+	}
+	for alias, appName := range added {
+		enabled[ss.Name][alias] = appName
+	}
+	st.Set("aliases", enabled)
+	t.Set("added-aliases", added)
+	return nil
+}
+
+func (m *SnapManager) undoAlias(t *state.Task, _ *tomb.Tomb) error {
+	st := t.State()
+	st.Lock()
+	defer st.Unlock()
+
+	ss, err := TaskSnapSetup(t)
+	if err != nil {
+		return err
+	}
+	var added map[string]string
+	if err := t.Get("added-aliases", &added); err != nil {
+		return err
+	}
+
+	if err := m.backend.RemoveAliases(aliasTargets(ss.Name, added)); err != nil {
+		return err
+	}
+
+	enabled, err := getAliases(st)
+	if err != nil {
+		return err
+	}
+	for alias := range added {
+		delete(enabled[ss.Name], alias)
+	}
+	if len(enabled[ss.Name]) == 0 {
+		delete(enabled, ss.Name)
+	}
+	st.Set("aliases", enabled)
+	return nil
+}
+
+// removeAliases disables the given enabled aliases of the snap of the
+// task, recording them for undoing.
+func (m *SnapManager) removeAliases(t *state.Task, snapName string, aliases []string, enabled map[string]map[string]string) error {
+	removed := make(map[string]string)
+	for _, alias := range aliases {
+		if appName, ok := enabled[snapName][alias]; ok {
+			removed[alias] = appName
+		}
+	}
+
+	if err := m.backend.RemoveAliases(aliasTargets(snapName, removed)); err != nil {
+		return err
+	}
+
+	for alias := range removed {
+		delete(enabled[snapName], alias)
+	}
+	if len(enabled[snapName]) == 0 {
+		delete(enabled, snapName)
+	}
+	t.State().Set("aliases", enabled)
+	t.Set("removed-aliases", removed)
+	return nil
+}
+
+func (m *SnapManager) doUnalias(t *state.Task, _ *tomb.Tomb) error {
+	st := t.State()
+	st.Lock()
+	defer st.Unlock()
+
+	ss, err := TaskSnapSetup(t)
+	if err != nil {
+		return err
+	}
+	var aliases []string
+	if err := t.Get("aliases", &aliases); err != nil {
+		return err
+	}
+	enabled, err := getAliases(st)
+	if err != nil {
+		return err
+	}
+
+	return m.removeAliases(t, ss.Name, aliases, enabled)
+}
+
+func (m *SnapManager) doClearAliases(t *state.Task, _ *tomb.Tomb) error {
+	st := t.State()
+	st.Lock()
+	defer st.Unlock()
+
+	ss, err := TaskSnapSetup(t)
+	if err != nil {
+		return err
+	}
+	enabled, err := getAliases(st)
+	if err != nil {
+		return err
+	}
+	aliases := make([]string, 0, len(enabled[ss.Name]))
+	for alias := range enabled[ss.Name] {
+		aliases = append(aliases, alias)
+	}
+
+	return m.removeAliases(t, ss.Name, aliases, enabled)
+}
+
+func (m *SnapManager) undoUnalias(t *state.Task, _ *tomb.Tomb) error {
+	st := t.State()
+	st.Lock()
+	defer st.Unlock()
+
+	ss, err := TaskSnapSetup(t)
+	if err != nil {
+		return err
+	}
+	var removed map[string]string
+	if err := t.Get("removed-aliases", &removed); err != nil {
+		return err
+	}
+
+	if err := m.backend.AddAliases(aliasTargets(ss.Name, removed)); err != nil {
+		return err
+	}
+
+	enabled, err := getAliases(st)
+	if err != nil {
+		return err
+	}
+	if enabled[ss.Name] == nil {
+		enabled[ss.Name] = make(map[string]string)
+	}
+	for alias, appName := range removed {
+		enabled[ss.Name][alias] = appName
+	}
+	st.Set("aliases", enabled)
+	return nil
+}
@@ -0,0 +1,323 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package snapstate_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/ubuntu-core/snappy/overlord/snapstate"
+	"github.com/ubuntu-core/snappy/overlord/state"
+	"github.com/ubuntu-core/snappy/snap"
+	"github.com/ubuntu-core/snappy/wrappers"
+)
+
+func (s *snapmgrTestSuite) setAliasSnap(active bool) {
+	snapstate.Set(s.state, "alias-snap", &snapstate.SnapState{
+		Active:   active,
+		Sequence: []*snap.SideInfo{{OfficialName: "alias-snap", Revision: 11}},
+	})
+}
+
+func (s *snapmgrTestSuite) TestAliasTasks(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.setAliasSnap(true)
+
+	ts, err := snapstate.Alias(s.state, "alias-snap", []string{"alias1", "alias2"})
+	c.Assert(err, IsNil)
+	c.Assert(ts.Tasks(), HasLen, 1)
+
+	t := ts.Tasks()[0]
+	c.Check(t.Kind(), Equals, "alias")
+	c.Check(t.Summary(), Equals, `Enable aliases "alias1", "alias2" for snap "alias-snap"`)
+	ss, err := snapstate.TaskSnapSetup(t)
+	c.Assert(err, IsNil)
+	c.Check(ss, DeepEquals, &snapstate.SnapSetup{Name: "alias-snap", Revision: 11})
+	var aliases []string
+	c.Assert(t.Get("aliases", &aliases), IsNil)
+	c.Check(aliases, DeepEquals, []string{"alias1", "alias2"})
+}
+
+func (s *snapmgrTestSuite) TestAliasErrors(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.setAliasSnap(true)
+	snapstate.Set(s.state, "inactive-snap", &snapstate.SnapState{
+		Sequence: []*snap.SideInfo{{OfficialName: "inactive-snap", Revision: 1}},
+	})
+	snapstate.Set(s.state, "other-snap", &snapstate.SnapState{
+		Active:   true,
+		Sequence: []*snap.SideInfo{{OfficialName: "other-snap", Revision: 1}},
+	})
+	s.state.Set("aliases", map[string]map[string]string{
+		"other-snap": {"alias2": "cmd"},
+	})
+
+	tests := []struct {
+		snap    string
+		aliases []string
+		err     string
+	}{
+		{"unknown-snap", []string{"alias1"}, `cannot find snap "unknown-snap"`},
+		{"inactive-snap", []string{"alias1"}, `snap "inactive-snap" is not active`},
+		{"alias-snap", []string{"alias1", "alias5"}, `snap "alias-snap" has no alias "alias5"`},
+		{"alias-snap", []string{"alias2"}, `cannot enable alias "alias2" for snap "alias-snap", already enabled for snap "other-snap"`},
+	}
+	for _, test := range tests {
+		_, err := snapstate.Alias(s.state, test.snap, test.aliases)
+		c.Check(err, ErrorMatches, test.err)
+	}
+	c.Check(s.state.NumTask(), Equals, 0)
+}
+
+func (s *snapmgrTestSuite) TestAliasConflictsWithSnapCommands(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.setAliasSnap(true)
+	snapstate.Set(s.state, "alias3", &snapstate.SnapState{
+		Active:   true,
+		Sequence: []*snap.SideInfo{{OfficialName: "alias3", Revision: 1}},
+	})
+
+	_, err := snapstate.Alias(s.state, "alias-snap", []string{"alias3"})
+	c.Check(err, ErrorMatches, `cannot enable alias "alias3" for snap "alias-snap", it conflicts with the commands of snap "alias3"`)
+}
+
+func (s *snapmgrTestSuite) TestAliasIntegration(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.setAliasSnap(true)
+	s.state.Set("aliases", map[string]map[string]string{
+		"alias-snap": {"alias1": "cmd1"},
+	})
+
+	chg := s.state.NewChange("alias", "enable aliases")
+	ts, err := snapstate.Alias(s.state, "alias-snap", []string{"alias1", "alias3", "alias4"})
+	c.Assert(err, IsNil)
+	chg.AddAll(ts)
+
+	s.state.Unlock()
+	defer s.snapmgr.Stop()
+	s.settle()
+	s.state.Lock()
+
+	c.Check(chg.Status(), Equals, state.DoneStatus)
+	// the already enabled alias is left alone
+	c.Check(s.fakeBackend.ops, DeepEquals, []fakeOp{{
+		op: "add-aliases",
+		aliases: []*wrappers.Alias{
+			{Name: "alias3", Target: "alias-snap.cmd2"},
+			{Name: "alias4", Target: "alias-snap"},
+		},
+	}})
+
+	aliases, err := snapstate.Aliases(s.state)
+	c.Assert(err, IsNil)
+	c.Check(aliases, DeepEquals, map[string]map[string]string{
+		"alias-snap": {"alias1": "cmd1", "alias3": "cmd2", "alias4": "alias-snap"},
+	})
+}
+
+func (s *snapmgrTestSuite) TestAliasUndo(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.setAliasSnap(true)
+	s.state.Set("aliases", map[string]map[string]string{
+		"alias-snap": {"alias1": "cmd1"},
+	})
+
+	chg := s.state.NewChange("alias", "enable aliases")
+	ts, err := snapstate.Alias(s.state, "alias-snap", []string{"alias1", "alias2"})
+	c.Assert(err, IsNil)
+	chg.AddAll(ts)
+
+	terr := s.state.NewTask("error-trigger", "provoking total undo")
+	terr.WaitAll(ts)
+	chg.AddTask(terr)
+
+	s.state.Unlock()
+	defer s.snapmgr.Stop()
+	s.settle()
+	s.state.Lock()
+
+	c.Check(chg.Status(), Equals, state.ErrorStatus)
+	added := []*wrappers.Alias{{Name: "alias2", Target: "alias-snap.cmd2"}}
+	c.Check(s.fakeBackend.ops, DeepEquals, []fakeOp{
+		{op: "add-aliases", aliases: added},
+		{op: "remove-aliases", aliases: added},
+	})
+
+	aliases, err := snapstate.Aliases(s.state)
+	c.Assert(err, IsNil)
+	c.Check(aliases, DeepEquals, map[string]map[string]string{
+		"alias-snap": {"alias1": "cmd1"},
+	})
+}
+
+func (s *snapmgrTestSuite) TestUnaliasErrors(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.setAliasSnap(false)
+	s.state.Set("aliases", map[string]map[string]string{
+		"alias-snap": {"alias1": "cmd1"},
+	})
+
+	_, err := snapstate.Unalias(s.state, "unknown-snap", []string{"alias1"})
+	c.Check(err, ErrorMatches, `cannot find snap "unknown-snap"`)
+	_, err = snapstate.Unalias(s.state, "alias-snap", []string{"alias1", "alias2"})
+	c.Check(err, ErrorMatches, `alias "alias2" is not enabled for snap "alias-snap"`)
+	c.Check(s.state.NumTask(), Equals, 0)
+}
+
+func (s *snapmgrTestSuite) TestUnaliasIntegration(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.setAliasSnap(true)
+	s.state.Set("aliases", map[string]map[string]string{
+		"alias-snap": {"alias1": "cmd1", "alias2": "cmd2"},
+	})
+
+	chg := s.state.NewChange("unalias", "disable aliases")
+	ts, err := snapstate.Unalias(s.state, "alias-snap", []string{"alias1"})
+	c.Assert(err, IsNil)
+	c.Check(ts.Tasks()[0].Kind(), Equals, "unalias")
+	chg.AddAll(ts)
+
+	s.state.Unlock()
+	defer s.snapmgr.Stop()
+	s.settle()
+	s.state.Lock()
+
+	c.Check(chg.Status(), Equals, state.DoneStatus)
+	c.Check(s.fakeBackend.ops, DeepEquals, []fakeOp{{
+		op:      "remove-aliases",
+		aliases: []*wrappers.Alias{{Name: "alias1", Target: "alias-snap.cmd1"}},
+	}})
+
+	aliases, err := snapstate.Aliases(s.state)
+	c.Assert(err, IsNil)
+	c.Check(aliases, DeepEquals, map[string]map[string]string{
+		"alias-snap": {"alias2": "cmd2"},
+	})
+}
+
+func (s *snapmgrTestSuite) TestRemoveClearsAliases(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.setAliasSnap(true)
+	s.state.Set("aliases", map[string]map[string]string{
+		"alias-snap": {"alias1": "cmd1", "alias2": "cmd2"},
+		"other-snap": {"alias5": "cmd"},
+	})
+
+	ts, err := snapstate.Remove(s.state, "alias-snap", 0)
+	c.Assert(err, IsNil)
+	c.Assert(ts.Tasks(), HasLen, 6)
+	clearAliases := ts.Tasks()[0]
+	c.Assert(clearAliases.Kind(), Equals, "clear-aliases")
+	c.Check(ts.Tasks()[1].Kind(), Equals, "unlink-snap")
+	c.Check(ts.Tasks()[1].WaitTasks(), DeepEquals, []*state.Task{clearAliases})
+	s.fakeBackend.ops = nil
+
+	chg := s.state.NewChange("clear-aliases", "clear aliases")
+	t := s.state.NewTask("clear-aliases", "clear aliases")
+	t.Set("snap-setup", &snapstate.SnapSetup{Name: "alias-snap", Revision: 11})
+	chg.AddTask(t)
+
+	s.state.Unlock()
+	defer s.snapmgr.Stop()
+	s.settle()
+	s.state.Lock()
+
+	c.Check(chg.Status(), Equals, state.DoneStatus)
+	c.Check(s.fakeBackend.ops, DeepEquals, []fakeOp{{
+		op: "remove-aliases",
+		aliases: []*wrappers.Alias{
+			{Name: "alias1", Target: "alias-snap.cmd1"},
+			{Name: "alias2", Target: "alias-snap.cmd2"},
+		},
+	}})
+
+	aliases, err := snapstate.Aliases(s.state)
+	c.Assert(err, IsNil)
+	c.Check(aliases, DeepEquals, map[string]map[string]string{
+		"other-snap": {"alias5": "cmd"},
+	})
+}
+
+func (s *snapmgrTestSuite) TestInstallConflictsWithAliases(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.state.Set("aliases", map[string]map[string]string{
+		"alias-snap": {"some-snap": "cmd1"},
+	})
+
+	_, err := snapstate.Install(s.state, "some-snap", "some-channel", s.user.ID, 0)
+	c.Check(err, ErrorMatches, `snap "some-snap" command namespace conflicts with alias "some-snap" enabled for snap "alias-snap"`)
+	c.Check(s.state.NumTask(), Equals, 0)
+}
+
+func (s *snapmgrTestSuite) TestLinkConflictsWithAliasesEnabledMeanwhile(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	chg := s.state.NewChange("install", "install a snap")
+	ts, err := snapstate.Install(s.state, "some-snap", "some-channel", s.user.ID, 0)
+	c.Assert(err, IsNil)
+	chg.AddAll(ts)
+
+	// enabled before the snap gets linked
+	s.state.Set("aliases", map[string]map[string]string{
+		"alias-snap": {"some-snap": "cmd1"},
+	})
+
+	s.state.Unlock()
+	defer s.snapmgr.Stop()
+	s.settle()
+	s.state.Lock()
+
+	c.Check(chg.Status(), Equals, state.ErrorStatus)
+	c.Check(chg.Err(), ErrorMatches, `(?s).*snap "some-snap" command namespace conflicts with alias "some-snap" enabled for snap "alias-snap".*`)
+	for _, op := range s.fakeBackend.ops {
+		c.Check(op.op, Not(Equals), "link-snap")
+	}
+}
+
+func (s *snapmgrTestSuite) TestAliasConflictsWithSnapBeingInstalled(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.setAliasSnap(true)
+	chg := s.state.NewChange("install", "install a snap")
+	ts, err := snapstate.Install(s.state, "alias3", "some-channel", s.user.ID, 0)
+	c.Assert(err, IsNil)
+	chg.AddAll(ts)
+
+	_, err = snapstate.Alias(s.state, "alias-snap", []string{"alias3"})
+	c.Check(err, ErrorMatches, `cannot enable alias "alias3" for snap "alias-snap", it conflicts with the commands of snap "alias3" being changed`)
+}
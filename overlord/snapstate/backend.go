@@ -20,19 +20,23 @@
 package snapstate
 
 import (
+	"fmt"
+
 	"github.com/ubuntu-core/snappy/progress"
 	"github.com/ubuntu-core/snappy/snap"
 	"github.com/ubuntu-core/snappy/snappy"
 	"github.com/ubuntu-core/snappy/store"
+	"github.com/ubuntu-core/snappy/wrappers"
 )
 
 type managerBackend interface {
 	// install releated
-	Download(name, channel string, checker func(*snap.Info) error, meter progress.Meter, auther store.Authenticator) (*snap.Info, string, error)
+	Download(name, channel, storeID string, checker func(*snap.Info) error, meter progress.Meter, auther store.Authenticator) (*snap.Info, string, error)
 	CheckSnap(snapFilePath string, curInfo *snap.Info, flags int) error
 	SetupSnap(snapFilePath string, si *snap.SideInfo, flags int) error
 	CopySnapData(newSnap, oldSnap *snap.Info, flags int) error
 	LinkSnap(info *snap.Info) error
+	UnlinkSnapForRefresh(info *snap.Info, meter progress.Meter) error
 	// the undoers for install
 	UndoSetupSnap(s snap.PlaceInfo) error
 	UndoCopySnapData(newSnap *snap.Info, flags int) error
@@ -44,6 +48,13 @@ type managerBackend interface {
 	RemoveSnapData(info *snap.Info) error
 	RemoveSnapCommonData(info *snap.Info) error
 
+	// service related
+	ServiceControl(apps []*snap.AppInfo, action string, meter progress.Meter) error
+
+	// alias related
+	AddAliases(aliases []*wrappers.Alias) error
+	RemoveAliases(aliases []*wrappers.Alias) error
+
 	// testing helpers
 	Candidate(sideInfo *snap.SideInfo)
 }
@@ -52,8 +63,8 @@ type defaultBackend struct{}
 
 func (b *defaultBackend) Candidate(*snap.SideInfo) {}
 
-func (b *defaultBackend) Download(name, channel string, checker func(*snap.Info) error, meter progress.Meter, auther store.Authenticator) (*snap.Info, string, error) {
-	mStore := snappy.NewConfiguredUbuntuStoreSnapRepository()
+func (b *defaultBackend) Download(name, channel, storeID string, checker func(*snap.Info) error, meter progress.Meter, auther store.Authenticator) (*snap.Info, string, error) {
+	mStore := snappy.NewConfiguredUbuntuStoreSnapRepositoryForStoreID(storeID)
 	snap, err := mStore.Snap(name, channel, auther)
 	if err != nil {
 		return nil, "", err
@@ -93,6 +104,10 @@ func (b *defaultBackend) LinkSnap(info *snap.Info) error {
 	return snappy.LinkSnap(info, meter)
 }
 
+func (b *defaultBackend) UnlinkSnapForRefresh(info *snap.Info, meter progress.Meter) error {
+	return snappy.UnlinkSnapForRefresh(info, meter)
+}
+
 func (b *defaultBackend) UndoSetupSnap(s snap.PlaceInfo) error {
 	meter := &progress.NullProgress{}
 	snappy.UndoSetupSnap(s, meter)
@@ -124,3 +139,33 @@ func (b *defaultBackend) RemoveSnapData(info *snap.Info) error {
 func (b *defaultBackend) RemoveSnapCommonData(info *snap.Info) error {
 	return snappy.RemoveSnapCommonData(info)
 }
+
+func (b *defaultBackend) ServiceControl(apps []*snap.AppInfo, action string, meter progress.Meter) error {
+	switch action {
+	case "start":
+		return wrappers.StartServices(apps, meter)
+	case "stop":
+		return wrappers.StopServices(apps, meter)
+	case "restart":
+		return wrappers.RestartServices(apps, meter)
+	case "enable":
+		if err := wrappers.EnableServices(apps, meter); err != nil {
+			return err
+		}
+		return wrappers.StartServices(apps, meter)
+	case "disable":
+		if err := wrappers.DisableServices(apps, meter); err != nil {
+			return err
+		}
+		return wrappers.StopServices(apps, meter)
+	}
+	return fmt.Errorf("unknown service action %q", action)
+}
+
+func (b *defaultBackend) AddAliases(aliases []*wrappers.Alias) error {
+	return wrappers.AddAliases(aliases)
+}
+
+func (b *defaultBackend) RemoveAliases(aliases []*wrappers.Alias) error {
+	return wrappers.RemoveAliases(aliases)
+}
@@ -29,6 +29,7 @@ import (
 	"github.com/ubuntu-core/snappy/progress"
 	"github.com/ubuntu-core/snappy/snap"
 	"github.com/ubuntu-core/snappy/store"
+	"github.com/ubuntu-core/snappy/wrappers"
 )
 
 type fakeOp struct {
@@ -38,11 +39,14 @@ type fakeOp struct {
 	name     string
 	revno    int
 	channel  string
+	storeID  string
 	flags    int
 	active   bool
 	sinfo    snap.SideInfo
 
 	old string
+
+	aliases []*wrappers.Alias
 }
 
 type fakeSnappyBackend struct {
@@ -54,7 +58,7 @@ type fakeSnappyBackend struct {
 	linkSnapFailTrigger string
 }
 
-func (f *fakeSnappyBackend) Download(name, channel string, checker func(*snap.Info) error, p progress.Meter, auther store.Authenticator) (*snap.Info, string, error) {
+func (f *fakeSnappyBackend) Download(name, channel, storeID string, checker func(*snap.Info) error, p progress.Meter, auther store.Authenticator) (*snap.Info, string, error) {
 	p.Notify("download")
 	var macaroon string
 	if auther != nil {
@@ -65,6 +69,7 @@ func (f *fakeSnappyBackend) Download(name, channel string, checker func(*snap.In
 		macaroon: macaroon,
 		name:     name,
 		channel:  channel,
+		storeID:  storeID,
 	})
 	p.SetTotal(float64(f.fakeTotalProgress))
 	p.Set(float64(f.fakeCurrentProgress))
@@ -122,7 +127,22 @@ func (f *fakeSnappyBackend) SetupSnap(snapFilePath string, si *snap.SideInfo, fl
 
 func (f *fakeSnappyBackend) ReadInfo(name string, si *snap.SideInfo) (*snap.Info, error) {
 	// naive emulation for now, always works
-	return &snap.Info{SuggestedName: name, SideInfo: *si}, nil
+	info := &snap.Info{SuggestedName: name, SideInfo: *si}
+	if name == "services-snap" {
+		info.Apps = map[string]*snap.AppInfo{
+			"svc1": {Snap: info, Name: "svc1", Daemon: "simple"},
+			"svc2": {Snap: info, Name: "svc2", Daemon: "forking"},
+			"app":  {Snap: info, Name: "app"},
+		}
+	}
+	if name == "alias-snap" {
+		info.Apps = map[string]*snap.AppInfo{
+			"cmd1":       {Snap: info, Name: "cmd1", Aliases: []string{"alias1"}},
+			"cmd2":       {Snap: info, Name: "cmd2", Aliases: []string{"alias2", "alias3"}},
+			"alias-snap": {Snap: info, Name: "alias-snap", Aliases: []string{"alias4"}},
+		}
+	}
+	return info, nil
 }
 
 func (f *fakeSnappyBackend) CopySnapData(newInfo, oldInfo *snap.Info, flags int) error {
@@ -189,6 +209,15 @@ func (f *fakeSnappyBackend) UnlinkSnap(info *snap.Info, meter progress.Meter) er
 	return nil
 }
 
+func (f *fakeSnappyBackend) UnlinkSnapForRefresh(info *snap.Info, meter progress.Meter) error {
+	meter.Notify("unlink")
+	f.ops = append(f.ops, fakeOp{
+		op:   "unlink-snap-for-refresh",
+		name: info.MountDir(),
+	})
+	return nil
+}
+
 func (f *fakeSnappyBackend) RemoveSnapFiles(s snap.PlaceInfo, meter progress.Meter) error {
 	meter.Notify("remove-snap-files")
 	f.ops = append(f.ops, fakeOp{
@@ -214,6 +243,35 @@ func (f *fakeSnappyBackend) RemoveSnapCommonData(info *snap.Info) error {
 	return nil
 }
 
+func (f *fakeSnappyBackend) ServiceControl(apps []*snap.AppInfo, action string, meter progress.Meter) error {
+	meter.Notify("service-control")
+	names := make([]string, len(apps))
+	for i, app := range apps {
+		names[i] = app.Name
+	}
+	f.ops = append(f.ops, fakeOp{
+		op:   "service-control:" + action,
+		name: strings.Join(names, ","),
+	})
+	return nil
+}
+
+func (f *fakeSnappyBackend) AddAliases(aliases []*wrappers.Alias) error {
+	f.ops = append(f.ops, fakeOp{
+		op:      "add-aliases",
+		aliases: aliases,
+	})
+	return nil
+}
+
+func (f *fakeSnappyBackend) RemoveAliases(aliases []*wrappers.Alias) error {
+	f.ops = append(f.ops, fakeOp{
+		op:      "remove-aliases",
+		aliases: aliases,
+	})
+	return nil
+}
+
 func (f *fakeSnappyBackend) Candidate(sideInfo *snap.SideInfo) {
 	var sinfo snap.SideInfo
 	if sideInfo != nil {
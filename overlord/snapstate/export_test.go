@@ -70,6 +70,12 @@ func (m *SnapManager) AddForeignTaskHandlers(tracker ForeignTaskTracker) {
 	m.runner.AddHandler("error-trigger", erroringHandler, nil)
 }
 
+func MockReadInfoFromSnapFile(mock func(path string) (*snap.Info, error)) func() {
+	old := readInfoFromSnapFile
+	readInfoFromSnapFile = mock
+	return func() { readInfoFromSnapFile = old }
+}
+
 func MockReadInfo(mock func(name string, si *snap.SideInfo) (*snap.Info, error)) func() {
 	readInfo = mock
 	return func() { readInfo = snap.ReadInfo }
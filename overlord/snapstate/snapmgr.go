@@ -37,9 +37,23 @@ type SnapManager struct {
 	state   *state.State
 	backend managerBackend
 
+	validateRefresh func(st *state.State, info *snap.Info) error
+	checkInstall    func(st *state.State, info *snap.Info) error
+	checkRemove     func(st *state.State, name string) error
+	storeID         func(st *state.State) (string, error)
+
 	runner *state.TaskRunner
 }
 
+type cachedSnapMgrKey struct{}
+
+// cachedSnapMgr returns the snap manager cached in the state, or nil if
+// there is none.
+func cachedSnapMgr(s *state.State) *SnapManager {
+	m, _ := s.Cached(cachedSnapMgrKey{}).(*SnapManager)
+	return m
+}
+
 // SnapSetup holds the necessary snap details to perform most snap manager tasks.
 type SnapSetup struct {
 	Name     string `json:"name"`
@@ -50,6 +64,9 @@ type SnapSetup struct {
 	Flags int `json:"flags,omitempty"`
 
 	SnapPath string `json:"snap-path,omitempty"`
+	// SideInfo describes the snap at SnapPath when it is known from
+	// its assertions
+	SideInfo *snap.SideInfo `json:"side-info,omitempty"`
 }
 
 func (ss *SnapSetup) placeInfo() snap.PlaceInfo {
@@ -107,6 +124,11 @@ func Manager(s *state.State) (*SnapManager, error) {
 		runner:  runner,
 	}
 
+	// let Install, InstallPath and Remove find the checks of the manager
+	s.Lock()
+	s.Cache(cachedSnapMgrKey{}, m)
+	s.Unlock()
+
 	// this handler does nothing
 	runner.AddHandler("nop", func(t *state.Task, _ *tomb.Tomb) error {
 		return nil
@@ -127,6 +149,14 @@ func Manager(s *state.State) (*SnapManager, error) {
 	runner.AddHandler("clear-snap", m.doClearSnapData, nil)
 	runner.AddHandler("discard-snap", m.doDiscardSnap, nil)
 
+	// service related
+	runner.AddHandler("service-control", m.doServiceControl, nil)
+
+	// alias related
+	runner.AddHandler("alias", m.doAlias, m.undoAlias)
+	runner.AddHandler("unalias", m.doUnalias, m.undoUnalias)
+	runner.AddHandler("clear-aliases", m.doClearAliases, m.undoUnalias)
+
 	// test handlers
 	runner.AddHandler("fake-install-snap", func(t *state.Task, _ *tomb.Tomb) error {
 		return nil
@@ -179,7 +209,11 @@ func (m *SnapManager) doPrepareSnap(t *state.Task, _ *tomb.Tomb) error {
 
 	st.Lock()
 	t.Set("snap-setup", ss)
-	snapst.Candidate = &snap.SideInfo{Revision: ss.Revision}
+	if ss.SideInfo != nil {
+		snapst.Candidate = ss.SideInfo
+	} else {
+		snapst.Candidate = &snap.SideInfo{Revision: ss.Revision}
+	}
 	Set(st, ss.Name, snapst)
 	st.Unlock()
 	return nil
@@ -209,7 +243,21 @@ func (m *SnapManager) doDownloadSnap(t *state.Task, _ *tomb.Tomb) error {
 	}
 
 	checker := func(info *snap.Info) error {
-		return checkRevisionIsNew(ss.Name, snapst, info.Revision)
+		if err := checkRevisionIsNew(ss.Name, snapst, info.Revision); err != nil {
+			return err
+		}
+		st.Lock()
+		defer st.Unlock()
+		// the store told the type of the snap, check it before downloading
+		if m.checkInstall != nil {
+			if err := m.checkInstall(st, info); err != nil {
+				return err
+			}
+		}
+		if snapst.Current() == nil || m.validateRefresh == nil {
+			return nil
+		}
+		return m.validateRefresh(st, info)
 	}
 
 	pb := &TaskProgressAdapter{task: t}
@@ -225,7 +273,17 @@ func (m *SnapManager) doDownloadSnap(t *state.Task, _ *tomb.Tomb) error {
 		auther = user.Authenticator()
 	}
 
-	storeInfo, downloadedSnapFile, err := m.backend.Download(ss.Name, ss.Channel, checker, pb, auther)
+	var storeID string
+	if m.storeID != nil {
+		st.Lock()
+		storeID, err = m.storeID(st)
+		st.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+
+	storeInfo, downloadedSnapFile, err := m.backend.Download(ss.Name, ss.Channel, storeID, checker, pb, auther)
 	if err != nil {
 		return err
 	}
@@ -342,6 +400,31 @@ func (m *SnapManager) doDiscardSnap(t *state.Task, _ *tomb.Tomb) error {
 	return nil
 }
 
+// SetRefreshValidator sets the function called, with the state locked,
+// to check whether refreshing to the given snap revision is allowed.
+func (m *SnapManager) SetRefreshValidator(validate func(st *state.State, info *snap.Info) error) {
+	m.validateRefresh = validate
+}
+
+// SetInstallChecker sets the function called, with the state locked,
+// to check whether the given snap can be installed on the device. It is
+// called before the snap is downloaded or queued for installation.
+func (m *SnapManager) SetInstallChecker(check func(st *state.State, info *snap.Info) error) {
+	m.checkInstall = check
+}
+
+// SetRemoveChecker sets the function called, with the state locked,
+// to check whether the named snap can be removed from the device.
+func (m *SnapManager) SetRemoveChecker(check func(st *state.State, name string) error) {
+	m.checkRemove = check
+}
+
+// SetStoreIDFunc sets the function called, with the state locked, to
+// obtain the id of the store to download snaps from.
+func (m *SnapManager) SetStoreIDFunc(storeID func(st *state.State) (string, error)) {
+	m.storeID = storeID
+}
+
 // Ensure implements StateManager.Ensure.
 func (m *SnapManager) Ensure() error {
 	m.runner.Ensure()
@@ -483,7 +566,7 @@ func (m *SnapManager) doUnlinkCurrentSnap(t *state.Task, _ *tomb.Tomb) error {
 
 	pb := &TaskProgressAdapter{task: t}
 	st.Unlock() // pb itself will ask for locking
-	err = m.backend.UnlinkSnap(oldInfo, pb)
+	err = m.backend.UnlinkSnapForRefresh(oldInfo, pb)
 	st.Lock()
 	if err != nil {
 		return err
@@ -546,6 +629,10 @@ func (m *SnapManager) doLinkSnap(t *state.Task, _ *tomb.Tomb) error {
 	if err != nil {
 		return err
 	}
+	// the wrappers would replace aliases enabled since the change started
+	if err := checkSnapAliasConflict(st, ss.Name); err != nil {
+		return err
+	}
 
 	cand := snapst.Candidate
 
@@ -617,3 +704,44 @@ func (m *SnapManager) undoLinkSnap(t *state.Task, _ *tomb.Tomb) error {
 	Set(st, ss.Name, snapst)
 	return nil
 }
+
+// serviceAction is the service action to run for some apps of a snap.
+type serviceAction struct {
+	Action string   `json:"action"`
+	Apps   []string `json:"apps"`
+}
+
+func (m *SnapManager) doServiceControl(t *state.Task, _ *tomb.Tomb) error {
+	st := t.State()
+
+	st.Lock()
+	defer st.Unlock()
+
+	ss, err := TaskSnapSetup(t)
+	if err != nil {
+		return err
+	}
+	var sa serviceAction
+	if err := t.Get("service-action", &sa); err != nil {
+		return err
+	}
+
+	info, err := Info(st, ss.Name, ss.Revision)
+	if err != nil {
+		return err
+	}
+	apps := make([]*snap.AppInfo, 0, len(sa.Apps))
+	for _, appName := range sa.Apps {
+		app := info.Apps[appName]
+		if app == nil {
+			return fmt.Errorf("snap %q has no app %q", ss.Name, appName)
+		}
+		apps = append(apps, app)
+	}
+
+	pb := &TaskProgressAdapter{task: t}
+	st.Unlock() // pb itself will ask for locking
+	err = m.backend.ServiceControl(apps, sa.Action, pb)
+	st.Lock()
+	return err
+}
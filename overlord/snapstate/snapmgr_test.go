@@ -20,6 +20,7 @@
 package snapstate_test
 
 import (
+	"errors"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -268,6 +269,113 @@ func (s *snapmgrTestSuite) TestRemoveConflict(c *C) {
 	c.Assert(err, ErrorMatches, `snap "some-snap" has changes in progress`)
 }
 
+func (s *snapmgrTestSuite) TestRemoveCheckRemove(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	snapstate.Set(s.state, "some-snap", &snapstate.SnapState{
+		Active:   true,
+		Sequence: []*snap.SideInfo{{OfficialName: "some-snap"}},
+	})
+
+	s.snapmgr.SetRemoveChecker(func(st *state.State, name string) error {
+		c.Check(st, Equals, s.state)
+		c.Check(name, Equals, "some-snap")
+		return errors.New("snap is required")
+	})
+
+	_, err := snapstate.Remove(s.state, "some-snap", 0)
+	c.Assert(err, ErrorMatches, "snap is required")
+	c.Check(s.state.NumTask(), Equals, 0)
+}
+
+func (s *snapmgrTestSuite) TestInstallCheckInstallIntegration(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	var checked *snap.Info
+	s.snapmgr.SetInstallChecker(func(st *state.State, info *snap.Info) error {
+		c.Check(st, Equals, s.state)
+		checked = info
+		return errors.New("snap not allowed")
+	})
+
+	chg := s.state.NewChange("install", "install a snap")
+	ts, err := snapstate.Install(s.state, "some-snap", "some-channel", s.user.ID, 0)
+	c.Assert(err, IsNil)
+	chg.AddAll(ts)
+
+	s.state.Unlock()
+	defer s.snapmgr.Stop()
+	s.settle()
+	s.state.Lock()
+
+	c.Assert(chg.Status(), Equals, state.ErrorStatus)
+	c.Check(chg.Err(), ErrorMatches, `(?s).*snap not allowed.*`)
+	c.Assert(checked, NotNil)
+	c.Check(checked.Name(), Equals, "some-snap")
+	c.Check(checked.Revision, Equals, 11)
+
+	// the snap was checked with the store details, before it got
+	// downloaded and mounted
+	c.Assert(s.fakeBackend.ops, HasLen, 1)
+	c.Check(s.fakeBackend.ops[0].op, Equals, "download")
+
+	var snapst snapstate.SnapState
+	err = snapstate.Get(s.state, "some-snap", &snapst)
+	c.Check(err, Equals, state.ErrNoState)
+}
+
+func (s *snapmgrTestSuite) TestInstallPathCheckInstall(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	restore := snapstate.MockReadInfoFromSnapFile(func(path string) (*snap.Info, error) {
+		c.Check(path, Equals, "/path/to/some-snap.snap")
+		return &snap.Info{SuggestedName: "some-snap", Type: snap.TypeKernel}, nil
+	})
+	defer restore()
+
+	s.snapmgr.SetInstallChecker(func(st *state.State, info *snap.Info) error {
+		c.Check(info.Name(), Equals, "some-snap")
+		c.Check(info.Type, Equals, snap.TypeKernel)
+		return errors.New("snap not allowed")
+	})
+
+	_, err := snapstate.InstallPath(s.state, "some-snap", "/path/to/some-snap.snap", "", 0)
+	c.Assert(err, ErrorMatches, "snap not allowed")
+	c.Check(s.state.NumTask(), Equals, 0)
+}
+
+func (s *snapmgrTestSuite) TestInstallStoreIDIntegration(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.snapmgr.SetStoreIDFunc(func(st *state.State) (string, error) {
+		c.Check(st, Equals, s.state)
+		return "my-brand-store", nil
+	})
+
+	chg := s.state.NewChange("install", "install a snap")
+	ts, err := snapstate.Install(s.state, "some-snap", "some-channel", s.user.ID, 0)
+	c.Assert(err, IsNil)
+	chg.AddAll(ts)
+
+	s.state.Unlock()
+	defer s.snapmgr.Stop()
+	s.settle()
+	s.state.Lock()
+
+	c.Assert(chg.Status(), Equals, state.DoneStatus)
+	c.Check(s.fakeBackend.ops[0], DeepEquals, fakeOp{
+		op:       "download",
+		macaroon: s.user.Macaroon,
+		name:     "some-snap",
+		channel:  "some-channel",
+		storeID:  "my-brand-store",
+	})
+}
+
 func (s *snapmgrTestSuite) TestInstallIntegration(c *C) {
 	s.state.Lock()
 	defer s.state.Unlock()
@@ -404,7 +512,7 @@ func (s *snapmgrTestSuite) TestUpdateIntegration(c *C) {
 			revno: 11,
 		},
 		fakeOp{
-			op:   "unlink-snap",
+			op:   "unlink-snap-for-refresh",
 			name: "/snap/some-snap/7",
 		},
 		fakeOp{
@@ -524,7 +632,7 @@ func (s *snapmgrTestSuite) TestUpdateUndoIntegration(c *C) {
 			revno: 11,
 		},
 		{
-			op:   "unlink-snap",
+			op:   "unlink-snap-for-refresh",
 			name: "/snap/some-snap/7",
 		},
 		{
@@ -641,7 +749,7 @@ func (s *snapmgrTestSuite) TestUpdateTotalUndoIntegration(c *C) {
 			revno: 11,
 		},
 		{
-			op:   "unlink-snap",
+			op:   "unlink-snap-for-refresh",
 			name: "/snap/some-snap/7",
 		},
 		{
@@ -765,6 +873,50 @@ func (s *snapmgrTestSuite) TestUpdateSameRevisionIntegration(c *C) {
 	})
 }
 
+func (s *snapmgrTestSuite) TestUpdateValidateRefreshIntegration(c *C) {
+	si := snap.SideInfo{
+		OfficialName: "some-snap",
+		Revision:     7,
+	}
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	snapstate.Set(s.state, "some-snap", &snapstate.SnapState{
+		Active:   true,
+		Sequence: []*snap.SideInfo{&si},
+	})
+
+	var validated *snap.Info
+	s.snapmgr.SetRefreshValidator(func(st *state.State, info *snap.Info) error {
+		c.Check(st, Equals, s.state)
+		validated = info
+		return errors.New("refresh not validated")
+	})
+
+	chg := s.state.NewChange("install", "install a snap")
+	ts, err := snapstate.Update(s.state, "some-snap", "some-channel", s.user.ID, snappy.DoInstallGC)
+	c.Assert(err, IsNil)
+	chg.AddAll(ts)
+
+	s.state.Unlock()
+	defer s.snapmgr.Stop()
+	s.settle()
+	s.state.Lock()
+
+	c.Assert(chg.Status(), Equals, state.ErrorStatus)
+	c.Check(chg.Err(), ErrorMatches, `(?s).*refresh not validated.*`)
+	c.Assert(validated, NotNil)
+	c.Check(validated.Revision, Equals, 11)
+
+	var snapst snapstate.SnapState
+	err = snapstate.Get(s.state, "some-snap", &snapst)
+	c.Assert(err, IsNil)
+	c.Assert(snapst.Candidate, IsNil)
+	c.Assert(snapst.Sequence, HasLen, 1)
+	c.Assert(snapst.Current().Revision, Equals, 7)
+}
+
 func makeTestSnap(c *C, snapYamlContent string) (snapFilePath string) {
 	tmpdir := c.MkDir()
 	os.MkdirAll(filepath.Join(tmpdir, "meta"), 0755)
@@ -833,6 +985,42 @@ version: 1.0`)
 	c.Assert(snapst.LocalRevision, Equals, 100001)
 }
 
+func (s *snapmgrTestSuite) TestInstallPathWithSideInfoIntegration(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	si := &snap.SideInfo{
+		OfficialName: "mock",
+		SnapID:       "mock-id",
+		Revision:     42,
+		Channel:      "beta",
+	}
+	chg := s.state.NewChange("install", "install an asserted local snap")
+	ts, err := snapstate.InstallPathWithSideInfo(s.state, si, "/path/to/mock.snap", "beta", 0)
+	c.Assert(err, IsNil)
+	chg.AddAll(ts)
+
+	s.state.Unlock()
+	defer s.snapmgr.Stop()
+	s.settle()
+	s.state.Lock()
+
+	c.Assert(chg.Status(), Equals, state.DoneStatus)
+	c.Assert(s.fakeBackend.ops, HasLen, 6)
+	c.Check(s.fakeBackend.ops[4].op, Equals, "candidate")
+	c.Check(s.fakeBackend.ops[4].sinfo, DeepEquals, *si)
+	c.Check(s.fakeBackend.ops[5].op, Equals, "link-snap")
+	c.Check(s.fakeBackend.ops[5].name, Equals, "/snap/mock/42")
+
+	var snapst snapstate.SnapState
+	err = snapstate.Get(s.state, "mock", &snapst)
+	c.Assert(err, IsNil)
+	c.Assert(snapst.Sequence, HasLen, 1)
+	c.Check(snapst.Sequence[0], DeepEquals, si)
+	c.Check(snapst.Channel, Equals, "beta")
+	c.Check(snapst.LocalRevision, Equals, 0)
+}
+
 func (s *snapmgrTestSuite) TestInstallSubequentLocalIntegration(c *C) {
 	s.state.Lock()
 	defer s.state.Unlock()
@@ -860,7 +1048,7 @@ version: 1.0`)
 	c.Check(s.fakeBackend.ops[0].op, Equals, "check-snap")
 	c.Check(s.fakeBackend.ops[0].name, Matches, `.*/mock_1.0_all.snap`)
 
-	c.Check(s.fakeBackend.ops[2].op, Equals, "unlink-snap")
+	c.Check(s.fakeBackend.ops[2].op, Equals, "unlink-snap-for-refresh")
 	c.Check(s.fakeBackend.ops[2].name, Equals, "/snap/mock/100002")
 
 	c.Check(s.fakeBackend.ops[3].op, Equals, "copy-data")
@@ -1247,6 +1435,84 @@ func (s *snapmgrQuerySuite) TestAllEmptyAndEmptyNormalisation(c *C) {
 	c.Check(snapStates, HasLen, 0)
 }
 
+func (s *snapmgrTestSuite) TestServiceControlTasks(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	snapstate.Set(s.state, "services-snap", &snapstate.SnapState{
+		Active:   true,
+		Sequence: []*snap.SideInfo{{OfficialName: "services-snap", Revision: 7}},
+	})
+
+	ts, err := snapstate.ServiceControl(s.state, "services-snap", []string{"svc1", "svc2"}, "restart")
+	c.Assert(err, IsNil)
+	c.Assert(ts.Tasks(), HasLen, 1)
+
+	t := ts.Tasks()[0]
+	c.Check(t.Kind(), Equals, "service-control")
+	ss, err := snapstate.TaskSnapSetup(t)
+	c.Assert(err, IsNil)
+	c.Check(ss, DeepEquals, &snapstate.SnapSetup{Name: "services-snap", Revision: 7})
+}
+
+func (s *snapmgrTestSuite) TestServiceControlErrors(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	snapstate.Set(s.state, "services-snap", &snapstate.SnapState{
+		Active:   true,
+		Sequence: []*snap.SideInfo{{OfficialName: "services-snap", Revision: 7}},
+	})
+	snapstate.Set(s.state, "inactive-snap", &snapstate.SnapState{
+		Sequence: []*snap.SideInfo{{OfficialName: "inactive-snap", Revision: 1}},
+	})
+
+	tests := []struct {
+		snap   string
+		apps   []string
+		action string
+		err    string
+	}{
+		{"services-snap", []string{"svc1"}, "reload", `unknown service action "reload"`},
+		{"other-snap", []string{"svc1"}, "start", `cannot find snap "other-snap"`},
+		{"inactive-snap", []string{"svc1"}, "start", `snap "inactive-snap" is not active`},
+		{"services-snap", []string{"svc3"}, "start", `snap "services-snap" has no app "svc3"`},
+		{"services-snap", []string{"svc1", "app"}, "stop", `app "app" of snap "services-snap" is not a service`},
+	}
+	for _, test := range tests {
+		_, err := snapstate.ServiceControl(s.state, test.snap, test.apps, test.action)
+		c.Check(err, ErrorMatches, test.err)
+	}
+	c.Check(s.state.NumTask(), Equals, 0)
+}
+
+func (s *snapmgrTestSuite) TestServiceControlIntegration(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	snapstate.Set(s.state, "services-snap", &snapstate.SnapState{
+		Active:   true,
+		Sequence: []*snap.SideInfo{{OfficialName: "services-snap", Revision: 7}},
+	})
+
+	chg := s.state.NewChange("service-control", "restart some services")
+	ts, err := snapstate.ServiceControl(s.state, "services-snap", []string{"svc2", "svc1"}, "enable")
+	c.Assert(err, IsNil)
+	chg.AddAll(ts)
+
+	s.state.Unlock()
+	defer s.snapmgr.Stop()
+	s.settle()
+	s.state.Lock()
+
+	c.Check(chg.Status(), Equals, state.DoneStatus)
+	c.Check(s.fakeBackend.ops, DeepEquals, []fakeOp{{
+		op:   "service-control:enable",
+		name: "svc2,svc1",
+	}})
+	c.Check(ts.Tasks()[0].Log(), HasLen, 1)
+}
+
 type snapStateSuite struct{}
 
 var _ = Suite(&snapStateSuite{})
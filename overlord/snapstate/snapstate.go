@@ -34,10 +34,13 @@ import (
 // allow exchange in the tests
 var backend managerBackend = &defaultBackend{}
 
-func doInstall(s *state.State, curActive bool, snapName, snapPath, channel string, userID int, flags snappy.InstallFlags) (*state.TaskSet, error) {
+func doInstall(s *state.State, curActive bool, snapName string, si *snap.SideInfo, snapPath, channel string, userID int, flags snappy.InstallFlags) (*state.TaskSet, error) {
 	if err := checkChangeConflict(s, snapName); err != nil {
 		return nil, err
 	}
+	if err := checkSnapAliasConflict(s, snapName); err != nil {
+		return nil, err
+	}
 
 	if snapPath == "" && channel == "" {
 		channel = "stable"
@@ -51,6 +54,10 @@ func doInstall(s *state.State, curActive bool, snapName, snapPath, channel strin
 	}
 	ss.Name = snapName
 	ss.SnapPath = snapPath
+	if si != nil {
+		ss.Revision = si.Revision
+		ss.SideInfo = si
+	}
 	if snapPath != "" {
 		prepare = s.NewTask("prepare-snap", fmt.Sprintf(i18n.G("Prepare snap %q"), snapPath))
 	} else {
@@ -100,7 +107,9 @@ func checkChangeConflict(s *state.State, snapName string) error {
 	for _, task := range s.Tasks() {
 		k := task.Kind()
 		chg := task.Change()
-		if (k == "link-snap" || k == "unlink-snap") && (chg == nil || !chg.Status().Ready()) {
+		linking := k == "link-snap" || k == "unlink-snap"
+		aliasing := k == "alias" || k == "unalias"
+		if (linking || aliasing) && (chg == nil || !chg.Status().Ready()) {
 			ss, err := TaskSnapSetup(task)
 			if err != nil {
 				return fmt.Errorf("internal error: cannot obtain snap setup from task: %s", task.Summary())
@@ -125,19 +134,44 @@ func Install(s *state.State, name, channel string, userID int, flags snappy.Inst
 		return nil, fmt.Errorf("snap %q already installed", name)
 	}
 
-	return doInstall(s, false, name, "", channel, userID, flags)
+	return doInstall(s, false, name, nil, "", channel, userID, flags)
 }
 
 // InstallPath returns a set of tasks for installing snap from a file path.
 // Note that the state must be locked by the caller.
 func InstallPath(s *state.State, name, path, channel string, flags snappy.InstallFlags) (*state.TaskSet, error) {
+	return installPath(s, name, nil, path, channel, flags)
+}
+
+// InstallPathWithSideInfo returns a set of tasks for installing the
+// snap revision described by si, as known from its assertions, from
+// a file path.
+// Note that the state must be locked by the caller.
+func InstallPathWithSideInfo(s *state.State, si *snap.SideInfo, path, channel string, flags snappy.InstallFlags) (*state.TaskSet, error) {
+	if si.OfficialName == "" || si.Revision <= 0 {
+		return nil, fmt.Errorf("internal error: snap side info without name or revision")
+	}
+	return installPath(s, si.OfficialName, si, path, channel, flags)
+}
+
+func installPath(s *state.State, name string, si *snap.SideInfo, path, channel string, flags snappy.InstallFlags) (*state.TaskSet, error) {
 	var snapst SnapState
 	err := Get(s, name, &snapst)
 	if err != nil && err != state.ErrNoState {
 		return nil, err
 	}
 
-	return doInstall(s, snapst.Active, name, path, channel, 0, flags)
+	if m := cachedSnapMgr(s); m != nil && m.checkInstall != nil {
+		// a file that cannot be read is refused when it gets checked
+		// for mounting
+		if info, err := readInfoFromSnapFile(path); err == nil {
+			if err := m.checkInstall(s, info); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return doInstall(s, snapst.Active, name, si, path, channel, 0, flags)
 }
 
 // Update initiates a change updating a snap.
@@ -157,7 +191,7 @@ func Update(s *state.State, name, channel string, userID int, flags snappy.Insta
 	}
 
 	// TODO: pass the right UserID
-	return doInstall(s, snapst.Active, name, "", channel, userID, flags)
+	return doInstall(s, snapst.Active, name, nil, "", channel, userID, flags)
 }
 
 func removeInactiveRevision(s *state.State, name string, revision int, flags snappy.RemoveFlags) *state.TaskSet {
@@ -208,6 +242,12 @@ func Remove(s *state.State, name string, flags snappy.RemoveFlags) (*state.TaskS
 		return nil, fmt.Errorf("snap %q is not removable", name)
 	}
 
+	if m := cachedSnapMgr(s); m != nil && m.checkRemove != nil {
+		if err := m.checkRemove(s, name); err != nil {
+			return nil, err
+		}
+	}
+
 	// main/current SnapSetup
 	ss := SnapSetup{
 		Name:     name,
@@ -228,6 +268,16 @@ func Remove(s *state.State, name string, flags snappy.RemoveFlags) (*state.TaskS
 		chain = ts
 	}
 
+	aliases, err := getAliases(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(aliases[name]) > 0 {
+		clearAliases := s.NewTask("clear-aliases", fmt.Sprintf(i18n.G("Remove aliases for snap %q"), name))
+		clearAliases.Set("snap-setup", ss)
+		addNext(state.NewTaskSet(clearAliases))
+	}
+
 	if active { // unlink
 		unlink := s.NewTask("unlink-snap", fmt.Sprintf(i18n.G("Make snap %q unavailable to the system"), name))
 		unlink.Set("snap-setup", ss)
@@ -271,10 +321,70 @@ func Deactivate(s *state.State, name string) (*state.TaskSet, error) {
 	return nil, fmt.Errorf("deactivate not implemented")
 }
 
+// ServiceControl returns a set of tasks for running the given action,
+// one of start, stop, restart, enable or disable, on the services of
+// the named apps of a snap. Enabling and disabling the services also
+// starts and stops them.
+// Note that the state must be locked by the caller.
+func ServiceControl(s *state.State, name string, appNames []string, action string) (*state.TaskSet, error) {
+	switch action {
+	case "start", "stop", "restart", "enable", "disable":
+	default:
+		return nil, fmt.Errorf("unknown service action %q", action)
+	}
+
+	var snapst SnapState
+	err := Get(s, name, &snapst)
+	if err != nil && err != state.ErrNoState {
+		return nil, err
+	}
+	cur := snapst.Current()
+	if cur == nil {
+		return nil, fmt.Errorf("cannot find snap %q", name)
+	}
+	if !snapst.Active {
+		return nil, fmt.Errorf("snap %q is not active", name)
+	}
+	if err := checkChangeConflict(s, name); err != nil {
+		return nil, err
+	}
+
+	info, err := readInfo(name, cur)
+	if err != nil {
+		return nil, err
+	}
+	for _, appName := range appNames {
+		app := info.Apps[appName]
+		if app == nil {
+			return nil, fmt.Errorf("snap %q has no app %q", name, appName)
+		}
+		if app.Daemon == "" {
+			return nil, fmt.Errorf("app %q of snap %q is not a service", appName, name)
+		}
+	}
+
+	ss := SnapSetup{
+		Name:     name,
+		Revision: cur.Revision,
+	}
+	t := s.NewTask("service-control", fmt.Sprintf(i18n.G("Run service action %q for snap %q"), action, name))
+	t.Set("snap-setup", ss)
+	t.Set("service-action", serviceAction{Action: action, Apps: appNames})
+	return state.NewTaskSet(t), nil
+}
+
 // Retrieval functions
 
 var readInfo = snap.ReadInfo
 
+var readInfoFromSnapFile = func(path string) (*snap.Info, error) {
+	snapf, err := snap.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return snap.ReadInfoFromSnapFile(snapf, nil)
+}
+
 // Info returns the information about the snap with given name and revision.
 // Works also for a mounted candidate snap in the process of being installed.
 func Info(s *state.State, name string, revision int) (*snap.Info, error) {
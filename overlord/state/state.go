@@ -366,7 +366,7 @@ func (s *State) Prune(pruneWait, abortWait time.Duration) {
 
 // ReadState returns the state deserialized from r.
 func ReadState(backend Backend, r io.Reader) (*State, error) {
-	s := new(State)
+	s := New(backend)
 	s.Lock()
 	defer s.unlock()
 	d := json.NewDecoder(r)
@@ -374,7 +374,6 @@ func ReadState(backend Backend, r io.Reader) (*State, error) {
 	if err != nil {
 		return nil, err
 	}
-	s.backend = backend
 	s.modified = false
 	return s, err
 }
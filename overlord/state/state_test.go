@@ -192,6 +192,24 @@ func (ss *stateSuite) TestImplicitCheckpointAndRead(c *C) {
 	c.Check(&mSt2B, DeepEquals, mSt2)
 }
 
+func (ss *stateSuite) TestCacheAfterRead(c *C) {
+	b := new(fakeStateBackend)
+	st := state.New(b)
+	st.Lock()
+	st.Set("v", 1)
+	st.Unlock()
+
+	st2, err := state.ReadState(nil, bytes.NewBuffer(b.checkpoints[0]))
+	c.Assert(err, IsNil)
+
+	st2.Lock()
+	defer st2.Unlock()
+
+	type key1 struct{}
+	st2.Cache(key1{}, "value1")
+	c.Check(st2.Cached(key1{}), Equals, "value1")
+}
+
 func (ss *stateSuite) TestImplicitCheckpointRetry(c *C) {
 	restore := state.MockCheckpointRetryDelay(2*time.Millisecond, 1*time.Second)
 	defer restore()
@@ -25,6 +25,7 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/ubuntu-core/snappy/dirs"
 	"github.com/ubuntu-core/snappy/systemd"
@@ -118,6 +119,20 @@ type Info struct {
 
 	IconURL string
 	Prices  map[string]float64 `yaml:"prices,omitempty" json:"prices,omitempty"`
+	License string
+
+	// Channels maps the channels the snap is published in to the
+	// revision found there.
+	Channels map[string]*ChannelSnapInfo
+}
+
+// ChannelSnapInfo is the minimum information about the revision of a
+// snap published in a channel.
+type ChannelSnapInfo struct {
+	Channel  string `json:"channel"`
+	Revision int    `json:"revision"`
+	Version  string `json:"version"`
+	Size     int64  `json:"size,omitempty"`
 }
 
 // Name returns the blessed name for the snap.
@@ -215,11 +230,35 @@ type AppInfo struct {
 	StopCommand     string
 	PostStopCommand string
 	RestartCond     systemd.RestartCondition
+	RestartDelay    timeout.Timeout
+	WatchdogTimeout timeout.Timeout
+	RefreshMode     string
+
+	// Before and After are the names of the other apps of the snap
+	// whose services this app's service starts before or after.
+	Before []string
+	After  []string
 
 	Socket       bool
 	SocketMode   string
 	ListenStream string
 
+	// Timer is the schedule on which the app is run as a oneshot
+	// service, see timeutil.ParseSchedule.
+	Timer string
+
+	// Environment holds the extra environment variables of the app.
+	Environment map[string]string
+
+	// Aliases are the commands the app can be run as, once enabled,
+	// besides its wrapper.
+	Aliases []string
+
+	// Completer is the path, relative to the snap, of the program
+	// completing the arguments of the app's command; it gets run
+	// confined like the app itself.
+	Completer string
+
 	// TODO: this should go away once we have more plumbing and can change
 	// things vs refactor
 	// https://github.com/ubuntu-core/snappy/pull/794#discussion_r58688496
@@ -249,17 +288,74 @@ func (app *AppInfo) WrapperPath() string {
 	return filepath.Join(dirs.SnapBinariesDir, binName)
 }
 
+// CompleterPath returns the path to the link to the completer of the
+// app, which the snapd bash completion loader finds the apps to
+// complete by.
+func (app *AppInfo) CompleterPath() string {
+	return filepath.Join(dirs.CompletersDir, filepath.Base(app.WrapperPath()))
+}
+
+// SplitSnapApp splits the name of the wrapper of an app, "<snap>.<app>"
+// or just "<snap>" for the app named like its snap, into the names of
+// the snap and the app.
+func SplitSnapApp(snapApp string) (snapName, appName string) {
+	if i := strings.IndexRune(snapApp, '.'); i >= 0 {
+		return snapApp[:i], snapApp[i+1:]
+	}
+	return snapApp, snapApp
+}
+
+// JoinSnapApp is the inverse of SplitSnapApp, giving the name of the
+// wrapper of the named app of the named snap.
+func JoinSnapApp(snapName, appName string) string {
+	if snapName == appName {
+		return snapName
+	}
+	return snapName + "." + appName
+}
+
 func (app *AppInfo) launcherCommand(command string) string {
 	securityTag := app.SecurityTag()
 	return fmt.Sprintf("/usr/bin/ubuntu-core-launcher %s %s %s", securityTag, securityTag, filepath.Join(app.Snap.MountDir(), command))
 
 }
 
+// LauncherArgv returns the launcher argv to use when invoking argv
+// confined as the app; the launcher takes the security tag of the app
+// twice for historical reasons.
+func (app *AppInfo) LauncherArgv(argv ...string) []string {
+	securityTag := app.SecurityTag()
+	return append([]string{"/usr/bin/ubuntu-core-launcher", securityTag, securityTag}, argv...)
+}
+
+func (app *AppInfo) launcherCommandArgv(command string) []string {
+	argv := strings.Fields(command)
+	if len(argv) > 0 {
+		argv[0] = filepath.Join(app.Snap.MountDir(), argv[0])
+	}
+	return app.LauncherArgv(argv...)
+}
+
+// LauncherCommandArgv returns the launcher argv to use when invoking the app binary.
+func (app *AppInfo) LauncherCommandArgv() []string {
+	return app.launcherCommandArgv(app.Command)
+}
+
+// LauncherCompleterArgv returns the launcher argv to use when invoking the app completer binary.
+func (app *AppInfo) LauncherCompleterArgv() []string {
+	return app.launcherCommandArgv(app.Completer)
+}
+
 // LauncherCommand returns the launcher command line to use when invoking the app binary.
 func (app *AppInfo) LauncherCommand() string {
 	return app.launcherCommand(app.Command)
 }
 
+// LauncherCompleterCommand returns the launcher command line to use when invoking the app completer binary.
+func (app *AppInfo) LauncherCompleterCommand() string {
+	return app.launcherCommand(app.Completer)
+}
+
 // LauncherStopCommand returns the launcher command line to use when invoking the app stop command binary.
 func (app *AppInfo) LauncherStopCommand() string {
 	return app.launcherCommand(app.StopCommand)
@@ -280,6 +376,11 @@ func (app *AppInfo) ServiceSocketFile() string {
 	return filepath.Join(dirs.SnapServicesDir, app.SecurityTag()+".socket")
 }
 
+// TimerFile returns the systemd timer file path for the timer app.
+func (app *AppInfo) TimerFile() string {
+	return filepath.Join(dirs.SnapServicesDir, app.SecurityTag()+".timer")
+}
+
 func infoFromSnapYamlWithSideInfo(meta []byte, si *SideInfo) (*Info, error) {
 	info, err := InfoFromSnapYaml(meta)
 	if err != nil {
@@ -75,11 +75,26 @@ type appYaml struct {
 	SlotNames   []string                 `yaml:"slots,omitempty"`
 	PlugNames   []string                 `yaml:"plugs,omitempty"`
 
+	RestartDelay    timeout.Timeout `yaml:"restart-delay,omitempty"`
+	WatchdogTimeout timeout.Timeout `yaml:"watchdog-timeout,omitempty"`
+	RefreshMode     string          `yaml:"refresh-mode,omitempty"`
+
+	Before []string `yaml:"before,omitempty"`
+	After  []string `yaml:"after,omitempty"`
+
 	BusName string `yaml:"bus-name,omitempty"`
 
 	Socket       bool   `yaml:"socket,omitempty"`
 	ListenStream string `yaml:"listen-stream,omitempty"`
 	SocketMode   string `yaml:"socket-mode,omitempty"`
+
+	Timer string `yaml:"timer,omitempty"`
+
+	Environment map[string]string `yaml:"environment,omitempty"`
+
+	Aliases []string `yaml:"aliases,omitempty"`
+
+	Completer string `yaml:"completer,omitempty"`
 }
 
 // InfoFromSnapYaml creates a new info based on the given snap.yaml data
@@ -152,6 +167,10 @@ func InfoFromSnapYaml(yamlData []byte) (*Info, error) {
 		}
 	}
 	for appName, yApp := range y.Apps {
+		// timer apps are run as oneshot services
+		if yApp.Timer != "" && yApp.Daemon == "" {
+			yApp.Daemon = "oneshot"
+		}
 		// Collect all apps
 		app := &AppInfo{
 			Snap:            snap,
@@ -162,9 +181,18 @@ func InfoFromSnapYaml(yamlData []byte) (*Info, error) {
 			StopCommand:     yApp.StopCommand,
 			PostStopCommand: yApp.PostStopCommand,
 			RestartCond:     yApp.RestartCond,
+			RestartDelay:    yApp.RestartDelay,
+			WatchdogTimeout: yApp.WatchdogTimeout,
+			RefreshMode:     yApp.RefreshMode,
+			Before:          yApp.Before,
+			After:           yApp.After,
 			Socket:          yApp.Socket,
 			SocketMode:      yApp.SocketMode,
 			ListenStream:    yApp.ListenStream,
+			Timer:           yApp.Timer,
+			Environment:     yApp.Environment,
+			Aliases:         yApp.Aliases,
+			Completer:       yApp.Completer,
 			BusName:         yApp.BusName,
 		}
 		if len(y.Plugs) > 0 || len(yApp.PlugNames) > 0 {
@@ -20,11 +20,13 @@
 package snap_test
 
 import (
+	"path/filepath"
 	"testing"
 	"time"
 
 	. "gopkg.in/check.v1"
 
+	"github.com/ubuntu-core/snappy/dirs"
 	"github.com/ubuntu-core/snappy/snap"
 	"github.com/ubuntu-core/snappy/systemd"
 	"github.com/ubuntu-core/snappy/timeout"
@@ -921,3 +923,105 @@ apps:
 		},
 	})
 }
+
+func (s *YamlSuite) TestDaemonOptionsExample(c *C) {
+	y := []byte(`name: wat
+version: 42
+apps:
+ db:
+   command: db
+   daemon: simple
+   before: [api]
+   restart-delay: 5s
+   watchdog-timeout: 30s
+   refresh-mode: endure
+   environment:
+     PORT: 5432
+     DB_OPTS: --fast
+ api:
+   command: api
+   daemon: simple
+   after: [db]
+`)
+	info, err := snap.InfoFromSnapYaml(y)
+	c.Assert(err, IsNil)
+	c.Check(info.Apps, DeepEquals, map[string]*snap.AppInfo{
+		"db": {
+			Snap:            info,
+			Name:            "db",
+			Command:         "db",
+			Daemon:          "simple",
+			Before:          []string{"api"},
+			RestartDelay:    timeout.Timeout(5 * time.Second),
+			WatchdogTimeout: timeout.Timeout(30 * time.Second),
+			RefreshMode:     "endure",
+			Environment:     map[string]string{"PORT": "5432", "DB_OPTS": "--fast"},
+		},
+		"api": {
+			Snap:    info,
+			Name:    "api",
+			Command: "api",
+			Daemon:  "simple",
+			After:   []string{"db"},
+		},
+	})
+}
+
+func (s *YamlSuite) TestAppAliases(c *C) {
+	y := []byte(`name: tool
+version: 42
+apps:
+ convert:
+   command: convert
+   aliases: [convert, tool-convert]
+`)
+	info, err := snap.InfoFromSnapYaml(y)
+	c.Assert(err, IsNil)
+	c.Check(info.Apps["convert"].Aliases, DeepEquals, []string{"convert", "tool-convert"})
+}
+
+func (s *YamlSuite) TestAppCompleter(c *C) {
+	y := []byte(`name: tool
+version: 42
+apps:
+ convert:
+   command: convert
+   completer: bin/convert-complete
+`)
+	info, err := snap.InfoFromSnapYaml(y)
+	c.Assert(err, IsNil)
+	c.Check(info.Apps["convert"].Completer, Equals, "bin/convert-complete")
+	c.Check(info.Apps["convert"].CompleterPath(), Equals, filepath.Join(dirs.CompletersDir, "tool.convert"))
+}
+
+func (s *YamlSuite) TestTimerAppExample(c *C) {
+	y := []byte(`name: wat
+version: 42
+apps:
+ job:
+   command: job1
+   timer: mon-fri,10:00~12:00
+ svc:
+   command: svc1
+   daemon: simple
+   timer: "9:00"
+`)
+	info, err := snap.InfoFromSnapYaml(y)
+	c.Assert(err, IsNil)
+	c.Check(info.Apps, DeepEquals, map[string]*snap.AppInfo{
+		"job": {
+			Snap:    info,
+			Name:    "job",
+			Command: "job1",
+			Daemon:  "oneshot",
+			Timer:   "mon-fri,10:00~12:00",
+		},
+		"svc": {
+			Snap:    info,
+			Name:    "svc",
+			Command: "svc1",
+			Daemon:  "simple",
+			Timer:   "9:00",
+		},
+	})
+}
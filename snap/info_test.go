@@ -83,6 +83,25 @@ apps:
 	c.Check(info.Apps["foo"].WrapperPath(), Equals, filepath.Join(dirs.SnapBinariesDir, "foo"))
 }
 
+func (s *infoSuite) TestSplitSnapApp(c *C) {
+	for _, t := range []struct {
+		in, snap, app string
+	}{
+		{"foo.bar", "foo", "bar"},
+		{"foo", "foo", "foo"},
+		{"foo.bar.baz", "foo", "bar.baz"},
+	} {
+		snapName, appName := snap.SplitSnapApp(t.in)
+		c.Check(snapName, Equals, t.snap, Commentf(t.in))
+		c.Check(appName, Equals, t.app, Commentf(t.in))
+	}
+}
+
+func (s *infoSuite) TestJoinSnapApp(c *C) {
+	c.Check(snap.JoinSnapApp("foo", "bar"), Equals, "foo.bar")
+	c.Check(snap.JoinSnapApp("foo", "foo"), Equals, "foo")
+}
+
 func (s *infoSuite) TestAppInfoLauncherCommand(c *C) {
 	dirs.SetRootDir("")
 
@@ -92,12 +111,34 @@ apps:
      command: foo-bin
    bar:
      command: bar-bin -x
+     completer: bar-complete
 `))
 	c.Assert(err, IsNil)
 	info.Revision = 42
 
 	c.Check(info.Apps["bar"].LauncherCommand(), Equals, "/usr/bin/ubuntu-core-launcher snap.foo.bar snap.foo.bar /snap/foo/42/bar-bin -x")
 	c.Check(info.Apps["foo"].LauncherCommand(), Equals, "/usr/bin/ubuntu-core-launcher snap.foo.foo snap.foo.foo /snap/foo/42/foo-bin")
+	c.Check(info.Apps["bar"].LauncherCompleterCommand(), Equals, "/usr/bin/ubuntu-core-launcher snap.foo.bar snap.foo.bar /snap/foo/42/bar-complete")
+}
+
+func (s *infoSuite) TestAppInfoLauncherArgv(c *C) {
+	dirs.SetRootDir("")
+
+	info, err := snap.InfoFromSnapYaml([]byte(`name: foo
+apps:
+   foo:
+     command: foo-bin
+   bar:
+     command: bar-bin -x
+     completer: bar-complete
+`))
+	c.Assert(err, IsNil)
+	info.Revision = 42
+
+	c.Check(info.Apps["bar"].LauncherCommandArgv(), DeepEquals, []string{"/usr/bin/ubuntu-core-launcher", "snap.foo.bar", "snap.foo.bar", "/snap/foo/42/bar-bin", "-x"})
+	c.Check(info.Apps["foo"].LauncherCommandArgv(), DeepEquals, []string{"/usr/bin/ubuntu-core-launcher", "snap.foo.foo", "snap.foo.foo", "/snap/foo/42/foo-bin"})
+	c.Check(info.Apps["bar"].LauncherCompleterArgv(), DeepEquals, []string{"/usr/bin/ubuntu-core-launcher", "snap.foo.bar", "snap.foo.bar", "/snap/foo/42/bar-complete"})
+	c.Check(info.Apps["foo"].LauncherArgv("/bin/bash"), DeepEquals, []string{"/usr/bin/ubuntu-core-launcher", "snap.foo.foo", "snap.foo.foo", "/bin/bash"})
 }
 
 const sampleYaml = `
@@ -22,6 +22,9 @@ package snap
 import (
 	"fmt"
 	"regexp"
+	"sort"
+
+	"github.com/ubuntu-core/snappy/timeutil"
 )
 
 // Regular expression describing correct identifiers.
@@ -54,7 +57,10 @@ func Validate(info *Info) error {
 			return err
 		}
 	}
-	return nil
+	if err := validateAppOrder(info.Apps); err != nil {
+		return err
+	}
+	return validateAppAliases(info.Apps)
 }
 
 func validateField(name, cont string, whitelist *regexp.Regexp) error {
@@ -78,6 +84,18 @@ func ValidateApp(app *AppInfo) error {
 		return fmt.Errorf(`"daemon" field contains invalid value %q`, app.Daemon)
 	}
 
+	if err := validateAppDaemonOptions(app); err != nil {
+		return err
+	}
+	if err := validateAppEnvironment(app); err != nil {
+		return err
+	}
+	for _, alias := range app.Aliases {
+		if err := ValidateAlias(alias); err != nil {
+			return err
+		}
+	}
+
 	checks := map[string]string{
 		"name":              app.Name,
 		"command":           app.Command,
@@ -86,6 +104,7 @@ func ValidateApp(app *AppInfo) error {
 		"socket-mode":       app.SocketMode,
 		"listen-stream":     app.ListenStream,
 		"bus-name":          app.BusName,
+		"completer":         app.Completer,
 	}
 
 	for name, value := range checks {
@@ -93,5 +112,183 @@ func ValidateApp(app *AppInfo) error {
 			return err
 		}
 	}
+
+	return validateAppTimer(app)
+}
+
+func validateAppTimer(app *AppInfo) error {
+	if app.Timer == "" {
+		return nil
+	}
+	if app.Daemon != "oneshot" {
+		return fmt.Errorf(`"timer" field cannot be used with daemon %q`, app.Daemon)
+	}
+	if app.Socket {
+		return fmt.Errorf(`"timer" field cannot be used with "socket"`)
+	}
+	if _, err := timeutil.ParseSchedule(app.Timer); err != nil {
+		return fmt.Errorf(`"timer" field contains invalid value: %v`, err)
+	}
+	return nil
+}
+
+func validateAppDaemonOptions(app *AppInfo) error {
+	if app.Daemon == "" {
+		daemonOnly := []struct {
+			field string
+			isSet bool
+		}{
+			{"restart-delay", app.RestartDelay != 0},
+			{"watchdog-timeout", app.WatchdogTimeout != 0},
+			{"refresh-mode", app.RefreshMode != ""},
+			{"before", len(app.Before) > 0},
+			{"after", len(app.After) > 0},
+		}
+		for _, opt := range daemonOnly {
+			if opt.isSet {
+				return fmt.Errorf(`%q field can only be used with daemons`, opt.field)
+			}
+		}
+	}
+
+	// systemd refuses to restart oneshot services, which is what
+	// timer apps are
+	if app.RestartCond != "" {
+		switch {
+		case app.Timer != "":
+			return fmt.Errorf(`"restart-condition" field cannot be used with "timer"`)
+		case app.Daemon == "oneshot":
+			return fmt.Errorf(`"restart-condition" field cannot be used with daemon "oneshot"`)
+		}
+	}
+
+	switch app.RefreshMode {
+	case "", "endure", "restart":
+		// valid
+	default:
+		return fmt.Errorf(`"refresh-mode" field contains invalid value %q`, app.RefreshMode)
+	}
+	return nil
+}
+
+var validEnvName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// envValueBlacklist matches what can't go in the values of the
+// environment, as they end up double quoted in shell wrappers and
+// systemd units.
+var envValueBlacklist = regexp.MustCompile("[\"\\\\$`\\x00-\\x1f]")
+
+func validateAppEnvironment(app *AppInfo) error {
+	for name, value := range app.Environment {
+		if !validEnvName.MatchString(name) {
+			return fmt.Errorf(`"environment" field contains invalid variable name %q`, name)
+		}
+		if envValueBlacklist.MatchString(value) {
+			return fmt.Errorf(`"environment" field contains invalid value for %q: %q`, name, value)
+		}
+	}
+	return nil
+}
+
+// validateAppOrder checks that the services of the snap are only
+// ordered before or after other services of the snap, without cycles.
+func validateAppOrder(apps map[string]*AppInfo) error {
+	// what each service must start before
+	next := make(map[string][]string)
+	for name, app := range apps {
+		for _, other := range app.Before {
+			if err := validateOrderTarget(apps, name, "before", other); err != nil {
+				return err
+			}
+			next[name] = append(next[name], other)
+		}
+		for _, other := range app.After {
+			if err := validateOrderTarget(apps, name, "after", other); err != nil {
+				return err
+			}
+			next[other] = append(next[other], name)
+		}
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	marks := make(map[string]int)
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch marks[name] {
+		case visiting:
+			return fmt.Errorf("cannot order the services of the snap: %q is part of an ordering cycle", name)
+		case visited:
+			return nil
+		}
+		marks[name] = visiting
+		for _, other := range next[name] {
+			if err := visit(other); err != nil {
+				return err
+			}
+		}
+		marks[name] = visited
+		return nil
+	}
+
+	// in order, for reproducible errors
+	names := make([]string, 0, len(apps))
+	for name := range apps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateOrderTarget(apps map[string]*AppInfo, name, relation, other string) error {
+	if other == name {
+		return fmt.Errorf("app %q cannot be ordered %s itself", name, relation)
+	}
+	if app, ok := apps[other]; !ok || app.Daemon == "" {
+		return fmt.Errorf("app %q cannot be ordered %s %q: not a service of the snap", name, relation, other)
+	}
+	return nil
+}
+
+// Regular expression describing correct aliases; these have no dots,
+// which would put them in the namespace of the wrappers of another
+// snap.
+var validAlias = regexp.MustCompile(`^[a-zA-Z0-9][-_a-zA-Z0-9]*$`)
+
+// ValidateAlias checks if a string can be used as an alias of an app.
+func ValidateAlias(alias string) error {
+	// "snap" itself is what runs the wrappers
+	if !validAlias.MatchString(alias) || alias == "snap" {
+		return fmt.Errorf("invalid alias name: %q", alias)
+	}
+	return nil
+}
+
+// validateAppAliases checks that no alias is used for more than one
+// app of the snap.
+func validateAppAliases(apps map[string]*AppInfo) error {
+	// in order, for reproducible errors
+	names := make([]string, 0, len(apps))
+	for name := range apps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	aliasApps := make(map[string]string)
+	for _, name := range names {
+		for _, alias := range apps[name].Aliases {
+			if other, ok := aliasApps[alias]; ok {
+				return fmt.Errorf("cannot use alias %q for both app %q and app %q", alias, other, name)
+			}
+			aliasApps[alias] = name
+		}
+	}
 	return nil
 }
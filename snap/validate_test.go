@@ -20,9 +20,13 @@
 package snap_test
 
 import (
+	"time"
+
 	. "gopkg.in/check.v1"
 
 	. "github.com/ubuntu-core/snappy/snap"
+	"github.com/ubuntu-core/snappy/systemd"
+	"github.com/ubuntu-core/snappy/timeout"
 )
 
 type ValidateSuite struct{}
@@ -86,6 +90,39 @@ func (s *ValidateSuite) TestAppDaemonValue(c *C) {
 	c.Check(ValidateApp(&AppInfo{Daemon: "nono"}), ErrorMatches, `"daemon" field contains invalid value "nono"`)
 }
 
+func (s *ValidateSuite) TestAppTimer(c *C) {
+	c.Check(ValidateApp(&AppInfo{Daemon: "oneshot", Timer: "mon-fri,10:00~12:00,,sun,9:00"}), IsNil)
+	c.Check(ValidateApp(&AppInfo{Daemon: "simple", Timer: "10:00"}), ErrorMatches, `"timer" field cannot be used with daemon "simple"`)
+	c.Check(ValidateApp(&AppInfo{Timer: "10:00"}), ErrorMatches, `"timer" field cannot be used with daemon ""`)
+	c.Check(ValidateApp(&AppInfo{Daemon: "oneshot", Socket: true, Timer: "10:00"}), ErrorMatches, `"timer" field cannot be used with "socket"`)
+	c.Check(ValidateApp(&AppInfo{Daemon: "oneshot", Timer: "10:00~9:00"}), ErrorMatches, `"timer" field contains invalid value: cannot parse schedule .*`)
+}
+
+func (s *ValidateSuite) TestAppDaemonOptions(c *C) {
+	c.Check(ValidateApp(&AppInfo{Daemon: "simple", RestartDelay: timeout.Timeout(time.Second), WatchdogTimeout: timeout.Timeout(time.Second), RefreshMode: "endure"}), IsNil)
+	c.Check(ValidateApp(&AppInfo{Daemon: "simple", RefreshMode: "restart"}), IsNil)
+	c.Check(ValidateApp(&AppInfo{Daemon: "simple", RefreshMode: "never"}), ErrorMatches, `"refresh-mode" field contains invalid value "never"`)
+
+	c.Check(ValidateApp(&AppInfo{RestartDelay: timeout.Timeout(time.Second)}), ErrorMatches, `"restart-delay" field can only be used with daemons`)
+	c.Check(ValidateApp(&AppInfo{WatchdogTimeout: timeout.Timeout(time.Second)}), ErrorMatches, `"watchdog-timeout" field can only be used with daemons`)
+	c.Check(ValidateApp(&AppInfo{RefreshMode: "endure"}), ErrorMatches, `"refresh-mode" field can only be used with daemons`)
+	c.Check(ValidateApp(&AppInfo{Before: []string{"foo"}}), ErrorMatches, `"before" field can only be used with daemons`)
+	c.Check(ValidateApp(&AppInfo{After: []string{"foo"}}), ErrorMatches, `"after" field can only be used with daemons`)
+
+	c.Check(ValidateApp(&AppInfo{Daemon: "simple", RestartCond: systemd.RestartAlways}), IsNil)
+	c.Check(ValidateApp(&AppInfo{Daemon: "oneshot", RestartCond: systemd.RestartOnFailure}), ErrorMatches, `"restart-condition" field cannot be used with daemon "oneshot"`)
+	c.Check(ValidateApp(&AppInfo{Daemon: "oneshot", Timer: "10:00", RestartCond: systemd.RestartAlways}), ErrorMatches, `"restart-condition" field cannot be used with "timer"`)
+}
+
+func (s *ValidateSuite) TestAppEnvironment(c *C) {
+	c.Check(ValidateApp(&AppInfo{Environment: map[string]string{"PORT": "8080", "_opts": "-v --x=y 50%"}}), IsNil)
+	c.Check(ValidateApp(&AppInfo{Environment: map[string]string{"1X": "y"}}), ErrorMatches, `"environment" field contains invalid variable name "1X"`)
+	c.Check(ValidateApp(&AppInfo{Environment: map[string]string{"X-Y": "y"}}), ErrorMatches, `"environment" field contains invalid variable name "X-Y"`)
+	for _, value := range []string{`"`, `\\`, "$HOME", "`id`", "a\nb"} {
+		c.Check(ValidateApp(&AppInfo{Environment: map[string]string{"X": value}}), ErrorMatches, `"environment" field contains invalid value for "X": .*`, Commentf(value))
+	}
+}
+
 func (s *ValidateSuite) TestAppWhitelistError(c *C) {
 	err := ValidateApp(&AppInfo{Name: "x\n"})
 	c.Assert(err, NotNil)
@@ -121,6 +158,66 @@ apps:
 	c.Check(err, NotNil)
 }
 
+func (s *ValidateSuite) TestAppOrder(c *C) {
+	info, err := InfoFromSnapYaml([]byte(`name: foo
+version: 1.0
+apps:
+ db:
+   command: db
+   daemon: simple
+   before: [api]
+ api:
+   command: api
+   daemon: simple
+ proxy:
+   command: proxy
+   daemon: simple
+   after: [api, db]
+`))
+	c.Assert(err, IsNil)
+	c.Check(Validate(info), IsNil)
+}
+
+func (s *ValidateSuite) TestAppOrderErrors(c *C) {
+	for _, t := range []struct {
+		apps string
+		err  string
+	}{
+		{" a:\n  daemon: simple\n  after: [a]\n", `app "a" cannot be ordered after itself`},
+		{" a:\n  daemon: simple\n  before: [b]\n", `app "a" cannot be ordered before "b": not a service of the snap`},
+		{" a:\n  daemon: simple\n  after: [b]\n b:\n  command: b\n", `app "a" cannot be ordered after "b": not a service of the snap`},
+		{" a:\n  daemon: simple\n  after: [b]\n b:\n  daemon: simple\n  after: [c]\n c:\n  daemon: simple\n  before: [b]\n  after: [a]\n",
+			`cannot order the services of the snap: "a" is part of an ordering cycle`},
+	} {
+		info, err := InfoFromSnapYaml([]byte("name: foo\nversion: 1.0\napps:\n" + t.apps))
+		c.Assert(err, IsNil)
+		c.Check(Validate(info), ErrorMatches, t.err, Commentf(t.apps))
+	}
+}
+
+func (s *ValidateSuite) TestValidateAlias(c *C) {
+	for _, alias := range []string{"convert", "Convert2", "foo-bar_baz", "7z", "snappy"} {
+		c.Check(ValidateAlias(alias), IsNil, Commentf(alias))
+	}
+	for _, alias := range []string{"", "-foo", ".foo", "foo.bar", "foo.", "snap", "foo/bar", "foo bar", "foo\n"} {
+		c.Check(ValidateAlias(alias), ErrorMatches, `invalid alias name: ".*"`, Commentf(alias))
+	}
+	c.Check(ValidateApp(&AppInfo{Aliases: []string{"ok", "not/ok"}}), ErrorMatches, `invalid alias name: "not/ok"`)
+}
+
+func (s *ValidateSuite) TestAppAliasesUnique(c *C) {
+	info, err := InfoFromSnapYaml([]byte(`name: foo
+version: 1.0
+apps:
+ a:
+   aliases: [one, two]
+ b:
+   aliases: [two]
+`))
+	c.Assert(err, IsNil)
+	c.Check(Validate(info), ErrorMatches, `cannot use alias "two" for both app "a" and app "b"`)
+}
+
 func (s *ValidateSuite) TestIllegalSnapName(c *C) {
 	info, err := InfoFromSnapYaml([]byte(`name: foo.something
 version: 1.0
@@ -137,10 +137,13 @@ var storeConfig = (*store.SnapUbuntuStoreConfig)(nil)
 
 // TODO: kill this function once fewer places make a store on the fly
 
-// NewConfiguredUbuntuStoreSnapRepository creates a new fully configured store.SnapUbuntuStoreRepository with the store id selected by the gadget.
+// NewConfiguredUbuntuStoreSnapRepository creates a new fully configured store.SnapUbuntuStoreRepository with the default store id.
 func NewConfiguredUbuntuStoreSnapRepository() *store.SnapUbuntuStoreRepository {
-	storeID := ""
-	// TODO: set the store-id here from the model information
+	return NewConfiguredUbuntuStoreSnapRepositoryForStoreID("")
+}
+
+// NewConfiguredUbuntuStoreSnapRepositoryForStoreID creates a new fully configured store.SnapUbuntuStoreRepository with the given store id, usually the one named by the device model. The UBUNTU_STORE_ID environment variable takes precedence over it.
+func NewConfiguredUbuntuStoreSnapRepositoryForStoreID(storeID string) *store.SnapUbuntuStoreRepository {
 	if cand := os.Getenv("UBUNTU_STORE_ID"); cand != "" {
 		storeID = cand
 	}
@@ -246,13 +246,21 @@ func GenerateWrappers(s *snap.Info, inter interacter) error {
 // RemoveGeneratedWrappers removes the generated services, binaries, desktop
 // wrappers
 func RemoveGeneratedWrappers(s *snap.Info, inter interacter) error {
+	return removeGeneratedWrappers(s, false, inter)
+}
+
+func removeGeneratedWrappers(s *snap.Info, refresh bool, inter interacter) error {
 
 	err1 := wrappers.RemoveSnapBinaries(s)
 	if err1 != nil {
 		logger.Noticef("Failed to remove binaries for %q: %v", s.Name(), err1)
 	}
 
-	err2 := wrappers.RemoveSnapServices(s, inter)
+	removeServices := wrappers.RemoveSnapServices
+	if refresh {
+		removeServices = wrappers.RemoveSnapServicesForRefresh
+	}
+	err2 := removeServices(s, inter)
 	if err2 != nil {
 		logger.Noticef("Failed to remove services for %q: %v", s.Name(), err2)
 	}
@@ -369,6 +377,17 @@ func LinkSnap(s *snap.Info, inter interacter) error {
 
 // UnlinkSnap deactivates the given active snap.
 func UnlinkSnap(info *snap.Info, inter interacter) error {
+	return unlinkSnap(info, false, inter)
+}
+
+// UnlinkSnapForRefresh deactivates the given active snap for another
+// revision of it to be linked in its place, leaving the services of
+// its apps with refresh-mode "endure" running.
+func UnlinkSnapForRefresh(info *snap.Info, inter interacter) error {
+	return unlinkSnap(info, true, inter)
+}
+
+func unlinkSnap(info *snap.Info, refresh bool, inter interacter) error {
 	mountDir := info.MountDir()
 
 	currentSymlink := filepath.Join(mountDir, "..", "current")
@@ -384,7 +403,7 @@ func UnlinkSnap(info *snap.Info, inter interacter) error {
 	}
 
 	// remove generated services, binaries, security policy
-	err1 := RemoveGeneratedWrappers(info, inter)
+	err1 := removeGeneratedWrappers(info, refresh, inter)
 
 	// removing security setup move here!
 
@@ -24,7 +24,6 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
-	"strings"
 
 	. "gopkg.in/check.v1"
 
@@ -501,22 +500,20 @@ apps:
 	_, err := (&Overlord{}).InstallWithSideInfo(snapPath, fooSI10, AllowUnauthenticated, nil)
 	c.Assert(err, IsNil)
 
-	// ensure that the binary wrapper file got generated with the right
-	// path
-	oldSnapBin := filepath.Join(dirs.SnapSnapsDir[len(dirs.GlobalRootDir):], "foo", "10", "bin", "bar")
+	// ensure that the binary wrapper got linked to snap, which runs
+	// the current revision
 	binaryWrapper := filepath.Join(dirs.SnapBinariesDir, "foo.bar")
-	content, err := ioutil.ReadFile(binaryWrapper)
+	target, err := os.Readlink(binaryWrapper)
 	c.Assert(err, IsNil)
-	c.Assert(strings.Contains(string(content), oldSnapBin), Equals, true)
+	c.Assert(target, Equals, "/usr/bin/snap")
 
-	// and that it gets updated on upgrade
+	// and that it is still there after the upgrade
 	snapPath = makeTestSnapPackage(c, snapYamlContent+"version: 2.0")
 	_, err = (&Overlord{}).InstallWithSideInfo(snapPath, fooSI20, AllowUnauthenticated, nil)
 	c.Assert(err, IsNil)
-	newSnapBin := filepath.Join(dirs.SnapSnapsDir[len(dirs.GlobalRootDir):], "foo", "20", "bin", "bar")
-	content, err = ioutil.ReadFile(binaryWrapper)
+	target, err = os.Readlink(binaryWrapper)
 	c.Assert(err, IsNil)
-	c.Assert(strings.Contains(string(content), newSnapBin), Equals, true)
+	c.Assert(target, Equals, "/usr/bin/snap")
 }
 
 func (s *SnapTestSuite) TestSnappyHandleServicesOnInstall(c *C) {
@@ -591,7 +588,8 @@ apps:
 	// ensure that the binary wrapper file go generated with the right
 	// name
 	binaryWrapper := filepath.Join(dirs.SnapBinariesDir, "foo.bar")
-	c.Assert(osutil.FileExists(binaryWrapper), Equals, true)
+	_, err = os.Lstat(binaryWrapper)
+	c.Assert(err, IsNil)
 
 	// and that it gets removed on remove
 	snapDir := filepath.Join(dirs.SnapSnapsDir, "foo", "0")
@@ -600,7 +598,8 @@ apps:
 	c.Assert(err, IsNil)
 	err = (&Overlord{}).Uninstall(snap, &MockProgressMeter{})
 	c.Assert(err, IsNil)
-	c.Assert(osutil.FileExists(binaryWrapper), Equals, false)
+	_, err = os.Lstat(binaryWrapper)
+	c.Assert(os.IsNotExist(err), Equals, true)
 	c.Assert(osutil.FileExists(snapDir), Equals, false)
 }
 
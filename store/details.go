@@ -38,6 +38,7 @@ type snapDetails struct {
 	DownloadURL     string             `json:"download_url,omitempty"`
 	IconURL         string             `json:"icon_url"`
 	LastUpdated     string             `json:"last_updated,omitempty"`
+	License         string             `json:"license,omitempty"`
 	Name            string             `json:"package_name"`
 	Prices          map[string]float64 `json:"prices,omitempty"`
 	Publisher       string             `json:"publisher,omitempty"`
@@ -52,4 +53,22 @@ type snapDetails struct {
 	// FIXME: the store should return "developer" to us instead of
 	//        origin
 	Developer string `json:"origin" yaml:"origin"`
+
+	ChannelMapList []channelMap `json:"channel_maps_list,omitempty"`
+}
+
+// channelMap is the list of the channels of a track of the snap, with
+// the revision released in each of them.
+type channelMap struct {
+	Track       string                   `json:"track"`
+	SnapDetails []channelSnapInfoDetails `json:"map"`
+}
+
+// channelSnapInfoDetails is the revision released in a channel; the
+// store leaves the revision out for the channels that follow another one.
+type channelSnapInfoDetails struct {
+	Channel      string `json:"channel"`
+	Revision     int    `json:"revision"`
+	Version      string `json:"version"`
+	DownloadSize int64  `json:"binary_filesize"`
 }
@@ -51,6 +51,9 @@ const (
 	UbuntuCoreWireProtocol = "1"
 )
 
+// defaultTrack is the track of the channels named by their risk alone.
+const defaultTrack = "latest"
+
 func infoFromRemote(d snapDetails) *snap.Info {
 	info := &snap.Info{}
 	info.Architectures = d.Architectures
@@ -69,6 +72,31 @@ func infoFromRemote(d snapDetails) *snap.Info {
 	info.AnonDownloadURL = d.AnonDownloadURL
 	info.DownloadURL = d.DownloadURL
 	info.Prices = d.Prices
+	info.License = d.License
+
+	for _, chMap := range d.ChannelMapList {
+		for _, ch := range chMap.SnapDetails {
+			if ch.Revision == 0 {
+				continue
+			}
+			if info.Channels == nil {
+				info.Channels = make(map[string]*snap.ChannelSnapInfo)
+			}
+			// the channels of tracks other than the default one are
+			// only told apart by their track
+			name := ch.Channel
+			if chMap.Track != "" && chMap.Track != defaultTrack {
+				name = chMap.Track + "/" + ch.Channel
+			}
+			info.Channels[name] = &snap.ChannelSnapInfo{
+				Channel:  name,
+				Revision: ch.Revision,
+				Version:  ch.Version,
+				Size:     ch.DownloadSize,
+			}
+		}
+	}
+
 	return info
 }
 
@@ -197,12 +197,33 @@ const MockDetailsJSON = `{
                 ],
                 "binary_filesize": 20480,
                 "channel": "edge",
+                "channel_maps_list": [
+                    {
+                        "track": "latest",
+                        "map": [
+                            {"channel": "stable", "revision": 12, "version": "5.0", "binary_filesize": 16384},
+                            {"channel": "candidate"},
+                            {"channel": "beta"},
+                            {"channel": "edge", "revision": 25, "version": "6.0", "binary_filesize": 20480}
+                        ]
+                    },
+                    {
+                        "track": "1.0",
+                        "map": [
+                            {"channel": "stable", "revision": 8, "version": "1.0.4", "binary_filesize": 12288},
+                            {"channel": "candidate"},
+                            {"channel": "beta"},
+                            {"channel": "edge"}
+                        ]
+                    }
+                ],
                 "content": "application",
                 "description": "This is a simple hello world example.",
                 "download_sha512": "4bf23ce93efa1f32f0aeae7ec92564b7b0f9f8253a0bd39b2741219c1be119bb676c21208c6845ccf995e6aabe791d3f28a733ebcbbc3171bb23f67981f4068e",
                 "download_url": "https://public.apps.ubuntu.com/download-snap/buPKUD3TKqCOgLEjjHx5kSiCpIs5cMuQ_25.snap",
                 "icon_url": "https://myapps.developer.ubuntu.com/site_media/appmedia/2015/03/hello.svg_NZLfWbh.png",
                 "last_updated": "2016-04-19T19:50:50.435291Z",
+                "license": "GPL-3.0",
                 "origin": "canonical",
                 "package_name": "hello-world",
                 "prices": {},
@@ -328,6 +349,15 @@ func (t *remoteRepoTestSuite) TestUbuntuStoreRepositoryDetails(c *C) {
 	c.Check(result.Description(), Equals, "This is a simple hello world example.")
 	c.Check(result.Summary(), Equals, "Hello world example")
 	c.Assert(result.Prices, DeepEquals, map[string]float64{})
+	c.Check(result.License, Equals, "GPL-3.0")
+	c.Check(result.Channels, DeepEquals, map[string]*snap.ChannelSnapInfo{
+		"stable": {Channel: "stable", Revision: 12, Version: "5.0", Size: 16384},
+		"edge":   {Channel: "edge", Revision: 25, Version: "6.0", Size: 20480},
+		// not to be confused with the stable channel of the default track
+		"1.0/stable": {Channel: "1.0/stable", Revision: 8, Version: "1.0.4", Size: 12288},
+	})
+	// the channel map is asked for by default
+	c.Check(strings.Contains(defaultConfig.SearchURI.RawQuery, "channel_maps_list"), Equals, true)
 
 	c.Check(repo.SuggestedCurrency(), Equals, "GBP")
 }
@@ -25,7 +25,9 @@ import (
 
 var (
 	SystemdRun = run // NOTE: plain Run clashes with check.v1
-	Jctl       = jctl
+	JctlStream = jctlStream
+
+	JournalctlArgs = journalctlArgs
 )
 
 func MockStopDelays(checkDelay, notifyDelay time.Duration) func() {
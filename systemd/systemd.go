@@ -20,6 +20,7 @@
 package systemd
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
 	"errors"
@@ -29,6 +30,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/ubuntu-core/snappy/dirs"
@@ -61,25 +63,116 @@ func run(args ...string) ([]byte, error) {
 // systemctl. It's exported so it can be overridden by testing.
 var SystemctlCmd = run
 
-// jctl calls journalctl to get the JSON logs of the given services, wrapping the error if any.
-func jctl(svcs []string) ([]byte, error) {
-	cmd := []string{"journalctl", "-o", "json"}
+// LogOptions are the options of a StreamLogs query of the journal.
+type LogOptions struct {
+	// N is the number of most recent entries to start with; all of
+	// them if negative.
+	N int
+	// Follow keeps the stream open, delivering new entries as they
+	// are added to the journal.
+	Follow bool
+	// Since only includes the entries logged after it, if not zero.
+	Since time.Time
+}
 
+// journalctlArgs returns the journalctl arguments for the given
+// services and options.
+func journalctlArgs(svcs []string, opts LogOptions) []string {
+	args := []string{"-o", "json", "--no-pager"}
+	if opts.N < 0 {
+		args = append(args, "-n", "all")
+	} else {
+		args = append(args, "-n", strconv.Itoa(opts.N))
+	}
+	if opts.Follow {
+		args = append(args, "-f")
+	}
+	if !opts.Since.IsZero() {
+		// journalctl takes local times in this format
+		args = append(args, "--since", opts.Since.Local().Format("2006-01-02 15:04:05"))
+	}
 	for i := range svcs {
-		cmd = append(cmd, "-u", svcs[i])
+		args = append(args, "-u", svcs[i])
 	}
 
-	bs, err := exec.Command(cmd[0], cmd[1:]...).Output() // journalctl can be messy with its stderr
-	if err != nil {
+	return args
+}
+
+// jctlCmd is a running journalctl, whose output is read until it's
+// over or it's closed.
+type jctlCmd struct {
+	io.ReadCloser
+	cmd    *exec.Cmd
+	args   []string
+	stderr bytes.Buffer
+
+	mu      sync.Mutex
+	closed  bool
+	waited  bool
+	waitErr error
+}
+
+// wait waits for journalctl to exit, once, turning a failure into an
+// Error carrying what it wrote to stderr.
+func (j *jctlCmd) wait() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.waited {
+		return j.waitErr
+	}
+	j.waited = true
+	err := j.cmd.Wait()
+	if err != nil && !j.closed {
 		exitCode, _ := osutil.ExitCode(err)
-		return nil, &Error{cmd: cmd, exitCode: exitCode, msg: bs}
+		j.waitErr = &Error{cmd: j.args, exitCode: exitCode, msg: bytes.TrimSpace(j.stderr.Bytes())}
 	}
+	return j.waitErr
+}
 
-	return bs, nil
+// Read reads the output of journalctl; once that is over, it reports
+// the failure of journalctl, if it failed.
+func (j *jctlCmd) Read(p []byte) (int, error) {
+	n, err := j.ReadCloser.Read(p)
+	if err == io.EOF {
+		if werr := j.wait(); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
+
+func (j *jctlCmd) Close() error {
+	j.mu.Lock()
+	j.closed = true
+	j.mu.Unlock()
+	// journalctl -f never exits on its own
+	j.cmd.Process.Kill()
+	j.wait()
+	return nil
+}
+
+// jctlStream starts journalctl to stream the JSON logs of the given
+// services; closing the returned reader stops journalctl. If
+// journalctl fails, reading reports it once its output is over.
+func jctlStream(svcs []string, opts LogOptions) (io.ReadCloser, error) {
+	args := append([]string{"journalctl"}, journalctlArgs(svcs, opts)...)
+	cmd := exec.Command(args[0], args[1:]...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	j := &jctlCmd{ReadCloser: stdout, cmd: cmd, args: args}
+	cmd.Stderr = &j.stderr
+	if err := cmd.Start(); err != nil {
+		return nil, &Error{cmd: args, msg: []byte(err.Error())}
+	}
+
+	return j, nil
 }
 
-// JournalctlCmd is called from Logs to run journalctl; exported for testing.
-var JournalctlCmd = jctl
+// JournalctlStreamCmd is called from StreamLogs to start journalctl;
+// exported for testing.
+var JournalctlStreamCmd = jctlStream
 
 // Systemd exposes a minimal interface to manage systemd via the systemctl command.
 type Systemd interface {
@@ -89,10 +182,11 @@ type Systemd interface {
 	Start(service string) error
 	Stop(service string, timeout time.Duration) error
 	Kill(service, signal string) error
-	Restart(service string, timeout time.Duration) error
+	Restart(service string) error
 	Status(service string) (string, error)
 	ServiceStatus(service string) (*ServiceStatus, error)
 	Logs(services []string) ([]Log, error)
+	StreamLogs(services []string, opts LogOptions) (*LogStream, error)
 	WriteMountUnitFile(name, what, where string) (string, error)
 }
 
@@ -153,6 +247,9 @@ const (
 	// the default target for systemd units that we generate
 	SocketsTarget = "sockets.target"
 
+	// the default target for systemd timer units that we generate
+	TimersTarget = "timers.target"
+
 	// the location to put system services
 	snapServicesDir = "/etc/systemd/system"
 )
@@ -196,35 +293,75 @@ func (*systemd) Start(serviceName string) error {
 }
 
 // Logs for the given service
-func (*systemd) Logs(serviceNames []string) ([]Log, error) {
-	bs, err := JournalctlCmd(serviceNames)
+func (s *systemd) Logs(serviceNames []string) ([]Log, error) {
+	stream, err := s.StreamLogs(serviceNames, LogOptions{N: -1})
 	if err != nil {
 		return nil, err
 	}
-
-	const noEntries = "-- No entries --\n"
-	if len(bs) == len(noEntries) && string(bs) == noEntries {
-		return nil, nil
-	}
+	defer stream.Close()
 
 	var logs []Log
-	dec := json.NewDecoder(bytes.NewReader(bs))
 	for {
-		var log Log
-
-		err = dec.Decode(&log)
+		log, err := stream.Next()
+		if err == io.EOF {
+			return logs, nil
+		}
 		if err != nil {
-			break
+			return nil, err
 		}
-
 		logs = append(logs, log)
 	}
+}
 
-	if err != io.EOF {
+// StreamLogs starts streaming the logs of the given services, as
+// specified by opts. The stream must be closed when done with.
+func (*systemd) StreamLogs(serviceNames []string, opts LogOptions) (*LogStream, error) {
+	rc, err := JournalctlStreamCmd(serviceNames, opts)
+	if err != nil {
 		return nil, err
 	}
 
-	return logs, nil
+	return &LogStream{rc: rc, r: bufio.NewReader(rc)}, nil
+}
+
+// A LogStream decodes the journal entries output by journalctl, one
+// at a time as they come.
+type LogStream struct {
+	rc   io.ReadCloser
+	r    *bufio.Reader
+	once sync.Once
+}
+
+// Next returns the next entry of the stream, blocking until there's
+// one if following the journal. It returns io.EOF when the stream is
+// over.
+func (ls *LogStream) Next() (Log, error) {
+	for {
+		line, err := ls.r.ReadBytes('\n')
+		line = bytes.TrimSpace(line)
+		// journalctl tells about no entries and the like in lines
+		// like "-- No entries --"
+		if len(line) > 0 && !bytes.HasPrefix(line, []byte("-- ")) {
+			var log Log
+			if err := json.Unmarshal(line, &log); err != nil {
+				return nil, fmt.Errorf("cannot decode log entry: %v", err)
+			}
+			return log, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Close stops the stream; it's safe to call it more than once, and
+// from another goroutine to interrupt a blocked Next.
+func (ls *LogStream) Close() error {
+	var err error
+	ls.once.Do(func() {
+		err = ls.rc.Close()
+	})
+	return err
 }
 
 var statusregex = regexp.MustCompile(`(?m)^(?:(.*?)=(.*))?$`)
@@ -321,12 +458,10 @@ func (s *systemd) Kill(serviceName, signal string) error {
 	return err
 }
 
-// Restart the service, waiting for it to stop before starting it again.
-func (s *systemd) Restart(serviceName string, timeout time.Duration) error {
-	if err := s.Stop(serviceName, timeout); err != nil {
-		return err
-	}
-	return s.Start(serviceName)
+// Restart the service, in one go through systemctl restart.
+func (s *systemd) Restart(serviceName string) error {
+	_, err := SystemctlCmd("restart", serviceName)
+	return err
 }
 
 // Error is returned if the systemd action failed
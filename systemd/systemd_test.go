@@ -20,6 +20,8 @@
 package systemd_test
 
 import (
+	"bytes"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -31,6 +33,7 @@ import (
 
 	"github.com/ubuntu-core/snappy/dirs"
 	. "github.com/ubuntu-core/snappy/systemd"
+	"github.com/ubuntu-core/snappy/testutil"
 )
 
 type testreporter struct {
@@ -55,6 +58,7 @@ type SystemdTestSuite struct {
 	jsvcs [][]string
 	jouts [][]byte
 	jerrs []error
+	jopts []LogOptions
 
 	rep *testreporter
 }
@@ -75,18 +79,20 @@ func (s *SystemdTestSuite) SetUpTest(c *C) {
 	s.errors = nil
 	s.outs = nil
 
-	JournalctlCmd = s.myJctl
 	s.j = 0
 	s.jsvcs = nil
 	s.jouts = nil
 	s.jerrs = nil
 
+	JournalctlStreamCmd = s.myJctlStream
+	s.jopts = nil
+
 	s.rep = new(testreporter)
 }
 
 func (s *SystemdTestSuite) TearDownTest(c *C) {
 	SystemctlCmd = SystemdRun
-	JournalctlCmd = Jctl
+	JournalctlStreamCmd = JctlStream
 }
 
 func (s *SystemdTestSuite) myRun(args ...string) (out []byte, err error) {
@@ -101,8 +107,12 @@ func (s *SystemdTestSuite) myRun(args ...string) (out []byte, err error) {
 	return out, err
 }
 
-func (s *SystemdTestSuite) myJctl(svcs []string) (out []byte, err error) {
+func (s *SystemdTestSuite) myJctlStream(svcs []string, opts LogOptions) (io.ReadCloser, error) {
 	s.jsvcs = append(s.jsvcs, svcs)
+	s.jopts = append(s.jopts, opts)
+
+	var out []byte
+	var err error
 
 	if s.j < len(s.jouts) {
 		out = s.jouts[s.j]
@@ -111,8 +121,21 @@ func (s *SystemdTestSuite) myJctl(svcs []string) (out []byte, err error) {
 		err = s.jerrs[s.j]
 	}
 	s.j++
+	if err != nil {
+		return nil, err
+	}
 
-	return out, err
+	return &closeRecorder{Reader: bytes.NewReader(out)}, nil
+}
+
+type closeRecorder struct {
+	io.Reader
+	closed int
+}
+
+func (cr *closeRecorder) Close() error {
+	cr.closed++
+	return nil
 }
 
 func (s *SystemdTestSuite) TestDaemonReload(c *C) {
@@ -195,20 +218,9 @@ func (s *SystemdTestSuite) TestEnable(c *C) {
 }
 
 func (s *SystemdTestSuite) TestRestart(c *C) {
-	restore := MockStopDelays(time.Millisecond, 25*time.Second)
-	defer restore()
-	s.outs = [][]byte{
-		nil, // for the "stop" itself
-		[]byte("ActiveState=inactive\n"),
-		nil, // for the "start"
-	}
-	s.errors = []error{nil, nil, nil, nil, &Timeout{}}
-	err := New("", s.rep).Restart("foo", 100*time.Millisecond)
+	err := New("", s.rep).Restart("foo")
 	c.Assert(err, IsNil)
-	c.Check(s.argses, HasLen, 3)
-	c.Check(s.argses[0], DeepEquals, []string{"stop", "foo"})
-	c.Check(s.argses[1], DeepEquals, []string{"show", "--property=ActiveState", "foo"})
-	c.Check(s.argses[2], DeepEquals, []string{"start", "foo"})
+	c.Check(s.argses, DeepEquals, [][]string{{"restart", "foo"}})
 }
 
 func (s *SystemdTestSuite) TestKill(c *C) {
@@ -221,6 +233,17 @@ func (s *SystemdTestSuite) TestIsTimeout(c *C) {
 	c.Check(IsTimeout(&Timeout{}), Equals, true)
 }
 
+func (s *SystemdTestSuite) TestJournalctlArgs(c *C) {
+	c.Check(JournalctlArgs([]string{"foo", "bar"}, LogOptions{N: 10}), DeepEquals, []string{
+		"-o", "json", "--no-pager", "-n", "10", "-u", "foo", "-u", "bar",
+	})
+
+	since := time.Date(2016, 6, 1, 12, 30, 0, 0, time.UTC)
+	c.Check(JournalctlArgs([]string{"foo"}, LogOptions{N: -1, Follow: true, Since: since}), DeepEquals, []string{
+		"-o", "json", "--no-pager", "-n", "all", "-f", "--since", "2016-06-01 12:30:00", "-u", "foo",
+	})
+}
+
 func (s *SystemdTestSuite) TestLogErrJctl(c *C) {
 	s.jerrs = []error{&Timeout{}}
 
@@ -250,9 +273,140 @@ func (s *SystemdTestSuite) TestLogs(c *C) {
 	c.Check(err, IsNil)
 	c.Check(logs, DeepEquals, []Log{{"a": 1.}, {"a": 2.}})
 	c.Check(s.jsvcs, DeepEquals, [][]string{{"foo"}})
+	// all of the entries, not following the journal
+	c.Check(s.jopts, DeepEquals, []LogOptions{{N: -1}})
 	c.Check(s.j, Equals, 1)
 }
 
+func (s *SystemdTestSuite) TestLogsNoEntries(c *C) {
+	s.jouts = [][]byte{[]byte("-- No entries --\n")}
+
+	logs, err := New("", s.rep).Logs([]string{"foo"})
+	c.Check(err, IsNil)
+	c.Check(logs, IsNil)
+}
+
+func (s *SystemdTestSuite) TestStreamLogs(c *C) {
+	s.jouts = [][]byte{[]byte(`{"a": 1}
+{"a": 2}`)}
+
+	opts := LogOptions{N: 5, Follow: true}
+	stream, err := New("", s.rep).StreamLogs([]string{"foo"}, opts)
+	c.Assert(err, IsNil)
+	c.Check(s.jsvcs, DeepEquals, [][]string{{"foo"}})
+	c.Check(s.jopts, DeepEquals, []LogOptions{opts})
+
+	log, err := stream.Next()
+	c.Assert(err, IsNil)
+	c.Check(log, DeepEquals, Log{"a": 1.})
+	log, err = stream.Next()
+	c.Assert(err, IsNil)
+	c.Check(log, DeepEquals, Log{"a": 2.})
+	_, err = stream.Next()
+	c.Check(err, Equals, io.EOF)
+
+	c.Check(stream.Close(), IsNil)
+	c.Check(stream.Close(), IsNil)
+}
+
+func (s *SystemdTestSuite) TestStreamLogsIncremental(c *C) {
+	r, w := io.Pipe()
+	JournalctlStreamCmd = func([]string, LogOptions) (io.ReadCloser, error) {
+		return r, nil
+	}
+
+	stream, err := New("", s.rep).StreamLogs([]string{"foo"}, LogOptions{Follow: true})
+	c.Assert(err, IsNil)
+
+	go w.Write([]byte("{\"a\": 1}\n{\"a\""))
+	log, err := stream.Next()
+	c.Assert(err, IsNil)
+	c.Check(log, DeepEquals, Log{"a": 1.})
+
+	go w.Write([]byte(": 2}\n"))
+	log, err = stream.Next()
+	c.Assert(err, IsNil)
+	c.Check(log, DeepEquals, Log{"a": 2.})
+
+	// closing interrupts a blocked Next
+	go stream.Close()
+	_, err = stream.Next()
+	c.Check(err, NotNil)
+}
+
+func (s *SystemdTestSuite) TestJctlStream(c *C) {
+	cmd := testutil.MockCommand(c, "journalctl", `echo '{"a": 1}'`)
+	defer cmd.Restore()
+	JournalctlStreamCmd = JctlStream
+
+	stream, err := New("", s.rep).StreamLogs([]string{"foo"}, LogOptions{N: 5})
+	c.Assert(err, IsNil)
+	log, err := stream.Next()
+	c.Assert(err, IsNil)
+	c.Check(log, DeepEquals, Log{"a": 1.})
+	_, err = stream.Next()
+	c.Check(err, Equals, io.EOF)
+	c.Check(stream.Close(), IsNil)
+	c.Check(cmd.Calls(), DeepEquals, []string{"-o json --no-pager -n 5 -u foo"})
+}
+
+func (s *SystemdTestSuite) TestJctlStreamFails(c *C) {
+	cmd := testutil.MockCommand(c, "journalctl", `echo '{"a": 1}'; echo "no such unit" >&2; exit 1`)
+	defer cmd.Restore()
+	JournalctlStreamCmd = JctlStream
+
+	stream, err := New("", s.rep).StreamLogs([]string{"foo"}, LogOptions{})
+	c.Assert(err, IsNil)
+	defer stream.Close()
+	log, err := stream.Next()
+	c.Assert(err, IsNil)
+	c.Check(log, DeepEquals, Log{"a": 1.})
+	_, err = stream.Next()
+	c.Check(err, ErrorMatches, `\[journalctl -o json --no-pager -n 0 -u foo\] failed with exit status 1: no such unit`)
+}
+
+func (s *SystemdTestSuite) TestJctlStreamClose(c *C) {
+	cmd := testutil.MockCommand(c, "journalctl", `echo '{"a": 1}'; exec sleep 60`)
+	defer cmd.Restore()
+	JournalctlStreamCmd = JctlStream
+
+	stream, err := New("", s.rep).StreamLogs([]string{"foo"}, LogOptions{Follow: true})
+	c.Assert(err, IsNil)
+	_, err = stream.Next()
+	c.Assert(err, IsNil)
+
+	// closing kills journalctl, which is not reported as a failure
+	c.Check(stream.Close(), IsNil)
+	_, err = stream.Next()
+	c.Check(err, Not(ErrorMatches), `.*failed with exit status.*`)
+}
+
+func (s *SystemdTestSuite) TestStreamLogsNoEntries(c *C) {
+	s.jouts = [][]byte{[]byte("-- No entries --\n")}
+
+	stream, err := New("", s.rep).StreamLogs([]string{"foo"}, LogOptions{})
+	c.Assert(err, IsNil)
+	_, err = stream.Next()
+	c.Check(err, Equals, io.EOF)
+}
+
+func (s *SystemdTestSuite) TestStreamLogsErrJSON(c *C) {
+	s.jouts = [][]byte{[]byte("this is not valid json.\n")}
+
+	stream, err := New("", s.rep).StreamLogs([]string{"foo"}, LogOptions{})
+	c.Assert(err, IsNil)
+	_, err = stream.Next()
+	c.Check(err, ErrorMatches, "cannot decode log entry: .*")
+}
+
+func (s *SystemdTestSuite) TestStreamLogsErr(c *C) {
+	s.jerrs = []error{&Timeout{}}
+
+	stream, err := New("", s.rep).StreamLogs([]string{"foo"}, LogOptions{})
+	c.Check(err, NotNil)
+	c.Check(stream, IsNil)
+}
+
 func (s *SystemdTestSuite) TestLogString(c *C) {
 	c.Check(Log{}.String(), Equals, "-(no timestamp!)- - -")
 	c.Check(Log{
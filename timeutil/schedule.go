@@ -0,0 +1,236 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package timeutil
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Clock is a time of the day, to the minute.
+type Clock struct {
+	Hour   int
+	Minute int
+}
+
+func (c Clock) String() string {
+	return fmt.Sprintf("%02d:%02d", c.Hour, c.Minute)
+}
+
+func (c Clock) minutes() int {
+	return c.Hour*60 + c.Minute
+}
+
+// ClockSpan is a time of the day, or a window of the day when Spread
+// is set, in which case the time is picked within it.
+type ClockSpan struct {
+	Start  Clock
+	End    Clock
+	Spread bool
+}
+
+func (span ClockSpan) String() string {
+	if span.Spread {
+		return fmt.Sprintf("%s~%s", span.Start, span.End)
+	}
+	return span.Start.String()
+}
+
+// Pick returns the time of the span. If the span is spread over a
+// window the time is picked pseudo-randomly within it, derived from
+// key, so that the same key always gets the same time while different
+// keys get spread over the window.
+func (span ClockSpan) Pick(key string) Clock {
+	if !span.Spread {
+		return span.Start
+	}
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	r := rand.New(rand.NewSource(int64(h.Sum64())))
+	m := span.Start.minutes() + r.Intn(span.End.minutes()-span.Start.minutes())
+	return Clock{Hour: m / 60, Minute: m % 60}
+}
+
+// WeekSpan is a range of days of the week, from Monday to Sunday.
+type WeekSpan struct {
+	Start time.Weekday
+	End   time.Weekday
+}
+
+func (span WeekSpan) String() string {
+	if span.Start == span.End {
+		return weekdayNames[span.Start]
+	}
+	return fmt.Sprintf("%s-%s", weekdayNames[span.Start], weekdayNames[span.End])
+}
+
+// Schedule is a set of times of the day, restricted to some days of
+// the week if any are given.
+type Schedule struct {
+	WeekSpans  []WeekSpan
+	ClockSpans []ClockSpan
+}
+
+func (sched *Schedule) String() string {
+	parts := make([]string, 0, len(sched.WeekSpans)+len(sched.ClockSpans))
+	for _, span := range sched.WeekSpans {
+		parts = append(parts, span.String())
+	}
+	for _, span := range sched.ClockSpans {
+		parts = append(parts, span.String())
+	}
+	return strings.Join(parts, ",")
+}
+
+var weekdayNames = map[time.Weekday]string{
+	time.Monday:    "mon",
+	time.Tuesday:   "tue",
+	time.Wednesday: "wed",
+	time.Thursday:  "thu",
+	time.Friday:    "fri",
+	time.Saturday:  "sat",
+	time.Sunday:    "sun",
+}
+
+// weekdayIndex returns the position of the day in a week starting on
+// Monday.
+func weekdayIndex(d time.Weekday) int {
+	return (int(d) + 6) % 7
+}
+
+func parseWeekday(s string) (time.Weekday, error) {
+	for d, name := range weekdayNames {
+		if s == name {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("%q is not a valid weekday", s)
+}
+
+func parseWeekSpan(s string) (WeekSpan, error) {
+	startStr, endStr := s, s
+	if i := strings.IndexRune(s, '-'); i >= 0 {
+		startStr, endStr = s[:i], s[i+1:]
+	}
+	start, err := parseWeekday(startStr)
+	if err != nil {
+		return WeekSpan{}, err
+	}
+	end, err := parseWeekday(endStr)
+	if err != nil {
+		return WeekSpan{}, err
+	}
+	if weekdayIndex(end) < weekdayIndex(start) {
+		return WeekSpan{}, fmt.Errorf("%q is not a valid range of weekdays", s)
+	}
+	return WeekSpan{Start: start, End: end}, nil
+}
+
+func parseClock(s string) (Clock, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 || len(parts[0]) == 0 || len(parts[0]) > 2 || len(parts[1]) != 2 {
+		return Clock{}, fmt.Errorf("%q is not a valid time", s)
+	}
+	hour, err := strconv.Atoi(parts[0])
+	if err != nil || hour < 0 || hour > 23 {
+		return Clock{}, fmt.Errorf("%q is not a valid time", s)
+	}
+	minute, err := strconv.Atoi(parts[1])
+	if err != nil || minute < 0 || minute > 59 {
+		return Clock{}, fmt.Errorf("%q is not a valid time", s)
+	}
+	return Clock{Hour: hour, Minute: minute}, nil
+}
+
+func parseClockSpan(s string) (ClockSpan, error) {
+	i := strings.IndexRune(s, '~')
+	if i < 0 {
+		c, err := parseClock(s)
+		if err != nil {
+			return ClockSpan{}, err
+		}
+		return ClockSpan{Start: c, End: c}, nil
+	}
+
+	start, err := parseClock(s[:i])
+	if err != nil {
+		return ClockSpan{}, err
+	}
+	end, err := parseClock(s[i+1:])
+	if err != nil {
+		return ClockSpan{}, err
+	}
+	if end.minutes() <= start.minutes() {
+		return ClockSpan{}, fmt.Errorf("%q is not a valid window: it must end after it starts", s)
+	}
+	return ClockSpan{Start: start, End: end, Spread: true}, nil
+}
+
+func parseSingleSchedule(s string) (*Schedule, error) {
+	var sched Schedule
+	for _, item := range strings.Split(s, ",") {
+		if strings.ContainsRune(item, ':') {
+			span, err := parseClockSpan(item)
+			if err != nil {
+				return nil, err
+			}
+			sched.ClockSpans = append(sched.ClockSpans, span)
+			continue
+		}
+		if len(sched.ClockSpans) > 0 {
+			return nil, fmt.Errorf("weekdays must come before times")
+		}
+		span, err := parseWeekSpan(item)
+		if err != nil {
+			return nil, err
+		}
+		sched.WeekSpans = append(sched.WeekSpans, span)
+	}
+	if len(sched.ClockSpans) == 0 {
+		return nil, fmt.Errorf("no time given in %q", s)
+	}
+	return &sched, nil
+}
+
+// ParseSchedule parses a timer schedule, made of one or more
+// schedules separated by ",,". Each schedule is an optional list of
+// weekdays or ranges of them, like "mon" or "mon-fri", followed by a
+// list of times of the day, like "10:00", or windows of it, like
+// "10:00~12:00", in which the time is spread at random; all separated
+// by ",". For example:
+//
+//	mon-fri,10:00~12:00,,sat,sun,9:00
+func ParseSchedule(s string) ([]*Schedule, error) {
+	if s == "" {
+		return nil, fmt.Errorf("cannot parse schedule: empty schedule")
+	}
+	var scheds []*Schedule
+	for _, single := range strings.Split(s, ",,") {
+		sched, err := parseSingleSchedule(single)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse schedule %q: %v", s, err)
+		}
+		scheds = append(scheds, sched)
+	}
+	return scheds, nil
+}
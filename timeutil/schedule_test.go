@@ -0,0 +1,114 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package timeutil_test
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/ubuntu-core/snappy/timeutil"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type scheduleSuite struct{}
+
+var _ = Suite(&scheduleSuite{})
+
+func (s *scheduleSuite) TestParseSchedule(c *C) {
+	for _, t := range []struct {
+		in  string
+		out string
+	}{
+		{"9:00", "09:00"},
+		{"10:00,22:30", "10:00,22:30"},
+		{"mon,10:00", "mon,10:00"},
+		{"mon-fri,10:00~12:00", "mon-fri,10:00~12:00"},
+		{"sat,sun,9:00,,mon-fri,7:15~7:45", "sat,sun,09:00;mon-fri,07:15~07:45"},
+	} {
+		scheds, err := timeutil.ParseSchedule(t.in)
+		c.Assert(err, IsNil, Commentf(t.in))
+		var strs []string
+		for _, sched := range scheds {
+			strs = append(strs, sched.String())
+		}
+		c.Check(strings.Join(strs, ";"), Equals, t.out, Commentf(t.in))
+	}
+}
+
+func (s *scheduleSuite) TestParseScheduleStructure(c *C) {
+	scheds, err := timeutil.ParseSchedule("mon-fri,sun,10:00~12:30")
+	c.Assert(err, IsNil)
+	c.Check(scheds, DeepEquals, []*timeutil.Schedule{{
+		WeekSpans: []timeutil.WeekSpan{
+			{Start: time.Monday, End: time.Friday},
+			{Start: time.Sunday, End: time.Sunday},
+		},
+		ClockSpans: []timeutil.ClockSpan{
+			{Start: timeutil.Clock{Hour: 10}, End: timeutil.Clock{Hour: 12, Minute: 30}, Spread: true},
+		},
+	}})
+}
+
+func (s *scheduleSuite) TestParseScheduleErrors(c *C) {
+	for _, t := range []struct {
+		in  string
+		err string
+	}{
+		{"", `cannot parse schedule: empty schedule`},
+		{"mon", `cannot parse schedule "mon": no time given in "mon"`},
+		{"10:00,mon", `cannot parse schedule "10:00,mon": weekdays must come before times`},
+		{"moo,10:00", `cannot parse schedule "moo,10:00": "moo" is not a valid weekday`},
+		{"fri-mon,10:00", `cannot parse schedule "fri-mon,10:00": "fri-mon" is not a valid range of weekdays`},
+		{"24:00", `cannot parse schedule "24:00": "24:00" is not a valid time`},
+		{"10:60", `cannot parse schedule "10:60": "10:60" is not a valid time`},
+		{"10:0", `cannot parse schedule "10:0": "10:0" is not a valid time`},
+		{"1:2:3", `cannot parse schedule "1:2:3": "1:2:3" is not a valid time`},
+		{"12:00~10:00", `cannot parse schedule "12:00~10:00": "12:00~10:00" is not a valid window: it must end after it starts`},
+		{"10:00~10:00", `cannot parse schedule "10:00~10:00": "10:00~10:00" is not a valid window: it must end after it starts`},
+		{"10:00,,", `cannot parse schedule "10:00,,": "" is not a valid weekday`},
+	} {
+		_, err := timeutil.ParseSchedule(t.in)
+		c.Check(err, ErrorMatches, regexp.QuoteMeta(t.err), Commentf(t.in))
+	}
+}
+
+func (s *scheduleSuite) TestClockSpanPick(c *C) {
+	fixed := timeutil.ClockSpan{Start: timeutil.Clock{Hour: 10, Minute: 5}, End: timeutil.Clock{Hour: 10, Minute: 5}}
+	c.Check(fixed.Pick("foo"), Equals, timeutil.Clock{Hour: 10, Minute: 5})
+
+	spread := timeutil.ClockSpan{Start: timeutil.Clock{Hour: 10, Minute: 50}, End: timeutil.Clock{Hour: 11, Minute: 10}, Spread: true}
+	seen := make(map[timeutil.Clock]bool)
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("snap.app%d", i)
+		t := spread.Pick(key)
+		m := t.Hour*60 + t.Minute
+		c.Assert(m >= 10*60+50 && m < 11*60+10, Equals, true, Commentf("%s", t))
+		// the same key always gets the same time
+		c.Check(spread.Pick(key), Equals, t)
+		seen[t] = true
+	}
+	// different keys are spread over the window
+	c.Check(len(seen) > 1, Equals, true)
+}
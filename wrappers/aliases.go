@@ -0,0 +1,101 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package wrappers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ubuntu-core/snappy/dirs"
+)
+
+// Alias is an alias of an app, a symlink next to the wrappers that
+// runs the app as its wrapper would.
+type Alias struct {
+	Name string
+	// Target is the name of the wrapper of the app, "<snap>.<app>".
+	Target string
+}
+
+func (a *Alias) path() string {
+	return filepath.Join(dirs.SnapBinariesDir, a.Name)
+}
+
+// exists returns whether the alias is already in place, failing if
+// another file is in its place.
+func (a *Alias) exists() (bool, error) {
+	target, err := os.Readlink(a.path())
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil || target != a.Target {
+		return false, fmt.Errorf("cannot create alias %q: %q already exists", a.Name, a.path())
+	}
+	return true, nil
+}
+
+// AddAliases creates the symlinks of the given aliases, either all of
+// them or, on conflict with other files, none.
+func AddAliases(aliases []*Alias) (err error) {
+	if err := os.MkdirAll(dirs.SnapBinariesDir, 0755); err != nil {
+		return err
+	}
+
+	var added []*Alias
+	defer func() {
+		if err != nil {
+			for _, alias := range added {
+				os.Remove(alias.path())
+			}
+		}
+	}()
+
+	for _, alias := range aliases {
+		exists, err := alias.exists()
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		if err := os.Symlink(alias.Target, alias.path()); err != nil {
+			return fmt.Errorf("cannot create alias %q: %v", alias.Name, err)
+		}
+		added = append(added, alias)
+	}
+
+	return nil
+}
+
+// RemoveAliases removes the symlinks of the given aliases, leaving
+// alone other files in their place.
+func RemoveAliases(aliases []*Alias) error {
+	for _, alias := range aliases {
+		target, err := os.Readlink(alias.path())
+		if err != nil || target != alias.Target {
+			continue
+		}
+		if err := os.Remove(alias.path()); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("cannot remove alias %q: %v", alias.Name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2016 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package wrappers_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/ubuntu-core/snappy/dirs"
+	"github.com/ubuntu-core/snappy/wrappers"
+)
+
+type aliasesTestSuite struct {
+	tempdir string
+}
+
+var _ = Suite(&aliasesTestSuite{})
+
+func (s *aliasesTestSuite) SetUpTest(c *C) {
+	s.tempdir = c.MkDir()
+	dirs.SetRootDir(s.tempdir)
+}
+
+func (s *aliasesTestSuite) TearDownTest(c *C) {
+	dirs.SetRootDir("")
+}
+
+func (s *aliasesTestSuite) TestAddAliasesAndRemove(c *C) {
+	aliases := []*wrappers.Alias{
+		{Name: "convert", Target: "tool.convert"},
+		{Name: "tool", Target: "tool"},
+	}
+
+	err := wrappers.AddAliases(aliases)
+	c.Assert(err, IsNil)
+
+	target, err := os.Readlink(filepath.Join(dirs.SnapBinariesDir, "convert"))
+	c.Assert(err, IsNil)
+	c.Check(target, Equals, "tool.convert")
+	target, err = os.Readlink(filepath.Join(dirs.SnapBinariesDir, "tool"))
+	c.Assert(err, IsNil)
+	c.Check(target, Equals, "tool")
+
+	// adding them again is fine
+	err = wrappers.AddAliases(aliases)
+	c.Assert(err, IsNil)
+
+	err = wrappers.RemoveAliases(aliases)
+	c.Assert(err, IsNil)
+
+	l, err := filepath.Glob(filepath.Join(dirs.SnapBinariesDir, "*"))
+	c.Assert(err, IsNil)
+	c.Check(l, HasLen, 0)
+}
+
+func (s *aliasesTestSuite) TestAddAliasesConflictAddsNone(c *C) {
+	c.Assert(os.MkdirAll(dirs.SnapBinariesDir, 0755), IsNil)
+	other := filepath.Join(dirs.SnapBinariesDir, "resize")
+	c.Assert(os.Symlink("other.resize", other), IsNil)
+
+	err := wrappers.AddAliases([]*wrappers.Alias{
+		{Name: "convert", Target: "tool.convert"},
+		{Name: "resize", Target: "tool.resize"},
+	})
+	c.Assert(err, ErrorMatches, `cannot create alias "resize": ".*/snap/bin/resize" already exists`)
+
+	_, err = os.Lstat(filepath.Join(dirs.SnapBinariesDir, "convert"))
+	c.Check(os.IsNotExist(err), Equals, true)
+	target, err := os.Readlink(other)
+	c.Assert(err, IsNil)
+	c.Check(target, Equals, "other.resize")
+}
+
+func (s *aliasesTestSuite) TestRemoveAliasesLeavesOtherFiles(c *C) {
+	c.Assert(os.MkdirAll(dirs.SnapBinariesDir, 0755), IsNil)
+	other := filepath.Join(dirs.SnapBinariesDir, "convert")
+	c.Assert(ioutil.WriteFile(other, nil, 0755), IsNil)
+
+	err := wrappers.RemoveAliases([]*wrappers.Alias{{Name: "convert", Target: "tool.convert"}})
+	c.Assert(err, IsNil)
+
+	_, err = os.Lstat(other)
+	c.Check(err, IsNil)
+}
@@ -21,87 +21,21 @@
 package wrappers
 
 import (
-	"bytes"
+	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
-	"text/template"
 
-	"github.com/ubuntu-core/snappy/arch"
 	"github.com/ubuntu-core/snappy/dirs"
-	"github.com/ubuntu-core/snappy/logger"
 	"github.com/ubuntu-core/snappy/osutil"
 	"github.com/ubuntu-core/snappy/snap"
-	"github.com/ubuntu-core/snappy/snap/snapenv"
 )
 
-// Doesn't need to handle complications like internal quotes, just needs to
-// wrap right side of an env variable declaration with quotes for the shell.
-func quoteEnvVar(envVar string) string {
-	return "export " + strings.Replace(envVar, "=", "=\"", 1) + "\""
-}
-
-func generateSnapBinaryWrapper(app *snap.AppInfo) (string, error) {
-	wrapperTemplate := `#!/bin/sh
-set -e
-
-# snap info
-{{.EnvVars}}
-
-if [ ! -d "$SNAP_USER_DATA" ]; then
-   mkdir -p "$SNAP_USER_DATA"
-fi
-export HOME="$SNAP_USER_DATA"
-
-# Snap name is: {{.App.Snap.Name}}
-# App name is: {{.App.Name}}
-
-{{.App.LauncherCommand}} "$@"
-`
-
-	if err := snap.ValidateApp(app); err != nil {
-		return "", err
-	}
-
-	var templateOut bytes.Buffer
-	t := template.Must(template.New("wrapper").Parse(wrapperTemplate))
-	wrapperData := struct {
-		App     *snap.AppInfo
-		EnvVars string
-		// XXX: needed by snapenv
-		SnapName string
-		SnapArch string
-		SnapPath string
-		Version  string
-		Revision int
-		Home     string
-	}{
-		App: app,
-		// XXX: needed by snapenv
-		SnapName: app.Snap.Name(),
-		SnapArch: arch.UbuntuArchitecture(),
-		SnapPath: app.Snap.MountDir(),
-		Version:  app.Snap.Version,
-		Revision: app.Snap.Revision,
-		Home:     "$HOME",
-	}
-
-	envVars := []string{}
-	for _, envVar := range append(
-		snapenv.GetBasicSnapEnvVars(wrapperData),
-		snapenv.GetUserSnapEnvVars(wrapperData)...) {
-		envVars = append(envVars, quoteEnvVar(envVar))
-	}
-	wrapperData.EnvVars = strings.Join(envVars, "\n")
-
-	if err := t.Execute(&templateOut, wrapperData); err != nil {
-		// this can never happen, except we forget a variable
-		logger.Panicf("Unable to execute template: %v", err)
-	}
-
-	return templateOut.String(), nil
-}
+// snapBinary is what the wrappers of the apps link to; "snap run" then
+// runs the app the wrapper is named after.
+const snapBinary = "/usr/bin/snap"
 
-// AddSnapBinaries writes the wrapper binaries for the applications from the snap which aren't services.
+// AddSnapBinaries links the wrapper binaries for the applications from the snap which aren't services.
 func AddSnapBinaries(s *snap.Info) error {
 	if err := os.MkdirAll(dirs.SnapBinariesDir, 0755); err != nil {
 		return err
@@ -112,23 +46,62 @@ func AddSnapBinaries(s *snap.Info) error {
 			continue
 		}
 
-		content, err := generateSnapBinaryWrapper(app)
-		if err != nil {
+		if err := snap.ValidateApp(app); err != nil {
 			return err
 		}
 
-		if err := osutil.AtomicWriteFile(app.WrapperPath(), []byte(content), 0755, 0); err != nil {
+		if err := osutil.AtomicSymlink(snapBinary, app.WrapperPath()); err != nil {
 			return err
 		}
+
+		if app.Completer != "" {
+			if err := addCompleter(app); err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
 }
 
+// addCompleter links the completer of the app into the completers
+// directory, under the name of the app's wrapper, for the bash
+// completion loader of snapd to find; files not put there for the snap
+// of the app are left alone.
+func addCompleter(app *snap.AppInfo) error {
+	if err := os.MkdirAll(dirs.CompletersDir, 0755); err != nil {
+		return err
+	}
+
+	completerPath := app.CompleterPath()
+	_, err := os.Lstat(completerPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err == nil && !ownsCompleter(app) {
+		return fmt.Errorf("cannot create completer for app %q: %q already exists", app.Name, completerPath)
+	}
+
+	return osutil.AtomicSymlink(filepath.Join(app.Snap.MountDir(), app.Completer), completerPath)
+}
+
+// ownsCompleter returns whether the completer link of the app is one
+// made for a revision of its snap.
+func ownsCompleter(app *snap.AppInfo) bool {
+	target, err := os.Readlink(app.CompleterPath())
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(target, filepath.Join(dirs.SnapSnapsDir, app.Snap.Name())+"/")
+}
+
 // RemoveSnapBinaries removes the wrapper binaries for the applications from the snap which aren't services from.
 func RemoveSnapBinaries(s *snap.Info) error {
 	for _, app := range s.Apps {
 		os.Remove(app.WrapperPath())
+		if app.Completer != "" && ownsCompleter(app) {
+			os.Remove(app.CompleterPath())
+		}
 	}
 
 	return nil
@@ -20,16 +20,14 @@
 package wrappers_test
 
 import (
-	"fmt"
 	"io/ioutil"
+	"os"
 	"path/filepath"
-	"regexp"
 	"testing"
 
 	. "gopkg.in/check.v1"
 
 	"github.com/ubuntu-core/snappy/dirs"
-	"github.com/ubuntu-core/snappy/osutil"
 	"github.com/ubuntu-core/snappy/snap"
 	"github.com/ubuntu-core/snappy/snap/snaptest"
 	"github.com/ubuntu-core/snappy/wrappers"
@@ -74,17 +72,107 @@ func (s *binariesTestSuite) TestAddSnapBinariesAndRemove(c *C) {
 
 	wrapper := filepath.Join(s.tempdir, "/snap/bin/hello-snap.hello")
 
-	content, err := ioutil.ReadFile(wrapper)
+	target, err := os.Readlink(wrapper)
 	c.Assert(err, IsNil)
+	c.Check(target, Equals, "/usr/bin/snap")
 
-	needle := fmt.Sprintf(`
-/usr/bin/ubuntu-core-launcher snap.hello-snap.hello snap.hello-snap.hello %s/snap/hello-snap/11/bin/hello "$@"
-`, s.tempdir)
+	// the service gets no wrapper
+	_, err = os.Lstat(filepath.Join(s.tempdir, "/snap/bin/hello-snap.svc1"))
+	c.Check(os.IsNotExist(err), Equals, true)
 
-	c.Assert(string(content), Matches, "(?ms).*"+regexp.QuoteMeta(needle)+".*")
+	err = wrappers.RemoveSnapBinaries(info)
+	c.Assert(err, IsNil)
+
+	_, err = os.Lstat(wrapper)
+	c.Check(os.IsNotExist(err), Equals, true)
+}
+
+func (s *binariesTestSuite) TestAddSnapBinariesReplacesOldWrapper(c *C) {
+	info := snaptest.MockSnap(c, packageHello, &snap.SideInfo{Revision: 11})
+
+	wrapper := filepath.Join(s.tempdir, "/snap/bin/hello-snap.hello")
+	c.Assert(os.MkdirAll(filepath.Dir(wrapper), 0755), IsNil)
+	c.Assert(ioutil.WriteFile(wrapper, []byte("#!/bin/sh\n"), 0755), IsNil)
+
+	err := wrappers.AddSnapBinaries(info)
+	c.Assert(err, IsNil)
+
+	target, err := os.Readlink(wrapper)
+	c.Assert(err, IsNil)
+	c.Check(target, Equals, "/usr/bin/snap")
+}
+
+func (s *binariesTestSuite) TestAddSnapBinariesIllegalChars(c *C) {
+	info := &snap.Info{SideInfo: snap.SideInfo{OfficialName: "hello-snap"}}
+	info.Apps = map[string]*snap.AppInfo{
+		"hello": {Snap: info, Name: "bin/hello\nSomething nasty", Command: "bin/hello"},
+	}
+
+	err := wrappers.AddSnapBinaries(info)
+	c.Assert(err, NotNil)
+}
+
+const packageCompleter = `name: hello-snap
+version: 1.10
+apps:
+ hello:
+   command: bin/hello
+   completer: bin/hello-complete
+`
+
+func (s *binariesTestSuite) TestAddSnapBinariesCompleterAndRemove(c *C) {
+	info := snaptest.MockSnap(c, packageCompleter, &snap.SideInfo{Revision: 11})
+
+	err := wrappers.AddSnapBinaries(info)
+	c.Assert(err, IsNil)
+
+	completer := filepath.Join(s.tempdir, "/var/lib/snapd/complete/hello-snap.hello")
+	target, err := os.Readlink(completer)
+	c.Assert(err, IsNil)
+	c.Check(target, Equals, filepath.Join(info.MountDir(), "bin/hello-complete"))
 
 	err = wrappers.RemoveSnapBinaries(info)
 	c.Assert(err, IsNil)
 
-	c.Check(osutil.FileExists(wrapper), Equals, false)
+	_, err = os.Lstat(completer)
+	c.Check(os.IsNotExist(err), Equals, true)
+}
+
+func (s *binariesTestSuite) TestAddSnapBinariesCompleterReplacesOwn(c *C) {
+	info := snaptest.MockSnap(c, packageCompleter, &snap.SideInfo{Revision: 10})
+	c.Assert(wrappers.AddSnapBinaries(info), IsNil)
+
+	// a refresh links the completer of the new revision
+	info = snaptest.MockSnap(c, packageCompleter, &snap.SideInfo{Revision: 11})
+	c.Assert(wrappers.AddSnapBinaries(info), IsNil)
+
+	target, err := os.Readlink(info.Apps["hello"].CompleterPath())
+	c.Assert(err, IsNil)
+	c.Check(target, Equals, filepath.Join(info.MountDir(), "bin/hello-complete"))
+}
+
+func (s *binariesTestSuite) TestAddSnapBinariesCompleterLeavesOthersAlone(c *C) {
+	info := snaptest.MockSnap(c, packageCompleter, &snap.SideInfo{Revision: 11})
+	completer := info.Apps["hello"].CompleterPath()
+	c.Assert(os.MkdirAll(filepath.Dir(completer), 0755), IsNil)
+
+	for _, other := range []string{"/snap/other-snap/1/complete", "/usr/share/hello/complete"} {
+		os.Remove(completer)
+		c.Assert(os.Symlink(other, completer), IsNil)
+
+		err := wrappers.AddSnapBinaries(info)
+		c.Check(err, ErrorMatches, `cannot create completer for app "hello": ".*/hello-snap.hello" already exists`)
+
+		c.Assert(wrappers.RemoveSnapBinaries(info), IsNil)
+		target, err := os.Readlink(completer)
+		c.Assert(err, IsNil)
+		c.Check(target, Equals, other)
+	}
+
+	os.Remove(completer)
+	c.Assert(ioutil.WriteFile(completer, nil, 0644), IsNil)
+	c.Check(wrappers.AddSnapBinaries(info), ErrorMatches, `cannot create completer .* already exists`)
+	c.Assert(wrappers.RemoveSnapBinaries(info), IsNil)
+	_, err := os.Stat(completer)
+	c.Check(err, IsNil)
 }
@@ -25,12 +25,10 @@ import (
 
 // some internal helper exposed for testing
 var (
-	// binaries
-	GenerateSnapBinaryWrapper = generateSnapBinaryWrapper
-
 	// services
 	GenerateSnapServiceFile = generateSnapServiceFile
 	GenerateSnapSocketFile  = generateSnapSocketFile
+	GenerateSnapTimerFile   = generateSnapTimerFile
 
 	// desktop
 	SanitizeDesktopFile = sanitizeDesktopFile
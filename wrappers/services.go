@@ -24,6 +24,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"text/template"
 	"time"
@@ -36,6 +37,7 @@ import (
 	"github.com/ubuntu-core/snappy/snap/snapenv"
 	"github.com/ubuntu-core/snappy/systemd"
 	"github.com/ubuntu-core/snappy/timeout"
+	"github.com/ubuntu-core/snappy/timeutil"
 )
 
 type interacter interface {
@@ -45,6 +47,22 @@ type interacter interface {
 // wait this time between TERM and KILL
 var killWait = 5 * time.Second
 
+// appEnvVars returns the extra environment variables of the app, as
+// "NAME=value", sorted by name.
+func appEnvVars(app *snap.AppInfo) []string {
+	names := make([]string, 0, len(app.Environment))
+	for name := range app.Environment {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	envVars := make([]string, len(names))
+	for i, name := range names {
+		envVars[i] = name + "=" + app.Environment[name]
+	}
+	return envVars
+}
+
 func serviceStopTimeout(app *snap.AppInfo) time.Duration {
 	tout := app.StopTimeout
 	if tout == 0 {
@@ -75,8 +93,25 @@ func generateSnapSocketFile(app *snap.AppInfo) (string, error) {
 	return genSocketFile(app), nil
 }
 
+func generateSnapTimerFile(app *snap.AppInfo) (string, error) {
+	if err := snap.ValidateApp(app); err != nil {
+		return "", err
+	}
+
+	scheds, err := timeutil.ParseSchedule(app.Timer)
+	if err != nil {
+		return "", err
+	}
+
+	return genTimerFile(app, scheds), nil
+}
+
 // AddSnapServices adds and starts service units for the applications from the snap which are services.
 func AddSnapServices(s *snap.Info, inter interacter) error {
+	if err := removeStaleServices(s, inter); err != nil {
+		return err
+	}
+
 	for _, app := range s.Apps {
 		if app.Daemon == "" {
 			continue
@@ -103,6 +138,18 @@ func AddSnapServices(s *snap.Info, inter interacter) error {
 				return err
 			}
 		}
+		// Generate systemd timer file if needed
+		if app.Timer != "" {
+			content, err := generateSnapTimerFile(app)
+			if err != nil {
+				return err
+			}
+			timerFilePath := app.TimerFile()
+			os.MkdirAll(filepath.Dir(timerFilePath), 0755)
+			if err := osutil.AtomicWriteFile(timerFilePath, []byte(content), 0644, 0); err != nil {
+				return err
+			}
+		}
 		// daemon-reload and enable plus start
 		serviceName := filepath.Base(app.ServiceFile())
 		sysd := systemd.New(dirs.GlobalRootDir, inter)
@@ -111,6 +158,18 @@ func AddSnapServices(s *snap.Info, inter interacter) error {
 			return err
 		}
 
+		// the service of a timer app is only run by its timer
+		if app.Timer != "" {
+			timerName := filepath.Base(app.TimerFile())
+			if err := sysd.Enable(timerName); err != nil {
+				return err
+			}
+			if err := sysd.Start(timerName); err != nil {
+				return err
+			}
+			continue
+		}
+
 		// enable the service
 		if err := sysd.Enable(serviceName); err != nil {
 			return err
@@ -138,6 +197,17 @@ func AddSnapServices(s *snap.Info, inter interacter) error {
 
 // RemoveSnapServices stops and removes service units for the applications from the snap which are services.
 func RemoveSnapServices(s *snap.Info, inter interacter) error {
+	return removeSnapServices(s, false, inter)
+}
+
+// RemoveSnapServicesForRefresh is like RemoveSnapServices, except that
+// the services of the apps with refresh-mode "endure" are left
+// running, for the services of the next revision to take over.
+func RemoveSnapServicesForRefresh(s *snap.Info, inter interacter) error {
+	return removeSnapServices(s, true, inter)
+}
+
+func removeSnapServices(s *snap.Info, refresh bool, inter interacter) error {
 	sysd := systemd.New(dirs.GlobalRootDir, inter)
 
 	nservices := 0
@@ -146,22 +216,32 @@ func RemoveSnapServices(s *snap.Info, inter interacter) error {
 		if app.Daemon == "" {
 			continue
 		}
+		if refresh && app.RefreshMode == "endure" {
+			// keep it running and enabled, and its units in
+			// place until the new revision replaces them
+			continue
+		}
 		nservices++
 
 		serviceName := filepath.Base(app.ServiceFile())
-		if err := sysd.Disable(serviceName); err != nil {
-			return err
-		}
-		tout := serviceStopTimeout(app)
-		if err := sysd.Stop(serviceName, tout); err != nil {
-			if !systemd.IsTimeout(err) {
+		switch {
+		case app.Timer != "":
+			if err := disableAndStopTimer(sysd, app); err != nil {
+				return err
+			}
+			if err := stopService(sysd, app, inter); err != nil {
+				return err
+			}
+		default:
+			if err := sysd.Disable(serviceName); err != nil {
+				return err
+			}
+			if err := disableAndStopSocket(sysd, app); err != nil {
+				return err
+			}
+			if err := stopService(sysd, app, inter); err != nil {
 				return err
 			}
-			inter.Notify(fmt.Sprintf("%s refused to stop, killing.", serviceName))
-			// ignore errors for kill; nothing we'd do differently at this point
-			sysd.Kill(serviceName, "TERM")
-			time.Sleep(killWait)
-			sysd.Kill(serviceName, "KILL")
 		}
 
 		if err := os.Remove(app.ServiceFile()); err != nil && !os.IsNotExist(err) {
@@ -171,6 +251,10 @@ func RemoveSnapServices(s *snap.Info, inter interacter) error {
 		if err := os.Remove(app.ServiceSocketFile()); err != nil && !os.IsNotExist(err) {
 			logger.Noticef("Failed to remove socket file for %q: %v", serviceName, err)
 		}
+
+		if err := os.Remove(app.TimerFile()); err != nil && !os.IsNotExist(err) {
+			logger.Noticef("Failed to remove timer file for %q: %v", serviceName, err)
+		}
 	}
 
 	// only reload if we actually had services
@@ -183,45 +267,309 @@ func RemoveSnapServices(s *snap.Info, inter interacter) error {
 	return nil
 }
 
+// removeStaleServices stops and removes the service, socket and timer
+// units of the snap that no app of s calls for. These are the units
+// of the apps with refresh-mode "endure" that were left in place when
+// refreshing to s, if s dropped the apps or made them non-daemons.
+func removeStaleServices(s *snap.Info, inter interacter) error {
+	sysd := systemd.New(dirs.GlobalRootDir, inter)
+
+	prefix := "snap." + s.Name() + "."
+	unitFiles, err := filepath.Glob(filepath.Join(dirs.SnapServicesDir, prefix+"*"))
+	if err != nil {
+		return err
+	}
+
+	nstale := 0
+	for _, unitFile := range unitFiles {
+		unitName := filepath.Base(unitFile)
+		ext := filepath.Ext(unitName)
+		app := s.Apps[strings.TrimSuffix(strings.TrimPrefix(unitName, prefix), ext)]
+		daemon := app != nil && app.Daemon != ""
+		switch ext {
+		case ".service":
+			if daemon {
+				continue
+			}
+		case ".socket":
+			if daemon && app.Socket {
+				continue
+			}
+		case ".timer":
+			if daemon && app.Timer != "" {
+				continue
+			}
+		default:
+			continue
+		}
+		nstale++
+
+		if err := sysd.Disable(unitName); err != nil {
+			return err
+		}
+		if err := stopUnit(sysd, unitName, time.Duration(timeout.DefaultTimeout), inter); err != nil {
+			return err
+		}
+		if err := os.Remove(unitFile); err != nil && !os.IsNotExist(err) {
+			logger.Noticef("Failed to remove stale unit file %q: %v", unitName, err)
+		}
+	}
+
+	if nstale > 0 {
+		if err := sysd.DaemonReload(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// stopService stops the service of app, killing it if it refuses to
+// stop within its stop timeout.
+func stopService(sysd systemd.Systemd, app *snap.AppInfo, inter interacter) error {
+	return stopUnit(sysd, filepath.Base(app.ServiceFile()), serviceStopTimeout(app), inter)
+}
+
+// stopUnit stops the named unit, killing it if it refuses to stop
+// within tout.
+func stopUnit(sysd systemd.Systemd, unitName string, tout time.Duration, inter interacter) error {
+	if err := sysd.Stop(unitName, tout); err != nil {
+		if !systemd.IsTimeout(err) {
+			return err
+		}
+		inter.Notify(fmt.Sprintf("%s refused to stop, killing.", unitName))
+		// ignore errors for kill; nothing we'd do differently at this point
+		sysd.Kill(unitName, "TERM")
+		time.Sleep(killWait)
+		sysd.Kill(unitName, "KILL")
+	}
+	return nil
+}
+
+// disableAndStopTimer disables and stops the timer of the timer app,
+// so that it doesn't run the app anymore.
+func disableAndStopTimer(sysd systemd.Systemd, app *snap.AppInfo) error {
+	timerName := filepath.Base(app.TimerFile())
+	if err := sysd.Disable(timerName); err != nil {
+		return err
+	}
+	return sysd.Stop(timerName, serviceStopTimeout(app))
+}
+
+// disableAndStopSocket disables and stops the socket of the app, if
+// it has one, so that it doesn't activate the service anymore.
+func disableAndStopSocket(sysd systemd.Systemd, app *snap.AppInfo) error {
+	if !app.Socket {
+		return nil
+	}
+	socketName := filepath.Base(app.ServiceSocketFile())
+	if err := sysd.Disable(socketName); err != nil {
+		return err
+	}
+	return sysd.Stop(socketName, serviceStopTimeout(app))
+}
+
+// StartServices starts the services of the given apps, and their
+// sockets; for timer apps, it starts their timers instead.
+func StartServices(apps []*snap.AppInfo, inter interacter) error {
+	sysd := systemd.New(dirs.GlobalRootDir, inter)
+
+	for _, app := range apps {
+		if app.Daemon == "" {
+			continue
+		}
+		if app.Timer != "" {
+			if err := sysd.Start(filepath.Base(app.TimerFile())); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := sysd.Start(filepath.Base(app.ServiceFile())); err != nil {
+			return err
+		}
+		if app.Socket {
+			if err := sysd.Start(filepath.Base(app.ServiceSocketFile())); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// StopServices stops the services of the given apps, killing the ones
+// that refuse to stop, together with their sockets, so that these
+// don't activate them again, and the timers of timer apps.
+func StopServices(apps []*snap.AppInfo, inter interacter) error {
+	sysd := systemd.New(dirs.GlobalRootDir, inter)
+
+	for _, app := range apps {
+		if app.Daemon == "" {
+			continue
+		}
+		if app.Timer != "" {
+			if err := sysd.Stop(filepath.Base(app.TimerFile()), serviceStopTimeout(app)); err != nil {
+				return err
+			}
+		}
+		if app.Socket {
+			if err := sysd.Stop(filepath.Base(app.ServiceSocketFile()), serviceStopTimeout(app)); err != nil {
+				return err
+			}
+		}
+		if err := stopService(sysd, app, inter); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RestartServices restarts the services of the given apps through
+// systemd; for timer apps, it restarts their timers instead.
+func RestartServices(apps []*snap.AppInfo, inter interacter) error {
+	sysd := systemd.New(dirs.GlobalRootDir, inter)
+
+	for _, app := range apps {
+		if app.Daemon == "" {
+			continue
+		}
+		unit := app.ServiceFile()
+		if app.Timer != "" {
+			unit = app.TimerFile()
+		}
+		if err := sysd.Restart(filepath.Base(unit)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// EnableServices enables the services of the given apps, and their
+// sockets, so that they start on boot; for timer apps, it enables their
+// timers instead.
+func EnableServices(apps []*snap.AppInfo, inter interacter) error {
+	sysd := systemd.New(dirs.GlobalRootDir, inter)
+
+	for _, app := range apps {
+		if app.Daemon == "" {
+			continue
+		}
+		if app.Timer != "" {
+			if err := sysd.Enable(filepath.Base(app.TimerFile())); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := sysd.Enable(filepath.Base(app.ServiceFile())); err != nil {
+			return err
+		}
+		if app.Socket {
+			if err := sysd.Enable(filepath.Base(app.ServiceSocketFile())); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// DisableServices disables the services of the given apps, and their
+// sockets, so that they don't start on boot; for timer apps, it
+// disables their timers instead.
+func DisableServices(apps []*snap.AppInfo, inter interacter) error {
+	sysd := systemd.New(dirs.GlobalRootDir, inter)
+
+	for _, app := range apps {
+		if app.Daemon == "" {
+			continue
+		}
+		if app.Timer != "" {
+			if err := sysd.Disable(filepath.Base(app.TimerFile())); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := sysd.Disable(filepath.Base(app.ServiceFile())); err != nil {
+			return err
+		}
+		if app.Socket {
+			if err := sysd.Disable(filepath.Base(app.ServiceSocketFile())); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// siblingServiceName returns the name of the service unit of the
+// other app of the same snap as appInfo with the given name.
+func siblingServiceName(appInfo *snap.AppInfo, name string) string {
+	sibling := &snap.AppInfo{Snap: appInfo.Snap, Name: name}
+	return filepath.Base(sibling.ServiceFile())
+}
+
 func genServiceFile(appInfo *snap.AppInfo) string {
 	serviceTemplate := `[Unit]
 # Auto-generated, DO NO EDIT
 Description=Service for snap application {{.App.Snap.Name}}.{{.App.Name}}
-After=snapd.frameworks.target{{ if .App.Socket }} {{.SocketFileName}}{{end}}
+After=snapd.frameworks.target{{ if .App.Socket }} {{.SocketFileName}}{{end}}{{range .After}} {{.}}{{end}}
 Requires=snapd.frameworks.target{{ if .App.Socket }} {{.SocketFileName}}{{end}}
-X-Snappy=yes
+{{if .Before}}Before={{.Before}}
+{{end}}X-Snappy=yes
 
 [Service]
 ExecStart={{.App.LauncherCommand}}
 Restart={{.Restart}}
-WorkingDirectory={{.App.Snap.DataDir}}
+{{if .RestartDelay}}RestartSec={{.RestartDelay.Seconds}}
+{{end}}{{if .WatchdogTimeout}}WatchdogSec={{.WatchdogTimeout.Seconds}}
+{{end}}WorkingDirectory={{.App.Snap.DataDir}}
 Environment={{.EnvVars}}
 {{if .App.StopCommand}}ExecStop={{.App.LauncherStopCommand}}{{end}}
 {{if .App.PostStopCommand}}ExecStopPost={{.App.LauncherPostStopCommand}}{{end}}
 {{if .StopTimeout}}TimeoutStopSec={{.StopTimeout.Seconds}}{{end}}
 Type={{.App.Daemon}}
 {{if .App.BusName}}BusName={{.App.BusName}}{{end}}
-
+{{if not .App.Timer}}
 [Install]
 WantedBy={{.ServiceTargetUnit}}
-`
+{{end}}`
 	var templateOut bytes.Buffer
 	t := template.Must(template.New("wrapper").Parse(serviceTemplate))
 
 	restartCond := appInfo.RestartCond.String()
 	if restartCond == "" {
 		restartCond = systemd.RestartOnFailure.String()
+		// systemd refuses to restart oneshot services, which is
+		// what timer apps are
+		if appInfo.Daemon == "oneshot" {
+			restartCond = "no"
+		}
 	}
 	socketFileName := ""
 	if appInfo.Socket {
 		socketFileName = filepath.Base(appInfo.ServiceSocketFile())
 	}
+	before := make([]string, len(appInfo.Before))
+	for i, name := range appInfo.Before {
+		before[i] = siblingServiceName(appInfo, name)
+	}
+	after := make([]string, len(appInfo.After))
+	for i, name := range appInfo.After {
+		after[i] = siblingServiceName(appInfo, name)
+	}
 
 	wrapperData := struct {
 		App *snap.AppInfo
 
 		SocketFileName    string
+		Before            string
+		After             []string
 		Restart           string
+		RestartDelay      time.Duration
+		WatchdogTimeout   time.Duration
 		StopTimeout       time.Duration
 		ServiceTargetUnit string
 
@@ -237,7 +585,11 @@ WantedBy={{.ServiceTargetUnit}}
 		App: appInfo,
 
 		SocketFileName:    socketFileName,
+		Before:            strings.Join(before, " "),
+		After:             after,
 		Restart:           restartCond,
+		RestartDelay:      time.Duration(appInfo.RestartDelay),
+		WatchdogTimeout:   time.Duration(appInfo.WatchdogTimeout),
 		StopTimeout:       serviceStopTimeout(appInfo),
 		ServiceTargetUnit: systemd.ServicesTarget,
 
@@ -252,6 +604,10 @@ WantedBy={{.ServiceTargetUnit}}
 	}
 	allVars := snapenv.GetBasicSnapEnvVars(wrapperData)
 	allVars = append(allVars, snapenv.GetUserSnapEnvVars(wrapperData)...)
+	for _, envVar := range appEnvVars(appInfo) {
+		// % introduces specifiers in systemd units
+		allVars = append(allVars, strings.Replace(envVar, "%", "%%", -1))
+	}
 	wrapperData.EnvVars = "\"" + strings.Join(allVars, "\" \"") + "\"" // allVars won't be empty
 
 	if err := t.Execute(&templateOut, wrapperData); err != nil {
@@ -296,3 +652,66 @@ WantedBy={{.SocketTargetUnit}}
 
 	return templateOut.String()
 }
+
+func genTimerFile(appInfo *snap.AppInfo, scheds []*timeutil.Schedule) string {
+	timerTemplate := `[Unit]
+# Auto-generated, DO NO EDIT
+Description=Timer for snap application {{.App.Snap.Name}}.{{.App.Name}}
+X-Snappy=yes
+
+[Timer]
+Unit={{.ServiceFileName}}
+{{range .OnCalendar}}OnCalendar={{.}}
+{{end}}
+[Install]
+WantedBy={{.TimerTargetUnit}}
+`
+	var templateOut bytes.Buffer
+	t := template.Must(template.New("wrapper").Parse(timerTemplate))
+
+	wrapperData := struct {
+		App             *snap.AppInfo
+		ServiceFileName string
+		OnCalendar      []string
+		TimerTargetUnit string
+	}{
+		App:             appInfo,
+		ServiceFileName: filepath.Base(appInfo.ServiceFile()),
+		OnCalendar:      onCalendar(appInfo, scheds),
+		TimerTargetUnit: systemd.TimersTarget,
+	}
+
+	if err := t.Execute(&templateOut, wrapperData); err != nil {
+		// this can never happen, except we forget a variable
+		logger.Panicf("Unable to execute template: %v", err)
+	}
+
+	return templateOut.String()
+}
+
+// onCalendar returns the systemd calendar events of the schedules of
+// the timer app, one for each of their times. The times of the spread
+// windows are picked from the app and the position of the window, so
+// that they vary across apps but stay the same when the timer file is
+// generated again.
+func onCalendar(appInfo *snap.AppInfo, scheds []*timeutil.Schedule) []string {
+	var events []string
+	for i, sched := range scheds {
+		days := make([]string, len(sched.WeekSpans))
+		for i, span := range sched.WeekSpans {
+			days[i] = span.Start.String()[:3]
+			if span.End != span.Start {
+				days[i] += ".." + span.End.String()[:3]
+			}
+		}
+		prefix := ""
+		if len(days) > 0 {
+			prefix = strings.Join(days, ",") + " "
+		}
+		for j, span := range sched.ClockSpans {
+			key := fmt.Sprintf("%s.%s/%d/%d", appInfo.Snap.Name(), appInfo.Name, i, j)
+			events = append(events, fmt.Sprintf("%s*-*-* %s", prefix, span.Pick(key)))
+		}
+	}
+	return events
+}
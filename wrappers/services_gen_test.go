@@ -288,3 +288,128 @@ func (s *servicesWrapperGenSuite) TestGenerateSnapSocketFileMode(c *C) {
 	c.Assert(content, Matches, "(?ms).*SocketMode=0600")
 
 }
+
+func (s *servicesWrapperGenSuite) TestGenerateSnapServiceFileTimer(c *C) {
+	yamlText := `
+name: snap
+version: 1.0
+apps:
+    app:
+        command: bin/start
+        timer: "10:00"
+`
+	info, err := snap.InfoFromSnapYaml([]byte(yamlText))
+	c.Assert(err, IsNil)
+	info.Revision = 44
+
+	wrapperText, err := wrappers.GenerateSnapServiceFile(info.Apps["app"])
+	c.Assert(err, IsNil)
+	c.Check(wrapperText, Matches, "(?ms).*^Type=oneshot$.*")
+	c.Check(wrapperText, Matches, "(?ms).*^Restart=no$.*")
+	// only the timer starts it
+	c.Check(wrapperText, Not(Matches), "(?ms).*Install.*")
+}
+
+func (s *servicesWrapperGenSuite) TestGenerateSnapTimerFile(c *C) {
+	yamlText := `
+name: snap
+version: 1.0
+apps:
+    app:
+        command: bin/start
+        timer: mon-fri,sun,9:00,22:30,,sat,7:05
+`
+	info, err := snap.InfoFromSnapYaml([]byte(yamlText))
+	c.Assert(err, IsNil)
+	info.Revision = 44
+
+	content, err := wrappers.GenerateSnapTimerFile(info.Apps["app"])
+	c.Assert(err, IsNil)
+	c.Check(content, Equals, `[Unit]
+# Auto-generated, DO NO EDIT
+Description=Timer for snap application snap.app
+X-Snappy=yes
+
+[Timer]
+Unit=snap.snap.app.service
+OnCalendar=Mon..Fri,Sun *-*-* 09:00
+OnCalendar=Mon..Fri,Sun *-*-* 22:30
+OnCalendar=Sat *-*-* 07:05
+
+[Install]
+WantedBy=timers.target
+`)
+}
+
+func (s *servicesWrapperGenSuite) TestGenerateSnapTimerFileSpread(c *C) {
+	app := &snap.AppInfo{
+		Snap:    &snap.Info{SuggestedName: "snap"},
+		Name:    "app",
+		Command: "bin/start",
+		Daemon:  "oneshot",
+		Timer:   "23:00~23:59",
+	}
+
+	content, err := wrappers.GenerateSnapTimerFile(app)
+	c.Assert(err, IsNil)
+	c.Check(content, Matches, `(?ms).*^OnCalendar=\*-\*-\* 23:[0-5][0-9]$.*`)
+
+	// generating it again picks the same time
+	again, err := wrappers.GenerateSnapTimerFile(app)
+	c.Assert(err, IsNil)
+	c.Check(again, Equals, content)
+}
+
+func (s *servicesWrapperGenSuite) TestGenerateSnapTimerFileInvalid(c *C) {
+	app := &snap.AppInfo{
+		Snap:   &snap.Info{SuggestedName: "snap"},
+		Name:   "app",
+		Daemon: "oneshot",
+		Timer:  "someday",
+	}
+
+	_, err := wrappers.GenerateSnapTimerFile(app)
+	c.Assert(err, ErrorMatches, `"timer" field contains invalid value: .*`)
+}
+
+func (s *servicesWrapperGenSuite) TestGenerateSnapServiceFileDaemonOptions(c *C) {
+	yamlText := `
+name: snap
+version: 1.0
+apps:
+    db:
+        command: bin/db
+        daemon: simple
+        before: [api]
+        restart-delay: 5s
+        watchdog-timeout: 30s
+        environment:
+            PORT: 5432
+            DB_OPTS: --cache=50%
+    api:
+        command: bin/api
+        daemon: simple
+        after: [db]
+`
+	info, err := snap.InfoFromSnapYaml([]byte(yamlText))
+	c.Assert(err, IsNil)
+	info.Revision = 44
+
+	dbWrapper, err := wrappers.GenerateSnapServiceFile(info.Apps["db"])
+	c.Assert(err, IsNil)
+	c.Check(dbWrapper, Matches, `(?ms).*^Requires=snapd.frameworks.target
+Before=snap.snap.api.service
+X-Snappy=yes$.*`)
+	c.Check(dbWrapper, Matches, `(?ms).*^Restart=on-failure
+RestartSec=5
+WatchdogSec=30
+WorkingDirectory=.*`)
+	c.Check(dbWrapper, Matches, `(?ms).*^Environment=.* "SNAP_USER_DATA=/root/snap/snap/44" "DB_OPTS=--cache=50%%" "PORT=5432"$.*`)
+
+	apiWrapper, err := wrappers.GenerateSnapServiceFile(info.Apps["api"])
+	c.Assert(err, IsNil)
+	c.Check(apiWrapper, Matches, `(?ms).*^After=snapd.frameworks.target snap.snap.db.service
+Requires=snapd.frameworks.target
+X-Snappy=yes$.*`)
+	c.Check(apiWrapper, Not(Matches), `(?ms).*(RestartSec|WatchdogSec).*`)
+}
@@ -102,6 +102,163 @@ func (s *servicesTestSuite) TestAddSnapServicesAndRemove(c *C) {
 	c.Check(sysdLog[3], DeepEquals, []string{"daemon-reload"})
 }
 
+func (s *servicesTestSuite) TestAddSnapTimerAndRemove(c *C) {
+	var sysdLog [][]string
+	systemd.SystemctlCmd = func(cmd ...string) ([]byte, error) {
+		sysdLog = append(sysdLog, cmd)
+		return []byte("ActiveState=inactive\n"), nil
+	}
+
+	info := snaptest.MockSnap(c, `name: wat
+version: 42
+apps:
+ job:
+   command: job
+   timer: mon,10:00
+`, &snap.SideInfo{Revision: 11})
+
+	err := wrappers.AddSnapServices(info, nil)
+	c.Assert(err, IsNil)
+
+	svcFile := filepath.Join(s.tempdir, "/etc/systemd/system/snap.wat.job.service")
+	timerFile := filepath.Join(s.tempdir, "/etc/systemd/system/snap.wat.job.timer")
+	c.Check(osutil.FileExists(svcFile), Equals, true)
+	content, err := ioutil.ReadFile(timerFile)
+	c.Assert(err, IsNil)
+	c.Check(string(content), Matches, "(?ms).*^OnCalendar=Mon \\*-\\*-\\* 10:00$.*")
+
+	// the timer is enabled and started, not the service
+	c.Check(sysdLog, DeepEquals, [][]string{
+		{"daemon-reload"},
+		{"--root", dirs.GlobalRootDir, "enable", "snap.wat.job.timer"},
+		{"start", "snap.wat.job.timer"},
+	})
+
+	sysdLog = nil
+
+	err = wrappers.RemoveSnapServices(info, &progress.NullProgress{})
+	c.Assert(err, IsNil)
+
+	c.Check(osutil.FileExists(svcFile), Equals, false)
+	c.Check(osutil.FileExists(timerFile), Equals, false)
+
+	c.Assert(sysdLog, HasLen, 6)
+	c.Check(sysdLog[0], DeepEquals, []string{"--root", dirs.GlobalRootDir, "disable", "snap.wat.job.timer"})
+	c.Check(sysdLog[1], DeepEquals, []string{"stop", "snap.wat.job.timer"})
+	c.Check(sysdLog[3], DeepEquals, []string{"stop", "snap.wat.job.service"})
+	c.Check(sysdLog[5], DeepEquals, []string{"daemon-reload"})
+}
+
+func (s *servicesTestSuite) TestRemoveSnapServicesForRefresh(c *C) {
+	var sysdLog [][]string
+	systemd.SystemctlCmd = func(cmd ...string) ([]byte, error) {
+		sysdLog = append(sysdLog, cmd)
+		return []byte("ActiveState=inactive\n"), nil
+	}
+
+	info := snaptest.MockSnap(c, `name: wat
+version: 42
+apps:
+ db:
+   command: db
+   daemon: simple
+   refresh-mode: endure
+ api:
+   command: api
+   daemon: simple
+`, &snap.SideInfo{Revision: 11})
+
+	err := wrappers.AddSnapServices(info, nil)
+	c.Assert(err, IsNil)
+
+	sysdLog = nil
+
+	err = wrappers.RemoveSnapServicesForRefresh(info, &progress.NullProgress{})
+	c.Assert(err, IsNil)
+
+	// the enduring service is neither disabled nor stopped
+	c.Check(sysdLog, DeepEquals, [][]string{
+		{"--root", dirs.GlobalRootDir, "disable", "snap.wat.api.service"},
+		{"stop", "snap.wat.api.service"},
+		{"show", "--property=ActiveState", "snap.wat.api.service"},
+		{"daemon-reload"},
+	})
+	// the enduring service keeps its unit
+	c.Check(osutil.FileExists(info.Apps["db"].ServiceFile()), Equals, true)
+	c.Check(osutil.FileExists(info.Apps["api"].ServiceFile()), Equals, false)
+
+	sysdLog = nil
+
+	// but it's stopped for good on removal
+	err = wrappers.RemoveSnapServices(info, &progress.NullProgress{})
+	c.Assert(err, IsNil)
+	c.Check(sysdLog, HasLen, 7)
+}
+
+func (s *servicesTestSuite) TestAddSnapServicesRemovesStaleEnduringServices(c *C) {
+	var sysdLog [][]string
+	systemd.SystemctlCmd = func(cmd ...string) ([]byte, error) {
+		sysdLog = append(sysdLog, cmd)
+		return []byte("ActiveState=inactive\n"), nil
+	}
+
+	info := snaptest.MockSnap(c, `name: wat
+version: 42
+apps:
+ db:
+   command: db
+   daemon: simple
+   refresh-mode: endure
+ job:
+   command: job
+   timer: mon,10:00
+   refresh-mode: endure
+ api:
+   command: api
+   daemon: simple
+   refresh-mode: endure
+`, &snap.SideInfo{Revision: 11})
+	c.Assert(wrappers.AddSnapServices(info, nil), IsNil)
+	c.Assert(wrappers.RemoveSnapServicesForRefresh(info, &progress.NullProgress{}), IsNil)
+
+	// the new revision drops db, makes job a plain command and keeps api
+	newInfo := snaptest.MockSnap(c, `name: wat
+version: 43
+apps:
+ job:
+   command: job
+ api:
+   command: api
+   daemon: simple
+   refresh-mode: endure
+`, &snap.SideInfo{Revision: 12})
+
+	sysdLog = nil
+	c.Assert(wrappers.AddSnapServices(newInfo, nil), IsNil)
+
+	c.Check(osutil.FileExists(info.Apps["db"].ServiceFile()), Equals, false)
+	c.Check(osutil.FileExists(info.Apps["job"].ServiceFile()), Equals, false)
+	c.Check(osutil.FileExists(info.Apps["job"].TimerFile()), Equals, false)
+	c.Check(osutil.FileExists(newInfo.Apps["api"].ServiceFile()), Equals, true)
+
+	// the stale units are stopped and disabled before api is taken over
+	c.Check(sysdLog, DeepEquals, [][]string{
+		{"--root", dirs.GlobalRootDir, "disable", "snap.wat.db.service"},
+		{"stop", "snap.wat.db.service"},
+		{"show", "--property=ActiveState", "snap.wat.db.service"},
+		{"--root", dirs.GlobalRootDir, "disable", "snap.wat.job.service"},
+		{"stop", "snap.wat.job.service"},
+		{"show", "--property=ActiveState", "snap.wat.job.service"},
+		{"--root", dirs.GlobalRootDir, "disable", "snap.wat.job.timer"},
+		{"stop", "snap.wat.job.timer"},
+		{"show", "--property=ActiveState", "snap.wat.job.timer"},
+		{"daemon-reload"},
+		{"daemon-reload"},
+		{"--root", dirs.GlobalRootDir, "enable", "snap.wat.api.service"},
+		{"start", "snap.wat.api.service"},
+	})
+}
+
 func (s *servicesTestSuite) TestRemoveSnapPackageFallbackToKill(c *C) {
 	restore := wrappers.MockKillWait(200 * time.Millisecond)
 	defer restore()
@@ -139,3 +296,118 @@ apps:
 
 	c.Check(sysdLog[len(sysdLog)-1], DeepEquals, []string{"daemon-reload"})
 }
+
+func (s *servicesTestSuite) TestStartStopServices(c *C) {
+	var sysdLog [][]string
+	systemd.SystemctlCmd = func(cmd ...string) ([]byte, error) {
+		sysdLog = append(sysdLog, cmd)
+		return []byte("ActiveState=inactive\n"), nil
+	}
+
+	info := snaptest.MockSnap(c, packageHello, &snap.SideInfo{Revision: 12})
+	apps := []*snap.AppInfo{info.Apps["hello"], info.Apps["svc1"]}
+	svcFName := "snap.hello-snap.svc1.service"
+
+	err := wrappers.StartServices(apps, nil)
+	c.Assert(err, IsNil)
+	c.Check(sysdLog, DeepEquals, [][]string{{"start", svcFName}})
+
+	sysdLog = nil
+	err = wrappers.StopServices(apps, &progress.NullProgress{})
+	c.Assert(err, IsNil)
+	c.Assert(sysdLog, HasLen, 2)
+	c.Check(sysdLog[0], DeepEquals, []string{"stop", svcFName})
+	c.Check(sysdLog[1], DeepEquals, []string{"show", "--property=ActiveState", svcFName})
+}
+
+func (s *servicesTestSuite) TestStopServicesStopsSockets(c *C) {
+	var sysdLog [][]string
+	systemd.SystemctlCmd = func(cmd ...string) ([]byte, error) {
+		sysdLog = append(sysdLog, cmd)
+		return []byte("ActiveState=inactive\n"), nil
+	}
+
+	info := snaptest.MockSnap(c, `name: wat
+version: 42
+apps:
+ wat:
+   command: wat
+   daemon: simple
+   listen-stream: /var/run/wat.sock
+   socket: true
+`, &snap.SideInfo{Revision: 11})
+	apps := []*snap.AppInfo{info.Apps["wat"]}
+
+	err := wrappers.StopServices(apps, &progress.NullProgress{})
+	c.Assert(err, IsNil)
+	c.Check(sysdLog, DeepEquals, [][]string{
+		{"stop", "snap.wat.wat.socket"},
+		{"show", "--property=ActiveState", "snap.wat.wat.socket"},
+		{"stop", "snap.wat.wat.service"},
+		{"show", "--property=ActiveState", "snap.wat.wat.service"},
+	})
+}
+
+func (s *servicesTestSuite) TestRestartServices(c *C) {
+	var sysdLog [][]string
+	systemd.SystemctlCmd = func(cmd ...string) ([]byte, error) {
+		sysdLog = append(sysdLog, cmd)
+		return nil, nil
+	}
+
+	info := snaptest.MockSnap(c, `name: wat
+version: 42
+apps:
+ wat:
+   command: wat
+   daemon: simple
+ tick:
+   command: tick
+   daemon: oneshot
+   timer: 10:00
+ cli:
+   command: cli
+`, &snap.SideInfo{Revision: 11})
+	apps := []*snap.AppInfo{info.Apps["wat"], info.Apps["tick"], info.Apps["cli"]}
+
+	err := wrappers.RestartServices(apps, nil)
+	c.Assert(err, IsNil)
+	c.Check(sysdLog, DeepEquals, [][]string{
+		{"restart", "snap.wat.wat.service"},
+		{"restart", "snap.wat.tick.timer"},
+	})
+}
+
+func (s *servicesTestSuite) TestEnableDisableServices(c *C) {
+	var sysdLog [][]string
+	systemd.SystemctlCmd = func(cmd ...string) ([]byte, error) {
+		sysdLog = append(sysdLog, cmd)
+		return nil, nil
+	}
+
+	info := snaptest.MockSnap(c, `name: wat
+version: 42
+apps:
+ wat:
+   command: wat
+   daemon: simple
+   listen-stream: /var/run/wat.sock
+   socket: true
+`, &snap.SideInfo{Revision: 11})
+	apps := []*snap.AppInfo{info.Apps["wat"]}
+
+	err := wrappers.EnableServices(apps, nil)
+	c.Assert(err, IsNil)
+	c.Check(sysdLog, DeepEquals, [][]string{
+		{"--root", dirs.GlobalRootDir, "enable", "snap.wat.wat.service"},
+		{"--root", dirs.GlobalRootDir, "enable", "snap.wat.wat.socket"},
+	})
+
+	sysdLog = nil
+	err = wrappers.DisableServices(apps, nil)
+	c.Assert(err, IsNil)
+	c.Check(sysdLog, DeepEquals, [][]string{
+		{"--root", dirs.GlobalRootDir, "disable", "snap.wat.wat.service"},
+		{"--root", dirs.GlobalRootDir, "disable", "snap.wat.wat.socket"},
+	})
+}